@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/charry/cluster"
 	_ "github.com/charry/cluster/consumers" // 自动注册集群消费者
 	"github.com/charry/config"
 	_ "github.com/charry/config/consumers" // 自动注册配置消费者
@@ -8,6 +9,7 @@ import (
 	"github.com/charry/consul"
 	_ "github.com/charry/consul/consumers" // 自动注册 consul 消费者
 	"github.com/charry/event"
+	_ "github.com/charry/event/consumers" // 自动注册事件模块消费者
 	"github.com/charry/logger"
 	_ "github.com/charry/tcp/consumers" // 自动注册 tcp 消费者
 )
@@ -46,8 +48,11 @@ func StartUp() error {
 	//   [0] ClientCreatedConsumer - 加载 Consul 配置
 	//   [1] RPCStartConsumer - 启动 RPC 服务器
 	//   [2] ServiceRegisterConsumer - 注册服务到 Consul
+	// 使用 Gossip 服务发现时不依赖 Consul，直接启动 TCP 服务器和集群模块
 	cfg := config.Get()
-	if err := consul.Init(cfg); err != nil {
+	if cfg.Server.ClusterDiscovery == cluster.DiscoveryGossip {
+		event.PublishEvent(event_name.ClusterGossipStart, nil)
+	} else if err := consul.Init(cfg); err != nil {
 		logger.Errorf("初始化 Consul 客户端失败: %v", err)
 		return err
 	}
@@ -0,0 +1,291 @@
+package cluster
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// ErrNodeNotFound 节点不存在
+var ErrNodeNotFound = errors.New("节点不存在")
+
+// NodeDetails 节点详情（管理接口）
+type NodeDetails struct {
+	NodeSnapshot
+	Labels       map[string]string  `json:"labels"`
+	Peer         *tcp.Handshake     `json:"peer,omitempty"`         // 对方握手信息
+	Capabilities *tcp.Capabilities  `json:"capabilities,omitempty"` // 协商的协议版本和功能
+	QueueLengths [priorityLanes]int `json:"queue_lengths"`          // 各优先级队列长度（下标为 Priority）
+	RateLimit    RateLimit          `json:"rate_limit"`             // 请求限制
+	NextRetry    *time.Time         `json:"next_retry,omitempty"`   // 下次重连时间
+	Routes       []tcp.Route        `json:"routes"`                 // 节点上注册的响应处理器
+}
+
+// RoutingTable 路由表（管理接口）
+type RoutingTable struct {
+	Requests []tcp.Route            `json:"requests"` // 本节点的请求处理器
+	Streams  []tcp.Route            `json:"streams"`  // 本节点的流消息处理器
+	Readers  []tcp.Route            `json:"readers"`  // 本节点的分块消息处理器
+	Handlers []tcp.Route            `json:"handlers"` // 本节点服务器路由的处理函数（tcp.Server.Handle）
+	RPCs     []RPCInfo              `json:"rpcs"`     // 已注册的类型化 RPC
+	Nodes    map[string][]tcp.Route `json:"nodes"`    // 各节点上注册的响应处理器：serviceID -> routes
+}
+
+// CaptureDump 抓包结果（管理接口）
+type CaptureDump struct {
+	Status tcp.CaptureStatus   `json:"status"`
+	Frames []tcp.CapturedFrame `json:"frames"`
+}
+
+// IsDraining 节点是否排空
+func (n *Node) IsDraining() bool {
+	return n.draining.Load()
+}
+
+// ForceReconnect 立即重建节点连接池（忽略隔离的探测间隔）
+func (n *Node) ForceReconnect() {
+	n.quarantine.probeNow()
+	select {
+	case n.reconnectChan <- struct{}{}:
+	default:
+	}
+}
+
+// NodeDetails 获取节点详情
+func (m *Manager) NodeDetails(serviceID string) (*NodeDetails, error) {
+	node := m.GetNode(serviceID)
+	if node == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, serviceID)
+	}
+
+	stats := node.Stats()
+	details := &NodeDetails{
+		NodeSnapshot: snapshotOf(node, &stats),
+		Labels:       node.Labels(),
+		Peer:         node.Peer(),
+		Capabilities: node.Capabilities(),
+		QueueLengths: node.QueueLengths(),
+		RateLimit:    node.RateLimit(),
+		Routes:       node.router.Routes(),
+	}
+	if !stats.Reconnect.NextAt.IsZero() {
+		details.NextRetry = &stats.Reconnect.NextAt
+	}
+	return details, nil
+}
+
+// ForceReconnect 立即重连节点
+func (m *Manager) ForceReconnect(serviceID string) error {
+	node := m.GetNode(serviceID)
+	if node == nil {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, serviceID)
+	}
+
+	logger.Infof("强制重连节点: %s", serviceID)
+	node.ForceReconnect()
+	return nil
+}
+
+// DrainNode 设置节点排空状态
+// 排空的节点保持连接，但不再被负载均衡、key 归属和角色选择选中，已在途的请求不受影响
+func (m *Manager) DrainNode(serviceID string, drain bool) error {
+	node := m.GetNode(serviceID)
+	if node == nil {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, serviceID)
+	}
+
+	if node.draining.Swap(drain) == drain {
+		return nil
+	}
+	if drain {
+		logger.Infof("节点开始排空: %s", serviceID)
+	} else {
+		logger.Infof("节点取消排空: %s", serviceID)
+	}
+	m.notifyMembership()
+	return nil
+}
+
+// RoutingTable 获取路由表
+func (m *Manager) RoutingTable() *RoutingTable {
+	table := &RoutingTable{
+		Requests: tcp.ReqRoutes(),
+		Streams:  tcp.StreamRoutes(),
+		Readers:  tcp.ReaderRoutes(),
+		RPCs:     RegisteredRPCs(),
+		Nodes:    make(map[string][]tcp.Route),
+	}
+	if tcp.GlobalServer != nil {
+		table.Handlers = tcp.GlobalServer.Router().Routes()
+	}
+	for _, node := range m.GetAllNodes() {
+		table.Nodes[node.ServiceID] = node.router.Routes()
+	}
+	return table
+}
+
+// AdminAuthFunc 管理接口的认证函数，返回错误时以 401 拒绝请求
+type AdminAuthFunc func(r *http.Request) error
+
+// AdminTokenAuth 按 Authorization: Bearer <token> 认证管理接口请求
+func AdminTokenAuth(token string) AdminAuthFunc {
+	return func(r *http.Request) error {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return errors.New("管理接口认证失败")
+		}
+		return nil
+	}
+}
+
+// adminError 带 HTTP 状态码的管理接口错误
+type adminError struct {
+	status int
+	err    error
+}
+
+func (e *adminError) Error() string {
+	return e.err.Error()
+}
+
+func (e *adminError) Unwrap() error {
+	return e.err
+}
+
+// AdminHandler 集群管理 HTTP 接口（JSON，集群中没有 gRPC 服务，管理操作只以 HTTP 提供）
+// 每个请求先经 auth 认证；auth 为 nil 时只允许 GET 查询，改变状态的请求（重连、排空、暂停、抓包）以 403 拒绝
+// 出错时按原因返回 400（请求格式错误）、404（节点不存在）或 500
+//
+//	GET    /nodes                 节点列表（集群快照）
+//	GET    /nodes/{id}            节点详情
+//	POST   /nodes/{id}/reconnect  强制重连
+//	POST   /nodes/{id}/drain      排空节点
+//	DELETE /nodes/{id}/drain      取消排空
+//	POST   /nodes/{id}/pause      暂停节点
+//	DELETE /nodes/{id}/pause      恢复节点
+//	GET    /routes                路由表
+//	GET    /topology              拓扑图（?format=dot 输出 Graphviz DOT）
+//	GET    /sessions              连接到本节点的会话（对方握手时出示的身份）
+//	GET    /capture               抓包状态和最近记录的消息
+//	POST   /capture               开始抓包（请求体为 tcp.CaptureOptions JSON，可为空，不能指定 file）
+//	DELETE /capture               停止抓包
+//
+// 挂载到子路径时配合 http.StripPrefix 使用
+func (m *Manager) AdminHandler(auth AdminAuthFunc) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /nodes", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, m.Snapshot(), nil)
+	})
+	mux.HandleFunc("GET /nodes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		details, err := m.NodeDetails(r.PathValue("id"))
+		writeAdminJSON(w, details, err)
+	})
+	mux.HandleFunc("POST /nodes/{id}/reconnect", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, nil, m.ForceReconnect(r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /nodes/{id}/drain", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, nil, m.DrainNode(r.PathValue("id"), true))
+	})
+	mux.HandleFunc("DELETE /nodes/{id}/drain", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, nil, m.DrainNode(r.PathValue("id"), false))
+	})
+	mux.HandleFunc("POST /nodes/{id}/pause", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, nil, m.PauseNode(r.PathValue("id")))
+	})
+	mux.HandleFunc("DELETE /nodes/{id}/pause", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, nil, m.ResumeNode(r.PathValue("id")))
+	})
+	mux.HandleFunc("GET /routes", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, m.RoutingTable(), nil)
+	})
+	mux.HandleFunc("GET /topology", func(w http.ResponseWriter, r *http.Request) {
+		graph := m.TopologyGraph()
+		if r.URL.Query().Get("format") == "dot" {
+			w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+			fmt.Fprint(w, graph.DOT())
+			return
+		}
+		writeAdminJSON(w, graph, nil)
+	})
+	mux.HandleFunc("GET /sessions", func(w http.ResponseWriter, r *http.Request) {
+		sessions := []*tcp.Session{}
+		if tcp.GlobalServer != nil {
+			sessions = append(sessions, tcp.GlobalServer.Sessions()...)
+		}
+		writeAdminJSON(w, sessions, nil)
+	})
+	mux.HandleFunc("GET /capture", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, CaptureDump{Status: tcp.GetCaptureStatus(), Frames: tcp.CapturedFrames()}, nil)
+	})
+	mux.HandleFunc("POST /capture", func(w http.ResponseWriter, r *http.Request) {
+		var opts tcp.CaptureOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+			writeAdminJSON(w, nil, &adminError{http.StatusBadRequest, fmt.Errorf("抓包选项格式错误: %w", err)})
+			return
+		}
+		// 不允许通过管理接口指定输出文件（否则调用方可以写入进程有权限的任意路径）
+		if opts.File != "" {
+			writeAdminJSON(w, nil, &adminError{http.StatusBadRequest, errors.New("管理接口不支持抓包输出文件（file）")})
+			return
+		}
+		writeAdminJSON(w, nil, tcp.StartCapture(opts))
+	})
+	mux.HandleFunc("DELETE /capture", func(w http.ResponseWriter, r *http.Request) {
+		tcp.StopCapture()
+		writeAdminJSON(w, nil, nil)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth == nil {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				writeAdminJSON(w, nil, &adminError{http.StatusForbidden, errors.New("管理接口未配置认证，只允许查询")})
+				return
+			}
+		} else if err := auth(r); err != nil {
+			logger.Warnf("管理接口认证失败: %s %s, %s", r.Method, r.URL.Path, r.RemoteAddr)
+			writeAdminJSON(w, nil, &adminError{http.StatusUnauthorized, err})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// writeAdminJSON 输出管理接口结果（出错时为对应的状态码和错误信息，操作成功且无数据时为 {"ok": true}）
+func writeAdminJSON(w http.ResponseWriter, v any, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	switch {
+	case err != nil:
+		w.WriteHeader(adminStatus(err))
+		v = map[string]string{"error": err.Error()}
+	case v == nil:
+		v = map[string]bool{"ok": true}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		logger.Warnf("输出管理接口结果失败: %v", err)
+	}
+}
+
+// adminStatus 错误对应的 HTTP 状态码
+func adminStatus(err error) int {
+	var ae *adminError
+	switch {
+	case errors.As(err, &ae):
+		return ae.status
+	case errors.Is(err, ErrNodeNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,167 @@
+package cluster
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Strategy 负载均衡策略
+type Strategy string
+
+const (
+	StrategyRoundRobin   Strategy = "round_robin"   // 轮询
+	StrategyWeighted     Strategy = "weighted"      // 按节点权重随机
+	StrategyLeastPending Strategy = "least_pending" // 进行中请求最少
+	StrategyRandom       Strategy = "random"        // 随机
+)
+
+// balancer 负载均衡状态
+type balancer struct {
+	// 轮询计数：nodeType -> counter
+	counters map[string]*atomic.Uint64
+	mu       sync.Mutex
+
+	// 区域感知路由配置（为 nil 时不启用）
+	zone atomic.Pointer[ZonePreference]
+}
+
+// newBalancer 创建负载均衡状态
+func newBalancer() *balancer {
+	return &balancer{
+		counters: make(map[string]*atomic.Uint64),
+	}
+}
+
+// next 获取轮询序号
+func (b *balancer) next(nodeType string) uint64 {
+	b.mu.Lock()
+	counter, exists := b.counters[nodeType]
+	if !exists {
+		counter = &atomic.Uint64{}
+		b.counters[nodeType] = counter
+	}
+	b.mu.Unlock()
+
+	return counter.Add(1) - 1
+}
+
+// PickNode 按策略从指定类型的已连接节点中选择一个
+// 健康分过低、熔断中的节点会被跳过，按权重、最少请求策略还会按健康分降权
+// 启用区域感知路由（SetZonePreference）时优先选择同可用区的节点
+func (m *Manager) PickNode(nodeType string, strategy Strategy) (*Node, error) {
+	return m.pickNode(nodeType, strategy, nil)
+}
+
+// pickNode 按策略选择节点，exclude 中的节点不参与选择
+func (m *Manager) pickNode(nodeType string, strategy Strategy, exclude map[string]bool) (*Node, error) {
+	nodes := m.connectedNodes(nodeType)
+	if len(exclude) > 0 {
+		candidates := nodes[:0]
+		for _, node := range nodes {
+			if !exclude[node.ServiceID] {
+				candidates = append(candidates, node)
+			}
+		}
+		nodes = candidates
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("没有可用节点: %s", nodeType)
+	}
+
+	nodes = availableNodes(healthyNodes(nodes))
+	nodes, factors := m.localityFactors(nodes)
+
+	switch strategy {
+	case StrategyRoundRobin, "":
+		nodes = nearestNodes(nodes, factors)
+		return nodes[m.balancer.next(nodeType)%uint64(len(nodes))], nil
+
+	case StrategyWeighted:
+		return pickWeighted(nodes, withHealth(nodes, factors)), nil
+
+	case StrategyLeastPending:
+		return pickLeastPending(nodes, withHealth(nodes, factors)), nil
+
+	case StrategyRandom:
+		nodes = nearestNodes(nodes, factors)
+		return nodes[rand.IntN(len(nodes))], nil
+
+	default:
+		return nil, fmt.Errorf("未知的负载均衡策略: %s", strategy)
+	}
+}
+
+// connectedNodes 获取指定类型的已连接、未排空且未暂停的节点（按 ServiceID 排序，保证轮询顺序稳定）
+func (m *Manager) connectedNodes(nodeType string) []*Node {
+	nodes := m.GetNodesByType(nodeType)
+
+	connected := nodes[:0]
+	for _, node := range nodes {
+		if node.GetStatus() == NodeStatusConnected && node.selectable() {
+			connected = append(connected, node)
+		}
+	}
+
+	sort.Slice(connected, func(i, j int) bool {
+		return connected[i].ServiceID < connected[j].ServiceID
+	})
+	return connected
+}
+
+// pickWeighted 按权重随机选择（权重 <= 0 的节点不参与，全部为 0 时退化为随机）
+// factors 非空时权重乘以对应的系数
+func pickWeighted(nodes []*Node, factors []float64) *Node {
+	weight := func(i int) float64 {
+		w := nodes[i].Weight()
+		if factors != nil {
+			w *= factors[i]
+		}
+		return w
+	}
+
+	total := 0.0
+	for i := range nodes {
+		if w := weight(i); w > 0 {
+			total += w
+		}
+	}
+	if total <= 0 {
+		nodes = nearestNodes(nodes, factors)
+		return nodes[rand.IntN(len(nodes))]
+	}
+
+	r := rand.Float64() * total
+	for i, node := range nodes {
+		if w := weight(i); w > 0 {
+			if r < w {
+				return node
+			}
+			r -= w
+		}
+	}
+	return nodes[len(nodes)-1]
+}
+
+// pickLeastPending 选择进行中请求最少的节点（相同时随机选择，避免集中到同一节点）
+// factors 非空时按 (进行中请求数 + 1) / 系数 比较，远端或不健康的节点需要明显更空闲才会被选中
+func pickLeastPending(nodes []*Node, factors []float64) *Node {
+	var best []*Node
+	var lowest float64
+	for i, node := range nodes {
+		load := float64(node.Pending())
+		if factors != nil {
+			load = (load + 1) / factors[i]
+		}
+		switch {
+		case len(best) == 0 || load < lowest:
+			best = append(best[:0], node)
+			lowest = load
+		case load == lowest:
+			best = append(best, node)
+		}
+	}
+	return best[rand.IntN(len(best))]
+}
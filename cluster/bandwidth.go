@@ -0,0 +1,116 @@
+package cluster
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// Bandwidth 节点每个连接的写入带宽限制
+// 大批量数据（状态同步、大广播）按字节限速，避免占满共享链路；零值表示不限制
+// PriorityHigh 的控制消息和心跳不受限制，也不消耗额度
+type Bandwidth struct {
+	BytesPerSecond int64 // 每个连接每秒最多写入的字节数（<= 0 不限制）
+	Burst          int64 // 突发字节数（默认为 BytesPerSecond）
+}
+
+// byteBucket 字节令牌桶
+// 单条消息可以超过桶容量：先扣减为负数再等待补足，保证大消息不会永远等不到额度
+type byteBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// newByteBucket 创建字节令牌桶（桶初始为满）
+func newByteBucket(limit Bandwidth) *byteBucket {
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.BytesPerSecond
+	}
+	return &byteBucket{
+		rate:   float64(limit.BytesPerSecond),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait 扣减 size 字节额度，额度不足时等待补足，返回等待时间
+// ctx 结束时退还额度并返回错误
+func (b *byteBucket) wait(ctx context.Context, size int) (time.Duration, error) {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= float64(size)
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if wait <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return wait, nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens += float64(size)
+		b.mu.Unlock()
+		return 0, ctx.Err()
+	}
+}
+
+// SetBandwidth 设置节点每个连接的写入带宽限制（零值取消限制），已有连接的额度重新计算
+func (n *Node) SetBandwidth(limit Bandwidth) {
+	if limit.BytesPerSecond <= 0 {
+		n.bandwidth.Store(nil)
+	} else {
+		n.bandwidth.Store(&limit)
+	}
+	n.connBuckets.Clear()
+}
+
+// Bandwidth 获取节点每个连接的写入带宽限制
+func (n *Node) Bandwidth() Bandwidth {
+	if limit := n.bandwidth.Load(); limit != nil {
+		return *limit
+	}
+	return Bandwidth{}
+}
+
+// throttle 按连接的带宽限制等待写入额度
+func (n *Node) throttle(ctx context.Context, conn net.Conn, priority Priority, size int) error {
+	limit := n.bandwidth.Load()
+	if limit == nil || priority == PriorityHigh {
+		return nil
+	}
+
+	bucket, ok := n.connBuckets.Load(conn)
+	if !ok {
+		bucket, _ = n.connBuckets.LoadOrStore(conn, newByteBucket(*limit))
+	}
+
+	wait, err := bucket.(*byteBucket).wait(ctx, size)
+	if wait > 0 {
+		n.counters.throttled.Add(int64(wait))
+	}
+	return err
+}
+
+// SetBandwidth 设置所有节点（包括之后加入的节点）每个连接的写入带宽限制
+func (m *Manager) SetBandwidth(limit Bandwidth) {
+	m.bandwidth.Store(&limit)
+	for _, node := range m.GetAllNodes() {
+		node.SetBandwidth(limit)
+	}
+}
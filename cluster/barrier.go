@@ -0,0 +1,55 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// peerPollInterval WaitForPeers 检查节点数的间隔
+const peerPollInterval = 100 * time.Millisecond
+
+// HealthyPeers 指定类型已连接且健康的节点数（不含本节点，以及排空、暂停的节点）
+func (m *Manager) HealthyPeers(nodeType string) int {
+	count := 0
+	for _, node := range m.connectedNodes(nodeType) {
+		if node.HealthScore() >= UnhealthyScore {
+			count++
+		}
+	}
+	return count
+}
+
+// WaitForPeers 启动屏障：阻塞直到指定类型至少 n 个健康节点已连接
+// 用于依赖法定人数或后端服务的节点，在依赖就绪前不对外提供服务；ctx 结束时返回错误（附带当前节点数）
+func (m *Manager) WaitForPeers(ctx context.Context, nodeType string, n int) error {
+	count := m.HealthyPeers(nodeType)
+	if count >= n {
+		return nil
+	}
+	logger.Infof("等待 %s 节点就绪: %d/%d", nodeType, count, n)
+
+	ticker := time.NewTicker(peerPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待 %s 节点就绪失败: %d/%d: %w", nodeType, count, n, ctx.Err())
+		case <-m.stopChan:
+			return fmt.Errorf("集群管理器已关闭")
+		case <-ticker.C:
+			current := m.HealthyPeers(nodeType)
+			if current >= n {
+				logger.Infof("✓ %s 节点已就绪: %d/%d", nodeType, current, n)
+				return nil
+			}
+			if current != count {
+				logger.Infof("等待 %s 节点就绪: %d/%d", nodeType, current, n)
+				count = current
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBatchMaxBytes 合并写入默认的最大字节数
+const DefaultBatchMaxBytes = 64 * 1024
+
+// Batching 节点发送合并配置
+// 发送协程取到一条消息后，继续取出队列中已排队的消息，拼接后一次写入连接，减少系统调用
+// 消息帧本身不变，接收方按帧解码，无需感知合并
+type Batching struct {
+	MaxBytes      int           // 单次写入最多合并的字节数（默认 DefaultBatchMaxBytes）
+	FlushInterval time.Duration // 队列为空时最多等待后续消息的时间（0 表示只合并已排队的消息，不额外等待）
+}
+
+// SetBatching 启用节点的发送合并
+func (n *Node) SetBatching(opts Batching) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultBatchMaxBytes
+	}
+	n.batching.Store(&opts)
+}
+
+// DisableBatching 关闭节点的发送合并
+func (n *Node) DisableBatching() {
+	n.batching.Store(nil)
+}
+
+// Batching 获取节点的发送合并配置（未启用时返回 false）
+func (n *Node) Batching() (Batching, bool) {
+	if opts := n.batching.Load(); opts != nil {
+		return *opts, true
+	}
+	return Batching{}, false
+}
+
+// collect 以 first 为首条消息，按配置继续取出排队的消息组成一批
+func (n *Node) collect(first *sendItem) []*sendItem {
+	opts := n.batching.Load()
+	if opts == nil {
+		return []*sendItem{first}
+	}
+
+	batch := []*sendItem{first}
+	size := len(first.data)
+	var timeout <-chan time.Time
+	for size < opts.MaxBytes {
+		item := n.lanes.poll()
+		if item == nil {
+			if opts.FlushInterval <= 0 {
+				break
+			}
+			if timeout == nil {
+				timer := time.NewTimer(opts.FlushInterval)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+			if item = n.lanes.wait(timeout, n.stopChan); item == nil {
+				break
+			}
+		}
+		// 超出上限的消息已经取出，仍放入本批（单条大消息不拆分）
+		batch = append(batch, item)
+		size += len(item.data)
+	}
+	return batch
+}
+
+// writeBatch 将一批消息拼接后一次写入同一连接，结果通知每条消息的发送方
+func (n *Node) writeBatch(batch []*sendItem) {
+	// 跳过排队期间调用方已放弃的消息
+	pending := batch[:0]
+	for _, item := range batch {
+		if err := item.ctx.Err(); err != nil {
+			item.done <- err
+			continue
+		}
+		pending = append(pending, item)
+	}
+	switch len(pending) {
+	case 0:
+		return
+	case 1:
+		pending[0].done <- n.write(pending[0])
+		return
+	}
+
+	err := n.writeMerged(pending)
+	for _, item := range pending {
+		item.done <- err
+	}
+}
+
+// writeMerged 合并写入多条消息，所有发送方都放弃后才中断
+func (n *Node) writeMerged(items []*sendItem) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var remaining atomic.Int32
+	remaining.Store(int32(len(items)))
+	for _, item := range items {
+		stop := context.AfterFunc(item.ctx, func() {
+			if remaining.Add(-1) == 0 {
+				cancel()
+			}
+		})
+		defer stop()
+	}
+
+	pool := n.GetPool()
+	if pool == nil {
+		return fmt.Errorf("节点未连接")
+	}
+
+	conn, err := pool.GetCtx(ctx)
+	if err != nil {
+		return fmt.Errorf("获取连接失败: %w", err)
+	}
+	defer pool.Put(conn)
+
+	// 带宽限制按本批中非控制消息的总字节数计算
+	size, total := 0, 0
+	priority := PriorityHigh
+	for _, item := range items {
+		total += len(item.data)
+		if item.priority != PriorityHigh {
+			size += len(item.data)
+			priority = PriorityNormal
+		}
+	}
+	if err := n.throttle(ctx, conn, priority, size); err != nil {
+		return fmt.Errorf("等待写入带宽失败: %w", err)
+	}
+
+	data := make([]byte, 0, total)
+	for _, item := range items {
+		if item.onConn != nil {
+			item.onConn(conn)
+		}
+		data = append(data, item.data...)
+	}
+
+	if _, err := writeContext(ctx, conn, data); err != nil {
+		if !errors.Is(err, context.Canceled) {
+			n.health.result(true)
+			n.recordFailure()
+		}
+		conn.Close()
+		return fmt.Errorf("发送失败: %w", err)
+	}
+
+	// 流量统计按一次写入计一条消息，补齐被合并的消息数
+	n.counters.msgsSent.Add(uint64(len(items) - 1))
+	n.counters.batches.Add(1)
+	return nil
+}
+
+// SetBatching 启用所有节点（包括之后加入的节点）的发送合并
+func (m *Manager) SetBatching(opts Batching) {
+	m.batching.Store(&opts)
+	for _, node := range m.GetAllNodes() {
+		node.SetBatching(opts)
+	}
+}
+
+// DisableBatching 关闭所有节点（包括之后加入的节点）的发送合并
+func (m *Manager) DisableBatching() {
+	m.batching.Store(nil)
+	for _, node := range m.GetAllNodes() {
+		node.DisableBatching()
+	}
+}
@@ -0,0 +1,329 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
+	"github.com/charry/logger"
+)
+
+// ErrCircuitOpen 节点熔断中，请求未发送
+var ErrCircuitOpen = errors.New("节点熔断中")
+
+// 熔断默认参数
+const (
+	DefaultBreakerWindow      = 10 * time.Second
+	DefaultBreakerMinRequests = 20
+	DefaultBreakerErrorRate   = 0.5
+	DefaultBreakerSlowRate    = 0.5
+	DefaultBreakerOpenTimeout = 5 * time.Second
+)
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = 0 // 正常放行
+	BreakerOpen     BreakerState = 1 // 熔断，请求立即失败
+	BreakerHalfOpen BreakerState = 2 // 半开，放行少量探测请求
+)
+
+// String 熔断器状态名称
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// BreakerOptions 节点熔断配置
+// 统计窗口内请求数达到 MinRequests 后，失败率或慢请求比例达到阈值即熔断；
+// 熔断 OpenTimeout 后进入半开，放行 HalfOpenProbes 个探测请求，全部成功则恢复，任一失败则再次熔断
+type BreakerOptions struct {
+	Window         time.Duration // 统计窗口（默认 DefaultBreakerWindow）
+	MinRequests    int           // 窗口内最少请求数（默认 DefaultBreakerMinRequests）
+	ErrorRate      float64       // 失败率阈值 0 ~ 1（默认 DefaultBreakerErrorRate）
+	SlowCall       time.Duration // 耗时超过该值视为慢请求（0 表示不按延迟熔断）
+	SlowRate       float64       // 慢请求比例阈值 0 ~ 1（默认 DefaultBreakerSlowRate）
+	OpenTimeout    time.Duration // 熔断持续时间（默认 DefaultBreakerOpenTimeout）
+	HalfOpenProbes int           // 半开时的探测请求数（默认 1）
+}
+
+// withDefaults 补全未设置的参数
+func (o BreakerOptions) withDefaults() BreakerOptions {
+	if o.Window <= 0 {
+		o.Window = DefaultBreakerWindow
+	}
+	if o.MinRequests <= 0 {
+		o.MinRequests = DefaultBreakerMinRequests
+	}
+	if o.ErrorRate <= 0 {
+		o.ErrorRate = DefaultBreakerErrorRate
+	}
+	if o.SlowRate <= 0 {
+		o.SlowRate = DefaultBreakerSlowRate
+	}
+	if o.OpenTimeout <= 0 {
+		o.OpenTimeout = DefaultBreakerOpenTimeout
+	}
+	if o.HalfOpenProbes <= 0 {
+		o.HalfOpenProbes = 1
+	}
+	return o
+}
+
+// BreakerEventData 熔断器状态变化事件数据
+type BreakerEventData struct {
+	NodeEventData
+	From      BreakerState
+	To        BreakerState
+	ErrorRate float64 // 熔断时窗口内的失败率
+	SlowRate  float64 // 熔断时窗口内的慢请求比例
+}
+
+// breakerResult 请求结果
+type breakerResult int
+
+const (
+	breakerSuccess breakerResult = iota // 成功（耗时超过 SlowCall 时计为慢请求）
+	breakerFailure                      // 失败
+	breakerIgnore                       // 不计入统计（如调用方主动取消）
+)
+
+// nodeBreaker 节点熔断器
+type nodeBreaker struct {
+	opts BreakerOptions
+
+	state    BreakerState
+	openedAt time.Time
+
+	// 当前统计窗口
+	windowStart time.Time
+	total       int
+	failures    int
+	slow        int
+
+	// 半开状态：在途和已成功的探测请求数
+	probes    int
+	succeeded int
+
+	mu sync.Mutex
+
+	// 状态变化回调（在锁外调用）
+	onChange func(data BreakerEventData)
+}
+
+// newNodeBreaker 创建熔断器
+func newNodeBreaker(opts BreakerOptions, onChange func(data BreakerEventData)) *nodeBreaker {
+	return &nodeBreaker{
+		opts:        opts.withDefaults(),
+		windowStart: time.Now(),
+		onChange:    onChange,
+	}
+}
+
+// allow 请求是否放行，放行时返回记录结果的函数（必须调用一次）
+func (b *nodeBreaker) allow() (func(result breakerResult), error) {
+	if b == nil {
+		return func(breakerResult) {}, nil
+	}
+
+	b.mu.Lock()
+	var change *BreakerEventData
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.opts.OpenTimeout {
+		change = b.transition(BreakerHalfOpen)
+	}
+
+	var err error
+	switch b.state {
+	case BreakerOpen:
+		err = ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probes >= b.opts.HalfOpenProbes {
+			err = ErrCircuitOpen
+		} else {
+			b.probes++
+		}
+	}
+	probe := b.state == BreakerHalfOpen
+	b.mu.Unlock()
+
+	b.notify(change)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	var once sync.Once
+	return func(result breakerResult) {
+		once.Do(func() { b.record(result, time.Since(start), probe) })
+	}, nil
+}
+
+// record 记录请求结果，按需切换状态
+func (b *nodeBreaker) record(result breakerResult, latency time.Duration, probe bool) {
+	failed := result == breakerFailure
+	slow := result == breakerSuccess && b.opts.SlowCall > 0 && latency > b.opts.SlowCall
+
+	b.mu.Lock()
+	var change *BreakerEventData
+	switch {
+	case probe:
+		// 熔断器状态可能已被重置（如重新设置配置），只处理仍处于半开状态的探测结果
+		if b.state != BreakerHalfOpen {
+			break
+		}
+		b.probes--
+		switch {
+		case result == breakerIgnore:
+		case failed || slow:
+			change = b.transition(BreakerOpen)
+		default:
+			b.succeeded++
+			if b.succeeded >= b.opts.HalfOpenProbes {
+				change = b.transition(BreakerClosed)
+			}
+		}
+
+	case b.state == BreakerClosed && result != breakerIgnore:
+		now := time.Now()
+		if now.Sub(b.windowStart) >= b.opts.Window {
+			b.resetWindow(now)
+		}
+		b.total++
+		if failed {
+			b.failures++
+		}
+		if slow {
+			b.slow++
+		}
+
+		if b.total >= b.opts.MinRequests {
+			errorRate := float64(b.failures) / float64(b.total)
+			slowRate := float64(b.slow) / float64(b.total)
+			if errorRate >= b.opts.ErrorRate || (b.opts.SlowCall > 0 && slowRate >= b.opts.SlowRate) {
+				change = b.transition(BreakerOpen)
+				change.ErrorRate = errorRate
+				change.SlowRate = slowRate
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(change)
+}
+
+// transition 切换状态（调用方持有锁）
+func (b *nodeBreaker) transition(to BreakerState) *BreakerEventData {
+	change := &BreakerEventData{From: b.state, To: to}
+	b.state = to
+	b.probes = 0
+	b.succeeded = 0
+
+	switch to {
+	case BreakerOpen:
+		b.openedAt = time.Now()
+	case BreakerClosed:
+		b.resetWindow(time.Now())
+	}
+	return change
+}
+
+// resetWindow 开始新的统计窗口（调用方持有锁）
+func (b *nodeBreaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.total = 0
+	b.failures = 0
+	b.slow = 0
+}
+
+// notify 通知状态变化
+func (b *nodeBreaker) notify(change *BreakerEventData) {
+	if change != nil && b.onChange != nil {
+		b.onChange(*change)
+	}
+}
+
+// currentState 当前状态（熔断已到期时视为半开）
+func (b *nodeBreaker) currentState() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.opts.OpenTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// SetBreaker 设置节点熔断配置（零值使用默认参数），重新设置时熔断器恢复为关闭状态
+func (n *Node) SetBreaker(opts BreakerOptions) {
+	n.breaker.Store(newNodeBreaker(opts, n.breakerChanged))
+}
+
+// DisableBreaker 关闭节点熔断
+func (n *Node) DisableBreaker() {
+	n.breaker.Store(nil)
+}
+
+// BreakerState 获取节点熔断器状态（未启用熔断时为 BreakerClosed）
+func (n *Node) BreakerState() BreakerState {
+	return n.breaker.Load().currentState()
+}
+
+// breakerChanged 熔断器状态变化，发布 ClusterBreakerChanged 事件
+func (n *Node) breakerChanged(data BreakerEventData) {
+	switch data.To {
+	case BreakerOpen:
+		logger.Warnf("节点已熔断: %s, 失败率 %.2f, 慢请求比例 %.2f", n.ServiceID, data.ErrorRate, data.SlowRate)
+	case BreakerHalfOpen:
+		logger.Infof("节点熔断半开，开始探测: %s", n.ServiceID)
+	case BreakerClosed:
+		logger.Infof("✓ 节点熔断已恢复: %s", n.ServiceID)
+	}
+
+	data.NodeEventData = n.eventData()
+	event.PublishEvent(event_name.ClusterBreakerChanged, &data)
+}
+
+// SetBreaker 为所有节点（包括之后加入的节点）启用熔断
+func (m *Manager) SetBreaker(opts BreakerOptions) {
+	m.breakerOpts.Store(&opts)
+	for _, node := range m.GetAllNodes() {
+		node.SetBreaker(opts)
+	}
+}
+
+// DisableBreaker 关闭所有节点的熔断
+func (m *Manager) DisableBreaker() {
+	m.breakerOpts.Store(nil)
+	for _, node := range m.GetAllNodes() {
+		node.DisableBreaker()
+	}
+}
+
+// availableNodes 过滤熔断中的节点（全部熔断时原样返回，由熔断器立即拒绝请求）
+func availableNodes(nodes []*Node) []*Node {
+	available := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		if node.BreakerState() != BreakerOpen {
+			available = append(available, node)
+		}
+	}
+	if len(available) == 0 {
+		return nodes
+	}
+	return available
+}
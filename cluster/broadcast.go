@@ -0,0 +1,54 @@
+package cluster
+
+import (
+	"sync"
+
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// Broadcast 并发向指定类型的所有节点发送请求（不等待响应）
+// 返回每个节点的发送结果：serviceID -> error（nil 表示成功）
+// 适用于缓存失效、配置推送等场景
+func (m *Manager) Broadcast(nodeType string, req *tcp.ClusterReqMsg) map[string]error {
+	return m.broadcast(m.GetNodesByType(nodeType), req)
+}
+
+// BroadcastAll 并发向所有节点发送请求（不等待响应）
+func (m *Manager) BroadcastAll(req *tcp.ClusterReqMsg) map[string]error {
+	return m.broadcast(m.GetAllNodes(), req)
+}
+
+// broadcast 并发发送并汇总结果
+func (m *Manager) broadcast(nodes []*Node, req *tcp.ClusterReqMsg) map[string]error {
+	results := make(map[string]error, len(nodes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *Node) {
+			defer wg.Done()
+
+			err := node.SendReq(req)
+
+			mu.Lock()
+			results[node.ServiceID] = err
+			mu.Unlock()
+		}(node)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range results {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		logger.Warnf("广播部分失败: module=%d, cmd=%d, 失败 %d/%d",
+			req.Module, req.Cmd, failed, len(results))
+	}
+
+	return results
+}
@@ -0,0 +1,112 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/charry/event"
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// DefaultCallTimeout Call 的默认超时（ctx 未设置截止时间时使用）
+const DefaultCallTimeout = 10 * time.Second
+
+// Call 发送请求并等待响应
+// 请求按 SessionId 关联响应（为空时自动生成），同一连接上可同时有多个请求在途
+// ctx 未设置截止时间时使用 DefaultCallTimeout
+func (n *Node) Call(ctx context.Context, req *tcp.ClusterReqMsg) (*tcp.ClusterRespMsg, error) {
+	return n.CallPriority(ctx, req, PriorityNormal)
+}
+
+// CallPriority 按优先级发送请求并等待响应（排队规则见 SendReqPriority）
+func (n *Node) CallPriority(ctx context.Context, req *tcp.ClusterReqMsg, priority Priority) (*tcp.ClusterRespMsg, error) {
+	n.pending.Add(1)
+	defer n.pending.Add(-1)
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultCallTimeout)
+		defer cancel()
+	}
+
+	if req.SessionId == "" {
+		req.SessionId = event.NewID()
+	}
+	if len(req.SessionId) > tcp.HeaderSessionIdSize {
+		return nil, fmt.Errorf("sessionId 超过 %d 字节: %s", tcp.HeaderSessionIdSize, req.SessionId)
+	}
+
+	if n.GetPool() == nil {
+		return nil, fmt.Errorf("节点未连接")
+	}
+
+	// 熔断中立即失败
+	done, err := n.breaker.Load().allow()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, n.ServiceID)
+	}
+	result := breakerIgnore
+	defer func() { done(result) }()
+
+	// 收到响应前占用在途名额
+	release, err := n.limiter.Load().acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("等待发送许可失败: %s, %w", n.ServiceID, err)
+	}
+	defer release()
+
+	// 先登记再发送，避免响应先于登记到达（截止时间后仍未收到的响应计为迟到的响应）
+	deadline, _ := ctx.Deadline()
+	call, err := n.calls.Add(req.SessionId, deadline)
+	if err != nil {
+		return nil, err
+	}
+	defer n.calls.Remove(req.SessionId)
+
+	// ctx 中有追踪上下文时请求携带其下一跳，ctx 中的元数据随请求发送
+	tcp.InjectTrace(ctx, req)
+	tcp.InjectMetadata(ctx, req)
+
+	// 写入前登记所在连接，响应由接收协程分发（写入失败已由发送协程计入健康状态）
+	err = n.enqueue(ctx, priority, tcp.EncodeClusterReqMsgWith(req, n.frameOptions()), func(conn net.Conn) {
+		n.calls.Bind(call, conn)
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			result = breakerFailure
+		}
+		return nil, err
+	}
+
+	select {
+	case resp := <-call.Resp():
+		result = breakerSuccess
+		n.health.result(false)
+		n.recordSuccess()
+		return resp, nil
+	case err := <-call.Err():
+		result = breakerFailure
+		n.health.result(true)
+		return nil, err
+	case <-ctx.Done():
+		// 调用方主动取消不计入错误率
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			result = breakerFailure
+		}
+		n.health.result(result == breakerFailure)
+		return nil, fmt.Errorf("等待响应失败: module=%d, cmd=%d, sessionId=%s, %w",
+			req.Module, req.Cmd, req.SessionId, ctx.Err())
+	}
+}
+
+// startReceivers 为连接池中的每个连接启动接收协程
+func (n *Node) startReceivers(pool *ConnectionPool) {
+	for i, conn := range pool.Conns() {
+		go n.receiveLoop(pool, conn, i)
+	}
+	logger.Debugf("已启动接收协程: %s, 连接数: %d", n.ServiceID, pool.GetPoolSize())
+}
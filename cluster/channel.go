@@ -0,0 +1,160 @@
+package cluster
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// 频道消息协议（系统模块，与心跳共用模块号）
+// Payload: 频道名长度(2字节) + 频道名 + 消息体
+const (
+	ChannelModule uint32 = tcp.HeartbeatModule
+	ChannelCmd    uint32 = 2
+)
+
+// maxChannelNameLen 频道名最大长度
+const maxChannelNameLen = 0xffff
+
+// ChannelHandler 频道消息处理函数
+type ChannelHandler func(channel string, payload []byte)
+
+// channelSubscriber 频道订阅者
+type channelSubscriber struct {
+	id      uint64
+	handler ChannelHandler
+}
+
+var (
+	// channels 本节点的频道订阅：channel -> subscribers
+	channels   = make(map[string][]*channelSubscriber)
+	channelsMu sync.RWMutex
+	channelSeq uint64
+)
+
+// PublishChannel 向频道发布消息
+// 消息通过节点间 TCP 连接直接发送给所有节点（无中心代理），本节点的订阅者同样会收到
+// 返回发送失败的节点错误（部分失败时其余节点仍会收到）
+func PublishChannel(channel string, payload []byte) error {
+	if channel == "" || len(channel) > maxChannelNameLen {
+		return fmt.Errorf("频道名长度必须在 1 ~ %d 之间", maxChannelNameLen)
+	}
+
+	// 本节点订阅者
+	dispatchChannel(channel, payload)
+
+	if GlobalManager == nil {
+		return nil
+	}
+
+	req := &tcp.ClusterReqMsg{
+		Module:  ChannelModule,
+		Cmd:     ChannelCmd,
+		Payload: encodeChannelMsg(channel, payload),
+	}
+
+	var firstErr error
+	failed := 0
+	for serviceID, err := range GlobalManager.BroadcastAll(req) {
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", serviceID, err)
+			}
+		}
+	}
+	if firstErr != nil {
+		return fmt.Errorf("频道消息发送失败 %d 个节点: %s, %w", failed, channel, firstErr)
+	}
+	return nil
+}
+
+// SubscribeChannel 订阅频道，返回取消订阅函数
+// 处理函数在接收连接的协程中按顺序执行，耗时操作应自行异步处理
+func SubscribeChannel(channel string, handler ChannelHandler) func() {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+
+	channelSeq++
+	sub := &channelSubscriber{id: channelSeq, handler: handler}
+	channels[channel] = append(channels[channel], sub)
+	logger.Infof("订阅频道: %s", channel)
+
+	return func() {
+		channelsMu.Lock()
+		defer channelsMu.Unlock()
+
+		subs := channels[channel]
+		for i, s := range subs {
+			if s.id == sub.id {
+				// 复制后删除，避免影响正在分发的列表
+				next := make([]*channelSubscriber, 0, len(subs)-1)
+				next = append(next, subs[:i]...)
+				next = append(next, subs[i+1:]...)
+				if len(next) == 0 {
+					delete(channels, channel)
+				} else {
+					channels[channel] = next
+				}
+				return
+			}
+		}
+	}
+}
+
+// dispatchChannel 将消息分发给本节点的订阅者
+func dispatchChannel(channel string, payload []byte) {
+	channelsMu.RLock()
+	subs := channels[channel]
+	channelsMu.RUnlock()
+
+	for _, sub := range subs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Errorf("频道消息处理发生 panic: %v, 频道: %s", r, channel)
+				}
+			}()
+			sub.handler(channel, payload)
+		}()
+	}
+}
+
+// encodeChannelMsg 编码频道消息
+func encodeChannelMsg(channel string, payload []byte) []byte {
+	buf := make([]byte, 2+len(channel)+len(payload))
+	binary.BigEndian.PutUint16(buf[0:2], uint16(len(channel)))
+	copy(buf[2:], channel)
+	copy(buf[2+len(channel):], payload)
+	return buf
+}
+
+// decodeChannelMsg 解码频道消息
+func decodeChannelMsg(data []byte) (string, []byte, error) {
+	if len(data) < 2 {
+		return "", nil, fmt.Errorf("频道消息长度不足")
+	}
+	nameLen := int(binary.BigEndian.Uint16(data[0:2]))
+	if len(data) < 2+nameLen {
+		return "", nil, fmt.Errorf("频道名长度错误: %d", nameLen)
+	}
+	return string(data[2 : 2+nameLen]), data[2+nameLen:], nil
+}
+
+// handleChannelReq 处理其他节点发来的频道消息（不响应）
+func handleChannelReq(req *tcp.ClusterReqMsg) *tcp.ClusterRespMsg {
+	channel, payload, err := decodeChannelMsg(req.Payload)
+	if err != nil {
+		logger.Warnf("解析频道消息失败: %v", err)
+		return nil
+	}
+	dispatchChannel(channel, payload)
+	return nil
+}
+
+func init() {
+	tcp.RegisterReqHandler(ChannelModule, ChannelCmd, handleChannelReq)
+}
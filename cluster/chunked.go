@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/charry/event"
+	"github.com/charry/tcp"
+)
+
+// CallReader 以分块消息发送 body 并等待响应（对方通过 tcp.RegisterReaderHandler 处理）
+// req.Payload 随打开帧发送，body 按 tcp.StreamChunkSize 分块发送，多兆字节的数据不必整个放入一条消息；
+// 分块消息固定在一个连接上直接写入，不经过发送队列（不受优先级、合并发送和带宽限制影响）
+// ctx 未设置截止时间时使用 DefaultCallTimeout
+func (n *Node) CallReader(ctx context.Context, req *tcp.ClusterReqMsg, body io.Reader) (*tcp.ClusterRespMsg, error) {
+	n.pending.Add(1)
+	defer n.pending.Add(-1)
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultCallTimeout)
+		defer cancel()
+	}
+
+	if req.SessionId == "" {
+		req.SessionId = event.NewID()
+	}
+
+	pool := n.GetPool()
+	if pool == nil {
+		return nil, fmt.Errorf("节点未连接")
+	}
+	if !n.Capabilities().Has(tcp.FeatureChunked) {
+		return nil, fmt.Errorf("节点不支持分块消息: %s", n.ServiceID)
+	}
+
+	// 只借用连接确定发送的连接，写入由连接自身的写锁保证不与其他消息交错
+	conn, err := pool.GetCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取连接失败: %w", err)
+	}
+	pool.Put(conn)
+
+	// 先登记再发送，避免响应先于登记到达
+	deadline, _ := ctx.Deadline()
+	call, err := n.calls.Add(req.SessionId, deadline)
+	if err != nil {
+		return nil, err
+	}
+	defer n.calls.Remove(req.SessionId)
+	n.calls.Bind(call, conn)
+
+	if err := tcp.SendStreamWith(ctx, conn, req, body, n.frameOptions()); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-call.Resp():
+		n.recordSuccess()
+		return resp, nil
+	case err := <-call.Err():
+		return nil, err
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			n.health.result(true)
+		}
+		return nil, fmt.Errorf("等待响应失败: module=%d, cmd=%d, sessionId=%s, %w",
+			req.Module, req.Cmd, req.SessionId, ctx.Err())
+	}
+}
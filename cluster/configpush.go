@@ -0,0 +1,119 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/charry/config"
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// 配置推送协议（系统模块，与心跳共用模块号）
+// 请求 Payload: 配置 JSON（与 Consul KV 中的应用配置格式相同，只合并存在的字段）
+// 响应 Code: 0 表示已应用，非 0 时 Payload 为错误信息
+const (
+	ConfigPushModule uint32 = tcp.HeartbeatModule
+	ConfigPushCmd    uint32 = 4
+
+	configPushFailedCode uint32 = 1
+)
+
+// ConfigPushReport 配置推送结果
+type ConfigPushReport struct {
+	Applied []string         // 已应用配置的节点（按 ServiceID 排序）
+	Failed  map[string]error // 未应用的节点：serviceID -> 原因（发送失败、超时或对方应用失败）
+}
+
+// Complete 是否所有节点都已应用
+func (r *ConfigPushReport) Complete() bool {
+	return len(r.Failed) == 0
+}
+
+// PushConfig 通过节点间 TCP 连接向指定类型的所有节点推送配置，等待每个节点确认
+// nodeType 为空时推送给所有节点；对方合并配置后发布 ConfigChanged 事件
+// 用于紧急变更，不经过 Consul KV（不会持久化，节点重启后以 KV 配置为准）
+func (m *Manager) PushConfig(nodeType string, payload []byte) *ConfigPushReport {
+	nodes := m.GetAllNodes()
+	if nodeType != "" {
+		nodes = m.GetNodesByType(nodeType)
+	}
+
+	report := &ConfigPushReport{Failed: make(map[string]error)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *Node) {
+			defer wg.Done()
+
+			err := pushConfigTo(node, payload)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Failed[node.ServiceID] = err
+			} else {
+				report.Applied = append(report.Applied, node.ServiceID)
+			}
+		}(node)
+	}
+	wg.Wait()
+
+	sort.Strings(report.Applied)
+	if !report.Complete() {
+		logger.Warnf("配置推送部分失败: 已应用 %d 个节点, 失败 %d 个节点", len(report.Applied), len(report.Failed))
+	} else {
+		logger.Infof("✓ 配置已推送到 %d 个节点", len(report.Applied))
+	}
+	return report
+}
+
+// pushConfigTo 向单个节点推送配置并等待确认
+func pushConfigTo(node *Node, payload []byte) error {
+	req := &tcp.ClusterReqMsg{
+		Module:  ConfigPushModule,
+		Cmd:     ConfigPushCmd,
+		Payload: payload,
+	}
+
+	// 配置推送属于控制消息，优先于普通请求发送
+	resp, err := node.CallPriority(context.Background(), req, PriorityHigh)
+	if err != nil {
+		return err
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("节点应用配置失败: %s", resp.Payload)
+	}
+	return nil
+}
+
+// handleConfigPushReq 处理其他节点推送的配置
+func handleConfigPushReq(req *tcp.ClusterReqMsg) *tcp.ClusterRespMsg {
+	resp := &tcp.ClusterRespMsg{
+		Module:    req.Module,
+		Cmd:       req.Cmd,
+		SessionId: req.SessionId,
+	}
+
+	if err := config.MergeFromJSON(string(req.Payload)); err != nil {
+		logger.Errorf("应用推送的配置失败: %v", err)
+		resp.Code = configPushFailedCode
+		resp.Payload = []byte(err.Error())
+		return resp
+	}
+
+	logger.Info("✓ 已应用推送的配置")
+	updatedCfg := config.Get()
+	event.PublishEvent(event_name.ConfigChanged, &updatedCfg)
+	return resp
+}
+
+func init() {
+	tcp.RegisterReqHandler(ConfigPushModule, ConfigPushCmd, handleConfigPushReq)
+}
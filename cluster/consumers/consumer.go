@@ -1,6 +1,8 @@
 package consumers
 
 import (
+	"context"
+
 	"github.com/charry/cluster"
 	"github.com/charry/constants/event_name"
 	"github.com/charry/constants/priority"
@@ -12,10 +14,10 @@ import (
 type ClusterInitConsumer struct{}
 
 func (c *ClusterInitConsumer) CaseEvent() []string {
-	return []string{event_name.ConsulClientCreated}
+	return []string{event_name.ConsulClientCreated, event_name.ClusterGossipStart}
 }
 
-func (c *ClusterInitConsumer) Triggered(evt *event.Event) error {
+func (c *ClusterInitConsumer) Triggered(ctx context.Context, evt *event.Event) error {
 	logger.Info("初始化集群模块...")
 	return cluster.Init()
 }
@@ -35,7 +37,7 @@ func (c *ClusterStopConsumer) CaseEvent() []string {
 	return []string{event_name.AppShutdown}
 }
 
-func (c *ClusterStopConsumer) Triggered(evt *event.Event) error {
+func (c *ClusterStopConsumer) Triggered(ctx context.Context, evt *event.Event) error {
 	cluster.Close()
 	return nil
 }
@@ -0,0 +1,63 @@
+package cluster
+
+import (
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// sendDatagram 请求的路由以 UDP 报文发送（tcp.RouteDatagram）且对方接收报文时，以报文发送
+// 返回 false 时由调用方改用 TCP 发送（对方未启用 UDP、报文过大或发送失败）
+func (n *Node) sendDatagram(req *tcp.ClusterReqMsg) bool {
+	if !tcp.IsDatagramRoute(req.Module, req.Cmd) || !n.caps.Load().Has(tcp.FeatureDatagram) {
+		return false
+	}
+	frame, err := tcp.EncodeDatagram(req, n.frameOptions())
+	if err != nil {
+		return false
+	}
+	defer frame.Release()
+	conn, err := n.datagramConn()
+	if err != nil {
+		logger.Warnf("创建 UDP 套接字失败，改用 TCP: %s, %v", n.ServiceID, err)
+		return false
+	}
+	if _, err := conn.Write(frame.B); err != nil {
+		// 对方端口不可达等错误会在之后的写入中返回，重新创建套接字
+		if !errors.Is(err, net.ErrClosed) {
+			logger.Debugf("发送 UDP 报文失败，改用 TCP: %s, %v", n.ServiceID, err)
+		}
+		n.closeDatagram()
+		return false
+	}
+	n.counters.datagrams.Add(1)
+	n.counters.bytesSent.Add(uint64(len(frame.B)))
+	return true
+}
+
+// datagramConn 发送报文的套接字（首次使用时按节点地址创建）
+func (n *Node) datagramConn() (net.Conn, error) {
+	n.datagramMu.Lock()
+	defer n.datagramMu.Unlock()
+	if n.datagram == nil {
+		conn, err := net.Dial("udp", net.JoinHostPort(n.Config.Addr.Host, strconv.Itoa(n.Config.Addr.Port)))
+		if err != nil {
+			return nil, err
+		}
+		n.datagram = conn
+	}
+	return n.datagram, nil
+}
+
+// closeDatagram 关闭发送报文的套接字
+func (n *Node) closeDatagram() {
+	n.datagramMu.Lock()
+	defer n.datagramMu.Unlock()
+	if n.datagram != nil {
+		n.datagram.Close()
+		n.datagram = nil
+	}
+}
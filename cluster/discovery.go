@@ -0,0 +1,192 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/charry/config"
+	"github.com/charry/consul"
+	"github.com/charry/logger"
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// ServiceInstance 服务实例
+type ServiceInstance struct {
+	ServiceID string
+	Config    *config.AppConfig
+}
+
+// Discovery 服务发现接口
+// 实现：ConsulDiscovery（Consul 服务目录）、GossipDiscovery（Gossip 成员协议）
+type Discovery interface {
+	// Register 注册本节点
+	Register(appConfig *config.AppConfig) error
+
+	// Deregister 注销本节点
+	Deregister(appConfig *config.AppConfig) error
+
+	// Watch 监听服务（serviceName 为 "type-environment"）的健康实例，阻塞直到 stopChan 关闭
+	// 首次就绪和每次变化时以全量实例列表回调
+	Watch(serviceName string, stopChan <-chan struct{}, handler func(instances []*ServiceInstance))
+
+	// Instances 查询服务当前的全量健康实例（用于全量同步）
+	Instances(serviceName string) ([]*ServiceInstance, error)
+}
+
+// serviceIDOf 服务 ID（与 Consul 注册规则一致：type-environment-id）
+func serviceIDOf(appConfig *config.AppConfig) string {
+	return fmt.Sprintf("%s-%s-%d", appConfig.Type, appConfig.Environment, appConfig.Id)
+}
+
+// serviceNameOf 服务名称（同类服务共享：type-environment）
+func serviceNameOf(appConfig *config.AppConfig) string {
+	return fmt.Sprintf("%s-%s", appConfig.Type, appConfig.Environment)
+}
+
+// ConsulDiscovery 基于 Consul 服务目录的服务发现
+type ConsulDiscovery struct {
+	client *consul.Client
+}
+
+// NewConsulDiscovery 创建 Consul 服务发现
+func NewConsulDiscovery(client *consul.Client) *ConsulDiscovery {
+	return &ConsulDiscovery{client: client}
+}
+
+// Register 注册服务到 Consul
+func (d *ConsulDiscovery) Register(appConfig *config.AppConfig) error {
+	return d.client.RegisterService(appConfig)
+}
+
+// Deregister 从 Consul 注销服务
+func (d *ConsulDiscovery) Deregister(appConfig *config.AppConfig) error {
+	return d.client.DeregisterService(appConfig)
+}
+
+// Watch 通过阻塞查询监听 Consul 服务变化
+// 查询失败恢复后或索引回退（Consul 重启、快照恢复）时重置索引并以全量实例回调，不依赖增量索引
+func (d *ConsulDiscovery) Watch(serviceName string, stopChan <-chan struct{}, handler func(instances []*ServiceInstance)) {
+	var lastIndex uint64
+	isFirstCheck := true
+	recovering := false
+
+	for {
+		select {
+		case <-stopChan:
+			logger.Info("停止监听服务变化")
+			return
+		default:
+			// 使用阻塞查询监听服务变化
+			instances, meta, err := d.query(serviceName, &consulapi.QueryOptions{
+				WaitIndex: lastIndex,
+				WaitTime:  30 * time.Second,
+			})
+
+			if err != nil {
+				logger.Errorf("查询服务失败: %v", err)
+				// 失败期间可能错过变化，恢复后重新全量同步
+				lastIndex = 0
+				recovering = true
+				time.Sleep(5 * time.Second)
+				continue
+			}
+
+			// 索引回退说明 Consul 状态被重置，旧索引不再可信
+			if meta.LastIndex < lastIndex {
+				logger.Warnf("服务索引回退: %d -> %d，执行全量同步", lastIndex, meta.LastIndex)
+				recovering = true
+			}
+
+			// 第一次查询加载现有服务，之后只在索引变化或恢复后回调
+			if !isFirstCheck && !recovering && meta.LastIndex <= lastIndex {
+				continue
+			}
+			lastIndex = meta.LastIndex
+
+			switch {
+			case isFirstCheck:
+				isFirstCheck = false
+				logger.Infof("加载现有服务，共 %d 个", len(instances))
+			case recovering:
+				logger.Infof("服务查询已恢复，全量同步 %d 个服务", len(instances))
+			default:
+				logger.Info("检测到服务变化")
+			}
+			recovering = false
+			handler(instances)
+		}
+	}
+}
+
+// Instances 查询 Consul 中服务当前的健康实例
+func (d *ConsulDiscovery) Instances(serviceName string) ([]*ServiceInstance, error) {
+	instances, _, err := d.query(serviceName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("查询服务失败: %w", err)
+	}
+	return instances, nil
+}
+
+// query 查询健康的服务实例（解析失败的实例跳过）
+func (d *ConsulDiscovery) query(serviceName string, opts *consulapi.QueryOptions) ([]*ServiceInstance, *consulapi.QueryMeta, error) {
+	services, meta, err := d.client.GetClient().Health().Service(
+		serviceName,
+		"",
+		true, // 只获取健康的服务
+		opts,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instances := make([]*ServiceInstance, 0, len(services))
+	for _, service := range services {
+		appConfig, err := parseServiceConfig(service)
+		if err != nil {
+			logger.Errorf("解析服务配置失败: %s, %v", service.Service.ID, err)
+			continue
+		}
+		instances = append(instances, &ServiceInstance{ServiceID: service.Service.ID, Config: appConfig})
+	}
+	return instances, meta, nil
+}
+
+// parseServiceConfig 从 Consul 服务解析 AppConfig
+// Meta 中已经包含了 AppConfig 的所有字段（展开后）
+func parseServiceConfig(service *consulapi.ServiceEntry) (*config.AppConfig, error) {
+	meta := service.Service.Meta
+
+	appConfig := &config.AppConfig{
+		Type:        meta["type"],
+		Environment: meta["environment"],
+		Addr: config.Addr{
+			Host: meta["host"],
+		},
+		Data: make(map[string]any),
+	}
+
+	// 解析 id
+	if idStr, ok := meta["id"]; ok {
+		var id uint16
+		fmt.Sscanf(idStr, "%d", &id)
+		appConfig.Id = id
+	}
+
+	// 解析 port
+	if portStr, ok := meta["port"]; ok {
+		var port int
+		fmt.Sscanf(portStr, "%d", &port)
+		appConfig.Addr.Port = port
+	}
+
+	// 解析 data（JSON 字符串）
+	if dataJSON, ok := meta["data"]; ok && dataJSON != "" {
+		var data map[string]any
+		if err := json.Unmarshal([]byte(dataJSON), &data); err == nil {
+			appConfig.Data = data
+		}
+	}
+
+	return appConfig, nil
+}
@@ -0,0 +1,71 @@
+package cluster
+
+import (
+	"github.com/charry/config"
+	"github.com/charry/event"
+)
+
+// NodeEventData 节点加入、移除事件数据
+type NodeEventData struct {
+	ServiceID   string
+	Id          uint16
+	Type        string
+	Environment string
+}
+
+// NodeUpdatedEventData 节点配置变化事件数据
+type NodeUpdatedEventData struct {
+	NodeEventData
+	OldConfig *config.AppConfig
+	NewConfig *config.AppConfig
+	Changes   []string // 变化的字段（如 addr.port、data.weight）
+}
+
+// ClusterChangedEventData 集群变化事件数据（一次服务变化处理的汇总）
+type ClusterChangedEventData struct {
+	Added     []string // 加入的节点 ServiceID
+	Removed   []string // 移除的节点 ServiceID
+	Updated   []string // 配置变化的节点 ServiceID
+	NodeCount int      // 变化后的节点数
+}
+
+// ShardEventData 分片获得、释放事件数据
+type ShardEventData struct {
+	Shard     int    // 分片编号
+	ServiceID string // 本节点服务 ID
+}
+
+// eventData 节点事件数据
+func (n *Node) eventData() NodeEventData {
+	return NodeEventData{
+		ServiceID:   n.ServiceID,
+		Id:          n.Id,
+		Type:        n.Type,
+		Environment: n.Environment,
+	}
+}
+
+// OnMembershipChange 注册节点增减监听（在节点加入、移除、排空或暂停状态变化后同步调用）
+func (m *Manager) OnMembershipChange(fn func()) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// membershipChanged 发布节点事件并通知监听者
+func (m *Manager) membershipChanged(eventName string, node *Node) {
+	data := node.eventData()
+	event.PublishEvent(eventName, &data)
+	m.notifyMembership()
+}
+
+// notifyMembership 通知成员变化监听器
+func (m *Manager) notifyMembership() {
+	m.listenersMu.RLock()
+	listeners := m.listeners
+	m.listenersMu.RUnlock()
+
+	for _, fn := range listeners {
+		fn()
+	}
+}
@@ -0,0 +1,83 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charry/event"
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// DefaultCallAttempts CallAny 默认最多尝试次数（含首次）
+const DefaultCallAttempts = 3
+
+// CallOptions CallAny 配置
+type CallOptions struct {
+	Strategy       Strategy      // 负载均衡策略（默认轮询）
+	Priority       Priority      // 发送优先级（默认 PriorityNormal）
+	MaxAttempts    int           // 最多尝试次数，含首次（默认 DefaultCallAttempts）
+	AttemptTimeout time.Duration // 单次尝试超时（默认 DefaultCallTimeout，不超过 ctx 剩余时间）
+	Backoff        time.Duration // 重试前等待时间（默认立即重试）
+}
+
+// CallAny 向指定类型的节点发送请求并等待响应，失败时自动切换到同类型的其他节点重试
+// 超时、连接断开、节点熔断等失败都会重试，每个节点最多尝试一次
+// 重试次数受 MaxAttempts 限制，总耗时受 ctx 限制；调用方取消或 ctx 到期后不再重试
+// 各次尝试使用相同的 SessionId，对端可据此去重
+func (m *Manager) CallAny(ctx context.Context, nodeType string, req *tcp.ClusterReqMsg, opts CallOptions) (*tcp.ClusterRespMsg, error) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultCallAttempts
+	}
+	if opts.AttemptTimeout <= 0 {
+		opts.AttemptTimeout = DefaultCallTimeout
+	}
+
+	if req.SessionId == "" {
+		req.SessionId = event.NewID()
+	}
+	if len(req.SessionId) > tcp.HeaderSessionIdSize {
+		return nil, fmt.Errorf("sessionId 超过 %d 字节: %s", tcp.HeaderSessionIdSize, req.SessionId)
+	}
+
+	tried := make(map[string]bool)
+	var errs []error
+	for attempt := 1; attempt <= opts.MaxAttempts; attempt++ {
+		node, err := m.pickNode(nodeType, opts.Strategy, tried)
+		if err != nil {
+			if len(errs) == 0 {
+				return nil, err
+			}
+			break // 没有其他可用节点
+		}
+		tried[node.ServiceID] = true
+
+		attemptCtx, cancel := context.WithTimeout(ctx, opts.AttemptTimeout)
+		resp, err := node.CallPriority(attemptCtx, req, opts.Priority)
+		cancel()
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", node.ServiceID, err))
+
+		if ctx.Err() != nil || attempt == opts.MaxAttempts {
+			break
+		}
+		logger.Warnf("调用节点失败，切换节点重试: %s, module=%d, cmd=%d, 第 %d 次, %v",
+			node.ServiceID, req.Module, req.Cmd, attempt, err)
+
+		if opts.Backoff > 0 {
+			select {
+			case <-time.After(opts.Backoff):
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("调用 %s 节点失败（尝试 %d 次）: %w", nodeType, len(errs), errors.Join(errs...))
+}
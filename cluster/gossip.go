@@ -0,0 +1,441 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/charry/config"
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// 服务发现方式（config server.cluster_discovery）
+const (
+	DiscoveryConsul = "consul" // Consul 服务发现（默认）
+	DiscoveryGossip = "gossip" // Gossip 成员协议（无需 Consul）
+)
+
+// Gossip 消息协议（系统模块，与心跳共用模块号）
+// 请求和响应的 Payload 均为成员列表 JSON（push-pull 交换）
+const (
+	GossipModule uint32 = tcp.HeartbeatModule
+	GossipCmd    uint32 = 3
+)
+
+// GossipOptions Gossip 成员协议配置
+type GossipOptions struct {
+	Seeds       []string      // 种子节点 TCP 地址（host:port），没有已知成员时从种子加入
+	Interval    time.Duration // 交换间隔（默认 1 秒）
+	Fanout      int           // 每轮交换的成员数（默认 3）
+	FailTimeout time.Duration // 多久未收到成员的新心跳判定为失效（默认 10 秒）
+	DialTimeout time.Duration // 单次交换的连接和读写超时（默认 2 秒）
+}
+
+// gossipMember 成员信息（在节点间传播）
+type gossipMember struct {
+	ServiceID string            `json:"service_id"`
+	Heartbeat uint64            `json:"heartbeat"`      // 心跳计数，只增不减（以启动时间为起点，重启后仍然更大）
+	Left      bool              `json:"left,omitempty"` // 主动离开
+	Config    *config.AppConfig `json:"config"`
+}
+
+// gossipEntry 本地成员表项
+type gossipEntry struct {
+	member   gossipMember
+	lastSeen time.Time // 最近一次收到新心跳的时间
+	dead     bool      // 已失效或已离开（保留为墓碑，防止旧心跳使其复活）
+}
+
+// gossipWatcher 成员变化监听者
+type gossipWatcher struct {
+	serviceName string
+	handler     func(instances []*ServiceInstance)
+}
+
+// GossipDiscovery 基于 Gossip 成员协议的服务发现（无需 Consul）
+// 节点间通过 TCP 端口 push-pull 交换成员表：种子节点引导加入，心跳超时检测失效，配置随心跳传播
+type GossipDiscovery struct {
+	options GossipOptions
+
+	self    gossipMember
+	members map[string]*gossipEntry
+	mu      sync.Mutex
+
+	// 监听者: id -> watcher
+	watchers   map[uint64]*gossipWatcher
+	watcherSeq uint64
+	watchMu    sync.Mutex
+	notifyMu   sync.Mutex // 串行通知，避免并发交换导致回调交错
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopChan  chan struct{}
+}
+
+// NewGossipDiscovery 创建 Gossip 服务发现
+func NewGossipDiscovery(options GossipOptions) *GossipDiscovery {
+	if options.Interval <= 0 {
+		options.Interval = time.Second
+	}
+	if options.Fanout <= 0 {
+		options.Fanout = 3
+	}
+	if options.FailTimeout <= 0 {
+		options.FailTimeout = 10 * time.Second
+	}
+	if options.DialTimeout <= 0 {
+		options.DialTimeout = 2 * time.Second
+	}
+
+	return &GossipDiscovery{
+		options:  options,
+		members:  make(map[string]*gossipEntry),
+		watchers: make(map[uint64]*gossipWatcher),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Register 加入集群：开始在成员间传播本节点（只能注册一次）
+func (g *GossipDiscovery) Register(appConfig *config.AppConfig) error {
+	if appConfig == nil {
+		return fmt.Errorf("appConfig is nil")
+	}
+
+	started := false
+	g.startOnce.Do(func() {
+		g.mu.Lock()
+		g.self = gossipMember{
+			ServiceID: serviceIDOf(appConfig),
+			Heartbeat: uint64(time.Now().UnixNano()),
+			Config:    appConfig,
+		}
+		g.mu.Unlock()
+
+		tcp.RegisterReqHandler(GossipModule, GossipCmd, g.handleReq)
+		go g.loop()
+		started = true
+	})
+	if !started {
+		return fmt.Errorf("Gossip 已注册")
+	}
+
+	logger.Infof("✓ Gossip 成员协议已启动: %s, 种子节点: %v", serviceIDOf(appConfig), g.options.Seeds)
+	return nil
+}
+
+// Deregister 主动离开：向部分成员广播离开状态后停止
+func (g *GossipDiscovery) Deregister(appConfig *config.AppConfig) error {
+	g.stopOnce.Do(func() {
+		close(g.stopChan)
+
+		g.mu.Lock()
+		g.self.Heartbeat++
+		g.self.Left = true
+		g.mu.Unlock()
+
+		var wg sync.WaitGroup
+		for _, addr := range g.pickTargets() {
+			wg.Add(1)
+			go func(addr string) {
+				defer wg.Done()
+				_ = g.exchange(addr)
+			}(addr)
+		}
+		wg.Wait()
+		logger.Infof("Gossip 已离开集群: %s", serviceIDOf(appConfig))
+	})
+	return nil
+}
+
+// Update 更新本节点配置（元数据），随下一轮心跳传播到其他成员
+func (g *GossipDiscovery) Update(appConfig *config.AppConfig) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.self.Config = appConfig
+	g.self.Heartbeat++
+}
+
+// Watch 监听指定服务的存活成员（serviceName 为空时监听所有成员）
+func (g *GossipDiscovery) Watch(serviceName string, stopChan <-chan struct{}, handler func(instances []*ServiceInstance)) {
+	g.watchMu.Lock()
+	g.watcherSeq++
+	id := g.watcherSeq
+	watcher := &gossipWatcher{serviceName: serviceName, handler: handler}
+	g.watchers[id] = watcher
+	g.watchMu.Unlock()
+
+	// 首次回调当前成员
+	handler(g.instances(serviceName))
+
+	<-stopChan
+
+	g.watchMu.Lock()
+	delete(g.watchers, id)
+	g.watchMu.Unlock()
+	logger.Info("停止监听服务变化")
+}
+
+// Instances 指定服务当前的存活成员
+func (g *GossipDiscovery) Instances(serviceName string) ([]*ServiceInstance, error) {
+	return g.instances(serviceName), nil
+}
+
+// notify 成员变化时通知所有监听者
+func (g *GossipDiscovery) notify() {
+	g.notifyMu.Lock()
+	defer g.notifyMu.Unlock()
+
+	g.watchMu.Lock()
+	watchers := make([]*gossipWatcher, 0, len(g.watchers))
+	for _, watcher := range g.watchers {
+		watchers = append(watchers, watcher)
+	}
+	g.watchMu.Unlock()
+
+	for _, watcher := range watchers {
+		watcher.handler(g.instances(watcher.serviceName))
+	}
+}
+
+// instances 指定服务的存活成员
+func (g *GossipDiscovery) instances(serviceName string) []*ServiceInstance {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	instances := make([]*ServiceInstance, 0, len(g.members))
+	for serviceID, entry := range g.members {
+		if entry.dead {
+			continue
+		}
+		if serviceName != "" && serviceNameOf(entry.member.Config) != serviceName {
+			continue
+		}
+		instances = append(instances, &ServiceInstance{ServiceID: serviceID, Config: entry.member.Config})
+	}
+	return instances
+}
+
+// loop 定时与随机成员交换成员表
+func (g *GossipDiscovery) loop() {
+	ticker := time.NewTicker(g.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.stopChan:
+			return
+		case <-ticker.C:
+			g.tick()
+		}
+	}
+}
+
+// tick 执行一轮 Gossip
+func (g *GossipDiscovery) tick() {
+	// 更新自身心跳
+	g.mu.Lock()
+	g.self.Heartbeat++
+	g.mu.Unlock()
+
+	g.detectFailures()
+
+	for _, addr := range g.pickTargets() {
+		go func(addr string) {
+			if err := g.exchange(addr); err != nil {
+				logger.Debugf("Gossip 交换失败: %s, %v", addr, err)
+			}
+		}(addr)
+	}
+}
+
+// pickTargets 随机选择交换对象，没有存活成员时使用种子节点
+func (g *GossipDiscovery) pickTargets() []string {
+	g.mu.Lock()
+	addrs := make([]string, 0, len(g.members))
+	for _, entry := range g.members {
+		if !entry.dead && entry.member.Config != nil {
+			addrs = append(addrs, memberAddr(entry.member.Config))
+		}
+	}
+	g.mu.Unlock()
+
+	if len(addrs) == 0 {
+		addrs = append(addrs, g.options.Seeds...)
+	}
+
+	rand.Shuffle(len(addrs), func(i, j int) {
+		addrs[i], addrs[j] = addrs[j], addrs[i]
+	})
+	if len(addrs) > g.options.Fanout {
+		addrs = addrs[:g.options.Fanout]
+	}
+	return addrs
+}
+
+// exchange 与指定地址交换成员表（发送本地成员表，合并对方返回的成员表）
+func (g *GossipDiscovery) exchange(addr string) error {
+	g.mu.Lock()
+	selfAddr := memberAddr(g.self.Config)
+	g.mu.Unlock()
+	if addr == selfAddr {
+		return nil // 种子节点是自己
+	}
+
+	payload, err := json.Marshal(g.snapshot())
+	if err != nil {
+		return fmt.Errorf("序列化成员表失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.options.DialTimeout)
+	conn, err := tcp.Dial(ctx, addr)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// 握手：不与其他环境、集群的节点交换成员表
+	if _, err := tcp.SendHandshake(conn); err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(g.options.DialTimeout))
+
+	req := &tcp.ClusterReqMsg{Module: GossipModule, Cmd: GossipCmd, Payload: payload}
+	if _, err := conn.Write(tcp.EncodeClusterReqMsg(req)); err != nil {
+		return err
+	}
+
+	msg, err := tcp.DecodeMsg(conn)
+	if err != nil {
+		return err
+	}
+	resp, ok := msg.(*tcp.ClusterRespMsg)
+	if !ok {
+		return fmt.Errorf("非响应消息")
+	}
+
+	var members []gossipMember
+	if err := json.Unmarshal(resp.Payload, &members); err != nil {
+		return fmt.Errorf("解析成员表失败: %w", err)
+	}
+	g.merge(members)
+	return nil
+}
+
+// handleReq 处理其他节点发来的成员表，返回本地成员表
+func (g *GossipDiscovery) handleReq(req *tcp.ClusterReqMsg) *tcp.ClusterRespMsg {
+	var members []gossipMember
+	if err := json.Unmarshal(req.Payload, &members); err != nil {
+		logger.Warnf("解析 Gossip 成员表失败: %v", err)
+		return nil
+	}
+	g.merge(members)
+
+	payload, err := json.Marshal(g.snapshot())
+	if err != nil {
+		logger.Errorf("序列化 Gossip 成员表失败: %v", err)
+		return nil
+	}
+	return &tcp.ClusterRespMsg{Module: req.Module, Cmd: req.Cmd, Payload: payload}
+}
+
+// snapshot 本地成员表（自身 + 存活或已离开的成员）
+func (g *GossipDiscovery) snapshot() []gossipMember {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	members := make([]gossipMember, 0, len(g.members)+1)
+	members = append(members, g.self)
+	for _, entry := range g.members {
+		// 失效的成员不再传播，由各节点自行检测；离开的成员需要传播离开状态
+		if !entry.dead || entry.member.Left {
+			members = append(members, entry.member)
+		}
+	}
+	return members
+}
+
+// merge 合并成员表，心跳更新的成员才会生效，成员变化时通知监听者
+func (g *GossipDiscovery) merge(members []gossipMember) {
+	changed := false
+
+	g.mu.Lock()
+	now := time.Now()
+	for _, member := range members {
+		if member.ServiceID == "" || member.ServiceID == g.self.ServiceID || member.Config == nil {
+			continue
+		}
+
+		entry, exists := g.members[member.ServiceID]
+		if exists && member.Heartbeat <= entry.member.Heartbeat {
+			continue
+		}
+		if !exists {
+			entry = &gossipEntry{dead: true}
+			g.members[member.ServiceID] = entry
+		}
+
+		wasDead := entry.dead
+		oldConfig := entry.member.Config
+		entry.member = member
+		entry.lastSeen = now
+
+		switch {
+		case member.Left:
+			entry.dead = true
+			if !wasDead {
+				logger.Infof("Gossip 成员已离开: %s", member.ServiceID)
+				changed = true
+			}
+		case wasDead:
+			entry.dead = false
+			logger.Infof("Gossip 发现新成员: %s", member.ServiceID)
+			changed = true
+		case isConfigChanged(oldConfig, member.Config):
+			changed = true
+		}
+	}
+	g.mu.Unlock()
+
+	if changed {
+		g.notify()
+	}
+}
+
+// detectFailures 检测失效成员，清理过期墓碑
+func (g *GossipDiscovery) detectFailures() {
+	changed := false
+
+	g.mu.Lock()
+	now := time.Now()
+	for serviceID, entry := range g.members {
+		silence := now.Sub(entry.lastSeen)
+		if entry.dead {
+			// 墓碑保留一段时间，确保旧心跳已在集群中消失
+			if silence > 10*g.options.FailTimeout {
+				delete(g.members, serviceID)
+			}
+			continue
+		}
+		if silence > g.options.FailTimeout {
+			entry.dead = true
+			logger.Warnf("Gossip 成员失效: %s", serviceID)
+			changed = true
+		}
+	}
+	g.mu.Unlock()
+
+	if changed {
+		g.notify()
+	}
+}
+
+// memberAddr 成员 TCP 地址
+func memberAddr(cfg *config.AppConfig) string {
+	return net.JoinHostPort(cfg.Addr.Host, fmt.Sprintf("%d", cfg.Addr.Port))
+}
@@ -0,0 +1,127 @@
+package cluster
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// hashRingReplicas 每个节点的虚拟节点数
+const hashRingReplicas = 160
+
+// hashRing 一致性哈希环
+type hashRing struct {
+	hashes []uint32          // 已排序的虚拟节点哈希
+	owners map[uint32]string // 虚拟节点哈希 -> ServiceID
+}
+
+// newHashRing 按节点 ServiceID 构建哈希环
+func newHashRing(serviceIDs []string) *hashRing {
+	r := &hashRing{
+		hashes: make([]uint32, 0, len(serviceIDs)*hashRingReplicas),
+		owners: make(map[uint32]string, len(serviceIDs)*hashRingReplicas),
+	}
+	for _, id := range serviceIDs {
+		for i := 0; i < hashRingReplicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(id + "#" + strconv.Itoa(i)))
+			if _, exists := r.owners[h]; exists {
+				continue // 哈希冲突，跳过该虚拟节点
+			}
+			r.owners[h] = id
+			r.hashes = append(r.hashes, h)
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool {
+		return r.hashes[i] < r.hashes[j]
+	})
+	return r
+}
+
+// walk 从 key 所在位置顺时针遍历节点（每个节点只返回一次），fn 返回 true 时停止
+func (r *hashRing) walk(key string, fn func(serviceID string) bool) {
+	if len(r.hashes) == 0 {
+		return
+	}
+
+	h := crc32.ChecksumIEEE([]byte(key))
+	start := sort.Search(len(r.hashes), func(i int) bool {
+		return r.hashes[i] >= h
+	})
+
+	visited := make(map[string]bool)
+	for i := 0; i < len(r.hashes); i++ {
+		id := r.owners[r.hashes[(start+i)%len(r.hashes)]]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		if fn(id) {
+			return
+		}
+	}
+}
+
+// hashRings 各节点类型的哈希环（节点变化时失效，下次使用时重建）
+type hashRings struct {
+	rings map[string]*hashRing
+	mu    sync.Mutex
+}
+
+// newHashRings 创建哈希环集合
+func newHashRings() *hashRings {
+	return &hashRings{
+		rings: make(map[string]*hashRing),
+	}
+}
+
+// invalidate 使指定类型的哈希环失效
+func (h *hashRings) invalidate(nodeType string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.rings, nodeType)
+}
+
+// PickNodeByKey 按 key 一致性哈希选择节点
+// 同一 key 总是落到同一节点；节点增减时只有少量 key 会迁移
+// 目标节点未连接时顺延到环上的下一个已连接节点
+func (m *Manager) PickNodeByKey(nodeType, key string) (*Node, error) {
+	ring := m.hashRing(nodeType)
+
+	var picked *Node
+	ring.walk(key, func(serviceID string) bool {
+		node := m.GetNode(serviceID)
+		if node != nil && node.GetStatus() == NodeStatusConnected {
+			picked = node
+			return true
+		}
+		return false
+	})
+
+	if picked == nil {
+		return nil, fmt.Errorf("没有可用节点: %s", nodeType)
+	}
+	return picked, nil
+}
+
+// hashRing 获取指定类型的哈希环，不存在时按当前节点构建
+func (m *Manager) hashRing(nodeType string) *hashRing {
+	m.rings.mu.Lock()
+	defer m.rings.mu.Unlock()
+
+	if ring, exists := m.rings.rings[nodeType]; exists {
+		return ring
+	}
+
+	// 包含未连接的节点，保证连接抖动时 key 的归属不变
+	nodes := m.GetNodesByType(nodeType)
+	ids := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ServiceID
+	}
+
+	ring := newHashRing(ids)
+	m.rings.rings[nodeType] = ring
+	return ring
+}
@@ -0,0 +1,150 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// 健康评分参数
+const (
+	// UnhealthyScore 健康分低于该值的节点在负载均衡时被跳过（所有节点都不健康时不跳过）
+	UnhealthyScore = 0.2
+
+	healthRTTBaseline   = 100 * time.Millisecond // RTT 等于该值时 RTT 系数为 0.5
+	healthRTTAlpha      = 0.3                    // RTT 平滑系数
+	healthErrorAlpha    = 0.1                    // 错误率平滑系数
+	healthReconnectWait = 30 * time.Second       // 重连后该时间内健康分减半
+	healthStaleBeats    = 3                      // 连续多少个心跳周期没有响应视为失联
+)
+
+// HealthStats 节点健康状态
+type HealthStats struct {
+	RTT           time.Duration // 心跳往返时间（平滑值）
+	ErrorRate     float64       // 请求错误率（平滑值，0 ~ 1）
+	Reconnects    uint64        // 累计重连次数
+	LastHeartbeat time.Time     // 最近一次收到心跳响应的时间
+	BeatsLost     uint64        // 累计丢失的心跳数（下一次心跳前没有收到响应）
+	Score         float64       // 健康分（0 ~ 1，越大越健康）
+}
+
+// nodeHealth 节点健康跟踪
+type nodeHealth struct {
+	rtt           time.Duration
+	errorRate     float64
+	reconnects    uint64
+	lastReconnect time.Time
+	lastBeat      time.Time
+	beatsLost     uint64
+	mu            sync.Mutex
+}
+
+// beatLost 记录一次丢失的心跳
+func (h *nodeHealth) beatLost() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.beatsLost++
+}
+
+// beatReceived 收到心跳响应，按该心跳的往返时间更新 RTT
+func (h *nodeHealth) beatReceived(rtt time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastBeat = time.Now()
+	if rtt <= 0 {
+		return
+	}
+	if h.rtt == 0 {
+		h.rtt = rtt
+	} else {
+		h.rtt = time.Duration(healthRTTAlpha*float64(rtt) + (1-healthRTTAlpha)*float64(h.rtt))
+	}
+}
+
+// connected 连接（重新）建立，心跳失联计时从此开始
+func (h *nodeHealth) connected(reconnect bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.lastBeat = now
+	if reconnect {
+		h.reconnects++
+		h.lastReconnect = now
+	}
+}
+
+// result 记录一次请求结果
+func (h *nodeHealth) result(failed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sample := 0.0
+	if failed {
+		sample = 1
+	}
+	h.errorRate = healthErrorAlpha*sample + (1-healthErrorAlpha)*h.errorRate
+}
+
+// stats 计算健康状态（interval 为与该节点的心跳间隔）
+func (h *nodeHealth) stats(interval time.Duration) HealthStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := HealthStats{
+		RTT:           h.rtt,
+		ErrorRate:     h.errorRate,
+		Reconnects:    h.reconnects,
+		LastHeartbeat: h.lastBeat,
+		BeatsLost:     h.beatsLost,
+	}
+
+	now := time.Now()
+	if !h.lastBeat.IsZero() && now.Sub(h.lastBeat) > healthStaleBeats*interval {
+		return stats // 心跳失联，健康分为 0
+	}
+
+	score := 1 - h.errorRate
+	score *= float64(healthRTTBaseline) / float64(healthRTTBaseline+h.rtt)
+	if !h.lastReconnect.IsZero() && now.Sub(h.lastReconnect) < healthReconnectWait {
+		score *= 0.5
+	}
+	stats.Score = score
+	return stats
+}
+
+// Health 获取节点健康状态
+func (n *Node) Health() HealthStats {
+	return n.health.stats(n.HeartbeatTiming().Interval)
+}
+
+// HealthScore 获取节点健康分（0 ~ 1）
+func (n *Node) HealthScore() float64 {
+	return n.health.stats(n.HeartbeatTiming().Interval).Score
+}
+
+// healthyNodes 过滤不健康的节点（全部不健康时原样返回，避免完全不可用）
+func healthyNodes(nodes []*Node) []*Node {
+	healthy := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		if node.HealthScore() >= UnhealthyScore {
+			healthy = append(healthy, node)
+		}
+	}
+	if len(healthy) == 0 {
+		return nodes
+	}
+	return healthy
+}
+
+// withHealth 就近系数乘以健康分（factors 为 nil 时视为全 1）
+func withHealth(nodes []*Node, factors []float64) []float64 {
+	weighted := make([]float64, len(nodes))
+	for i, node := range nodes {
+		weighted[i] = node.HealthScore()
+		if factors != nil {
+			weighted[i] *= factors[i]
+		}
+	}
+	return weighted
+}
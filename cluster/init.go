@@ -1,7 +1,9 @@
 package cluster
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/charry/config"
 	"github.com/charry/consul"
@@ -11,25 +13,68 @@ import (
 var (
 	// GlobalManager 全局集群管理器
 	GlobalManager *Manager
+
+	// GlobalDiscovery 全局服务发现
+	GlobalDiscovery Discovery
 )
 
 // Init 初始化集群模块
 func Init() error {
 	logger.Info("初始化集群模块...")
 
-	if consul.GlobalClient == nil {
-		return fmt.Errorf("Consul 客户端未初始化")
+	// 获取配置
+	cfg := config.Get()
+
+	switch cfg.Server.ClusterDiscovery {
+	case DiscoveryGossip:
+		// Gossip 服务发现：不依赖 Consul，由集群模块负责注册本节点
+		discovery := NewGossipDiscovery(GossipOptions{Seeds: cfg.Server.ClusterGossipSeeds})
+		if err := discovery.Register(&cfg.App); err != nil {
+			return fmt.Errorf("加入 Gossip 集群失败: %w", err)
+		}
+		GlobalDiscovery = discovery
+
+	case DiscoveryConsul, "":
+		// Consul 服务发现：服务注册由 Consul 模块完成
+		if consul.GlobalClient == nil {
+			return fmt.Errorf("Consul 客户端未初始化")
+		}
+		GlobalDiscovery = NewConsulDiscovery(consul.GlobalClient)
+
+	default:
+		return fmt.Errorf("未知的服务发现方式: %s", cfg.Server.ClusterDiscovery)
 	}
 
 	// 创建集群管理器
-	GlobalManager = NewManager(consul.GlobalClient.GetClient())
-
-	// 获取配置
-	cfg := config.Get()
+	GlobalManager = NewManager(GlobalDiscovery)
+	GlobalManager.SetRateLimit(RateLimit{
+		RequestsPerSecond: cfg.Server.ClusterRateLimit,
+		MaxInflight:       cfg.Server.ClusterMaxInflight,
+	})
+	GlobalManager.SetBandwidth(Bandwidth{BytesPerSecond: cfg.Server.ClusterBandwidth})
+	if cfg.Server.ClusterBatch.Enabled {
+		opts := Batching{MaxBytes: cfg.Server.ClusterBatch.MaxBytes}
+		if err := parseDuration(cfg.Server.ClusterBatch.FlushInterval, &opts.FlushInterval); err != nil {
+			return fmt.Errorf("解析发送合并配置失败: %w", err)
+		}
+		GlobalManager.SetBatching(opts)
+	}
+	policy, err := reconnectPolicyOf(cfg.Server.ClusterReconnect)
+	if err != nil {
+		return err
+	}
+	GlobalManager.SetReconnectPolicy(policy)
+	if cfg.Server.ClusterBreaker.Enabled {
+		opts, err := breakerOptionsOf(cfg.Server.ClusterBreaker)
+		if err != nil {
+			return err
+		}
+		GlobalManager.SetBreaker(opts)
+	}
 
-	// 监听同类型服务
-	serviceName := fmt.Sprintf("%s-%s", cfg.App.Type, cfg.App.Environment)
-	GlobalManager.WatchServices(serviceName)
+	// 监听同类型服务和配置的其他服务（有节点缓存时先预连接缓存中的节点）
+	GlobalManager.SetNodeCache(cfg.Server.ClusterNodeCache)
+	GlobalManager.WatchServices(watchedServicesOf(&cfg)...)
 
 	logger.Info("✓ 集群模块初始化完成")
 	return nil
@@ -39,8 +84,75 @@ func Init() error {
 func Close() {
 	if GlobalManager != nil {
 		logger.Info("关闭集群模块...")
+
+		// Gossip 需要主动通知其他成员离开（Consul 由 Consul 模块注销）
+		if gossip, ok := GlobalDiscovery.(*GossipDiscovery); ok {
+			cfg := config.Get()
+			gossip.Deregister(&cfg.App)
+		}
+
 		GlobalManager.Close()
 		logger.Info("✓ 集群模块已关闭")
 	}
 }
 
+// watchedServicesOf 需要监听的服务：本节点所在服务在前，其后为 cluster_watch（去重）
+func watchedServicesOf(cfg *config.Config) []string {
+	names := []string{serviceNameOf(&cfg.App)}
+	seen := map[string]bool{names[0]: true}
+	for _, name := range cfg.Server.ClusterWatch {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// reconnectPolicyOf 从配置解析重连退避策略
+func reconnectPolicyOf(cfg config.ReconnectConfig) (ReconnectPolicy, error) {
+	policy := ReconnectPolicy{
+		Multiplier:  cfg.Multiplier,
+		Jitter:      cfg.Jitter,
+		MaxAttempts: cfg.MaxAttempts,
+	}
+	err := errors.Join(
+		parseDuration(cfg.InitialDelay, &policy.InitialDelay),
+		parseDuration(cfg.MaxDelay, &policy.MaxDelay),
+	)
+	if err != nil {
+		return policy, fmt.Errorf("解析重连配置失败: %w", err)
+	}
+	return policy, nil
+}
+
+// breakerOptionsOf 从配置解析熔断配置
+func breakerOptionsOf(cfg config.BreakerConfig) (BreakerOptions, error) {
+	opts := BreakerOptions{
+		MinRequests: cfg.MinRequests,
+		ErrorRate:   cfg.ErrorRate,
+		SlowRate:    cfg.SlowRate,
+	}
+	err := errors.Join(
+		parseDuration(cfg.Window, &opts.Window),
+		parseDuration(cfg.SlowCall, &opts.SlowCall),
+		parseDuration(cfg.OpenTimeout, &opts.OpenTimeout),
+	)
+	if err != nil {
+		return opts, fmt.Errorf("解析熔断配置失败: %w", err)
+	}
+	return opts, nil
+}
+
+// parseDuration 解析时长配置（空串时保持零值）
+func parseDuration(value string, d *time.Duration) error {
+	if value == "" {
+		return nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("无效的时长 %q: %w", value, err)
+	}
+	*d = parsed
+	return nil
+}
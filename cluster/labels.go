@@ -0,0 +1,216 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charry/config"
+)
+
+// LabelsKey 节点标签在配置 data 中的键（值为对象，如 {"role": "ingest", "gpu": true}）
+const LabelsKey = "labels"
+
+// Labels 获取节点标签（配置 data.labels，非字符串的值转换为字符串）
+func (n *Node) Labels() map[string]string {
+	return labelsOf(n.Config)
+}
+
+// labelsOf 从配置读取标签
+func labelsOf(appConfig *config.AppConfig) map[string]string {
+	labels := make(map[string]string)
+	if appConfig == nil || appConfig.Data == nil {
+		return labels
+	}
+
+	raw, _ := appConfig.Data[LabelsKey].(map[string]any)
+	for key, value := range raw {
+		labels[key] = fmt.Sprint(value)
+	}
+	return labels
+}
+
+// selectorOp 标签选择条件运算
+type selectorOp int
+
+const (
+	selectorEquals    selectorOp = iota // key=value、key==value
+	selectorNotEquals                   // key!=value
+	selectorIn                          // key in (a,b)
+	selectorNotIn                       // key notin (a,b)
+	selectorExists                      // key
+	selectorNotExists                   // !key
+)
+
+// requirement 单个标签选择条件
+type requirement struct {
+	key    string
+	op     selectorOp
+	values []string
+}
+
+// matches 是否满足条件
+// 与 Kubernetes 一致：!=、notin 对没有该标签的节点成立
+func (r requirement) matches(labels map[string]string) bool {
+	value, exists := labels[r.key]
+	switch r.op {
+	case selectorEquals, selectorIn:
+		return exists && containsString(r.values, value)
+	case selectorNotEquals, selectorNotIn:
+		return !exists || !containsString(r.values, value)
+	case selectorExists:
+		return exists
+	case selectorNotExists:
+		return !exists
+	}
+	return false
+}
+
+// Selector 标签选择器（所有条件同时满足才匹配，空选择器匹配所有节点）
+type Selector struct {
+	requirements []requirement
+}
+
+// Matches 标签是否匹配
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s.requirements {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSelector 解析标签选择器（语法与 Kubernetes 标签选择器相同，条件以逗号分隔）
+// 支持：key=value、key==value、key!=value、key in (a,b)、key notin (a,b)、key、!key
+// 例如："role=ingest,gpu=true"、"tier in (web,api),!canary"
+func ParseSelector(selector string) (Selector, error) {
+	var s Selector
+	for _, part := range splitSelector(selector) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		r, err := parseRequirement(part)
+		if err != nil {
+			return Selector{}, fmt.Errorf("解析标签选择器失败: %q, %w", selector, err)
+		}
+		s.requirements = append(s.requirements, r)
+	}
+	return s, nil
+}
+
+// splitSelector 按逗号拆分条件（括号内的逗号不拆分）
+func splitSelector(selector string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, selector[start:])
+}
+
+// parseRequirement 解析单个条件
+func parseRequirement(part string) (requirement, error) {
+	if key, ok := strings.CutPrefix(part, "!"); ok && !strings.Contains(key, "=") {
+		return newRequirement(key, selectorNotExists, nil)
+	}
+	if key, value, ok := strings.Cut(part, "!="); ok {
+		return newRequirement(key, selectorNotEquals, []string{value})
+	}
+	if key, value, ok := strings.Cut(part, "=="); ok {
+		return newRequirement(key, selectorEquals, []string{value})
+	}
+	if key, value, ok := strings.Cut(part, "="); ok {
+		return newRequirement(key, selectorEquals, []string{value})
+	}
+
+	fields := strings.Fields(part)
+	if len(fields) == 1 && !strings.ContainsAny(part, "()") {
+		return newRequirement(fields[0], selectorExists, nil)
+	}
+	if len(fields) < 2 {
+		return requirement{}, fmt.Errorf("无法识别的条件: %s", part)
+	}
+
+	key := fields[0]
+	rest := strings.TrimSpace(strings.TrimPrefix(part, key))
+	var op selectorOp
+	switch {
+	case strings.HasPrefix(rest, "notin"):
+		op, rest = selectorNotIn, strings.TrimPrefix(rest, "notin")
+	case strings.HasPrefix(rest, "in"):
+		op, rest = selectorIn, strings.TrimPrefix(rest, "in")
+	default:
+		return requirement{}, fmt.Errorf("无法识别的运算符: %s", part)
+	}
+
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "(") || !strings.HasSuffix(rest, ")") {
+		return requirement{}, fmt.Errorf("集合需要用括号包围: %s", part)
+	}
+
+	var values []string
+	for _, value := range strings.Split(rest[1:len(rest)-1], ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return requirement{}, fmt.Errorf("集合不能为空: %s", part)
+	}
+	return newRequirement(key, op, values)
+}
+
+// newRequirement 创建条件（去除键和值两端的空白）
+func newRequirement(key string, op selectorOp, values []string) (requirement, error) {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " !=()") {
+		return requirement{}, fmt.Errorf("标签键不合法: %q", key)
+	}
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return requirement{key: key, op: op, values: values}, nil
+}
+
+// containsString 切片是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetNodesByLabel 获取标签匹配选择器的节点（按 ServiceID 排序）
+func (m *Manager) GetNodesByLabel(selector string) ([]*Node, error) {
+	s, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*Node
+	for _, node := range m.GetAllNodes() {
+		if s.Matches(node.Labels()) {
+			nodes = append(nodes, node)
+		}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ServiceID < nodes[j].ServiceID
+	})
+	return nodes, nil
+}
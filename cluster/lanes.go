@@ -0,0 +1,216 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/charry/config"
+	"github.com/charry/tcp"
+)
+
+// Priority 发送优先级
+type Priority int
+
+const (
+	PriorityNormal Priority = iota // 普通请求（默认）
+	PriorityHigh                   // 控制消息（配置推送、协调等）
+	PriorityBulk                   // 大批量数据
+
+	priorityLanes = 3
+)
+
+// laneOrder 发送顺序（从高到低）
+var laneOrder = [priorityLanes]Priority{PriorityHigh, PriorityNormal, PriorityBulk}
+
+// laneQueueSize 每个优先级队列的容量（队列满时发送方阻塞）
+const laneQueueSize = 1024
+
+// sendItem 待发送的消息
+type sendItem struct {
+	ctx      context.Context
+	priority Priority
+	data     []byte
+	onConn   func(conn net.Conn) // 写入前回调（用于登记请求所在的连接）
+	done     chan error          // 发送结果（缓冲 1）
+}
+
+// sendLanes 节点的分优先级发送队列
+// 发送协程总是先取高优先级队列中的消息，大批量数据不会阻塞控制消息
+// 心跳直接写入每个连接，不经过队列
+type sendLanes struct {
+	lanes [priorityLanes]chan *sendItem
+}
+
+// newSendLanes 创建发送队列
+func newSendLanes() *sendLanes {
+	l := &sendLanes{}
+	for i := range l.lanes {
+		l.lanes[i] = make(chan *sendItem, laneQueueSize)
+	}
+	return l
+}
+
+// push 放入对应优先级的队列（队列满时阻塞）
+func (l *sendLanes) push(ctx context.Context, priority Priority, item *sendItem, stopChan <-chan struct{}) error {
+	if priority < 0 || priority >= priorityLanes {
+		priority = PriorityNormal
+	}
+
+	select {
+	case l.lanes[priority] <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-stopChan:
+		return fmt.Errorf("节点已断开")
+	}
+}
+
+// next 按优先级取出下一条消息（所有队列为空时阻塞）
+func (l *sendLanes) next(stopChan <-chan struct{}) (*sendItem, bool) {
+	if item := l.poll(); item != nil {
+		return item, true
+	}
+	item := l.wait(nil, stopChan)
+	return item, item != nil
+}
+
+// poll 按优先级取出一条已排队的消息（所有队列为空时返回 nil）
+func (l *sendLanes) poll() *sendItem {
+	// 依次检查高优先级队列
+	for _, priority := range laneOrder {
+		select {
+		case item := <-l.lanes[priority]:
+			return item
+		default:
+		}
+	}
+	return nil
+}
+
+// wait 等待任一队列的消息（timeout 到期或节点断开时返回 nil）
+func (l *sendLanes) wait(timeout <-chan time.Time, stopChan <-chan struct{}) *sendItem {
+	select {
+	case item := <-l.lanes[PriorityHigh]:
+		return item
+	case item := <-l.lanes[PriorityNormal]:
+		return item
+	case item := <-l.lanes[PriorityBulk]:
+		return item
+	case <-timeout:
+		return nil
+	case <-stopChan:
+		return nil
+	}
+}
+
+// QueueLengths 各优先级队列中等待发送的消息数（下标为 Priority）
+func (n *Node) QueueLengths() [priorityLanes]int {
+	var lengths [priorityLanes]int
+	for i, lane := range n.lanes.lanes {
+		lengths[i] = len(lane)
+	}
+	return lengths
+}
+
+// enqueue 按优先级排队发送，等待写入完成
+func (n *Node) enqueue(ctx context.Context, priority Priority, data []byte, onConn func(conn net.Conn)) error {
+	// 超过上限的消息对方会断开连接，直接拒绝（各节点的上限通常一致）
+	if size := int64(len(data) - tcp.HeaderLenSize); size > tcp.MaxFrameSize() {
+		return fmt.Errorf("消息长度 %d 超过上限 %d", size, tcp.MaxFrameSize())
+	}
+
+	item := &sendItem{ctx: ctx, priority: priority, data: data, onConn: onConn, done: make(chan error, 1)}
+	if err := n.lanes.push(ctx, priority, item, n.stopChan); err != nil {
+		return err
+	}
+
+	// 调用方放弃后立即返回，发送协程取到该消息时会跳过或中断写入
+	select {
+	case err := <-item.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-n.stopChan:
+		return fmt.Errorf("节点已断开")
+	}
+}
+
+// startSenders 启动发送协程（数量与连接池最多连接数相同）
+func (n *Node) startSenders() {
+	cfg := config.Get()
+	count := max(cfg.Server.ClusterConnCount, cfg.Server.ClusterConnMax, 1)
+	for i := 0; i < count; i++ {
+		go n.sendLoop()
+	}
+}
+
+// sendLoop 发送协程：按优先级取出消息，从连接池获取连接写入（启用发送合并时多条消息一次写入）
+func (n *Node) sendLoop() {
+	for {
+		item, ok := n.lanes.next(n.stopChan)
+		if !ok {
+			return
+		}
+		if batch := n.collect(item); len(batch) > 1 {
+			n.writeBatch(batch)
+			continue
+		}
+		item.done <- n.write(item)
+	}
+}
+
+// write 写入一条消息，写入失败时关闭该连接（由接收协程替换或重连）
+func (n *Node) write(item *sendItem) error {
+	// 排队期间调用方已放弃
+	if err := item.ctx.Err(); err != nil {
+		return err
+	}
+
+	pool := n.GetPool()
+	if pool == nil {
+		return fmt.Errorf("节点未连接")
+	}
+
+	conn, err := pool.GetCtx(item.ctx)
+	if err != nil {
+		return fmt.Errorf("获取连接失败: %w", err)
+	}
+	defer pool.Put(conn) // 写完立即归还
+
+	// 带宽限制：等待该连接的写入额度
+	if err := n.throttle(item.ctx, conn, item.priority, len(item.data)); err != nil {
+		return fmt.Errorf("等待写入带宽失败: %w", err)
+	}
+
+	if item.onConn != nil {
+		item.onConn(conn)
+	}
+
+	if _, err := writeContext(item.ctx, conn, item.data); err != nil {
+		// 调用方取消导致的中断不计入失败，但连接上可能只写出了部分消息，同样需要关闭
+		if !errors.Is(err, context.Canceled) {
+			n.health.result(true)
+			n.recordFailure()
+		}
+		conn.Close()
+		return fmt.Errorf("发送失败: %w", err)
+	}
+	return nil
+}
+
+// contextWriter 支持按 ctx 中断写入的连接（*tcp.QueuedConn、*tcp.LockedConn）
+type contextWriter interface {
+	WriteContext(ctx context.Context, b []byte) (int, error)
+}
+
+// writeContext 按 ctx 的截止时间和取消写入（连接池中的连接都是 *tcp.QueuedConn）
+func writeContext(ctx context.Context, conn net.Conn, data []byte) (int, error) {
+	if w, ok := conn.(contextWriter); ok {
+		return w.WriteContext(ctx, data)
+	}
+	return conn.Write(data)
+}
@@ -0,0 +1,165 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/charry/consul"
+	"github.com/charry/logger"
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// LockKeyPrefix 分布式锁在 Consul KV 中的键前缀
+const LockKeyPrefix = "charry/locks/"
+
+// DefaultLockTTL 锁会话默认 TTL（持有期间自动续期，进程崩溃后最多 TTL 时间释放）
+const DefaultLockTTL = 15 * time.Second
+
+var (
+	// localLocks 进程内锁：name -> 信号量（未连接 Consul 时使用）
+	localLocks   = make(map[string]chan struct{})
+	localLocksMu sync.Mutex
+)
+
+// Lock 分布式锁
+// Consul 客户端已初始化时基于 Consul 会话实现（跨节点互斥，持有期间自动续期），
+// 否则退化为进程内锁
+// 同一个 Lock 不可并发 Acquire，释放后可再次获取
+type Lock struct {
+	name string
+	ttl  time.Duration
+
+	// Consul 锁（为 nil 时使用进程内锁）
+	consulLock *consulapi.Lock
+
+	// 进程内锁的信号量
+	local chan struct{}
+
+	// 锁丢失通知（仅 Consul 锁）
+	lost <-chan struct{}
+
+	held bool
+	mu   sync.Mutex
+}
+
+// NewLock 创建分布式锁（TTL 为 DefaultLockTTL）
+func NewLock(name string) (*Lock, error) {
+	return NewLockWithTTL(name, DefaultLockTTL)
+}
+
+// NewLockWithTTL 创建指定会话 TTL 的分布式锁（Consul 要求 10 秒 ~ 24 小时）
+func NewLockWithTTL(name string, ttl time.Duration) (*Lock, error) {
+	if name == "" {
+		return nil, fmt.Errorf("锁名称不能为空")
+	}
+	if ttl <= 0 {
+		ttl = DefaultLockTTL
+	}
+
+	l := &Lock{name: name, ttl: ttl}
+
+	if consul.GlobalClient == nil {
+		localLocksMu.Lock()
+		sem, exists := localLocks[name]
+		if !exists {
+			sem = make(chan struct{}, 1)
+			localLocks[name] = sem
+		}
+		localLocksMu.Unlock()
+
+		l.local = sem
+		return l, nil
+	}
+
+	consulLock, err := consul.GlobalClient.GetClient().LockOpts(&consulapi.LockOptions{
+		Key:         LockKeyPrefix + name,
+		SessionName: "charry-lock-" + name,
+		SessionTTL:  ttl.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建分布式锁失败: %s, %w", name, err)
+	}
+	l.consulLock = consulLock
+	return l, nil
+}
+
+// Acquire 获取锁，阻塞直到获取成功或 ctx 结束
+func (l *Lock) Acquire(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held {
+		return fmt.Errorf("锁已持有: %s", l.name)
+	}
+
+	if l.consulLock == nil {
+		select {
+		case l.local <- struct{}{}:
+			l.held = true
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("获取锁失败: %s, %w", l.name, ctx.Err())
+		}
+	}
+
+	// Consul 锁通过 stopCh 取消等待
+	stopCh := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() { close(stopCh) })
+	defer stop()
+
+	lost, err := l.consulLock.Lock(stopCh)
+	if err != nil {
+		return fmt.Errorf("获取锁失败: %s, %w", l.name, err)
+	}
+	if lost == nil {
+		return fmt.Errorf("获取锁失败: %s, %w", l.name, ctx.Err())
+	}
+
+	l.lost = lost
+	l.held = true
+	logger.Debugf("已获取分布式锁: %s", l.name)
+	return nil
+}
+
+// Release 释放锁
+func (l *Lock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.held {
+		return fmt.Errorf("锁未持有: %s", l.name)
+	}
+	l.held = false
+	l.lost = nil
+
+	if l.consulLock == nil {
+		<-l.local
+		return nil
+	}
+
+	if err := l.consulLock.Unlock(); err != nil {
+		return fmt.Errorf("释放锁失败: %s, %w", l.name, err)
+	}
+	logger.Debugf("已释放分布式锁: %s", l.name)
+	return nil
+}
+
+// Lost 锁丢失通知（会话失效、续期失败等），持有期间应监听并中止临界区操作
+// 进程内锁和未持有时返回 nil（永不触发）
+func (l *Lock) Lost() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lost
+}
+
+// TTL 锁会话 TTL
+func (l *Lock) TTL() time.Duration {
+	return l.ttl
+}
+
+// IsDistributed 是否为跨节点的 Consul 锁
+func (l *Lock) IsDistributed() bool {
+	return l.consulLock != nil
+}
@@ -2,12 +2,15 @@ package cluster
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/charry/config"
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
 	"github.com/charry/logger"
-	consulapi "github.com/hashicorp/consul/api"
 )
 
 // Manager 集群管理器
@@ -16,38 +19,109 @@ type Manager struct {
 	nodes   map[string]*Node
 	nodesMu sync.RWMutex
 
-	// Consul 客户端（用于监听服务变化）
-	consulClient *consulapi.Client
+	// 服务发现（用于监听服务变化）
+	discovery Discovery
+
+	// 监听的服务名称（WatchServices 时设置）
+	serviceNames []string
+
+	// 节点缓存文件（SetNodeCache 设置，为空时不缓存）
+	nodeCache string
+
+	// 全量同步请求（缓冲 1，合并重复请求）
+	resyncChan chan struct{}
+
+	// 串行处理服务变化（监听回调与全量同步）
+	syncMu sync.Mutex
+
+	// 节点请求限制（新加入的节点使用）
+	rateLimit atomic.Pointer[RateLimit]
+
+	// 节点重连退避策略（新加入的节点使用）
+	reconnectPolicy atomic.Pointer[ReconnectPolicy]
+
+	// 节点熔断配置（新加入的节点使用，为 nil 时不熔断）
+	breakerOpts atomic.Pointer[BreakerOptions]
+
+	// 节点每个连接的写入带宽限制（新加入的节点使用）
+	bandwidth atomic.Pointer[Bandwidth]
+
+	// 节点发送合并（新加入的节点使用，为 nil 时不合并）
+	batching atomic.Pointer[Batching]
+
+	// 负载均衡状态
+	balancer *balancer
+
+	// 一致性哈希环
+	rings *hashRings
+
+	// 法定人数检查（StartQuorumWatch 启动）
+	quorum quorumState
+
+	// key 归属跟踪
+	ownership ownership
+
+	// 单例角色（热备切换）
+	roles roles
+
+	// 节点元数据监听者
+	metaWatchers metaWatchers
+
+	// 节点增减监听者
+	listeners   []func()
+	listenersMu sync.RWMutex
 
 	// 停止通道
 	stopChan chan struct{}
 }
 
 // NewManager 创建集群管理器
-func NewManager(consulClient *consulapi.Client) *Manager {
+func NewManager(discovery Discovery) *Manager {
 	return &Manager{
-		nodes:        make(map[string]*Node),
-		consulClient: consulClient,
-		stopChan:     make(chan struct{}),
+		nodes:      make(map[string]*Node),
+		discovery:  discovery,
+		balancer:   newBalancer(),
+		rings:      newHashRings(),
+		resyncChan: make(chan struct{}, 1),
+		stopChan:   make(chan struct{}),
 	}
 }
 
 // AddNode 添加节点
 func (m *Manager) AddNode(serviceID string, appConfig *config.AppConfig) error {
 	m.nodesMu.Lock()
-	defer m.nodesMu.Unlock()
 
 	// 检查是否已存在
 	if _, exists := m.nodes[serviceID]; exists {
+		m.nodesMu.Unlock()
 		logger.Infof("节点已存在: %s", serviceID)
 		return nil
 	}
 
 	// 创建节点
 	node := NewNode(serviceID, appConfig)
+	node.onReconnect = m.RequestResync
+	if limit := m.rateLimit.Load(); limit != nil {
+		node.SetRateLimit(*limit)
+	}
+	if policy := m.reconnectPolicy.Load(); policy != nil {
+		node.SetReconnectPolicy(*policy)
+	}
+	if opts := m.breakerOpts.Load(); opts != nil {
+		node.SetBreaker(*opts)
+	}
+	if limit := m.bandwidth.Load(); limit != nil {
+		node.SetBandwidth(*limit)
+	}
+	if opts := m.batching.Load(); opts != nil {
+		node.SetBatching(*opts)
+	}
 	m.nodes[serviceID] = node
+	m.rings.invalidate(node.Type)
+	m.nodesMu.Unlock()
 
 	logger.Infof("✓ 节点已添加: %s", serviceID)
+	m.membershipChanged(event_name.ClusterNodeAdded, node)
 
 	// 异步建立连接
 	go func() {
@@ -68,24 +142,49 @@ func (m *Manager) RemoveNode(serviceID string) {
 	node, exists := m.nodes[serviceID]
 	if exists {
 		delete(m.nodes, serviceID)
+		m.rings.invalidate(node.Type)
 	}
 	m.nodesMu.Unlock()
 
 	if node != nil {
 		node.Disconnect()
 		logger.Infof("✓ 节点已移除: %s", serviceID)
+		m.membershipChanged(event_name.ClusterNodeRemoved, node)
 	}
 }
 
-// UpdateNode 更新节点配置
+// UpdateNode 更新节点配置，配置有变化时发布 ClusterNodeUpdated 事件
 func (m *Manager) UpdateNode(serviceID string, appConfig *config.AppConfig) {
 	m.nodesMu.RLock()
 	node, exists := m.nodes[serviceID]
 	m.nodesMu.RUnlock()
 
-	if exists {
-		node.UpdateConfig(appConfig)
+	if !exists {
+		return
+	}
+
+	oldConfig := node.Config
+	changes := configDiff(oldConfig, appConfig)
+	node.UpdateConfig(appConfig)
+	if len(changes) == 0 {
+		return
+	}
+
+	event.PublishEvent(event_name.ClusterNodeUpdated, &NodeUpdatedEventData{
+		NodeEventData: node.eventData(),
+		OldConfig:     oldConfig,
+		NewConfig:     appConfig,
+		Changes:       changes,
+	})
+
+	var oldData, newData map[string]any
+	if oldConfig != nil {
+		oldData = oldConfig.Data
+	}
+	if appConfig != nil {
+		newData = appConfig.Data
 	}
+	m.notifyMetaChanges(node, oldData, newData, changes)
 }
 
 // GetNode 获取节点
@@ -121,6 +220,23 @@ func (m *Manager) GetNodesByType(typ string) []*Node {
 	return nodes
 }
 
+// NodesByType 按类型分组的节点（每组按 ServiceID 排序）
+func (m *Manager) NodesByType() map[string][]*Node {
+	m.nodesMu.RLock()
+	byType := make(map[string][]*Node)
+	for _, node := range m.nodes {
+		byType[node.Type] = append(byType[node.Type], node)
+	}
+	m.nodesMu.RUnlock()
+
+	for _, nodes := range byType {
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].ServiceID < nodes[j].ServiceID
+		})
+	}
+	return byType
+}
+
 // Close 关闭管理器
 func (m *Manager) Close() {
 	close(m.stopChan)
@@ -133,7 +249,7 @@ func (m *Manager) Close() {
 		node.Disconnect()
 	}
 	m.nodes = make(map[string]*Node)
+	m.rings = newHashRings()
 
 	logger.Info("✓ 集群管理器已关闭")
 }
-
@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/charry/logger"
+)
+
+// MetaHandler 节点元数据变化处理函数（字段被删除时 newValue 为 nil，新增时 oldValue 为 nil）
+type MetaHandler func(serviceID, key string, oldValue, newValue any)
+
+// metaWatcher 节点元数据监听者
+type metaWatcher struct {
+	id        uint64
+	serviceID string // 为空时监听所有节点
+	key       string
+	handler   MetaHandler
+}
+
+// metaWatchers 节点元数据监听注册表
+type metaWatchers struct {
+	watchers map[uint64]*metaWatcher
+	seq      uint64
+	mu       sync.RWMutex
+}
+
+// WatchNodeMeta 监听节点元数据（配置 data 中的字段）变化，返回取消监听函数
+// serviceID 为空时监听所有节点；处理函数在服务变化处理协程中同步调用
+func (m *Manager) WatchNodeMeta(serviceID, key string, handler MetaHandler) func() {
+	w := &m.metaWatchers
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watchers == nil {
+		w.watchers = make(map[uint64]*metaWatcher)
+	}
+	w.seq++
+	id := w.seq
+	w.watchers[id] = &metaWatcher{id: id, serviceID: serviceID, key: key, handler: handler}
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.watchers, id)
+	}
+}
+
+// notifyMetaChanges 通知元数据监听者（changes 为 configDiff 的结果）
+func (m *Manager) notifyMetaChanges(node *Node, old, new map[string]any, changes []string) {
+	w := &m.metaWatchers
+	w.mu.RLock()
+	if len(w.watchers) == 0 {
+		w.mu.RUnlock()
+		return
+	}
+	var matched []*metaWatcher
+	for _, change := range changes {
+		key, ok := strings.CutPrefix(change, "data.")
+		if !ok {
+			continue
+		}
+		for _, watcher := range w.watchers {
+			if watcher.key == key && (watcher.serviceID == "" || watcher.serviceID == node.ServiceID) {
+				matched = append(matched, watcher)
+			}
+		}
+	}
+	w.mu.RUnlock()
+
+	for _, watcher := range matched {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Errorf("节点元数据处理发生 panic: %v, 节点: %s, 字段: %s", r, node.ServiceID, watcher.key)
+				}
+			}()
+			watcher.handler(node.ServiceID, watcher.key, old[watcher.key], new[watcher.key])
+		}()
+	}
+}
@@ -0,0 +1,245 @@
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// connCounters 节点连接流量计数（跨重连累计）
+type connCounters struct {
+	bytesSent     atomic.Uint64
+	bytesReceived atomic.Uint64
+	msgsSent      atomic.Uint64
+	msgsReceived  atomic.Uint64
+	throttled     atomic.Int64  // 因带宽限制累计等待的时间（纳秒）
+	batches       atomic.Uint64 // 合并写入次数
+	datagrams     atomic.Uint64 // 以 UDP 报文发送的消息数
+}
+
+// meteredConn 统计流量的连接（每次 Write 为一条完整消息）
+type meteredConn struct {
+	net.Conn
+	counters *connCounters
+}
+
+func (c *meteredConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.counters.bytesSent.Add(uint64(n))
+	if err == nil {
+		c.counters.msgsSent.Add(1)
+	}
+	return n, err
+}
+
+func (c *meteredConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.counters.bytesReceived.Add(uint64(n))
+	return n, err
+}
+
+// NodeStats 节点详细统计
+type NodeStats struct {
+	ServiceID        string
+	Type             string
+	Status           NodeStatus
+	Health           HealthStats
+	Pending          int64     // 进行中的请求数
+	PendingCalls     int       // 等待响应的 Call 数
+	Streams          int       // 打开的流数
+	Pool             PoolStats // 连接池统计（未连接时为零值）
+	Reconnect        ReconnectState
+	Breaker          BreakerState
+	ThrottleWait     time.Duration // 因带宽限制累计等待的时间
+	BatchedWrites    uint64        // 合并多条消息的写入次数
+	BytesSent        uint64
+	BytesReceived    uint64
+	MessagesSent     uint64
+	MessagesReceived uint64
+	DatagramsSent    uint64 // 以 UDP 报文发送的请求数
+	CallTimeouts     uint64 // 超过截止时间仍未收到响应的请求数
+	OrphanResponses  uint64 // 没有请求在等待、也没有处理器的响应数（包括迟到的响应）
+	LateResponses    uint64 // 对应的请求已超时或被放弃的响应数
+}
+
+// Stats 获取节点详细统计
+func (n *Node) Stats() NodeStats {
+	calls := n.calls.Stats()
+
+	n.streamsMu.Lock()
+	streams := len(n.streams)
+	n.streamsMu.Unlock()
+
+	return NodeStats{
+		ServiceID:        n.ServiceID,
+		Type:             n.Type,
+		Status:           n.GetStatus(),
+		Health:           n.Health(),
+		Pending:          n.Pending(),
+		PendingCalls:     calls.Pending,
+		CallTimeouts:     calls.Timeouts,
+		OrphanResponses:  calls.Orphans,
+		LateResponses:    calls.Late,
+		Streams:          streams,
+		Pool:             n.PoolStats(),
+		Reconnect:        n.ReconnectState(),
+		Breaker:          n.BreakerState(),
+		ThrottleWait:     time.Duration(n.counters.throttled.Load()),
+		BatchedWrites:    n.counters.batches.Load(),
+		BytesSent:        n.counters.bytesSent.Load(),
+		BytesReceived:    n.counters.bytesReceived.Load(),
+		MessagesSent:     n.counters.msgsSent.Load(),
+		MessagesReceived: n.counters.msgsReceived.Load(),
+		DatagramsSent:    n.counters.datagrams.Load(),
+	}
+}
+
+// GetDetailedStats 获取所有节点的详细统计（按 ServiceID 排序）
+func (m *Manager) GetDetailedStats() []NodeStats {
+	nodes := m.GetAllNodes()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ServiceID < nodes[j].ServiceID
+	})
+
+	stats := make([]NodeStats, len(nodes))
+	for i, node := range nodes {
+		stats[i] = node.Stats()
+	}
+	return stats
+}
+
+// nodeMetric Prometheus 指标定义
+type nodeMetric struct {
+	name  string
+	typ   string // gauge / counter
+	help  string
+	value func(s *NodeStats) float64
+}
+
+// nodeMetrics 导出的节点指标
+var nodeMetrics = []nodeMetric{
+	{"charry_cluster_node_connected", "gauge", "Whether the node is connected (1) or not (0).", func(s *NodeStats) float64 {
+		if s.Status == NodeStatusConnected {
+			return 1
+		}
+		return 0
+	}},
+	{"charry_cluster_node_status", "gauge", "Node connection status (0 disconnected, 1 connecting, 2 connected, 3 failed, 4 quarantined).", func(s *NodeStats) float64 {
+		return float64(s.Status)
+	}},
+	{"charry_cluster_node_reconnects_total", "counter", "Number of reconnects to the node.", func(s *NodeStats) float64 {
+		return float64(s.Health.Reconnects)
+	}},
+	{"charry_cluster_node_reconnect_attempts", "gauge", "Consecutive failed reconnect attempts to the node.", func(s *NodeStats) float64 {
+		return float64(s.Reconnect.Attempts)
+	}},
+	{"charry_cluster_node_reconnect_backoff_seconds", "gauge", "Time until the next scheduled reconnect attempt (0 if none).", func(s *NodeStats) float64 {
+		if s.Reconnect.NextAt.IsZero() {
+			return 0
+		}
+		return max(time.Until(s.Reconnect.NextAt).Seconds(), 0)
+	}},
+	{"charry_cluster_node_breaker_state", "gauge", "Circuit breaker state (0 closed, 1 open, 2 half-open).", func(s *NodeStats) float64 {
+		return float64(s.Breaker)
+	}},
+	{"charry_cluster_node_heartbeat_rtt_seconds", "gauge", "Smoothed heartbeat round-trip time.", func(s *NodeStats) float64 {
+		return s.Health.RTT.Seconds()
+	}},
+	{"charry_cluster_node_heartbeats_lost_total", "counter", "Heartbeats to the node that got no response before the next one was sent.", func(s *NodeStats) float64 {
+		return float64(s.Health.BeatsLost)
+	}},
+	{"charry_cluster_node_health_score", "gauge", "Node health score between 0 and 1.", func(s *NodeStats) float64 {
+		return s.Health.Score
+	}},
+	{"charry_cluster_node_bytes_sent_total", "counter", "Bytes sent to the node.", func(s *NodeStats) float64 {
+		return float64(s.BytesSent)
+	}},
+	{"charry_cluster_node_bytes_received_total", "counter", "Bytes received from the node.", func(s *NodeStats) float64 {
+		return float64(s.BytesReceived)
+	}},
+	{"charry_cluster_node_messages_sent_total", "counter", "Messages sent to the node.", func(s *NodeStats) float64 {
+		return float64(s.MessagesSent)
+	}},
+	{"charry_cluster_node_messages_received_total", "counter", "Messages received from the node.", func(s *NodeStats) float64 {
+		return float64(s.MessagesReceived)
+	}},
+	{"charry_cluster_node_datagrams_sent_total", "counter", "Requests sent to the node as UDP datagrams.", func(s *NodeStats) float64 {
+		return float64(s.DatagramsSent)
+	}},
+	{"charry_cluster_node_call_timeouts_total", "counter", "Calls to the node that timed out waiting for a response.", func(s *NodeStats) float64 {
+		return float64(s.CallTimeouts)
+	}},
+	{"charry_cluster_node_orphan_responses_total", "counter", "Responses from the node that no call or handler was waiting for.", func(s *NodeStats) float64 {
+		return float64(s.OrphanResponses)
+	}},
+	{"charry_cluster_node_late_responses_total", "counter", "Responses from the node that arrived after their call timed out or was abandoned.", func(s *NodeStats) float64 {
+		return float64(s.LateResponses)
+	}},
+	{"charry_cluster_node_throttle_wait_seconds_total", "counter", "Time writes to the node waited for bandwidth.", func(s *NodeStats) float64 {
+		return s.ThrottleWait.Seconds()
+	}},
+	{"charry_cluster_node_batched_writes_total", "counter", "Writes to the node that coalesced several messages.", func(s *NodeStats) float64 {
+		return float64(s.BatchedWrites)
+	}},
+	{"charry_cluster_node_pending_calls", "gauge", "Calls waiting for a response from the node.", func(s *NodeStats) float64 {
+		return float64(s.PendingCalls)
+	}},
+	{"charry_cluster_node_pool_size", "gauge", "Connections in the node connection pool.", func(s *NodeStats) float64 {
+		return float64(s.Pool.Size)
+	}},
+	{"charry_cluster_node_pool_free", "gauge", "Idle connections in the node connection pool.", func(s *NodeStats) float64 {
+		return float64(s.Pool.Idle)
+	}},
+	{"charry_cluster_node_send_queue_depth", "gauge", "Messages waiting in the connection send queues of the node.", func(s *NodeStats) float64 {
+		return float64(s.Pool.SendQueued)
+	}},
+	{"charry_cluster_node_flow_window", "gauge", "Sum of the flow control request windows of the node connections (0 if disabled).", func(s *NodeStats) float64 {
+		return float64(s.Pool.FlowWindow)
+	}},
+	{"charry_cluster_node_flow_pending", "gauge", "Requests to the node not yet credited back by its flow control.", func(s *NodeStats) float64 {
+		return float64(s.Pool.FlowPending)
+	}},
+	{"charry_cluster_node_flow_waits_total", "counter", "Times a write to the node waited for flow control credits.", func(s *NodeStats) float64 {
+		return float64(s.Pool.FlowWaits)
+	}},
+	{"charry_cluster_node_send_queue_full_total", "counter", "Times a connection send queue to the node was full.", func(s *NodeStats) float64 {
+		return float64(s.Pool.SendFull)
+	}},
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出节点指标
+func (m *Manager) WritePrometheus(w io.Writer) error {
+	stats := m.GetDetailedStats()
+	bw := bufio.NewWriter(w)
+
+	for _, metric := range nodeMetrics {
+		fmt.Fprintf(bw, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", metric.name, metric.typ)
+		for i := range stats {
+			fmt.Fprintf(bw, "%s{service_id=\"%s\",type=\"%s\"} %g\n",
+				metric.name, escapeLabel(stats[i].ServiceID), escapeLabel(stats[i].Type), metric.value(&stats[i]))
+		}
+	}
+	return bw.Flush()
+}
+
+// MetricsHandler Prometheus 抓取接口
+func (m *Manager) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := m.WritePrometheus(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// escapeLabel 转义 Prometheus 标签值
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
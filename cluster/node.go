@@ -1,11 +1,15 @@
 package cluster
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/charry/config"
@@ -36,6 +40,72 @@ type Node struct {
 	statusMu   sync.RWMutex
 	lastUpdate time.Time
 
+	// 进行中的请求数（用于负载均衡）
+	pending atomic.Int64
+
+	// 是否排空（排空的节点不再被负载均衡选中）
+	draining atomic.Bool
+
+	// 是否暂停（暂停的节点保持连接和心跳，但不再被选中）
+	paused atomic.Bool
+
+	// 对方是否正在优雅关闭（收到关闭通知后不再被选中，重新握手成功时清除）
+	goingAway atomic.Bool
+
+	// 连接最近收到消息的时间：conn -> *atomic.Int64（UnixNano）
+	connSeen sync.Map
+
+	// 各连接的心跳跟踪：conn -> *tcp.HeartbeatTracker
+	connBeats sync.Map
+
+	// 对方握手信息和协商结果
+	peer atomic.Pointer[tcp.Handshake]
+	caps atomic.Pointer[tcp.Capabilities]
+
+	// 请求限制（为 nil 时不限制）
+	limiter atomic.Pointer[nodeLimiter]
+
+	// 熔断器（为 nil 时不熔断）
+	breaker atomic.Pointer[nodeBreaker]
+
+	// 每个连接的写入带宽限制（为 nil 时不限制）和各连接的额度：conn -> *byteBucket
+	bandwidth   atomic.Pointer[Bandwidth]
+	connBuckets sync.Map
+
+	// 发送合并（为 nil 时每条消息单独写入）
+	batching atomic.Pointer[Batching]
+
+	// 健康跟踪（用于负载均衡）
+	health nodeHealth
+
+	// 隔离跟踪（连续失败过多时降低重连频率）
+	quarantine nodeQuarantine
+
+	// 重连退避策略（为 nil 时使用默认策略）、连续重连失败次数和下次重连时间（UnixNano）
+	reconnect         atomic.Pointer[ReconnectPolicy]
+	reconnectAttempts atomic.Int64
+	reconnectAt       atomic.Int64
+
+	// 流量计数（跨重连累计）
+	counters connCounters
+
+	// 等待响应的请求（Call、SendStream）
+	calls *tcp.PendingRequests
+
+	// 打开的消息流: sessionId -> stream
+	streams   map[string]*Stream
+	streamsMu sync.Mutex
+
+	// 分优先级发送队列
+	lanes *sendLanes
+
+	// 发送 UDP 报文的套接字（首次发送时创建，断开时关闭）
+	datagram   net.Conn
+	datagramMu sync.Mutex
+
+	// 重连成功回调（由 Manager 设置，用于触发全量同步）
+	onReconnect func()
+
 	// 重连控制
 	reconnectChan chan struct{}
 	stopChan      chan struct{}
@@ -49,6 +119,7 @@ const (
 	NodeStatusConnecting   NodeStatus = 1 // 连接中
 	NodeStatusConnected    NodeStatus = 2 // 已连接
 	NodeStatusFailed       NodeStatus = 3 // 连接失败
+	NodeStatusQuarantined  NodeStatus = 4 // 连续失败过多，已隔离（按指数增长的探测间隔探测）
 )
 
 // NewNode 创建新节点
@@ -64,6 +135,9 @@ func NewNode(serviceID string, appConfig *config.AppConfig) *Node {
 		reconnectChan: make(chan struct{}, 1),
 		stopChan:      make(chan struct{}),
 		router:        NewRouter(),
+		lanes:         newSendLanes(),
+		calls:         tcp.NewPendingRequests(tcp.PendingOptions{}),
+		streams:       make(map[string]*Stream),
 	}
 }
 
@@ -78,47 +152,35 @@ func (n *Node) Connect(ctx context.Context) error {
 
 	n.setStatus(NodeStatusConnecting)
 
-	target := fmt.Sprintf("%s:%d", n.Config.Addr.Host, n.Config.Addr.Port)
+	target := tcp.JoinAddr(n.Config.Addr.Host, n.Config.Addr.Port)
 	logger.Infof("连接到节点: %s (%s)", n.ServiceID, target)
 
-	// 获取连接池大小配置
-	cfg := config.Get()
-	poolSize := cfg.Server.ClusterConnCount
-	if poolSize <= 0 {
-		poolSize = 4 // 默认 4 个连接
-	}
-
 	// 创建连接池
-	pool, err := NewConnectionPool(target, poolSize)
+	pool, err := n.newPool(target)
 	if err != nil {
 		n.setStatus(NodeStatusFailed)
+		n.recordFailure()
 		return fmt.Errorf("创建连接池失败: %w", err)
 	}
 
 	n.connPool = pool
+	n.health.connected(false)
 	n.setStatus(NodeStatusConnected)
-	logger.Infof("✓ 已连接到节点: %s (连接数: %d)", n.ServiceID, poolSize)
+	logger.Infof("✓ 已连接到节点: %s (连接数: %d)", n.ServiceID, pool.GetPoolSize())
+
+	// 启动接收协程（响应统一由接收协程读取和分发）
+	n.startReceivers(pool)
 
 	// 立即发送第一次心跳（避免对方超时）
 	go func() {
-		conn, err := pool.Get()
-		if err == nil {
-			// 发送心跳
-			err := tcp.SendHeartbeat(conn)
-			if err != nil {
-				return
-			}
-			// 等待响应
-			_, err = tcp.DecodeMsg(conn)
-			if err != nil {
-				return
-			}
-			pool.Put(conn)
-			logger.Infof("✓ 已发送初始心跳: %s", n.ServiceID)
+		if failed, _, _ := n.heartbeat(pool); failed > 0 {
+			return
 		}
+		logger.Infof("✓ 已发送初始心跳: %s", n.ServiceID)
 	}()
 
-	// 启动监控协程和心跳
+	// 启动发送协程、监控协程和心跳
+	n.startSenders()
 	go n.monitorConnection() // ⭐ 修复：启动监控协程
 	go n.sendHeartbeat()
 
@@ -138,6 +200,10 @@ func (n *Node) Disconnect() {
 		n.setStatus(NodeStatusDisconnected)
 		logger.Infof("已断开节点: %s", n.ServiceID)
 	}
+	n.closeDatagram()
+
+	n.calls.Fail(nil, fmt.Errorf("节点已断开: %s", n.ServiceID))
+	n.failStreams(nil, fmt.Errorf("节点已断开: %s", n.ServiceID))
 }
 
 // GetPool 获取连接池
@@ -154,58 +220,199 @@ func (n *Node) RegisterHandler(module, cmd uint32, handler MessageHandler) {
 
 // SendReq 异步发送请求消息（不等待响应）
 func (n *Node) SendReq(req *tcp.ClusterReqMsg) error {
-	pool := n.GetPool()
-	if pool == nil {
+	return n.SendReqPriorityCtx(context.Background(), req, PriorityNormal)
+}
+
+// SendReqCtx 发送请求消息（不等待响应）
+// 排队、等待发送许可、获取连接和写入都受 ctx 限制，调用方取消时立即返回
+func (n *Node) SendReqCtx(ctx context.Context, req *tcp.ClusterReqMsg) error {
+	return n.SendReqPriorityCtx(ctx, req, PriorityNormal)
+}
+
+// SendReqPriority 按优先级发送请求消息（不等待响应）
+// 消息进入节点对应优先级的发送队列，高优先级消息不会排在大批量数据之后
+func (n *Node) SendReqPriority(req *tcp.ClusterReqMsg, priority Priority) error {
+	return n.SendReqPriorityCtx(context.Background(), req, priority)
+}
+
+// SendReqPriorityCtx 按优先级发送请求消息（不等待响应），受 ctx 限制（见 SendReqCtx）
+func (n *Node) SendReqPriorityCtx(ctx context.Context, req *tcp.ClusterReqMsg, priority Priority) error {
+	n.pending.Add(1)
+	defer n.pending.Add(-1)
+
+	if n.GetPool() == nil {
 		return fmt.Errorf("节点未连接")
 	}
 
-	// 从连接池获取连接
-	conn, err := pool.Get()
+	done, err := n.breaker.Load().allow()
 	if err != nil {
-		return fmt.Errorf("获取连接失败: %w", err)
+		return fmt.Errorf("%w: %s", err, n.ServiceID)
 	}
-	defer pool.Put(conn) // 归还连接
 
-	// 编码并发送
-	data := tcp.EncodeClusterReqMsg(req)
-	_, err = conn.Write(data)
+	release, err := n.limiter.Load().acquire(ctx)
 	if err != nil {
-		// 触发重连
-		select {
-		case n.reconnectChan <- struct{}{}:
-		default:
+		done(breakerIgnore)
+		return fmt.Errorf("等待发送许可失败: %s, %w", n.ServiceID, err)
+	}
+	defer release()
+
+	tcp.InjectTrace(ctx, req)
+	tcp.InjectMetadata(ctx, req)
+
+	// UDP 路由的请求以报文发送（不保证送达，不计入节点健康统计）
+	if n.sendDatagram(req) {
+		done(breakerIgnore)
+		return nil
+	}
+
+	if err := n.enqueue(ctx, priority, tcp.EncodeClusterReqMsgWith(req, n.frameOptions()), nil); err != nil {
+		// 调用方主动取消不计入熔断统计
+		if errors.Is(err, context.Canceled) {
+			done(breakerIgnore)
+		} else {
+			done(breakerFailure)
 		}
-		return fmt.Errorf("发送失败: %w", err)
+		return err
 	}
 
+	done(breakerSuccess)
+	n.health.result(false)
+	n.recordSuccess()
 	return nil
 }
 
-// Send 发送原始字节流（兼容旧接口）
+// Send 发送已编码的请求消息并返回编码后的响应（兼容旧接口）
+// Deprecated: 使用 Call
 func (n *Node) Send(data []byte) ([]byte, error) {
-	pool := n.GetPool()
-	if pool == nil {
-		return nil, fmt.Errorf("节点未连接")
+	msg, err := tcp.DecodeMsg(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解析请求失败: %w", err)
+	}
+	req, ok := msg.(*tcp.ClusterReqMsg)
+	if !ok {
+		return nil, fmt.Errorf("只能发送请求消息")
 	}
 
-	conn, err := pool.Get()
+	resp, err := n.Call(context.Background(), req)
 	if err != nil {
 		return nil, err
 	}
-	defer pool.Put(conn)
+	return tcp.EncodeClusterRespMsg(resp), nil
+}
 
-	_, err = conn.Write(data)
+// newPool 按配置创建连接池（cluster_conn_count ~ cluster_conn_max 个连接）
+// 扩容的连接自动启动接收协程
+func (n *Node) newPool(target string) (*ConnectionPool, error) {
+	cfg := config.Get()
+	// 读超时按与该节点的心跳配置（0 表示不超时，连接池中 < 0 表示不超时）
+	readTimeout := n.HeartbeatTiming().ReadTimeout
+	if readTimeout == 0 {
+		readTimeout = -1
+	}
+	pool, err := NewConnectionPoolWithOptions(target, PoolOptions{
+		MinSize:     cfg.Server.ClusterConnCount,
+		MaxSize:     cfg.Server.ClusterConnMax,
+		ReadTimeout: readTimeout,
+		Handshake:   n.handshake,
+		FlowWindow:  n.flowWindow,
+		counters:    &n.counters,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	response := make([]byte, 4096)
-	bytesRead, err := conn.Read(response)
+	pool.SetOnConnect(func(conn net.Conn, idx int) {
+		go n.receiveLoop(pool, conn, idx)
+	})
+	return pool, nil
+}
+
+// handshake 与节点握手，校验对方属于同一环境、集群，且确实是该节点（地址未被其他服务复用）
+func (n *Node) handshake(conn net.Conn) error {
+	remote, err := tcp.SendHandshake(conn)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if remote.ServiceID != n.ServiceID {
+		return fmt.Errorf("服务 ID 不一致: 期望 %s, 对方 %s", n.ServiceID, remote.ServiceID)
+	}
+	caps, err := tcp.LocalHandshake().Negotiate(remote)
+	if err != nil {
+		return err
+	}
+
+	// 每个连接都会握手，只在协商结果变化时记录
+	if old := n.caps.Swap(caps); old == nil || old.ProtocolVersion != caps.ProtocolVersion || !slices.Equal(old.Features, caps.Features) || old.Codec != caps.Codec {
+		if caps.ProtocolVersion < tcp.ProtocolVersion {
+			logger.Warnf("节点协议版本较低，降级通信: %s, 版本 %d, 功能 %v", n.ServiceID, caps.ProtocolVersion, caps.Features)
+		} else {
+			logger.Infof("节点协商完成: %s, 版本 %d, 功能 %v, 编解码器 %s, 应用版本 %q", n.ServiceID, caps.ProtocolVersion, caps.Features, caps.Codec, caps.AppVersion)
+		}
+	}
+	n.peer.Store(remote)
+	n.goingAway.Store(false)
+	return nil
+}
+
+// flowWindow 对方告知的每个连接的请求窗口（未协商流量控制时为 0，连接池在握手之后调用）
+func (n *Node) flowWindow() int {
+	if peer := n.peer.Load(); peer != nil && n.caps.Load().Has(tcp.FeatureFlowControl) {
+		return peer.FlowWindow
 	}
+	return 0
+}
+
+// Peer 获取最近一次握手时对方的信息（未握手时为 nil）
+func (n *Node) Peer() *tcp.Handshake {
+	return n.peer.Load()
+}
 
-	return response[:bytesRead], nil
+// Capabilities 获取与节点协商的协议版本和功能（未握手时为 nil）
+func (n *Node) Capabilities() *tcp.Capabilities {
+	return n.caps.Load()
+}
+
+// Codec 获取发往节点的 (module, cmd) 消息体使用的编解码器（已声明时使用声明的，否则使用握手协商的）
+func (n *Node) Codec(module, cmd uint32) tcp.Codec {
+	var negotiated string
+	if caps := n.caps.Load(); caps != nil {
+		negotiated = caps.Codec
+	}
+	return tcp.CodecFor(module, cmd, negotiated)
+}
+
+// frameOptions 发往节点的消息的编码可选项（按握手协商的功能，如校验和）
+func (n *Node) frameOptions() tcp.FrameOptions {
+	return tcp.FrameOptionsOf(n.caps.Load())
+}
+
+// PoolStats 获取连接池统计（未连接时返回零值）
+func (n *Node) PoolStats() PoolStats {
+	pool := n.GetPool()
+	if pool == nil {
+		return PoolStats{}
+	}
+	return pool.Stats()
+}
+
+// Pending 获取进行中的请求数
+func (n *Node) Pending() int64 {
+	return n.pending.Load()
+}
+
+// Weight 获取节点权重（配置 data.weight，默认 1）
+func (n *Node) Weight() float64 {
+	if n.Config == nil || n.Config.Data == nil {
+		return 1
+	}
+
+	switch w := n.Config.Data["weight"].(type) {
+	case float64:
+		return w
+	case int:
+		return float64(w)
+	}
+	return 1
 }
 
 // UpdateConfig 更新节点配置
@@ -278,7 +485,10 @@ func (n *Node) checkConnectionState() {
 }
 
 // tryReconnect 尝试重连
+// 失败后按 ReconnectPolicy 指数退避重试；节点被隔离时，在下次探测时间之前不会重连
 func (n *Node) tryReconnect() {
+	n.reconnectAt.Store(0)
+
 	n.poolMu.Lock()
 	oldPool := n.connPool
 	if oldPool != nil {
@@ -287,50 +497,75 @@ func (n *Node) tryReconnect() {
 	}
 	n.poolMu.Unlock()
 
-	logger.Infof("尝试重连节点: %s", n.ServiceID)
-
-	// 创建新连接池（不启动新协程）
-	target := fmt.Sprintf("%s:%d", n.Config.Addr.Host, n.Config.Addr.Port)
-	cfg := config.Get()
-	poolSize := cfg.Server.ClusterConnCount
-	if poolSize <= 0 {
-		poolSize = 4
+	if wait := n.quarantine.wait(); wait > 0 {
+		n.setStatus(NodeStatusQuarantined)
+		n.scheduleReconnect(wait)
+		return
 	}
 
-	pool, err := NewConnectionPool(target, poolSize)
+	logger.Infof("尝试重连节点: %s (第 %d 次)", n.ServiceID, n.reconnectAttempts.Load()+1)
+	n.setStatus(NodeStatusConnecting)
+
+	// 创建新连接池（不启动新协程）
+	target := tcp.JoinAddr(n.Config.Addr.Host, n.Config.Addr.Port)
+	pool, err := n.newPool(target)
 	if err != nil {
 		logger.Errorf("重连节点失败: %s, %v", n.ServiceID, err)
-		// 5 秒后再次尝试
-		time.AfterFunc(5*time.Second, func() {
-			select {
-			case n.reconnectChan <- struct{}{}:
-			default:
-			}
-		})
+		n.reconnectFailed(err)
 		return
 	}
 
 	n.poolMu.Lock()
 	n.connPool = pool
 	n.poolMu.Unlock()
+	n.startReceivers(pool)
+	n.health.connected(true)
 	n.setStatus(NodeStatusConnected)
 
 	logger.Infof("✓ 节点重连成功: %s", n.ServiceID)
+	n.reconnectSucceeded()
+
+	// 断线期间可能错过了服务变化
+	if n.onReconnect != nil {
+		n.onReconnect()
+	}
 }
 
 // receiveLoop 接收协程（每个连接一个）
-func (n *Node) receiveLoop(conn net.Conn, connIndex int) {
+func (n *Node) receiveLoop(pool *ConnectionPool, conn net.Conn, connIndex int) {
 	logger.Infof("接收协程启动: %s, 连接%d", n.ServiceID, connIndex)
 
+	n.touchConn(conn)
+	defer n.connSeen.Delete(conn)
+	defer n.connBeats.Delete(conn)
+	defer n.connBuckets.Delete(conn)
+
 	for {
 		select {
 		case <-n.stopChan:
 			return
 		default:
-			// 解码消息
-			msg, err := tcp.DecodeMsg(conn)
+			// 解码消息（对方在读超时内没有发来消息时视为连接失效，心跳响应保证正常连接上总有消息）
+			// 消息数据在缓冲池的缓冲区中，心跳等不会被保留的消息处理后归还，其余由 GC 回收
+			msg, frameBuf, err := tcp.ReadFramePooled(conn, pool.ReadTimeout())
 			if err != nil {
+				// 该连接上在途的请求和流不会再收到响应
+				connErr := fmt.Errorf("连接%d 已断开: %s, %w", connIndex, n.ServiceID, err)
+				n.calls.Fail(conn, connErr)
+				n.failStreams(conn, connErr)
+
+				// 连接池主动关闭（断开或重连）或连接被缩容、替换，无需再触发重连
+				if pool.IsClosed() || pool.IsRetired(conn) {
+					return
+				}
 				logger.Warnf("连接%d 接收消息失败: %s, %v", connIndex, n.ServiceID, err)
+
+				// 先只替换这一个连接，对方不可达时再重建整个连接池
+				if err = pool.Replace(conn); err == nil {
+					return
+				}
+				logger.Warnf("替换连接%d 失败: %s, %v", connIndex, n.ServiceID, err)
+
 				// 触发重连
 				select {
 				case n.reconnectChan <- struct{}{}:
@@ -339,19 +574,47 @@ func (n *Node) receiveLoop(conn net.Conn, connIndex int) {
 				return
 			}
 
+			n.touchConn(conn)
+			n.counters.msgsReceived.Add(1)
+
 			// 分发消息
 			switch v := msg.(type) {
 			case *tcp.ClusterReqMsg:
-				// 收到请求消息（不应该发生，节点是客户端）
-				logger.Warnf("节点收到请求消息: module=%d, cmd=%d, sessionId=%s",
-					v.Module, v.Cmd, v.SessionId)
+				// 对方推送的请求（处理器可能再发起请求，不阻塞接收）
+				go tcp.HandlePush(conn, v, n.frameOptions())
 			case *tcp.ClusterRespMsg:
 				// 收到响应消息
+				if tcp.IsGoAwayMsg(v.Module, v.Cmd) {
+					// 对方正在优雅关闭，在途的请求仍会收到响应
+					if !n.goingAway.Swap(true) {
+						logger.Infof("节点正在关闭，不再选中: %s", n.ServiceID)
+					}
+					frameBuf.Release()
+					continue
+				}
+				if tcp.IsFlowCreditMsg(v.Module, v.Cmd) {
+					// 对方处理完请求后发放的额度
+					if flow, ok := conn.(*tcp.FlowConn); ok {
+						flow.Grant(tcp.FlowCredits(v))
+					}
+					frameBuf.Release()
+					continue
+				}
 				if tcp.IsHeartbeatMsg(v.Module, v.Cmd) {
-					// 心跳响应，忽略
+					// 心跳响应，更新 RTT
+					n.health.beatReceived(n.beatTracker(conn).Ack(v))
+					n.recordSuccess()
+					frameBuf.Release()
+					continue
+				}
+				// 优先交给等待中的 Call 和打开的流，其余交给路由器处理，都没有时作为孤立响应报告
+				if n.calls.Resolve(v) || n.resolveStream(v) {
+					continue
+				}
+				if !n.router.Has(v.Module, v.Cmd) {
+					n.calls.ReportOrphan(v)
 					continue
 				}
-				// 处理业务响应
 				if err := n.router.HandleResp(v); err != nil {
 					logger.Warnf("处理响应失败: sessionId=%s, %v", v.SessionId, err)
 				}
@@ -360,9 +623,103 @@ func (n *Node) receiveLoop(conn net.Conn, connIndex int) {
 	}
 }
 
+// heartbeat 向每个连接发送心跳，返回发送失败的连接数和最后一个错误
+// 心跳直接写入连接而不经过连接池，只需等待连接发送队列中排在前面的消息
+// 发送失败或连续 tcp.MaxMissedHeartbeats 个心跳没有响应的连接被关闭，由接收协程替换
+func (n *Node) heartbeat(pool *ConnectionPool) (int, int, error) {
+	conns := pool.Conns()
+	failed := 0
+	var lastErr error
+	for _, conn := range conns {
+		tracker := n.beatTracker(conn)
+		if tracker.Dead() {
+			failed++
+			lastErr = fmt.Errorf("连续 %d 个心跳没有响应", tracker.Stats().Missed)
+			logger.Warnf("%v，关闭并替换连接: %s", lastErr, n.ServiceID)
+			conn.Close()
+			continue
+		}
+
+		// 只发送心跳，不等待响应（接收协程会处理）
+		missed, err := tracker.Send(conn, n.HeartbeatTiming().Interval)
+		if missed {
+			n.health.beatLost()
+		}
+		if err != nil {
+			failed++
+			lastErr = err
+			conn.Close()
+		}
+	}
+	return failed, len(conns), lastErr
+}
+
+// beatTracker 获取连接的心跳跟踪
+func (n *Node) beatTracker(conn net.Conn) *tcp.HeartbeatTracker {
+	if tracker, ok := n.connBeats.Load(conn); ok {
+		return tracker.(*tcp.HeartbeatTracker)
+	}
+	tracker, _ := n.connBeats.LoadOrStore(conn, tcp.NewHeartbeatTracker())
+	return tracker.(*tcp.HeartbeatTracker)
+}
+
+// Heartbeats 各连接的心跳统计（按连接池中的顺序）
+func (n *Node) Heartbeats() []tcp.HeartbeatStats {
+	pool := n.GetPool()
+	if pool == nil {
+		return nil
+	}
+	conns := pool.Conns()
+	beats := make([]tcp.HeartbeatStats, 0, len(conns))
+	for _, conn := range conns {
+		var stats tcp.HeartbeatStats
+		if tracker, ok := n.connBeats.Load(conn); ok {
+			stats = tracker.(*tcp.HeartbeatTracker).Stats()
+		}
+		beats = append(beats, stats)
+	}
+	return beats
+}
+
+// HeartbeatTiming 与该节点之间的心跳间隔和读超时（按节点类型、服务 ID 配置，见 tcp.HeartbeatTimingFor）
+func (n *Node) HeartbeatTiming() tcp.HeartbeatTiming {
+	return tcp.HeartbeatTimingFor(n.ServiceID, n.Type)
+}
+
+// connStaleAfter 连接多久没有收到任何消息视为失效（心跳响应也算）
+func (n *Node) connStaleAfter() time.Duration {
+	return healthStaleBeats * n.HeartbeatTiming().Interval
+}
+
+// touchConn 记录连接收到消息的时间
+func (n *Node) touchConn(conn net.Conn) {
+	if seen, ok := n.connSeen.Load(conn); ok {
+		seen.(*atomic.Int64).Store(time.Now().UnixNano())
+		return
+	}
+	seen := &atomic.Int64{}
+	seen.Store(time.Now().UnixNano())
+	n.connSeen.Store(conn, seen)
+}
+
+// validateConns 检查每个连接最近是否收到过消息，关闭失效的连接（由接收协程替换）
+// 单个连接失效（如中间设备丢弃了空闲连接）时不会拖累整个连接池
+func (n *Node) validateConns(pool *ConnectionPool) {
+	staleAfter := n.connStaleAfter()
+	deadline := time.Now().Add(-staleAfter).UnixNano()
+	for _, conn := range pool.Conns() {
+		seen, ok := n.connSeen.Load(conn)
+		if !ok || seen.(*atomic.Int64).Load() >= deadline {
+			continue
+		}
+		logger.Warnf("连接超过 %v 没有收到消息，关闭并替换: %s", staleAfter, n.ServiceID)
+		conn.Close()
+	}
+}
+
 // sendHeartbeat 定时发送心跳（所有连接都发送，异步不等待响应）
 func (n *Node) sendHeartbeat() {
-	ticker := time.NewTicker(tcp.HeartbeatInterval)
+	ticker := time.NewTicker(n.HeartbeatTiming().Interval)
 	defer ticker.Stop()
 
 	for {
@@ -374,29 +731,14 @@ func (n *Node) sendHeartbeat() {
 			status := n.GetStatus()
 
 			if pool != nil && status == NodeStatusConnected {
-				// 对所有连接发送心跳
-				poolSize := pool.GetPoolSize()
-				var lastErr error
-
-				for i := 0; i < poolSize; i++ {
-					conn, err := pool.Get()
-					if err != nil {
-						lastErr = err
-						continue
-					}
-
-					// 只发送心跳，不等待响应（接收协程会处理）
-					err = tcp.SendHeartbeat(conn)
-					pool.Put(conn) // 立即归还
-
-					if err != nil {
-						lastErr = err
-					}
-				}
+				// 先检查失效连接，再对所有连接发送心跳
+				n.validateConns(pool)
+				failed, total, lastErr := n.heartbeat(pool)
 
 				// 如果所有连接都失败，触发重连
-				if lastErr != nil {
+				if total > 0 && failed == total {
 					logger.Warnf("发送心跳失败: %s, %v", n.ServiceID, lastErr)
+					n.recordFailure()
 					// 触发重连
 					select {
 					case n.reconnectChan <- struct{}{}:
@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/charry/config"
+	"github.com/charry/logger"
+)
+
+// NodeCacheMaxAge 节点缓存的有效期（超过该时间的缓存在启动时忽略）
+const NodeCacheMaxAge = 30 * time.Minute
+
+// nodeCacheFile 节点缓存文件内容
+type nodeCacheFile struct {
+	SavedAt time.Time          `json:"saved_at"`
+	Nodes   []*ServiceInstance `json:"nodes"`
+}
+
+// SetNodeCache 设置节点缓存文件（需在 WatchServices 之前调用，空串表示不缓存）
+// 节点列表变化时写入文件；重启后 WatchServices 先按缓存添加最近已知的节点并立即建立连接，
+// 不必等待服务发现首次返回，服务发现返回后按实际实例增删节点（不存在的缓存节点会被移除）
+func (m *Manager) SetNodeCache(path string) {
+	m.nodeCache = path
+}
+
+// preloadNodeCache 按缓存添加监听服务中最近已知的节点
+func (m *Manager) preloadNodeCache() {
+	if m.nodeCache == "" {
+		return
+	}
+
+	cache, err := loadNodeCache(m.nodeCache)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("读取节点缓存失败: %v", err)
+		}
+		return
+	}
+	if age := time.Since(cache.SavedAt); age > NodeCacheMaxAge {
+		logger.Infof("节点缓存已过期（%v 前保存），忽略", age.Round(time.Second))
+		return
+	}
+
+	cfg := config.Get()
+	selfServiceID := serviceIDOf(&cfg.App)
+	count := 0
+	for _, instance := range cache.Nodes {
+		if instance.Config == nil || instance.ServiceID == selfServiceID ||
+			!slices.Contains(m.serviceNames, serviceNameOf(instance.Config)) {
+			continue
+		}
+		if err := m.AddNode(instance.ServiceID, instance.Config); err == nil {
+			count++
+		}
+	}
+	if count > 0 {
+		logger.Infof("✓ 已按缓存预连接 %d 个节点", count)
+	}
+}
+
+// saveNodeCache 将当前节点列表写入缓存文件（先写临时文件再替换，避免写入中断损坏缓存）
+func (m *Manager) saveNodeCache() {
+	if m.nodeCache == "" {
+		return
+	}
+
+	cache := nodeCacheFile{SavedAt: time.Now()}
+	for _, node := range m.GetAllNodes() {
+		cache.Nodes = append(cache.Nodes, &ServiceInstance{ServiceID: node.ServiceID, Config: node.Config})
+	}
+
+	if err := writeNodeCache(m.nodeCache, &cache); err != nil {
+		logger.Warnf("写入节点缓存失败: %v", err)
+	}
+}
+
+// loadNodeCache 读取节点缓存文件
+func loadNodeCache(path string) (*nodeCacheFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache nodeCacheFile
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("解析节点缓存失败: %w", err)
+	}
+	return &cache, nil
+}
+
+// writeNodeCache 写入节点缓存文件
+func writeNodeCache(path string, cache *nodeCacheFile) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
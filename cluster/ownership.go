@@ -0,0 +1,134 @@
+package cluster
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charry/config"
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// OwnershipEventData 归属成员变化事件数据
+type OwnershipEventData struct {
+	Members []string // 变化后的成员（含本节点，按 ServiceID 排序）
+	Added   []string // 新增的成员
+	Removed []string // 移除的成员
+}
+
+// ownershipView 参与 key 归属计算的成员视图
+type ownershipView struct {
+	selfID  string
+	members []string // 含本节点，按 ServiceID 排序
+	nodes   map[string]*Node
+}
+
+// ownership key 归属跟踪（WatchServices 时启动）
+type ownership struct {
+	view      atomic.Pointer[ownershipView]
+	refreshMu sync.Mutex
+}
+
+// OwnerOf 使用全局管理器计算 key 的归属节点（属于本节点或集群未初始化时返回 nil）
+func OwnerOf(key string) *Node {
+	if GlobalManager == nil {
+		return nil
+	}
+	return GlobalManager.OwnerOf(key)
+}
+
+// OwnerOf 计算 key 的归属节点（属于本节点时返回 nil）
+// 在本节点和同类型的健康节点中按最高随机权重哈希选择，各节点在相同成员视图下结果一致，无需协调
+// 成员变化时只有归属于离开节点的 key 和分给新节点的 key 会移动，并发布 ClusterOwnershipChanged 事件
+func (m *Manager) OwnerOf(key string) *Node {
+	view := m.ownershipView()
+	owner := rendezvousOwner("/"+key, view.members)
+	if owner == view.selfID {
+		return nil
+	}
+	return view.nodes[owner]
+}
+
+// IsLocalKey key 是否归属本节点
+func (m *Manager) IsLocalKey(key string) bool {
+	view := m.ownershipView()
+	return rendezvousOwner("/"+key, view.members) == view.selfID
+}
+
+// ownershipView 获取当前成员视图（跟踪未启动时即时计算）
+func (m *Manager) ownershipView() *ownershipView {
+	if view := m.ownership.view.Load(); view != nil {
+		return view
+	}
+	return m.buildOwnershipView()
+}
+
+// buildOwnershipView 按当前健康节点计算成员视图
+func (m *Manager) buildOwnershipView() *ownershipView {
+	cfg := config.Get()
+	view := &ownershipView{
+		selfID: serviceIDOf(&cfg.App),
+		nodes:  make(map[string]*Node),
+	}
+
+	view.members = append(view.members, view.selfID)
+	for _, node := range m.connectedNodes(cfg.App.Type) {
+		if node.HealthScore() < UnhealthyScore {
+			continue
+		}
+		view.members = append(view.members, node.ServiceID)
+		view.nodes[node.ServiceID] = node
+	}
+	sort.Strings(view.members)
+	return view
+}
+
+// refreshOwnership 重新计算成员视图，成员变化时发布 ClusterOwnershipChanged 事件
+func (m *Manager) refreshOwnership() {
+	m.ownership.refreshMu.Lock()
+	defer m.ownership.refreshMu.Unlock()
+
+	view := m.buildOwnershipView()
+	previous := m.ownership.view.Swap(view)
+	if previous == nil || slices.Equal(previous.members, view.members) {
+		return
+	}
+
+	data := &OwnershipEventData{Members: view.members}
+	for _, member := range view.members {
+		if !slices.Contains(previous.members, member) {
+			data.Added = append(data.Added, member)
+		}
+	}
+	for _, member := range previous.members {
+		if !slices.Contains(view.members, member) {
+			data.Removed = append(data.Removed, member)
+		}
+	}
+
+	logger.Infof("key 归属成员变化: %d 个成员, 新增 %v, 移除 %v", len(view.members), data.Added, data.Removed)
+	event.PublishEvent(event_name.ClusterOwnershipChanged, data)
+}
+
+// ownershipLoop 节点增减时和每个心跳周期（健康状态变化）刷新成员视图
+func (m *Manager) ownershipLoop() {
+	m.refreshOwnership()
+	m.OnMembershipChange(m.refreshOwnership)
+
+	ticker := time.NewTicker(tcp.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			m.refreshOwnership()
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/charry/constants/event_name"
+	"github.com/charry/logger"
+)
+
+// IsPaused 节点是否暂停
+func (n *Node) IsPaused() bool {
+	return n.paused.Load()
+}
+
+// IsGoingAway 节点是否正在优雅关闭（收到对方的关闭通知）
+func (n *Node) IsGoingAway() bool {
+	return n.goingAway.Load()
+}
+
+// selectable 节点是否可被负载均衡、key 归属和角色选择选中（未排空、未暂停且对方未在关闭）
+func (n *Node) selectable() bool {
+	return !n.IsDraining() && !n.IsPaused() && !n.IsGoingAway()
+}
+
+// PauseNode 暂停节点（用于维护窗口、隔离灰度节点）
+// 暂停的节点保持连接和心跳，但不再被负载均衡、key 归属和角色选择选中；按 ServiceID 直接发送的请求不受影响
+// 发布 ClusterNodePaused 事件
+func (m *Manager) PauseNode(serviceID string) error {
+	return m.setPaused(serviceID, true)
+}
+
+// ResumeNode 恢复暂停的节点，发布 ClusterNodeResumed 事件
+func (m *Manager) ResumeNode(serviceID string) error {
+	return m.setPaused(serviceID, false)
+}
+
+// setPaused 设置节点暂停状态
+func (m *Manager) setPaused(serviceID string, paused bool) error {
+	node := m.GetNode(serviceID)
+	if node == nil {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, serviceID)
+	}
+
+	if node.paused.Swap(paused) == paused {
+		return nil
+	}
+
+	if paused {
+		logger.Infof("节点已暂停: %s", serviceID)
+		m.membershipChanged(event_name.ClusterNodePaused, node)
+	} else {
+		logger.Infof("节点已恢复: %s", serviceID)
+		m.membershipChanged(event_name.ClusterNodeResumed, node)
+	}
+	return nil
+}
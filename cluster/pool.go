@@ -4,15 +4,74 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/charry/logger"
+	"github.com/charry/tcp"
 )
 
-// ConnectionPool TCP 连接池
+// 连接池默认参数
+const (
+	defaultPoolGrowWait     = 5 * time.Millisecond // Get 等待超过该时间时扩容
+	defaultPoolShrinkPeriod = 60 * time.Second     // 缩容检查周期
+	poolDialTimeout         = 10 * time.Second     // 建立连接超时
+)
+
+// PoolOptions 连接池配置
+// MinSize == MaxSize 时为固定大小的连接池
+type PoolOptions struct {
+	MinSize      int           // 最少连接数（默认 4）
+	MaxSize      int           // 最多连接数（默认等于 MinSize）
+	GrowWait     time.Duration // Get 等待超过该时间且未达上限时新建连接（默认 5 毫秒）
+	ShrinkPeriod time.Duration // 缩容检查周期：周期内使用中的连接峰值不到一半时关闭一个空闲连接（默认 60 秒）
+
+	// RetireDelay 缩容时连接从池中移除后延迟关闭的时间，等待连接上在途的响应（默认 DefaultCallTimeout）
+	RetireDelay time.Duration
+
+	// Handshake 新建连接后、放入连接池之前调用（返回错误时关闭连接，为空不握手）
+	Handshake func(conn net.Conn) error
+
+	// ReadTimeout、WriteTimeout 连接的读超时（等待下一条消息）和每次写入的超时
+	// 0 使用 tcp 的全局超时（tcp.SetIOTimeouts），< 0 表示不超时
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// SendQueue 连接发送队列（Size 为 0 时使用 tcp 的全局配置，tcp.SetSendQueueOptions）
+	SendQueue tcp.SendQueueOptions
+
+	// FlowWindow 新建连接时对方告知的请求窗口（握手之后调用，返回 0 时不做流量控制，为空不做流量控制）
+	FlowWindow func() int
+
+	// 流量计数（为空不统计）
+	counters *connCounters
+}
+
+// PoolStats 连接池统计
+type PoolStats struct {
+	Size        int           // 当前连接数
+	Idle        int           // 空闲连接数
+	InUse       int           // 使用中的连接数
+	Utilization float64       // 使用率（InUse / Size）
+	Gets        uint64        // Get 次数
+	AvgWait     time.Duration // Get 平均等待时间
+	MaxWait     time.Duration // Get 最长等待时间
+	Grows       uint64        // 扩容次数
+	Shrinks     uint64        // 缩容次数
+	Repairs     uint64        // 替换失效连接的次数
+	SendQueued  int           // 各连接发送队列中排队的消息数
+	SendFull    uint64        // 连接发送队列满的次数
+	FlowWindow  int           // 各连接流量控制窗口之和（未启用时为 0）
+	FlowPending int           // 各连接在途（对方尚未发放额度）的请求数
+	FlowWaits   uint64        // 因流量控制窗口用尽等待的次数
+}
+
+// ConnectionPool TCP 连接池（按等待时间和使用率在 MinSize ~ MaxSize 之间伸缩）
+// 连接的写入带锁，借出的连接和流可以安全地并发写入
 type ConnectionPool struct {
-	// 连接列表
+	// 连接列表（长度为 MaxSize，未使用的槽位为 nil）
 	conns []net.Conn
 	mu    sync.RWMutex
 
@@ -20,54 +79,197 @@ type ConnectionPool struct {
 	freeConns chan int
 
 	// 连接配置
-	target   string
-	poolSize int
+	target  string
+	options PoolOptions
+
+	// 当前连接数和使用中的连接数
+	size      int
+	inUse     atomic.Int64
+	peakInUse atomic.Int64 // 本缩容周期内使用中的连接峰值
+
+	// 扩容中（同一时间只扩容一个连接）
+	growing atomic.Bool
+
+	// 已缩容、已替换、等待关闭的连接（接收协程据此判断无需重连）
+	retired map[net.Conn]struct{}
+
+	// 已替换的连接 -> 槽位（被替换时正在借出的连接归还时据此归还槽位）
+	replaced   map[net.Conn]int
+	replacedMu sync.Mutex
+
+	// 新建连接回调（扩容时启动接收协程）
+	onConnect func(conn net.Conn, idx int)
+
+	// 统计
+	gets      atomic.Uint64
+	waitTotal atomic.Int64
+	waitMax   atomic.Int64
+	grows     atomic.Uint64
+	shrinks   atomic.Uint64
+	repairs   atomic.Uint64
+	sendFull  atomic.Uint64
+	flowWaits atomic.Uint64
 
 	// 状态
-	closed bool
+	closed   bool
+	stopChan chan struct{}
 }
 
-// NewConnectionPool 创建连接池
+// NewConnectionPool 创建固定大小的连接池
 func NewConnectionPool(target string, poolSize int) (*ConnectionPool, error) {
-	if poolSize <= 0 {
-		poolSize = 4 // 默认 4 个连接
+	return NewConnectionPoolWithOptions(target, PoolOptions{MinSize: poolSize, MaxSize: poolSize})
+}
+
+// NewConnectionPoolWithOptions 创建连接池（立即建立 MinSize 个连接）
+func NewConnectionPoolWithOptions(target string, options PoolOptions) (*ConnectionPool, error) {
+	if options.MinSize <= 0 {
+		options.MinSize = 4 // 默认 4 个连接
+	}
+	if options.MaxSize < options.MinSize {
+		options.MaxSize = options.MinSize
+	}
+	if options.GrowWait <= 0 {
+		options.GrowWait = defaultPoolGrowWait
+	}
+	if options.ShrinkPeriod <= 0 {
+		options.ShrinkPeriod = defaultPoolShrinkPeriod
+	}
+	if options.RetireDelay <= 0 {
+		options.RetireDelay = DefaultCallTimeout
 	}
 
 	pool := &ConnectionPool{
-		conns:     make([]net.Conn, poolSize),
-		freeConns: make(chan int, poolSize),
+		conns:     make([]net.Conn, options.MaxSize),
+		freeConns: make(chan int, options.MaxSize),
 		target:    target,
-		poolSize:  poolSize,
+		options:   options,
+		retired:   make(map[net.Conn]struct{}),
+		replaced:  make(map[net.Conn]int),
+		stopChan:  make(chan struct{}),
 	}
 
 	// 初始化连接
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), poolDialTimeout)
 	defer cancel()
 
-	var d net.Dialer
-	for i := 0; i < poolSize; i++ {
-		conn, err := d.DialContext(ctx, "tcp", target)
+	for i := 0; i < options.MinSize; i++ {
+		conn, err := pool.dial(ctx)
 		if err != nil {
 			// 清理已创建的连接
 			pool.Close()
 			return nil, fmt.Errorf("创建连接 %d 失败: %w", i, err)
 		}
-		pool.conns[i] = conn
+		pool.conns[i] = pool.wrap(conn)
+		pool.size++
 		pool.freeConns <- i // 标记为空闲
 	}
 
-	logger.Infof("连接池创建成功: %s, 连接数: %d", target, poolSize)
+	if options.MaxSize > options.MinSize {
+		go pool.shrinkLoop()
+	}
+
+	logger.Infof("连接池创建成功: %s, 连接数: %d ~ %d", target, options.MinSize, options.MaxSize)
 	return pool, nil
 }
 
+// dial 建立连接并握手
+func (p *ConnectionPool) dial(ctx context.Context) (net.Conn, error) {
+	conn, err := tcp.Dial(ctx, p.target)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.options.Handshake != nil {
+		if err := p.options.Handshake(conn); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("握手失败: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// wrap 包装连接：写入经发送队列由单个写协程完成，按需统计流量
+func (p *ConnectionPool) wrap(conn net.Conn) net.Conn {
+	if p.options.counters != nil {
+		conn = &meteredConn{Conn: conn, counters: p.options.counters}
+	}
+	opts := p.options.SendQueue
+	opts.OnFull = func() { p.sendFull.Add(1) }
+	queued := tcp.NewQueuedConn(conn, opts)
+	queued.SetWriteTimeout(p.WriteTimeout())
+
+	// 流量控制在发送队列之前等待，心跳等系统消息不受窗口限制
+	if p.options.FlowWindow != nil {
+		if window := p.options.FlowWindow(); window > 0 {
+			return tcp.NewFlowConn(queued, tcp.FlowOptions{Window: window, OnWait: func() { p.flowWaits.Add(1) }})
+		}
+	}
+	return queued
+}
+
+// ReadTimeout 连接的读超时（0 表示不超时）
+func (p *ConnectionPool) ReadTimeout() time.Duration {
+	return ioTimeout(p.options.ReadTimeout, tcp.ReadTimeout())
+}
+
+// WriteTimeout 连接每次写入的超时（0 表示不超时）
+func (p *ConnectionPool) WriteTimeout() time.Duration {
+	return ioTimeout(p.options.WriteTimeout, tcp.WriteTimeout())
+}
+
+// ioTimeout 连接池配置的超时：0 使用全局超时，< 0 表示不超时
+func ioTimeout(configured, global time.Duration) time.Duration {
+	if configured == 0 {
+		return global
+	}
+	return max(configured, 0)
+}
+
+// SetOnConnect 设置扩容时新建连接的回调（需在使用连接池之前设置）
+func (p *ConnectionPool) SetOnConnect(fn func(conn net.Conn, idx int)) {
+	p.onConnect = fn
+}
+
 // Get 获取一个连接（阻塞直到有可用连接）
+// 等待超过 GrowWait 且未达上限时异步新建连接
 func (p *ConnectionPool) Get() (net.Conn, error) {
-	if p.closed {
+	return p.GetCtx(context.Background())
+}
+
+// GetCtx 获取一个连接，等待直到有可用连接或 ctx 结束
+func (p *ConnectionPool) GetCtx(ctx context.Context) (net.Conn, error) {
+	if p.IsClosed() {
+		return nil, fmt.Errorf("连接池已关闭")
+	}
+
+	start := time.Now()
+
+	var idx int
+	var ok bool
+	select {
+	case idx, ok = <-p.freeConns:
+	default:
+		// 等待期间每隔 GrowWait 尝试扩容一次
+		ticker := time.NewTicker(p.options.GrowWait)
+	wait:
+		for {
+			select {
+			case idx, ok = <-p.freeConns:
+				break wait
+			case <-ticker.C:
+				p.grow()
+			case <-ctx.Done():
+				ticker.Stop()
+				return nil, fmt.Errorf("等待可用连接失败: %w", ctx.Err())
+			}
+		}
+		ticker.Stop()
+	}
+	if !ok {
 		return nil, fmt.Errorf("连接池已关闭")
 	}
 
-	// 从空闲队列获取索引
-	idx := <-p.freeConns
+	p.recordGet(time.Since(start))
 
 	p.mu.RLock()
 	conn := p.conns[idx]
@@ -78,34 +280,233 @@ func (p *ConnectionPool) Get() (net.Conn, error) {
 
 // Put 归还连接
 func (p *ConnectionPool) Put(conn net.Conn) {
+	if p.IsClosed() {
+		return
+	}
+
+	// 持有读锁归还，避免与 Close 关闭空闲队列并发
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	if p.closed {
 		return
 	}
 
 	// 找到连接的索引
-	p.mu.RLock()
-	var idx int
-	found := false
-	for i, c := range p.conns {
-		if c == conn {
-			idx = i
-			found = true
-			break
+	for idx, c := range p.conns {
+		if c != nil && c == conn {
+			p.inUse.Add(-1)
+
+			// 归还到空闲队列
+			select {
+			case p.freeConns <- idx:
+			default:
+				// 队列满了，不应该发生
+				logger.Warn("连接池空闲队列已满")
+			}
+			return
 		}
 	}
-	p.mu.RUnlock()
 
-	if found {
-		// 归还到空闲队列
+	// 借出期间连接已被替换，归还对应槽位（槽位中现在是新连接）
+	p.replacedMu.Lock()
+	idx, replaced := p.replaced[conn]
+	delete(p.replaced, conn)
+	p.replacedMu.Unlock()
+	if replaced {
+		p.inUse.Add(-1)
 		select {
 		case p.freeConns <- idx:
 		default:
-			// 队列满了，不应该发生
 			logger.Warn("连接池空闲队列已满")
 		}
 	}
 }
 
+// recordGet 记录 Get 等待时间和使用中的连接数
+func (p *ConnectionPool) recordGet(wait time.Duration) {
+	p.gets.Add(1)
+	p.waitTotal.Add(int64(wait))
+	for {
+		max := p.waitMax.Load()
+		if int64(wait) <= max || p.waitMax.CompareAndSwap(max, int64(wait)) {
+			break
+		}
+	}
+
+	inUse := p.inUse.Add(1)
+	for {
+		peak := p.peakInUse.Load()
+		if inUse <= peak || p.peakInUse.CompareAndSwap(peak, inUse) {
+			break
+		}
+	}
+}
+
+// grow 异步新建一个连接（已达上限或正在扩容时忽略）
+func (p *ConnectionPool) grow() {
+	p.mu.RLock()
+	full := p.closed || p.size >= p.options.MaxSize
+	p.mu.RUnlock()
+	if full || !p.growing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer p.growing.Store(false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), poolDialTimeout)
+		conn, err := p.dial(ctx)
+		cancel()
+		if err != nil {
+			logger.Warnf("连接池扩容失败: %s, %v", p.target, err)
+			return
+		}
+
+		p.mu.Lock()
+		idx := -1
+		if !p.closed {
+			for i, c := range p.conns {
+				if c == nil {
+					idx = i
+					break
+				}
+			}
+		}
+		if idx < 0 {
+			p.mu.Unlock()
+			conn.Close()
+			return
+		}
+		locked := p.wrap(conn)
+		p.conns[idx] = locked
+		p.size++
+		size := p.size
+		p.mu.Unlock()
+
+		if p.onConnect != nil {
+			p.onConnect(locked, idx)
+		}
+		p.grows.Add(1)
+
+		p.mu.RLock()
+		if !p.closed {
+			p.freeConns <- idx
+		}
+		p.mu.RUnlock()
+		logger.Infof("连接池扩容: %s, 连接数: %d", p.target, size)
+	}()
+}
+
+// shrinkLoop 定期检查使用率，峰值不到一半时关闭一个空闲连接
+func (p *ConnectionPool) shrinkLoop() {
+	ticker := time.NewTicker(p.options.ShrinkPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+			peak := p.peakInUse.Swap(p.inUse.Load())
+
+			p.mu.RLock()
+			size := p.size
+			p.mu.RUnlock()
+
+			if size > p.options.MinSize && peak*2 < int64(size) {
+				p.shrink()
+			}
+		}
+	}
+}
+
+// shrink 移除一个空闲连接，延迟关闭以等待在途响应
+func (p *ConnectionPool) shrink() {
+	var idx int
+	select {
+	case idx = <-p.freeConns:
+	default:
+		return // 没有空闲连接
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	conn := p.conns[idx]
+	p.conns[idx] = nil
+	p.size--
+	p.retired[conn] = struct{}{}
+	size := p.size
+	p.mu.Unlock()
+
+	p.shrinks.Add(1)
+	time.AfterFunc(p.options.RetireDelay, func() {
+		conn.Close()
+	})
+	logger.Infof("连接池缩容: %s, 连接数: %d", p.target, size)
+}
+
+// Replace 用新建的连接替换失效的连接（只替换该连接，不影响池中的其他连接）
+// 新连接占用原槽位并触发 onConnect，旧连接立即关闭
+func (p *ConnectionPool) Replace(old net.Conn) error {
+	p.mu.RLock()
+	idx := slices.Index(p.conns, old)
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return fmt.Errorf("连接池已关闭")
+	}
+	if idx < 0 {
+		return fmt.Errorf("连接不在连接池中")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), poolDialTimeout)
+	conn, err := p.dial(ctx)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if p.closed || p.conns[idx] != old {
+		p.mu.Unlock()
+		conn.Close()
+		return fmt.Errorf("连接已被移除")
+	}
+	locked := p.wrap(conn)
+	p.conns[idx] = locked
+	p.retired[old] = struct{}{}
+	p.mu.Unlock()
+
+	// 旧连接若正被借出，归还时按槽位归还；空闲时槽位已在空闲队列中，延迟清理登记
+	p.replacedMu.Lock()
+	p.replaced[old] = idx
+	p.replacedMu.Unlock()
+	time.AfterFunc(p.options.RetireDelay, func() {
+		p.replacedMu.Lock()
+		delete(p.replaced, old)
+		p.replacedMu.Unlock()
+	})
+
+	old.Close()
+	if p.onConnect != nil {
+		p.onConnect(locked, idx)
+	}
+	p.repairs.Add(1)
+	logger.Infof("连接池已替换失效连接: %s, 连接%d", p.target, idx)
+	return nil
+}
+
+// IsRetired 连接是否已被缩容移除（接收协程退出时据此判断无需重连）
+func (p *ConnectionPool) IsRetired(conn net.Conn) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, retired := p.retired[conn]
+	return retired
+}
+
 // Close 关闭连接池
 func (p *ConnectionPool) Close() {
 	p.mu.Lock()
@@ -115,27 +516,89 @@ func (p *ConnectionPool) Close() {
 		return
 	}
 	p.closed = true
+	close(p.stopChan)
 
 	// 关闭所有连接
 	for _, conn := range p.conns {
 		if conn != nil {
-			err := conn.Close()
-			if err != nil {
-				return
-			}
+			conn.Close()
 		}
 	}
+	for conn := range p.retired {
+		conn.Close()
+	}
 
 	close(p.freeConns)
 	logger.Infof("连接池已关闭: %s", p.target)
 }
 
-// GetPoolSize 获取连接池大小
+// Conns 获取所有连接（用于启动接收协程，读取连接不受 Get/Put 限制）
+func (p *ConnectionPool) Conns() []net.Conn {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	conns := make([]net.Conn, 0, p.size)
+	for _, conn := range p.conns {
+		if conn != nil {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+// IsClosed 连接池是否已关闭
+func (p *ConnectionPool) IsClosed() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.closed
+}
+
+// GetPoolSize 获取当前连接数
 func (p *ConnectionPool) GetPoolSize() int {
-	return p.poolSize
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.size
 }
 
 // GetFreeCount 获取空闲连接数
 func (p *ConnectionPool) GetFreeCount() int {
 	return len(p.freeConns)
 }
+
+// Stats 获取连接池统计
+func (p *ConnectionPool) Stats() PoolStats {
+	size := p.GetPoolSize()
+	inUse := int(p.inUse.Load())
+
+	stats := PoolStats{
+		Size:    size,
+		Idle:    p.GetFreeCount(),
+		InUse:   inUse,
+		Gets:    p.gets.Load(),
+		MaxWait: time.Duration(p.waitMax.Load()),
+		Grows:   p.grows.Load(),
+		Shrinks: p.shrinks.Load(),
+		Repairs: p.repairs.Load(),
+
+		SendFull:  p.sendFull.Load(),
+		FlowWaits: p.flowWaits.Load(),
+	}
+	for _, conn := range p.Conns() {
+		if flow, ok := conn.(*tcp.FlowConn); ok {
+			flowStats := flow.Stats()
+			stats.FlowWindow += flowStats.Window
+			stats.FlowPending += flowStats.InFlight
+			conn = flow.Unwrap()
+		}
+		if queued, ok := conn.(*tcp.QueuedConn); ok {
+			stats.SendQueued += queued.Stats().Depth
+		}
+	}
+	if size > 0 {
+		stats.Utilization = float64(inUse) / float64(size)
+	}
+	if stats.Gets > 0 {
+		stats.AvgWait = time.Duration(p.waitTotal.Load() / int64(stats.Gets))
+	}
+	return stats
+}
@@ -0,0 +1,133 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
+	"github.com/charry/logger"
+)
+
+// 节点隔离参数
+const (
+	// QuarantineThreshold 默认连续连接或发送失败达到该次数后隔离节点（见 ReconnectPolicy.MaxAttempts）
+	QuarantineThreshold = 5
+
+	quarantineProbeMax = 5 * time.Minute // 隔离后探测间隔上限（ReconnectPolicy.MaxDelay 更大时以其为准）
+)
+
+// QuarantineEventData 节点隔离事件数据
+type QuarantineEventData struct {
+	NodeEventData
+	Failures      int           // 连续失败次数
+	ProbeInterval time.Duration // 下次探测间隔
+}
+
+// nodeQuarantine 节点隔离跟踪
+// 隔离期间不再按重连退避间隔重连，而是按指数增长的探测间隔探测，直到请求或心跳成功
+type nodeQuarantine struct {
+	failures    int       // 连续失败次数
+	quarantined bool      // 是否处于隔离状态
+	probeAt     time.Time // 隔离期间下次允许探测的时间
+	mu          sync.Mutex
+}
+
+// failed 记录一次失败，返回是否刚进入隔离状态和下次探测间隔
+// 连续失败达到 threshold 次后隔离，探测间隔从 2 倍 probeBase 开始翻倍
+func (q *nodeQuarantine) failed(threshold int, probeBase time.Duration) (entered bool, failures int, interval time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.failures++
+	if q.failures < threshold {
+		return false, q.failures, 0
+	}
+
+	probeMax := max(quarantineProbeMax, probeBase)
+	interval = probeMax
+	if shift := q.failures - threshold + 1; shift < 16 {
+		interval = min(probeBase<<shift, probeMax)
+	}
+	q.probeAt = time.Now().Add(interval)
+
+	entered = !q.quarantined
+	q.quarantined = true
+	return entered, q.failures, interval
+}
+
+// succeeded 记录一次成功，返回是否解除了隔离
+func (q *nodeQuarantine) succeeded() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	released := q.quarantined
+	q.failures = 0
+	q.quarantined = false
+	q.probeAt = time.Time{}
+	return released
+}
+
+// wait 距离下次允许探测的时间（未隔离或已到探测时间时为 0）
+func (q *nodeQuarantine) wait() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.quarantined {
+		return 0
+	}
+	return max(time.Until(q.probeAt), 0)
+}
+
+// probeNow 允许立即探测（仍保持隔离状态，探测失败后按更长的间隔继续探测）
+func (q *nodeQuarantine) probeNow() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.probeAt = time.Now()
+}
+
+// isQuarantined 是否处于隔离状态
+func (q *nodeQuarantine) isQuarantined() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.quarantined
+}
+
+// IsQuarantined 节点是否处于隔离状态
+func (n *Node) IsQuarantined() bool {
+	return n.quarantine.isQuarantined()
+}
+
+// recordFailure 记录一次连接或发送失败，达到阈值时隔离节点并发布 ClusterNodeQuarantined 事件
+func (n *Node) recordFailure() {
+	policy := n.ReconnectPolicy()
+	entered, failures, interval := n.quarantine.failed(policy.MaxAttempts, policy.MaxDelay)
+	if !entered {
+		return
+	}
+
+	logger.Warnf("节点连续失败 %d 次，已隔离: %s, %v 后探测", failures, n.ServiceID, interval)
+	event.PublishEvent(event_name.ClusterNodeQuarantined, &QuarantineEventData{
+		NodeEventData: n.eventData(),
+		Failures:      failures,
+		ProbeInterval: interval,
+	})
+}
+
+// recordSuccess 记录一次请求或心跳成功，清零连续失败次数
+func (n *Node) recordSuccess() {
+	if n.quarantine.succeeded() {
+		logger.Infof("✓ 节点已解除隔离: %s", n.ServiceID)
+	}
+}
+
+// scheduleReconnect 延迟触发重连
+func (n *Node) scheduleReconnect(delay time.Duration) {
+	n.reconnectAt.Store(time.Now().Add(delay).UnixNano())
+	time.AfterFunc(delay, func() {
+		select {
+		case n.reconnectChan <- struct{}{}:
+		default:
+		}
+	})
+}
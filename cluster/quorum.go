@@ -0,0 +1,140 @@
+package cluster
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/charry/config"
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
+	"github.com/charry/logger"
+)
+
+// ErrPartitionSuspected 疑似网络分区，写入被隔离
+var ErrPartitionSuspected = errors.New("疑似网络分区，拒绝写入")
+
+// QuorumOptions 法定人数检查配置
+type QuorumOptions struct {
+	MinFraction float64       // 已连接节点（含本节点）占注册节点的最小比例（默认 0.5）
+	Interval    time.Duration // 检查间隔（默认 10 秒）
+	Fence       bool          // 疑似分区时隔离写入（CheckQuorum 返回 ErrPartitionSuspected）
+}
+
+// PartitionEventData 网络分区事件数据
+type PartitionEventData struct {
+	Connected   int     // 已连接节点数（含本节点）
+	Registered  int     // 服务发现中注册的健康节点数（含本节点）
+	Fraction    float64 // Connected / Registered
+	MinFraction float64
+}
+
+// quorumState 法定人数检查状态
+type quorumState struct {
+	options   QuorumOptions
+	suspected bool
+	last      PartitionEventData
+	started   bool
+	mu        sync.RWMutex
+}
+
+// StartQuorumWatch 启动法定人数检查（需在 WatchServices 之后调用，只能启动一次）
+// 定时比较本节点已连接的同类节点与服务发现中注册的节点，已连接比例低于 MinFraction 时
+// 发布 ClusterPartitionSuspected 事件，恢复后发布 ClusterPartitionResolved 事件
+func (m *Manager) StartQuorumWatch(options QuorumOptions) {
+	if options.MinFraction <= 0 {
+		options.MinFraction = 0.5
+	}
+	if options.Interval <= 0 {
+		options.Interval = 10 * time.Second
+	}
+
+	m.quorum.mu.Lock()
+	if m.quorum.started {
+		m.quorum.mu.Unlock()
+		logger.Warn("法定人数检查已启动")
+		return
+	}
+	m.quorum.started = true
+	m.quorum.options = options
+	m.quorum.mu.Unlock()
+
+	logger.Infof("启动法定人数检查: 最小比例 %.2f, 间隔 %v, 隔离写入: %v",
+		options.MinFraction, options.Interval, options.Fence)
+
+	go func() {
+		ticker := time.NewTicker(options.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.stopChan:
+				return
+			case <-ticker.C:
+				m.checkQuorum()
+			}
+		}
+	}()
+}
+
+// checkQuorum 执行一次法定人数检查
+func (m *Manager) checkQuorum() {
+	cfg := config.Get()
+	instances, err := m.discovery.Instances(serviceNameOf(&cfg.App))
+	if err != nil {
+		// 无法获取注册信息时保持上次结果
+		logger.Warnf("法定人数检查失败: %v", err)
+		return
+	}
+
+	registered := len(instances)
+	connected := len(m.connectedNodes(cfg.App.Type)) + 1 // 含本节点
+	registered = max(registered, connected)              // 本节点可能尚未出现在健康实例中
+
+	m.quorum.mu.Lock()
+	data := PartitionEventData{
+		Connected:   connected,
+		Registered:  registered,
+		Fraction:    float64(connected) / float64(registered),
+		MinFraction: m.quorum.options.MinFraction,
+	}
+	wasSuspected := m.quorum.suspected
+	m.quorum.suspected = data.Fraction < data.MinFraction
+	m.quorum.last = data
+	suspected := m.quorum.suspected
+	m.quorum.mu.Unlock()
+
+	switch {
+	case suspected && !wasSuspected:
+		logger.Errorf("疑似网络分区: 已连接 %d/%d 个节点 (%.2f < %.2f)",
+			connected, registered, data.Fraction, data.MinFraction)
+		event.PublishEvent(event_name.ClusterPartitionSuspected, &data)
+	case !suspected && wasSuspected:
+		logger.Infof("✓ 网络分区已恢复: 已连接 %d/%d 个节点", connected, registered)
+		event.PublishEvent(event_name.ClusterPartitionResolved, &data)
+	}
+}
+
+// PartitionSuspected 是否疑似网络分区（未启动法定人数检查时为 false）
+func (m *Manager) PartitionSuspected() bool {
+	m.quorum.mu.RLock()
+	defer m.quorum.mu.RUnlock()
+	return m.quorum.suspected
+}
+
+// QuorumStatus 最近一次法定人数检查结果
+func (m *Manager) QuorumStatus() PartitionEventData {
+	m.quorum.mu.RLock()
+	defer m.quorum.mu.RUnlock()
+	return m.quorum.last
+}
+
+// CheckQuorum 写入前检查：启用隔离写入且疑似网络分区时返回 ErrPartitionSuspected
+func (m *Manager) CheckQuorum() error {
+	m.quorum.mu.RLock()
+	defer m.quorum.mu.RUnlock()
+	if m.quorum.options.Fence && m.quorum.suspected {
+		return ErrPartitionSuspected
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited 超出节点请求限制（FailFast 模式）
+var ErrRateLimited = errors.New("超出节点请求限制")
+
+// RateLimit 节点请求限制（客户端限流，保护处理不过来的对端）
+// 零值表示不限制；心跳不受限制
+type RateLimit struct {
+	RequestsPerSecond float64 // 每秒最多发出的请求数（<= 0 不限制）
+	Burst             int     // 突发容量（默认为每秒请求数向上取整）
+	MaxInflight       int     // 最多同时在途的请求数（<= 0 不限制），Call 在收到响应前都算在途
+	FailFast          bool    // 超出限制时立即返回 ErrRateLimited（默认排队等待，受 ctx 限制）
+}
+
+// nodeLimiter 节点请求限制器（令牌桶 + 在途信号量）
+type nodeLimiter struct {
+	limit    RateLimit
+	burst    float64
+	tokens   float64
+	last     time.Time
+	mu       sync.Mutex
+	inflight chan struct{} // 为 nil 时不限制在途数
+}
+
+// newNodeLimiter 创建限制器（不限制时返回 nil）
+func newNodeLimiter(limit RateLimit) *nodeLimiter {
+	if limit.RequestsPerSecond <= 0 && limit.MaxInflight <= 0 {
+		return nil
+	}
+
+	l := &nodeLimiter{limit: limit, last: time.Now()}
+	if limit.RequestsPerSecond > 0 {
+		l.burst = float64(limit.Burst)
+		if l.burst <= 0 {
+			l.burst = math.Ceil(limit.RequestsPerSecond)
+		}
+		l.tokens = l.burst
+	}
+	if limit.MaxInflight > 0 {
+		l.inflight = make(chan struct{}, limit.MaxInflight)
+	}
+	return l
+}
+
+// acquire 获取发送许可，返回释放函数
+// 先占用在途名额再取令牌，排队等待名额时不消耗令牌
+func (l *nodeLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	release := func() {}
+	if l.inflight != nil {
+		if l.limit.FailFast {
+			select {
+			case l.inflight <- struct{}{}:
+			default:
+				return nil, ErrRateLimited
+			}
+		} else {
+			select {
+			case l.inflight <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		release = func() { <-l.inflight }
+	}
+
+	if err := l.take(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// take 从令牌桶取一个令牌（不限速时直接返回）
+func (l *nodeLimiter) take(ctx context.Context) error {
+	if l.limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.limit.RequestsPerSecond)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.limit.RequestsPerSecond * float64(time.Second))
+		l.mu.Unlock()
+
+		if l.limit.FailFast {
+			return ErrRateLimited
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// SetRateLimit 设置节点请求限制（零值取消限制），正在排队的请求仍按原限制处理
+func (n *Node) SetRateLimit(limit RateLimit) {
+	n.limiter.Store(newNodeLimiter(limit))
+}
+
+// RateLimit 获取节点请求限制
+func (n *Node) RateLimit() RateLimit {
+	if l := n.limiter.Load(); l != nil {
+		return l.limit
+	}
+	return RateLimit{}
+}
+
+// SetRateLimit 设置所有节点（包括之后加入的节点）的请求限制
+func (m *Manager) SetRateLimit(limit RateLimit) {
+	m.rateLimit.Store(&limit)
+	for _, node := range m.GetAllNodes() {
+		node.SetRateLimit(limit)
+	}
+}
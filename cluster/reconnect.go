@@ -0,0 +1,146 @@
+package cluster
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
+)
+
+// 重连退避默认参数
+const (
+	DefaultReconnectDelay      = time.Second      // 首次失败后的重连间隔
+	DefaultReconnectMaxDelay   = 30 * time.Second // 重连间隔上限
+	DefaultReconnectMultiplier = 2.0              // 每次失败后间隔的倍数
+	DefaultReconnectJitter     = 0.2              // 随机抖动比例（±20%）
+)
+
+// ReconnectPolicy 重连退避策略
+// 连续重连失败时间隔按 Multiplier 指数增长到 MaxDelay，并加入随机抖动避免多个节点同时重连；
+// 连续失败达到 MaxAttempts 次后隔离节点，改为按更长的探测间隔重试
+type ReconnectPolicy struct {
+	InitialDelay time.Duration // 首次失败后的重连间隔（0 使用 DefaultReconnectDelay）
+	MaxDelay     time.Duration // 重连间隔上限（0 使用 DefaultReconnectMaxDelay），也是隔离后探测间隔的基数
+	Multiplier   float64       // 间隔倍数（小于 1 时使用 DefaultReconnectMultiplier）
+	Jitter       float64       // 随机抖动比例 0 ~ 1（0 使用 DefaultReconnectJitter，小于 0 表示不抖动）
+	MaxAttempts  int           // 连续失败达到该次数后隔离节点（0 使用 QuarantineThreshold）
+}
+
+// withDefaults 补全未设置的参数
+func (p ReconnectPolicy) withDefaults() ReconnectPolicy {
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultReconnectDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultReconnectMaxDelay
+	}
+	p.MaxDelay = max(p.MaxDelay, p.InitialDelay)
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultReconnectMultiplier
+	}
+	switch {
+	case p.Jitter == 0:
+		p.Jitter = DefaultReconnectJitter
+	case p.Jitter < 0:
+		p.Jitter = 0
+	case p.Jitter > 1:
+		p.Jitter = 1
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = QuarantineThreshold
+	}
+	return p
+}
+
+// backoff 第 attempt 次（从 1 开始）连续失败后的重连间隔
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialDelay)
+	for i := 1; i < attempt && delay < float64(p.MaxDelay); i++ {
+		delay *= p.Multiplier
+	}
+	delay = min(delay, float64(p.MaxDelay))
+
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// ReconnectEventData 节点重连事件数据
+type ReconnectEventData struct {
+	NodeEventData
+	Attempt int           // 连续重连次数（重连成功事件中为成功前的失败次数）
+	Delay   time.Duration // 下次重连间隔（重连成功时为 0）
+	Error   string        // 本次重连失败原因（重连成功时为空）
+}
+
+// ReconnectState 节点重连状态
+type ReconnectState struct {
+	Attempts int       // 连续重连失败次数（重连成功后清零）
+	NextAt   time.Time // 下次重连时间（没有待执行的重连时为零值）
+}
+
+// SetReconnectPolicy 设置节点重连退避策略
+func (n *Node) SetReconnectPolicy(policy ReconnectPolicy) {
+	policy = policy.withDefaults()
+	n.reconnect.Store(&policy)
+}
+
+// ReconnectPolicy 获取节点重连退避策略（已补全默认值）
+func (n *Node) ReconnectPolicy() ReconnectPolicy {
+	if p := n.reconnect.Load(); p != nil {
+		return *p
+	}
+	return ReconnectPolicy{}.withDefaults()
+}
+
+// ReconnectState 获取节点重连状态
+func (n *Node) ReconnectState() ReconnectState {
+	state := ReconnectState{Attempts: int(n.reconnectAttempts.Load())}
+	if at := n.reconnectAt.Load(); at > 0 {
+		state.NextAt = time.Unix(0, at)
+	}
+	return state
+}
+
+// reconnectFailed 记录一次重连失败，按退避策略安排下次重连并发布 ClusterNodeReconnecting 事件
+func (n *Node) reconnectFailed(err error) {
+	attempt := int(n.reconnectAttempts.Add(1))
+	n.recordFailure()
+
+	// 隔离后按探测间隔重试，否则按指数退避重试
+	delay := n.quarantine.wait()
+	if delay > 0 {
+		n.setStatus(NodeStatusQuarantined)
+	} else {
+		n.setStatus(NodeStatusFailed)
+		delay = n.ReconnectPolicy().backoff(attempt)
+	}
+	n.scheduleReconnect(delay)
+
+	event.PublishEvent(event_name.ClusterNodeReconnecting, &ReconnectEventData{
+		NodeEventData: n.eventData(),
+		Attempt:       attempt,
+		Delay:         delay,
+		Error:         err.Error(),
+	})
+}
+
+// reconnectSucceeded 重连成功，清零重连次数并发布 ClusterNodeReconnected 事件
+func (n *Node) reconnectSucceeded() {
+	attempts := int(n.reconnectAttempts.Swap(0))
+	event.PublishEvent(event_name.ClusterNodeReconnected, &ReconnectEventData{
+		NodeEventData: n.eventData(),
+		Attempt:       attempts,
+	})
+}
+
+// SetReconnectPolicy 设置所有节点（包括之后加入的节点）的重连退避策略
+func (m *Manager) SetReconnectPolicy(policy ReconnectPolicy) {
+	policy = policy.withDefaults()
+	m.reconnectPolicy.Store(&policy)
+	for _, node := range m.GetAllNodes() {
+		node.SetReconnectPolicy(policy)
+	}
+}
@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/charry/logger"
@@ -44,6 +45,14 @@ func (r *Router) Unregister(module, cmd uint32) {
 	delete(r.handlers, key)
 }
 
+// Has 是否注册了消息处理器
+func (r *Router) Has(module, cmd uint32) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, exists := r.handlers[makeRouteKey(module, cmd)]
+	return exists
+}
+
 // Handle 处理消息
 func (r *Router) Handle(module, cmd uint32, payload []byte) error {
 	r.mu.RLock()
@@ -68,6 +77,21 @@ func (r *Router) HandleResp(resp *tcp.ClusterRespMsg) error {
 	return r.Handle(resp.Module, resp.Cmd, resp.Payload)
 }
 
+// Routes 已注册的路由（按 module、cmd 排序）
+func (r *Router) Routes() []tcp.Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	routes := make([]tcp.Route, 0, len(r.handlers))
+	for key := range r.handlers {
+		routes = append(routes, tcp.Route{Module: uint32(key >> 32), Cmd: uint32(key)})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return makeRouteKey(routes[i].Module, routes[i].Cmd) < makeRouteKey(routes[j].Module, routes[j].Cmd)
+	})
+	return routes
+}
+
 // makeRouteKey 生成路由键
 func makeRouteKey(module, cmd uint32) uint64 {
 	return (uint64(module) << 32) | uint64(cmd)
@@ -0,0 +1,190 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// RPCErrorCode 处理函数返回普通错误时的响应码（返回 *tcp.CodeError 时使用其响应码）
+const RPCErrorCode = tcp.HandlerErrorCode
+
+// 编解码器定义在 tcp 包（按 (module, cmd) 声明、按连接协商），此处保留别名
+type (
+	Codec        = tcp.Codec
+	ProtoMessage = tcp.ProtoMessage
+	ProtoCodec   = tcp.ProtoCodec
+	JSONCodec    = tcp.JSONCodec
+)
+
+// RPCError 对端处理函数返回的错误
+type RPCError struct {
+	Code    uint32
+	Message string
+	Details map[string]string // 附加信息（见 tcp.CodeError）
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("远程调用失败: code=%d, %s", e.Code, e.Message)
+}
+
+// rpcDesc 已注册的 RPC 描述
+type rpcDesc struct {
+	reqType  reflect.Type
+	respType reflect.Type
+	codec    Codec
+}
+
+var (
+	// rpcs 已注册的 RPC：(module << 32 | cmd) -> desc
+	rpcs   = make(map[uint64]*rpcDesc)
+	rpcsMu sync.RWMutex
+)
+
+// RegisterRPC 注册 (module, cmd) 对应的请求、响应消息类型和编解码器
+// codec 为 nil 时：Req、Resp 都实现 ProtoMessage 使用 ProtoCodec，否则使用 JSONCodec
+// 编解码器同时注册到 tcp 并声明为该 (module, cmd) 的编解码器
+func RegisterRPC[Req, Resp any](module, cmd uint32, codec Codec) {
+	if codec == nil {
+		codec = defaultCodec[Req, Resp]()
+	}
+	if _, ok := tcp.GetCodec(codec.Name()); !ok {
+		tcp.RegisterCodec(codec.Name(), codec)
+	}
+	tcp.SetRouteCodec(module, cmd, codec.Name())
+
+	rpcsMu.Lock()
+	defer rpcsMu.Unlock()
+	rpcs[uint64(module)<<32|uint64(cmd)] = &rpcDesc{
+		reqType:  reflect.TypeFor[Req](),
+		respType: reflect.TypeFor[Resp](),
+		codec:    codec,
+	}
+	logger.Infof("注册 RPC: module=%d, cmd=%d, %s -> %s (%s)",
+		module, cmd, reflect.TypeFor[Req](), reflect.TypeFor[Resp](), codec.Name())
+}
+
+// RPCInfo 已注册 RPC 的描述
+type RPCInfo struct {
+	Module   uint32 `json:"module"`
+	Cmd      uint32 `json:"cmd"`
+	Request  string `json:"request"`  // 请求消息类型
+	Response string `json:"response"` // 响应消息类型
+	Codec    string `json:"codec"`
+}
+
+// RegisteredRPCs 已注册的 RPC（按 module、cmd 排序）
+func RegisteredRPCs() []RPCInfo {
+	rpcsMu.RLock()
+	defer rpcsMu.RUnlock()
+
+	infos := make([]RPCInfo, 0, len(rpcs))
+	for key, desc := range rpcs {
+		infos = append(infos, RPCInfo{
+			Module:   uint32(key >> 32),
+			Cmd:      uint32(key),
+			Request:  desc.reqType.String(),
+			Response: desc.respType.String(),
+			Codec:    desc.codec.Name(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return makeRouteKey(infos[i].Module, infos[i].Cmd) < makeRouteKey(infos[j].Module, infos[j].Cmd)
+	})
+	return infos
+}
+
+// HandleRPC 注册 RPC 及其处理函数（本节点作为服务端）
+// 处理函数返回错误时以标准错误消息体响应（*tcp.CodeError 使用其响应码，其他错误使用 RPCErrorCode），调用方收到 *RPCError
+func HandleRPC[Req, Resp any](module, cmd uint32, codec Codec, handler func(req *Req) (*Resp, error)) {
+	RegisterRPC[Req, Resp](module, cmd, codec)
+	desc, _ := lookupRPC[Req, Resp](module, cmd)
+
+	tcp.RegisterReqHandler(module, cmd, func(msg *tcp.ClusterReqMsg) *tcp.ClusterRespMsg {
+		fail := func(err error) *tcp.ClusterRespMsg {
+			return tcp.ErrorResp(msg, err)
+		}
+
+		req := new(Req)
+		if err := desc.codec.Unmarshal(msg.Payload, req); err != nil {
+			logger.Warnf("解析 RPC 请求失败: module=%d, cmd=%d, %v", msg.Module, msg.Cmd, err)
+			return fail(tcp.NewCodeError(tcp.CodeBadRequest, "解析请求失败: %v", err))
+		}
+
+		result, err := handler(req)
+		if err != nil {
+			return fail(err)
+		}
+
+		payload, err := desc.codec.Marshal(result)
+		if err != nil {
+			logger.Errorf("编码 RPC 响应失败: module=%d, cmd=%d, %v", msg.Module, msg.Cmd, err)
+			return fail(fmt.Errorf("编码响应失败: %w", err))
+		}
+		return &tcp.ClusterRespMsg{
+			Module:    msg.Module,
+			Cmd:       msg.Cmd,
+			SessionId: msg.SessionId,
+			Payload:   payload,
+		}
+	})
+}
+
+// Call 向节点发起类型化的 RPC 调用，自动编解码请求和响应
+// (module, cmd) 需先通过 RegisterRPC 或 HandleRPC 注册，且消息类型一致
+func Call[Req, Resp any](ctx context.Context, node *Node, module, cmd uint32, req *Req) (*Resp, error) {
+	desc, err := lookupRPC[Req, Resp](module, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := desc.codec.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("编码 RPC 请求失败: %w", err)
+	}
+
+	msg, err := node.Call(ctx, &tcp.ClusterReqMsg{Module: module, Cmd: cmd, Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	if e := tcp.RespError(msg); e != nil {
+		return nil, &RPCError{Code: e.Code, Message: e.Message, Details: e.Details}
+	}
+
+	resp := new(Resp)
+	if err := desc.codec.Unmarshal(msg.Payload, resp); err != nil {
+		return nil, fmt.Errorf("解析 RPC 响应失败: %w", err)
+	}
+	return resp, nil
+}
+
+// lookupRPC 查找已注册的 RPC 并校验消息类型
+func lookupRPC[Req, Resp any](module, cmd uint32) (*rpcDesc, error) {
+	rpcsMu.RLock()
+	desc, exists := rpcs[uint64(module)<<32|uint64(cmd)]
+	rpcsMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("RPC 未注册: module=%d, cmd=%d", module, cmd)
+	}
+	if desc.reqType != reflect.TypeFor[Req]() || desc.respType != reflect.TypeFor[Resp]() {
+		return nil, fmt.Errorf("RPC 消息类型不匹配: module=%d, cmd=%d, 已注册 %s -> %s",
+			module, cmd, desc.reqType, desc.respType)
+	}
+	return desc, nil
+}
+
+// defaultCodec 按消息类型选择默认编解码器
+func defaultCodec[Req, Resp any]() Codec {
+	_, reqProto := any(new(Req)).(ProtoMessage)
+	_, respProto := any(new(Resp)).(ProtoMessage)
+	if reqProto && respProto {
+		return ProtoCodec{}
+	}
+	return JSONCodec{}
+}
@@ -0,0 +1,180 @@
+package cluster
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/charry/config"
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
+	"github.com/charry/logger"
+)
+
+// Sharding 分片分配
+// 将固定数量的分片确定性地分配给本节点和同类型的节点（最高随机权重哈希），
+// 任意节点在相同成员视图下得到相同结果；成员变化时只有离开节点的分片和新节点分到的分片会移动
+// 本节点获得、释放分片时发布 ClusterShardAcquired / ClusterShardReleased 事件
+type Sharding struct {
+	manager    *Manager
+	shardCount int
+	selfID     string
+	nodeType   string
+
+	// 分片归属：shard -> serviceID
+	owners []string
+	mu     sync.RWMutex
+
+	// 串行执行重新分配
+	rebalanceMu sync.Mutex
+}
+
+// NewSharding 创建分片分配（立即分配一次，之后随节点增减自动重新分配）
+func NewSharding(m *Manager, shardCount int) (*Sharding, error) {
+	if shardCount <= 0 {
+		return nil, fmt.Errorf("分片数必须大于 0: %d", shardCount)
+	}
+
+	cfg := config.Get()
+	s := &Sharding{
+		manager:    m,
+		shardCount: shardCount,
+		selfID:     serviceIDOf(&cfg.App),
+		nodeType:   cfg.App.Type,
+		owners:     make([]string, shardCount),
+	}
+
+	s.Rebalance()
+	m.OnMembershipChange(s.Rebalance)
+	return s, nil
+}
+
+// Rebalance 按当前成员重新分配分片，并发布本节点的分片变化事件
+func (s *Sharding) Rebalance() {
+	s.rebalanceMu.Lock()
+	defer s.rebalanceMu.Unlock()
+
+	members := []string{s.selfID}
+	for _, node := range s.manager.GetNodesByType(s.nodeType) {
+		members = append(members, node.ServiceID)
+	}
+
+	owners := make([]string, s.shardCount)
+	for shard := range owners {
+		owners[shard] = shardOwner(shard, members)
+	}
+
+	s.mu.Lock()
+	previous := s.owners
+	s.owners = owners
+	s.mu.Unlock()
+
+	var acquired, released []int
+	for shard := range owners {
+		wasLocal := previous[shard] == s.selfID
+		isLocal := owners[shard] == s.selfID
+		switch {
+		case isLocal && !wasLocal:
+			acquired = append(acquired, shard)
+		case wasLocal && !isLocal:
+			released = append(released, shard)
+		}
+	}
+
+	if len(acquired) == 0 && len(released) == 0 {
+		return
+	}
+	logger.Infof("分片重新分配: 成员 %d 个, 获得 %d 个, 释放 %d 个", len(members), len(acquired), len(released))
+
+	// 先释放再获得，便于服务先停止旧分片的处理
+	for _, shard := range released {
+		event.PublishEvent(event_name.ClusterShardReleased, &ShardEventData{Shard: shard, ServiceID: s.selfID})
+	}
+	for _, shard := range acquired {
+		event.PublishEvent(event_name.ClusterShardAcquired, &ShardEventData{Shard: shard, ServiceID: s.selfID})
+	}
+}
+
+// ShardCount 分片数
+func (s *Sharding) ShardCount() int {
+	return s.shardCount
+}
+
+// ShardFor 计算 key 所属的分片
+func (s *Sharding) ShardFor(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(s.shardCount))
+}
+
+// Owner 获取分片所属节点的服务 ID
+func (s *Sharding) Owner(shard int) string {
+	if shard < 0 || shard >= s.shardCount {
+		return ""
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.owners[shard]
+}
+
+// OwnerNode 获取分片所属的节点（属于本节点时返回 nil）
+func (s *Sharding) OwnerNode(shard int) *Node {
+	owner := s.Owner(shard)
+	if owner == "" || owner == s.selfID {
+		return nil
+	}
+	return s.manager.GetNode(owner)
+}
+
+// IsLocal 分片是否属于本节点
+func (s *Sharding) IsLocal(shard int) bool {
+	return s.Owner(shard) == s.selfID
+}
+
+// LocalShards 本节点持有的分片
+func (s *Sharding) LocalShards() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var shards []int
+	for shard, owner := range s.owners {
+		if owner == s.selfID {
+			shards = append(shards, shard)
+		}
+	}
+	return shards
+}
+
+// shardOwner 最高随机权重哈希：选择 hash(member, shard) 最大的成员
+func shardOwner(shard int, members []string) string {
+	return rendezvousOwner("#"+strconv.Itoa(shard), members)
+}
+
+// rendezvousOwner 最高随机权重哈希：选择 hash(member + suffix) 最大的成员
+func rendezvousOwner(suffix string, members []string) string {
+	var owner string
+	var best uint64
+
+	for _, member := range members {
+		h := fnv.New64a()
+		h.Write([]byte(member + suffix))
+		score := mix64(h.Sum64())
+		if owner == "" || score > best || (score == best && member < owner) {
+			owner = member
+			best = score
+		}
+	}
+	return owner
+}
+
+// mix64 打散哈希值（splitmix64 终结函数），避免相近的服务 ID 得分相关
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
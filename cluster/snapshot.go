@@ -0,0 +1,117 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/charry/config"
+)
+
+// String 节点状态名称
+func (s NodeStatus) String() string {
+	switch s {
+	case NodeStatusDisconnected:
+		return "disconnected"
+	case NodeStatusConnecting:
+		return "connecting"
+	case NodeStatusConnected:
+		return "connected"
+	case NodeStatusFailed:
+		return "failed"
+	case NodeStatusQuarantined:
+		return "quarantined"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// NodeSnapshot 节点状态快照
+type NodeSnapshot struct {
+	ServiceID         string            `json:"service_id"`
+	Id                uint16            `json:"id"`
+	Type              string            `json:"type"`
+	Environment       string            `json:"environment"`
+	Status            string            `json:"status"`
+	Config            *config.AppConfig `json:"config"`
+	LastUpdate        time.Time         `json:"last_update"`              // 最近一次配置更新时间
+	LastHeartbeat     time.Time         `json:"last_heartbeat,omitempty"` // 最近一次收到心跳响应的时间
+	RTT               string            `json:"rtt"`                      // 心跳往返时间（平滑值）
+	HeartbeatsLost    uint64            `json:"heartbeats_lost"`          // 累计丢失的心跳数
+	ErrorRate         float64           `json:"error_rate"`
+	Reconnects        uint64            `json:"reconnects"`
+	ReconnectAttempts int               `json:"reconnect_attempts"` // 连续重连失败次数
+	HealthScore       float64           `json:"health_score"`
+	Pending           int64             `json:"pending"`
+	PendingCalls      int               `json:"pending_calls"`
+	Streams           int               `json:"streams"`
+	PoolSize          int               `json:"pool_size"`
+	PoolFree          int               `json:"pool_free"`
+	Draining          bool              `json:"draining"`
+	Paused            bool              `json:"paused"`
+	Breaker           string            `json:"breaker"` // 熔断器状态
+}
+
+// ClusterSnapshot 集群状态快照（用于管理接口和事后排查）
+type ClusterSnapshot struct {
+	Self    string         `json:"self"`     // 本节点服务 ID
+	TakenAt time.Time      `json:"taken_at"` // 快照时间
+	Nodes   []NodeSnapshot `json:"nodes"`    // 按 ServiceID 排序
+}
+
+// Snapshot 获取集群状态快照
+func (m *Manager) Snapshot() *ClusterSnapshot {
+	cfg := config.Get()
+	snapshot := &ClusterSnapshot{
+		Self:    serviceIDOf(&cfg.App),
+		TakenAt: time.Now(),
+	}
+
+	nodes := m.GetAllNodes()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ServiceID < nodes[j].ServiceID
+	})
+	for _, node := range nodes {
+		stats := node.Stats()
+		snapshot.Nodes = append(snapshot.Nodes, snapshotOf(node, &stats))
+	}
+	return snapshot
+}
+
+// snapshotOf 生成节点状态快照
+func snapshotOf(node *Node, stats *NodeStats) NodeSnapshot {
+	return NodeSnapshot{
+		ServiceID:         node.ServiceID,
+		Id:                node.Id,
+		Type:              node.Type,
+		Environment:       node.Environment,
+		Status:            stats.Status.String(),
+		Config:            node.Config,
+		LastUpdate:        node.lastUpdate,
+		LastHeartbeat:     stats.Health.LastHeartbeat,
+		RTT:               stats.Health.RTT.String(),
+		HeartbeatsLost:    stats.Health.BeatsLost,
+		ErrorRate:         stats.Health.ErrorRate,
+		Reconnects:        stats.Health.Reconnects,
+		ReconnectAttempts: stats.Reconnect.Attempts,
+		HealthScore:       stats.Health.Score,
+		Pending:           stats.Pending,
+		PendingCalls:      stats.PendingCalls,
+		Streams:           stats.Streams,
+		PoolSize:          stats.Pool.Size,
+		PoolFree:          stats.Pool.Idle,
+		Draining:          node.IsDraining(),
+		Paused:            node.IsPaused(),
+		Breaker:           stats.Breaker.String(),
+	}
+}
+
+// ToJSON 转换快照为 JSON
+func (s *ClusterSnapshot) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("序列化集群快照失败: %w", err)
+	}
+	return string(data), nil
+}
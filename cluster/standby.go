@@ -0,0 +1,231 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/charry/config"
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// StandbyPriorityKey 节点在配置 data 中的备用优先级键（数值越小越优先，默认 0）
+const StandbyPriorityKey = "standby_priority"
+
+// 活动节点切换原因
+const (
+	FailoverReasonRemoved   = "removed"   // 活动节点下线
+	FailoverReasonUnhealthy = "unhealthy" // 活动节点断开或健康分过低
+)
+
+// FailoverEventData 角色切换事件数据
+type FailoverEventData struct {
+	Role     string
+	Previous string // 原活动节点 ServiceID
+	Active   string // 新活动节点 ServiceID（没有可用候选时为空）
+	Reason   string // 切换原因：removed、unhealthy
+	IsLocal  bool   // 新活动节点是否为本节点
+}
+
+// role 单例角色（一个活动节点，其余候选节点热备）
+type role struct {
+	name     string
+	selector Selector
+	active   string
+}
+
+// roles 角色注册表
+type roles struct {
+	roles   map[string]*role
+	mu      sync.Mutex
+	started sync.Once
+}
+
+// RegisterRole 注册单例角色，标签匹配 selector 的同类型节点（含本节点）为候选
+// 候选中 data.standby_priority 最小的健康节点成为活动节点（相同时按 ServiceID），其余热备
+// 活动节点在线且健康期间保持不变；下线、断开或健康分过低时提升下一个候选，并发布 ClusterFailover 事件
+// 各节点按自己的成员和健康视图独立计算，视图一致时结果一致
+func (m *Manager) RegisterRole(name, selector string) error {
+	s, err := ParseSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	m.roles.mu.Lock()
+	if m.roles.roles == nil {
+		m.roles.roles = make(map[string]*role)
+	}
+	if _, exists := m.roles.roles[name]; exists {
+		m.roles.mu.Unlock()
+		return fmt.Errorf("角色已注册: %s", name)
+	}
+	m.roles.roles[name] = &role{name: name, selector: s}
+	m.roles.mu.Unlock()
+
+	m.roles.started.Do(func() {
+		m.OnMembershipChange(m.evaluateRoles)
+		go m.roleLoop()
+	})
+	m.evaluateRoles()
+	return nil
+}
+
+// RoleActive 获取角色当前活动节点的 ServiceID（未注册或没有可用候选时为空）
+func (m *Manager) RoleActive(name string) string {
+	m.roles.mu.Lock()
+	defer m.roles.mu.Unlock()
+	if r, exists := m.roles.roles[name]; exists {
+		return r.active
+	}
+	return ""
+}
+
+// IsRoleActive 本节点是否为角色的活动节点
+func (m *Manager) IsRoleActive(name string) bool {
+	cfg := config.Get()
+	return m.RoleActive(name) == serviceIDOf(&cfg.App)
+}
+
+// RoleNode 获取角色活动节点（用于路由请求），活动节点为本节点或没有可用候选时返回错误
+func (m *Manager) RoleNode(name string) (*Node, error) {
+	active := m.RoleActive(name)
+	if active == "" {
+		return nil, fmt.Errorf("角色没有可用节点: %s", name)
+	}
+	if m.IsRoleActive(name) {
+		return nil, fmt.Errorf("角色活动节点为本节点: %s", name)
+	}
+
+	node := m.GetNode(active)
+	if node == nil {
+		return nil, fmt.Errorf("角色活动节点不存在: %s, %s", name, active)
+	}
+	return node, nil
+}
+
+// roleLoop 每个心跳周期重新评估（健康状态变化不会触发节点增减通知）
+func (m *Manager) roleLoop() {
+	ticker := time.NewTicker(tcp.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			m.evaluateRoles()
+		}
+	}
+}
+
+// roleCandidate 角色候选
+type roleCandidate struct {
+	serviceID string
+	priority  float64
+	healthy   bool
+}
+
+// evaluateRoles 重新评估所有角色的活动节点
+func (m *Manager) evaluateRoles() {
+	cfg := config.Get()
+	selfID := serviceIDOf(&cfg.App)
+
+	m.roles.mu.Lock()
+	var events []*FailoverEventData
+	for _, r := range m.roles.roles {
+		candidates := m.roleCandidates(r, &cfg.App, selfID)
+
+		// 当前活动节点仍健康时保持不变
+		reason := FailoverReasonRemoved
+		if r.active != "" {
+			if c, exists := candidates[r.active]; exists {
+				if c.healthy {
+					continue
+				}
+				reason = FailoverReasonUnhealthy
+			}
+		}
+
+		next := bestCandidate(candidates)
+		if next == r.active {
+			continue
+		}
+
+		previous := r.active
+		r.active = next
+		if previous == "" {
+			logger.Infof("角色活动节点: %s -> %s", r.name, next)
+			continue
+		}
+
+		logger.Warnf("角色切换: %s, %s -> %s (%s)", r.name, previous, next, reason)
+		events = append(events, &FailoverEventData{
+			Role:     r.name,
+			Previous: previous,
+			Active:   next,
+			Reason:   reason,
+			IsLocal:  next == selfID,
+		})
+	}
+	m.roles.mu.Unlock()
+
+	for _, data := range events {
+		event.PublishEvent(event_name.ClusterFailover, data)
+	}
+}
+
+// roleCandidates 角色的候选节点（本节点总是健康的）
+func (m *Manager) roleCandidates(r *role, self *config.AppConfig, selfID string) map[string]*roleCandidate {
+	candidates := make(map[string]*roleCandidate)
+	if r.selector.Matches(labelsOf(self)) {
+		candidates[selfID] = &roleCandidate{serviceID: selfID, priority: standbyPriority(self), healthy: true}
+	}
+
+	for _, node := range m.GetNodesByType(self.Type) {
+		if !r.selector.Matches(node.Labels()) {
+			continue
+		}
+		candidates[node.ServiceID] = &roleCandidate{
+			serviceID: node.ServiceID,
+			priority:  standbyPriority(node.Config),
+			healthy:   node.GetStatus() == NodeStatusConnected && node.selectable() && node.HealthScore() >= UnhealthyScore,
+		}
+	}
+	return candidates
+}
+
+// bestCandidate 选择优先级最高的健康候选（没有时返回空）
+func bestCandidate(candidates map[string]*roleCandidate) string {
+	healthy := make([]*roleCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if c.healthy {
+			healthy = append(healthy, c)
+		}
+	}
+	if len(healthy) == 0 {
+		return ""
+	}
+
+	sort.Slice(healthy, func(i, j int) bool {
+		if healthy[i].priority != healthy[j].priority {
+			return healthy[i].priority < healthy[j].priority
+		}
+		return healthy[i].serviceID < healthy[j].serviceID
+	})
+	return healthy[0].serviceID
+}
+
+// standbyPriority 节点的备用优先级（配置 data.standby_priority，默认 0）
+func standbyPriority(appConfig *config.AppConfig) float64 {
+	if appConfig == nil || appConfig.Data == nil {
+		return 0
+	}
+	if p, ok := appConfig.Data[StandbyPriorityKey].(float64); ok {
+		return p
+	}
+	return 0
+}
@@ -0,0 +1,399 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/charry/config"
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
+	"github.com/charry/logger"
+	"github.com/charry/tcp"
+)
+
+// 状态传输协议（系统模块，与心跳共用模块号）
+// 请求 Payload: stateTransferReq JSON
+// 响应 Code: 0 表示成功，open 的 Payload 为 stateTransferOpened JSON，chunk 的 Payload 为块数据；非 0 时 Payload 为错误信息
+const (
+	StateTransferModule uint32 = tcp.HeartbeatModule
+	StateTransferCmd    uint32 = 6
+
+	stateTransferFailedCode uint32 = 1
+	stateSessionGoneCode    uint32 = 2 // 传输会话不存在（已超时或提供方重启）
+)
+
+// 状态传输参数
+const (
+	stateSessionIdle = 5 * time.Minute // 提供方的传输会话空闲超时（超时后无法恢复，需重新传输）
+	stateChunkRetry  = 3               // 单块最多重试次数
+	stateRetryDelay  = time.Second     // 重试间隔
+)
+
+// StateSnapshot 一次状态传输使用的快照（同一次传输的各块来自同一快照）
+type StateSnapshot interface {
+	// Chunks 总块数
+	Chunks() int
+
+	// Chunk 读取第 index 块（恢复传输时可能重复读取同一块）
+	Chunk(index int) ([]byte, error)
+
+	// Close 释放快照（传输完成或会话超时后调用）
+	Close()
+}
+
+// StateHook 状态传输钩子
+// 持有状态的节点（如角色活动节点）通过 Snapshot 提供状态，新加入的节点通过 ApplyChunk / Complete 接收状态
+// 通常两端是同一实现
+type StateHook interface {
+	// Snapshot 生成状态快照（提供方）
+	Snapshot() (StateSnapshot, error)
+
+	// ApplyChunk 按顺序应用第 index 块（接收方）；index 为 0 时表示重新开始传输
+	ApplyChunk(index int, data []byte) error
+
+	// Complete 所有块已应用（接收方）
+	Complete(chunks int) error
+}
+
+// StateTransferEventData 状态传输进度事件数据
+type StateTransferEventData struct {
+	Name     string
+	Source   string // 提供方 ServiceID
+	Transfer string // 传输 ID
+	Applied  int    // 已应用的块数
+	Chunks   int    // 总块数
+	Bytes    int64  // 已接收的字节数
+}
+
+// errStateSessionGone 提供方的传输会话不存在（已超时或提供方重启）
+var errStateSessionGone = errors.New("状态传输会话已失效")
+
+// stateTransferReq 状态传输请求
+type stateTransferReq struct {
+	Op       string `json:"op"` // open / chunk / close
+	Name     string `json:"name"`
+	Transfer string `json:"transfer,omitempty"`
+	Index    int    `json:"index,omitempty"`
+}
+
+// stateTransferOpened open 的响应
+type stateTransferOpened struct {
+	Transfer string `json:"transfer"`
+	Chunks   int    `json:"chunks"`
+}
+
+// stateSession 提供方的传输会话
+type stateSession struct {
+	snapshot StateSnapshot
+	lastUsed time.Time
+}
+
+// stateProgress 接收方的传输进度（传输失败后再次调用 TransferState 时从 next 继续）
+type stateProgress struct {
+	transfer string
+	chunks   int
+	next     int
+	bytes    int64
+}
+
+var (
+	// stateHooks 状态传输钩子：name -> hook
+	stateHooks   = make(map[string]StateHook)
+	stateHooksMu sync.RWMutex
+
+	// stateSessions 提供方的传输会话：transfer -> session
+	stateSessions   = make(map[string]*stateSession)
+	stateSessionsMu sync.Mutex
+
+	// stateProgresses 接收方未完成的传输：name/source -> progress
+	stateProgresses   = make(map[string]*stateProgress)
+	stateProgressesMu sync.Mutex
+)
+
+// RegisterStateHook 注册状态传输钩子
+func RegisterStateHook(name string, hook StateHook) {
+	stateHooksMu.Lock()
+	defer stateHooksMu.Unlock()
+	stateHooks[name] = hook
+}
+
+// lookupStateHook 获取状态传输钩子
+func lookupStateHook(name string) (StateHook, error) {
+	stateHooksMu.RLock()
+	defer stateHooksMu.RUnlock()
+	hook, ok := stateHooks[name]
+	if !ok {
+		return nil, fmt.Errorf("未注册状态传输钩子: %s", name)
+	}
+	return hook, nil
+}
+
+// TransferState 从 source 节点拉取名为 name 的状态并按块应用，完成后返回
+// 新加入的节点在对外提供服务前调用；source 为空时选择同类型中 ServiceID 最小的健康节点，由指定节点提供时传入其 ServiceID（如 RoleActive 的结果）
+// 每应用一块发布 ClusterStateProgress 事件，完成后发布 ClusterStateTransferred 事件
+// 单块失败时重试，仍失败则返回错误并保留进度：再次调用时从下一块继续（提供方的会话超时后从头开始）
+func (m *Manager) TransferState(ctx context.Context, name, source string) error {
+	hook, err := lookupStateHook(name)
+	if err != nil {
+		return err
+	}
+
+	node, err := m.stateSource(source)
+	if err != nil {
+		return err
+	}
+
+	key := name + "/" + node.ServiceID
+	stateProgressesMu.Lock()
+	progress := stateProgresses[key]
+	stateProgressesMu.Unlock()
+
+	if progress == nil {
+		if progress, err = openStateTransfer(ctx, node, name); err != nil {
+			return err
+		}
+		logger.Infof("开始传输状态 %s: 来自 %s, 共 %d 块", name, node.ServiceID, progress.chunks)
+	} else {
+		logger.Infof("恢复传输状态 %s: 来自 %s, 从第 %d/%d 块继续", name, node.ServiceID, progress.next, progress.chunks)
+	}
+
+	for progress.next < progress.chunks {
+		data, err := fetchStateChunk(ctx, node, name, progress)
+		if err != nil {
+			// 提供方会话已失效，下次从头开始
+			if progress.transfer == "" {
+				m.clearStateProgress(key)
+			} else {
+				stateProgressesMu.Lock()
+				stateProgresses[key] = progress
+				stateProgressesMu.Unlock()
+			}
+			return fmt.Errorf("传输状态 %s 失败（已完成 %d/%d 块）: %w", name, progress.next, progress.chunks, err)
+		}
+
+		if err := hook.ApplyChunk(progress.next, data); err != nil {
+			m.clearStateProgress(key)
+			closeStateTransfer(node, name, progress.transfer)
+			return fmt.Errorf("应用状态 %s 第 %d 块失败: %w", name, progress.next, err)
+		}
+		progress.next++
+		progress.bytes += int64(len(data))
+		event.PublishEvent(event_name.ClusterStateProgress, progress.eventData(name, node.ServiceID))
+	}
+
+	m.clearStateProgress(key)
+	closeStateTransfer(node, name, progress.transfer)
+	if err := hook.Complete(progress.chunks); err != nil {
+		return fmt.Errorf("完成状态 %s 失败: %w", name, err)
+	}
+
+	logger.Infof("✓ 状态 %s 传输完成: 来自 %s, %d 块, %d 字节", name, node.ServiceID, progress.chunks, progress.bytes)
+	event.PublishEvent(event_name.ClusterStateTransferred, progress.eventData(name, node.ServiceID))
+	return nil
+}
+
+// stateSource 状态提供方节点
+func (m *Manager) stateSource(source string) (*Node, error) {
+	if source != "" {
+		node := m.GetNode(source)
+		if node == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, source)
+		}
+		return node, nil
+	}
+
+	cfg := config.Get()
+	for _, node := range m.connectedNodes(cfg.App.Type) {
+		if node.HealthScore() >= UnhealthyScore {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("没有可提供状态的 %s 节点", cfg.App.Type)
+}
+
+// clearStateProgress 清除传输进度
+func (m *Manager) clearStateProgress(key string) {
+	stateProgressesMu.Lock()
+	defer stateProgressesMu.Unlock()
+	delete(stateProgresses, key)
+}
+
+// eventData 生成进度事件数据
+func (p *stateProgress) eventData(name, source string) *StateTransferEventData {
+	return &StateTransferEventData{
+		Name:     name,
+		Source:   source,
+		Transfer: p.transfer,
+		Applied:  p.next,
+		Chunks:   p.chunks,
+		Bytes:    p.bytes,
+	}
+}
+
+// openStateTransfer 请求提供方生成快照，开始一次传输
+func openStateTransfer(ctx context.Context, node *Node, name string) (*stateProgress, error) {
+	resp, err := callStateTransfer(ctx, node, &stateTransferReq{Op: "open", Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("开始传输状态 %s 失败: %w", name, err)
+	}
+
+	var opened stateTransferOpened
+	if err := json.Unmarshal(resp.Payload, &opened); err != nil {
+		return nil, fmt.Errorf("解析状态传输响应失败: %w", err)
+	}
+	return &stateProgress{transfer: opened.Transfer, chunks: opened.Chunks}, nil
+}
+
+// fetchStateChunk 拉取下一块（失败时重试）
+// 提供方会话失效时清空 progress.transfer
+func fetchStateChunk(ctx context.Context, node *Node, name string, progress *stateProgress) ([]byte, error) {
+	req := &stateTransferReq{Op: "chunk", Name: name, Transfer: progress.transfer, Index: progress.next}
+
+	var lastErr error
+	for attempt := 0; attempt < stateChunkRetry; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(stateRetryDelay):
+			}
+		}
+
+		resp, err := callStateTransfer(ctx, node, req)
+		if err == nil {
+			return resp.Payload, nil
+		}
+		if errors.Is(err, errStateSessionGone) {
+			progress.transfer = ""
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// closeStateTransfer 通知提供方释放快照（失败时由会话超时释放）
+func closeStateTransfer(node *Node, name, transfer string) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCallTimeout)
+	defer cancel()
+	if _, err := callStateTransfer(ctx, node, &stateTransferReq{Op: "close", Name: name, Transfer: transfer}); err != nil {
+		logger.Debugf("释放状态传输会话失败: %v", err)
+	}
+}
+
+// callStateTransfer 发送状态传输请求
+func callStateTransfer(ctx context.Context, node *Node, req *stateTransferReq) (*tcp.ClusterRespMsg, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 状态数据量大，按大批量数据发送，不阻塞普通请求
+	resp, err := node.CallPriority(ctx, &tcp.ClusterReqMsg{
+		Module:  StateTransferModule,
+		Cmd:     StateTransferCmd,
+		Payload: payload,
+	}, PriorityBulk)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.Code {
+	case 0:
+		return resp, nil
+	case stateSessionGoneCode:
+		return nil, errStateSessionGone
+	default:
+		return nil, fmt.Errorf("%s", resp.Payload)
+	}
+}
+
+// handleStateTransferReq 处理其他节点的状态传输请求（提供方）
+func handleStateTransferReq(req *tcp.ClusterReqMsg) *tcp.ClusterRespMsg {
+	resp := &tcp.ClusterRespMsg{
+		Module:    req.Module,
+		Cmd:       req.Cmd,
+		SessionId: req.SessionId,
+	}
+	fail := func(code uint32, err error) *tcp.ClusterRespMsg {
+		resp.Code = code
+		resp.Payload = []byte(err.Error())
+		return resp
+	}
+
+	var r stateTransferReq
+	if err := json.Unmarshal(req.Payload, &r); err != nil {
+		return fail(stateTransferFailedCode, fmt.Errorf("解析状态传输请求失败: %w", err))
+	}
+
+	switch r.Op {
+	case "open":
+		hook, err := lookupStateHook(r.Name)
+		if err != nil {
+			return fail(stateTransferFailedCode, err)
+		}
+		snapshot, err := hook.Snapshot()
+		if err != nil {
+			return fail(stateTransferFailedCode, fmt.Errorf("生成状态快照失败: %w", err))
+		}
+
+		transfer := event.NewID()
+		stateSessionsMu.Lock()
+		expireStateSessions(time.Now())
+		stateSessions[transfer] = &stateSession{snapshot: snapshot, lastUsed: time.Now()}
+		stateSessionsMu.Unlock()
+
+		logger.Infof("提供状态 %s: 传输 %s, 共 %d 块", r.Name, transfer, snapshot.Chunks())
+		resp.Payload, _ = json.Marshal(&stateTransferOpened{Transfer: transfer, Chunks: snapshot.Chunks()})
+		return resp
+
+	case "chunk":
+		stateSessionsMu.Lock()
+		expireStateSessions(time.Now())
+		session, ok := stateSessions[r.Transfer]
+		if ok {
+			session.lastUsed = time.Now()
+		}
+		stateSessionsMu.Unlock()
+		if !ok {
+			return fail(stateSessionGoneCode, errStateSessionGone)
+		}
+
+		data, err := session.snapshot.Chunk(r.Index)
+		if err != nil {
+			return fail(stateTransferFailedCode, fmt.Errorf("读取状态第 %d 块失败: %w", r.Index, err))
+		}
+		resp.Payload = data
+		return resp
+
+	case "close":
+		stateSessionsMu.Lock()
+		session, ok := stateSessions[r.Transfer]
+		delete(stateSessions, r.Transfer)
+		stateSessionsMu.Unlock()
+		if ok {
+			session.snapshot.Close()
+		}
+		return resp
+
+	default:
+		return fail(stateTransferFailedCode, fmt.Errorf("未知的状态传输操作: %s", r.Op))
+	}
+}
+
+// expireStateSessions 释放空闲超时的传输会话（调用方持有锁）
+func expireStateSessions(now time.Time) {
+	for transfer, session := range stateSessions {
+		if now.Sub(session.lastUsed) > stateSessionIdle {
+			delete(stateSessions, transfer)
+			session.snapshot.Close()
+		}
+	}
+}
+
+func init() {
+	tcp.RegisterReqHandler(StateTransferModule, StateTransferCmd, handleStateTransferReq)
+}
@@ -0,0 +1,165 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/charry/event"
+	"github.com/charry/tcp"
+)
+
+// streamRecvBuffer 流接收缓冲（消息数），缓冲满时接收协程阻塞，形成背压
+const streamRecvBuffer = 64
+
+// Stream 复用节点连接的双向消息流
+// 同一条流的消息共享 SessionId 并固定在一个连接上发送，保证顺序；一个连接上可同时承载任意多条流
+// 对端通过 tcp.RegisterStreamHandler 处理流消息并可多次回复
+type Stream struct {
+	node      *Node
+	conn      net.Conn
+	module    uint32
+	cmd       uint32
+	sessionId string
+
+	recvCh chan *tcp.ClusterRespMsg
+	done   chan struct{}
+	err    error
+	once   sync.Once
+}
+
+// OpenStream 打开一条消息流（module、cmd 为流上请求消息使用的模块号和命令号）
+func (n *Node) OpenStream(module, cmd uint32) (*Stream, error) {
+	pool := n.GetPool()
+	if pool == nil {
+		return nil, fmt.Errorf("节点未连接")
+	}
+	if !n.Capabilities().Has(tcp.FeatureStreaming) {
+		return nil, fmt.Errorf("节点不支持消息流: %s", n.ServiceID)
+	}
+
+	// 只借用连接确定流所在的连接，写入由连接自身的写锁保证不交错
+	conn, err := pool.Get()
+	if err != nil {
+		return nil, fmt.Errorf("获取连接失败: %w", err)
+	}
+	pool.Put(conn)
+
+	s := &Stream{
+		node:      n,
+		conn:      conn,
+		module:    module,
+		cmd:       cmd,
+		sessionId: event.NewID(),
+		recvCh:    make(chan *tcp.ClusterRespMsg, streamRecvBuffer),
+		done:      make(chan struct{}),
+	}
+
+	n.streamsMu.Lock()
+	n.streams[s.sessionId] = s
+	n.streamsMu.Unlock()
+	return s, nil
+}
+
+// SessionId 流标识
+func (s *Stream) SessionId() string {
+	return s.sessionId
+}
+
+// Send 在流上发送一条消息
+func (s *Stream) Send(payload []byte) error {
+	select {
+	case <-s.done:
+		return s.closedErr()
+	default:
+	}
+
+	req := &tcp.ClusterReqMsg{Module: s.module, Cmd: s.cmd, SessionId: s.sessionId, Payload: payload}
+	if _, err := s.conn.Write(tcp.EncodeClusterReqMsgWith(req, s.node.frameOptions())); err != nil {
+		s.closeWithError(fmt.Errorf("发送失败: %w", err))
+		return s.closedErr()
+	}
+	return nil
+}
+
+// Recv 接收流上的下一条回复，阻塞直到收到回复、流关闭或 ctx 结束
+func (s *Stream) Recv(ctx context.Context) (*tcp.ClusterRespMsg, error) {
+	select {
+	case resp := <-s.recvCh:
+		return resp, nil
+	default:
+	}
+
+	select {
+	case resp := <-s.recvCh:
+		return resp, nil
+	case <-s.done:
+		// 关闭前已收到的回复仍可读取
+		select {
+		case resp := <-s.recvCh:
+			return resp, nil
+		default:
+			return nil, s.closedErr()
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close 关闭流（本地关闭，之后对端的回复会被丢弃）
+func (s *Stream) Close() {
+	s.closeWithError(nil)
+}
+
+// closeWithError 关闭流并记录原因
+func (s *Stream) closeWithError(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+
+		s.node.streamsMu.Lock()
+		delete(s.node.streams, s.sessionId)
+		s.node.streamsMu.Unlock()
+	})
+}
+
+// closedErr 流关闭后的错误
+func (s *Stream) closedErr() error {
+	if s.err != nil {
+		return fmt.Errorf("流已关闭: %s, %w", s.sessionId, s.err)
+	}
+	return fmt.Errorf("流已关闭: %s", s.sessionId)
+}
+
+// resolveStream 将回复交给对应的流，返回是否存在该流
+func (n *Node) resolveStream(resp *tcp.ClusterRespMsg) bool {
+	n.streamsMu.Lock()
+	s, exists := n.streams[resp.SessionId]
+	n.streamsMu.Unlock()
+	if !exists {
+		return false
+	}
+
+	select {
+	case s.recvCh <- resp:
+	case <-s.done:
+	}
+	return true
+}
+
+// failStreams 关闭指定连接上（conn 为 nil 时为所有连接）的流
+func (n *Node) failStreams(conn net.Conn, err error) {
+	n.streamsMu.Lock()
+	streams := make([]*Stream, 0, len(n.streams))
+	for _, s := range n.streams {
+		if conn == nil || s.conn == conn {
+			streams = append(streams, s)
+		}
+	}
+	n.streamsMu.Unlock()
+
+	for _, s := range streams {
+		s.closeWithError(err)
+	}
+}
@@ -0,0 +1,188 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charry/config"
+)
+
+// TopologyNode 拓扑图中的节点
+// id / title / subtitle / mainstat / secondarystat 与 Grafana Node Graph 面板的字段一致，可直接作为数据源
+type TopologyNode struct {
+	ID            string  `json:"id"`            // 服务 ID
+	Title         string  `json:"title"`         // 服务 ID
+	Subtitle      string  `json:"subtitle"`      // 类型和可用区
+	MainStat      string  `json:"mainstat"`      // 连接状态
+	SecondaryStat string  `json:"secondarystat"` // 心跳往返时间
+	Type          string  `json:"type"`
+	Zone          string  `json:"zone,omitempty"`
+	Region        string  `json:"region,omitempty"`
+	Status        string  `json:"status"`
+	Self          bool    `json:"self,omitempty"` // 是否为本节点
+	Draining      bool    `json:"draining,omitempty"`
+	Paused        bool    `json:"paused,omitempty"`
+	Breaker       string  `json:"breaker,omitempty"`
+	HealthScore   float64 `json:"health_score"`
+}
+
+// TopologyEdge 拓扑图中的连接（本节点 -> 对方节点）
+type TopologyEdge struct {
+	ID               string `json:"id"`
+	Source           string `json:"source"`
+	Target           string `json:"target"`
+	MainStat         string `json:"mainstat"`      // 发送流量
+	SecondaryStat    string `json:"secondarystat"` // 接收流量
+	Status           string `json:"status"`        // 连接状态
+	Connections      int    `json:"connections"`   // 连接池中的连接数
+	BytesSent        uint64 `json:"bytes_sent"`
+	BytesReceived    uint64 `json:"bytes_received"`
+	MessagesSent     uint64 `json:"messages_sent"`
+	MessagesReceived uint64 `json:"messages_received"`
+}
+
+// TopologyGraph 集群拓扑图（本节点视角）
+type TopologyGraph struct {
+	TakenAt time.Time      `json:"taken_at"`
+	Nodes   []TopologyNode `json:"nodes"` // 本节点在前，其余按 ServiceID 排序
+	Edges   []TopologyEdge `json:"edges"`
+}
+
+// TopologyGraph 获取集群拓扑图：节点、类型、可用区、连接状态和流量
+func (m *Manager) TopologyGraph() *TopologyGraph {
+	cfg := config.Get()
+	self := serviceIDOf(&cfg.App)
+	graph := &TopologyGraph{TakenAt: time.Now()}
+
+	graph.Nodes = append(graph.Nodes, TopologyNode{
+		ID:          self,
+		Title:       self,
+		Subtitle:    topologySubtitle(cfg.App.Type, dataString(&cfg.App, "zone")),
+		MainStat:    "self",
+		Type:        cfg.App.Type,
+		Zone:        dataString(&cfg.App, "zone"),
+		Region:      dataString(&cfg.App, "region"),
+		Status:      "self",
+		Self:        true,
+		HealthScore: 1,
+	})
+
+	nodes := m.GetAllNodes()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ServiceID < nodes[j].ServiceID
+	})
+	for _, node := range nodes {
+		stats := node.Stats()
+		status := stats.Status.String()
+		graph.Nodes = append(graph.Nodes, TopologyNode{
+			ID:            node.ServiceID,
+			Title:         node.ServiceID,
+			Subtitle:      topologySubtitle(node.Type, node.Zone()),
+			MainStat:      status,
+			SecondaryStat: stats.Health.RTT.Round(time.Microsecond).String(),
+			Type:          node.Type,
+			Zone:          node.Zone(),
+			Region:        node.Region(),
+			Status:        status,
+			Draining:      node.IsDraining(),
+			Paused:        node.IsPaused(),
+			Breaker:       stats.Breaker.String(),
+			HealthScore:   stats.Health.Score,
+		})
+		graph.Edges = append(graph.Edges, TopologyEdge{
+			ID:               self + "->" + node.ServiceID,
+			Source:           self,
+			Target:           node.ServiceID,
+			MainStat:         formatBytes(stats.BytesSent),
+			SecondaryStat:    formatBytes(stats.BytesReceived),
+			Status:           status,
+			Connections:      stats.Pool.Size,
+			BytesSent:        stats.BytesSent,
+			BytesReceived:    stats.BytesReceived,
+			MessagesSent:     stats.MessagesSent,
+			MessagesReceived: stats.MessagesReceived,
+		})
+	}
+	return graph
+}
+
+// DOT 输出 Graphviz DOT 格式（节点按可用区分组）
+func (g *TopologyGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph cluster {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+
+	// 按可用区分组
+	zones := make(map[string][]TopologyNode)
+	var zoneNames []string
+	for _, node := range g.Nodes {
+		if _, ok := zones[node.Zone]; !ok {
+			zoneNames = append(zoneNames, node.Zone)
+		}
+		zones[node.Zone] = append(zones[node.Zone], node)
+	}
+	sort.Strings(zoneNames)
+
+	for i, zone := range zoneNames {
+		indent := "  "
+		if zone != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%q;\n", i, zone)
+			indent = "    "
+		}
+		for _, node := range zones[zone] {
+			fmt.Fprintf(&b, "%s%q [label=%q, color=%q];\n", indent, node.ID,
+				node.ID+"\n"+node.Type+" ("+node.Status+")", dotColor(node))
+		}
+		if zone != "" {
+			b.WriteString("  }\n")
+		}
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", edge.Source, edge.Target,
+			fmt.Sprintf("%s / %s", edge.MainStat, edge.SecondaryStat))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotColor 节点在 DOT 中的颜色（按连接状态）
+func dotColor(node TopologyNode) string {
+	switch {
+	case node.Self:
+		return "blue"
+	case node.Draining, node.Paused:
+		return "orange"
+	case node.Status == NodeStatusConnected.String():
+		return "green"
+	case node.Status == NodeStatusConnecting.String():
+		return "gold"
+	default:
+		return "red"
+	}
+}
+
+// topologySubtitle 节点副标题：类型和可用区
+func topologySubtitle(nodeType, zone string) string {
+	if zone == "" {
+		return nodeType
+	}
+	return nodeType + " @ " + zone
+}
+
+// formatBytes 格式化字节数（如 1.5MB）
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
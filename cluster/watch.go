@@ -2,142 +2,82 @@ package cluster
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/charry/config"
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
 	"github.com/charry/logger"
-	consulapi "github.com/hashicorp/consul/api"
 )
 
-// WatchServices 监听 Consul 服务变化
-func (m *Manager) WatchServices(serviceName string) {
-	logger.Infof("开始监听服务变化: %s", serviceName)
-
-	go func() {
-		var lastIndex uint64
-		isFirstCheck := true
-
-		for {
-			select {
-			case <-m.stopChan:
-				logger.Info("停止监听服务变化")
-				return
-			default:
-				// 使用阻塞查询监听服务变化
-				services, meta, err := m.consulClient.Health().Service(
-					serviceName,
-					"",
-					true, // 只获取健康的服务
-					&consulapi.QueryOptions{
-						WaitIndex: lastIndex,
-						WaitTime:  30 * time.Second,
-					},
-				)
-
-				if err != nil {
-					logger.Errorf("查询服务失败: %v", err)
-					time.Sleep(5 * time.Second)
-					continue
-				}
-
-				// 第一次查询，只初始化索引
-				if isFirstCheck {
-					lastIndex = meta.LastIndex
-					isFirstCheck = false
-
-					// 初始化时加载现有服务
-					m.loadExistingServices(services)
-					logger.Info("✓ 服务监听已就绪")
-					continue
-				}
-
-				// 检查是否有变化
-				if meta.LastIndex > lastIndex {
-					lastIndex = meta.LastIndex
-					logger.Info("检测到服务变化")
-
-					// 处理服务变化
-					m.handleServiceChange(services)
-
-					// 打印当前所有节点
-					m.printAllNodes()
-				}
-			}
-		}
-	}()
+// WatchServices 通过服务发现监听服务变化（只能调用一次）
+// 可同时监听多个服务（如网关监听各后端服务），所有服务的节点合并在同一节点列表中，按类型区分
+func (m *Manager) WatchServices(serviceNames ...string) {
+	m.serviceNames = serviceNames
+	m.preloadNodeCache()
+
+	go m.resyncLoop()
+	go m.ownershipLoop()
+	for _, serviceName := range serviceNames {
+		logger.Infof("开始监听服务变化: %s", serviceName)
+		go m.discovery.Watch(serviceName, m.stopChan, func(instances []*ServiceInstance) {
+			m.handleServiceChange(serviceName, instances)
+
+			// 打印当前所有节点
+			m.printAllNodes()
+		})
+	}
 }
 
-// loadExistingServices 加载现有服务
-func (m *Manager) loadExistingServices(services []*consulapi.ServiceEntry) {
-	logger.Infof("加载现有服务，共 %d 个", len(services))
-
-	for _, service := range services {
-		// 跳过自己
-		cfg := config.Get()
-		selfServiceID := fmt.Sprintf("%s-%s-%d", cfg.App.Type, cfg.App.Environment, cfg.App.Id)
-		if service.Service.ID == selfServiceID {
-			continue
-		}
-
-		// 解析配置
-		appConfig, err := parseServiceConfig(service)
-		if err != nil {
-			logger.Errorf("解析服务配置失败: %s, %v", service.Service.ID, err)
-			continue
-		}
-
-		// 添加节点
-		m.AddNode(service.Service.ID, appConfig)
-	}
+// WatchedServices 获取监听的服务名称
+func (m *Manager) WatchedServices() []string {
+	return append([]string(nil), m.serviceNames...)
 }
 
-// handleServiceChange 处理服务变化
-func (m *Manager) handleServiceChange(services []*consulapi.ServiceEntry) {
+// handleServiceChange 处理服务变化（instances 为该服务的全量健康实例，只增删属于该服务的节点）
+func (m *Manager) handleServiceChange(serviceName string, instances []*ServiceInstance) {
+	m.syncMu.Lock()
+	defer m.syncMu.Unlock()
+
 	// 当前服务列表
-	currentServices := make(map[string]*consulapi.ServiceEntry)
-	for _, service := range services {
-		currentServices[service.Service.ID] = service
+	currentServices := make(map[string]*ServiceInstance)
+	for _, instance := range instances {
+		currentServices[instance.ServiceID] = instance
 	}
 
-	// 获取现有节点列表
+	// 获取该服务现有的节点列表
 	existingNodes := m.GetAllNodes()
 	existingNodeMap := make(map[string]*Node)
 	for _, node := range existingNodes {
-		existingNodeMap[node.ServiceID] = node
+		if serviceNameOf(node.Config) == serviceName {
+			existingNodeMap[node.ServiceID] = node
+		}
 	}
 
 	// 跳过自己
 	cfg := config.Get()
-	selfServiceID := fmt.Sprintf("%s-%s-%d", cfg.App.Type, cfg.App.Environment, cfg.App.Id)
+	selfServiceID := serviceIDOf(&cfg.App)
+
+	changed := &ClusterChangedEventData{}
 
 	// 1. 检查新增的服务
-	for serviceID, service := range currentServices {
+	for serviceID, instance := range currentServices {
 		if serviceID == selfServiceID {
 			continue
 		}
 
-		if _, exists := existingNodeMap[serviceID]; !exists {
+		if existingNode, exists := existingNodeMap[serviceID]; !exists {
 			// 新增服务
 			logger.Infof("发现新服务: %s", serviceID)
-			appConfig, err := parseServiceConfig(service)
-			if err != nil {
-				logger.Errorf("解析服务配置失败: %v", err)
-				continue
-			}
-			m.AddNode(serviceID, appConfig)
-		} else {
-			// 检查服务是否真的更新
-			newConfig, err := parseServiceConfig(service)
-			if err != nil {
-				continue
-			}
-			
-			// 比较配置是否变化
-			existingNode := existingNodeMap[serviceID]
-			if isConfigChanged(existingNode.Config, newConfig) {
-				m.UpdateNode(serviceID, newConfig)
-			}
+			m.AddNode(serviceID, instance.Config)
+			changed.Added = append(changed.Added, serviceID)
+		} else if isConfigChanged(existingNode.Config, instance.Config) {
+			// 配置变化
+			m.UpdateNode(serviceID, instance.Config)
+			changed.Updated = append(changed.Updated, serviceID)
 		}
 	}
 
@@ -147,47 +87,66 @@ func (m *Manager) handleServiceChange(services []*consulapi.ServiceEntry) {
 			// 服务下线
 			logger.Infof("服务下线: %s", serviceID)
 			m.RemoveNode(serviceID)
+			changed.Removed = append(changed.Removed, serviceID)
 		}
 	}
-}
-
-// parseServiceConfig 从 Consul 服务解析 AppConfig
-// Meta 中已经包含了 AppConfig 的所有字段（展开后）
-func parseServiceConfig(service *consulapi.ServiceEntry) (*config.AppConfig, error) {
-	meta := service.Service.Meta
 
-	appConfig := &config.AppConfig{
-		Type:        meta["type"],
-		Environment: meta["environment"],
-		Addr: config.Addr{
-			Host: meta["host"],
-		},
-		Data: make(map[string]any),
+	// 3. 汇总本次变化
+	if len(changed.Added)+len(changed.Removed)+len(changed.Updated) > 0 {
+		sort.Strings(changed.Added)
+		sort.Strings(changed.Removed)
+		sort.Strings(changed.Updated)
+		changed.NodeCount = len(m.GetAllNodes())
+		event.PublishEvent(event_name.ClusterChanged, changed)
 	}
 
-	// 解析 id
-	if idStr, ok := meta["id"]; ok {
-		var id uint16
-		fmt.Sscanf(idStr, "%d", &id)
-		appConfig.Id = id
+	// 无变化时同样写入，刷新缓存的保存时间
+	m.saveNodeCache()
+}
+
+// RequestResync 请求一次全量同步（异步执行，重复请求会合并）
+// 节点重连成功后自动请求，用于修复断线期间错过的服务变化
+func (m *Manager) RequestResync() {
+	select {
+	case m.resyncChan <- struct{}{}:
+	default:
 	}
+}
 
-	// 解析 port
-	if portStr, ok := meta["port"]; ok {
-		var port int
-		fmt.Sscanf(portStr, "%d", &port)
-		appConfig.Addr.Port = port
+// Resync 全量同步：重新查询各服务实例，与本地节点列表比对后增删节点（发布对应的节点事件）
+// 某个服务查询失败时继续同步其他服务，返回合并的错误
+func (m *Manager) Resync() error {
+	if len(m.serviceNames) == 0 {
+		return fmt.Errorf("未监听服务，无法同步")
 	}
 
-	// 解析 data（JSON 字符串）
-	if dataJSON, ok := meta["data"]; ok && dataJSON != "" {
-		var data map[string]any
-		if err := json.Unmarshal([]byte(dataJSON), &data); err == nil {
-			appConfig.Data = data
+	var errs []error
+	for _, serviceName := range m.serviceNames {
+		instances, err := m.discovery.Instances(serviceName)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", serviceName, err))
+			continue
 		}
+
+		logger.Infof("全量同步服务: %s, 共 %d 个", serviceName, len(instances))
+		m.handleServiceChange(serviceName, instances)
 	}
+	return errors.Join(errs...)
+}
 
-	return appConfig, nil
+// resyncLoop 处理全量同步请求，失败时 5 秒后重试
+func (m *Manager) resyncLoop() {
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-m.resyncChan:
+			if err := m.Resync(); err != nil {
+				logger.Errorf("全量同步失败: %v", err)
+				time.AfterFunc(5*time.Second, m.RequestResync)
+			}
+		}
+	}
 }
 
 // printAllNodes 打印所有节点信息
@@ -202,22 +161,57 @@ func (m *Manager) printAllNodes() {
 
 // isConfigChanged 比较两个 AppConfig 是否发生变化
 func isConfigChanged(old, new *config.AppConfig) bool {
+	return len(configDiff(old, new)) > 0
+}
+
+// configDiff 比较两个 AppConfig，返回变化的字段（data 按键比较，如 data.weight）
+func configDiff(old, new *config.AppConfig) []string {
 	if old == nil || new == nil {
-		return true
+		if old == new {
+			return nil
+		}
+		return []string{"*"}
 	}
 
+	var changes []string
+
 	// 比较基本字段
-	if old.Id != new.Id ||
-		old.Type != new.Type ||
-		old.Environment != new.Environment ||
-		old.Addr.Host != new.Addr.Host ||
-		old.Addr.Port != new.Addr.Port {
-		return true
-	}
-
-	// 比较 data（转换为 JSON 字符串比较）
-	oldDataJSON, _ := json.Marshal(old.Data)
-	newDataJSON, _ := json.Marshal(new.Data)
-	return string(oldDataJSON) != string(newDataJSON)
-}
+	if old.Id != new.Id {
+		changes = append(changes, "id")
+	}
+	if old.Type != new.Type {
+		changes = append(changes, "type")
+	}
+	if old.Environment != new.Environment {
+		changes = append(changes, "environment")
+	}
+	if old.Addr.Host != new.Addr.Host {
+		changes = append(changes, "addr.host")
+	}
+	if old.Addr.Port != new.Addr.Port {
+		changes = append(changes, "addr.port")
+	}
 
+	// 比较 data（每个键的值转换为 JSON 字符串比较）
+	keys := make(map[string]struct{}, len(old.Data)+len(new.Data))
+	for key := range old.Data {
+		keys[key] = struct{}{}
+	}
+	for key := range new.Data {
+		keys[key] = struct{}{}
+	}
+
+	var dataChanges []string
+	for key := range keys {
+		oldValue, oldExists := old.Data[key]
+		newValue, newExists := new.Data[key]
+		oldJSON, _ := json.Marshal(oldValue)
+		newJSON, _ := json.Marshal(newValue)
+		if oldExists != newExists || string(oldJSON) != string(newJSON) {
+			dataChanges = append(dataChanges, "data."+key)
+		}
+	}
+	sort.Strings(dataChanges)
+
+	return append(changes, dataChanges...)
+}
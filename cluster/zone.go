@@ -0,0 +1,122 @@
+package cluster
+
+import (
+	"math"
+
+	"github.com/charry/config"
+)
+
+// ZonePreference 区域感知路由配置
+// 节点通过配置 data.zone / data.region 声明所在可用区和地域，本节点的配置决定"就近"的含义
+type ZonePreference struct {
+	Enabled bool // 是否启用
+
+	// 跨可用区（同地域）节点的权重系数，跨地域节点为其平方（默认 0.1）
+	// 轮询、随机策略只在最近的一层节点中选择；按权重、最少请求策略对远端节点按系数降权
+	// < 0 表示严格模式：所有策略都只在最近的一层中选择，没有同区节点时才跨区
+	CrossZonePenalty float64
+}
+
+// 节点与本节点的距离
+const (
+	localitySameZone   = 0 // 同可用区
+	localitySameRegion = 1 // 同地域、不同可用区
+	localityRemote     = 2 // 不同地域（或未声明）
+)
+
+// SetZonePreference 设置区域感知路由
+func (m *Manager) SetZonePreference(pref ZonePreference) {
+	if pref.CrossZonePenalty == 0 {
+		pref.CrossZonePenalty = 0.1
+	}
+	if pref.CrossZonePenalty > 1 {
+		pref.CrossZonePenalty = 1
+	}
+	m.balancer.zone.Store(&pref)
+}
+
+// Zone 获取节点所在可用区（配置 data.zone）
+func (n *Node) Zone() string {
+	return dataString(n.Config, "zone")
+}
+
+// Region 获取节点所在地域（配置 data.region）
+func (n *Node) Region() string {
+	return dataString(n.Config, "region")
+}
+
+// localityFactors 计算各节点的就近系数（未启用时返回 nil）
+// 严格模式下只保留最近的一层节点，系数为 nil
+func (m *Manager) localityFactors(nodes []*Node) ([]*Node, []float64) {
+	pref := m.balancer.zone.Load()
+	if pref == nil || !pref.Enabled {
+		return nodes, nil
+	}
+
+	cfg := config.Get()
+	zone := dataString(&cfg.App, "zone")
+	region := dataString(&cfg.App, "region")
+
+	localities := make([]int, len(nodes))
+	nearest := localityRemote
+	for i, node := range nodes {
+		localities[i] = locality(zone, region, node)
+		nearest = min(nearest, localities[i])
+	}
+
+	if pref.CrossZonePenalty < 0 {
+		filtered := make([]*Node, 0, len(nodes))
+		for i, node := range nodes {
+			if localities[i] == nearest {
+				filtered = append(filtered, node)
+			}
+		}
+		return filtered, nil
+	}
+
+	factors := make([]float64, len(nodes))
+	for i := range nodes {
+		factors[i] = math.Pow(pref.CrossZonePenalty, float64(localities[i]-nearest))
+	}
+	return nodes, factors
+}
+
+// nearestNodes 只保留系数最大（最近）的节点
+func nearestNodes(nodes []*Node, factors []float64) []*Node {
+	if factors == nil {
+		return nodes
+	}
+
+	best := 0.0
+	for _, f := range factors {
+		best = max(best, f)
+	}
+
+	nearest := make([]*Node, 0, len(nodes))
+	for i, node := range nodes {
+		if factors[i] == best {
+			nearest = append(nearest, node)
+		}
+	}
+	return nearest
+}
+
+// locality 计算节点与本节点的距离
+func locality(zone, region string, node *Node) int {
+	if zone != "" && node.Zone() == zone {
+		return localitySameZone
+	}
+	if region != "" && node.Region() == region {
+		return localitySameRegion
+	}
+	return localityRemote
+}
+
+// dataString 读取配置 data 中的字符串字段
+func dataString(appConfig *config.AppConfig, key string) string {
+	if appConfig == nil || appConfig.Data == nil {
+		return ""
+	}
+	s, _ := appConfig.Data[key].(string)
+	return s
+}
@@ -22,8 +22,139 @@ type Config struct {
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	EventWorkerCount int `json:"event_worker_count"` // 事件处理工作协程数
-	ClusterConnCount int `json:"cluster_conn_count"` // 集群节点连接数（每个节点）
+	EventWorkerCount    int               `json:"event_worker_count"`     // 事件处理工作协程数
+	ClusterConnCount    int               `json:"cluster_conn_count"`     // 集群节点连接数（每个节点，连接池最少连接数）
+	ClusterConnMax      int               `json:"cluster_conn_max"`       // 集群节点最多连接数（大于 cluster_conn_count 时连接池按负载伸缩）
+	EventSchedules      map[string]string `json:"event_schedules"`        // 定时事件：事件名 -> cron 表达式（空串表示禁用）
+	EventHandlerTimeout string            `json:"event_handler_timeout"`  // 事件消费者默认处理超时（如 "30s"，空串表示不限制）
+	EventPlugins        []string          `json:"event_plugins"`          // 事件插件（.so 文件路径）
+	EventRingBufferSize int               `json:"event_ring_buffer_size"` // 事件环形队列容量（> 0 时使用无锁环形队列替代通道队列）
+	ClusterName         string            `json:"cluster_name"`           // 集群名称（节点间握手时校验，不同集群、环境的节点不允许互连）
+	ClusterDiscovery    string            `json:"cluster_discovery"`      // 集群服务发现方式：consul（默认）、gossip
+	ClusterGossipSeeds  []string          `json:"cluster_gossip_seeds"`   // Gossip 种子节点 TCP 地址（host:port）
+	ClusterWatch        []string          `json:"cluster_watch"`          // 除本节点所在服务外还需监听的服务名称（type-environment，如网关监听各后端服务）
+	ClusterNodeCache    string            `json:"cluster_node_cache"`     // 节点缓存文件（重启时先连接缓存中最近已知的节点，空串表示不缓存）
+	ClusterTLS          TLSConfig         `json:"cluster_tls"`            // 节点间 TLS（默认双向）
+	ClusterAuth         AuthConfig        `json:"cluster_auth"`           // 节点间 token 认证
+	ClusterDedupWindow  string            `json:"cluster_dedup_window"`   // 接收端请求去重窗口（如 "30s"，空串表示不去重）
+	ClusterMaxFrameSize int64             `json:"cluster_max_frame_size"` // 接收的单条消息最大字节数（0 表示默认 16MB，超过时断开连接）
+	ClusterCodec        string            `json:"cluster_codec"`          // 未声明编解码器的消息希望使用的编解码器（json、proto 或自定义注册的，对方不支持时使用 json）
+	ClusterChecksum     bool              `json:"cluster_checksum"`       // 节点间消息附带 CRC32 校验和（双方都启用时生效，校验不一致时断开连接）
+	ClusterCompression  int64             `json:"cluster_compression"`    // 消息体达到该字节数时压缩（0 表示不压缩，双方都启用时生效）
+	ClusterReadTimeout  string            `json:"cluster_read_timeout"`   // 节点连接等待下一条消息的超时（如 "30s"，"0s" 表示不超时，空串使用默认值）
+	ClusterWriteTimeout string            `json:"cluster_write_timeout"`  // 节点连接写入一条消息的超时（如 "10s"，"0s" 表示不超时，空串使用默认值）
+	ClusterHeartbeat    HeartbeatConfig   `json:"cluster_heartbeat"`      // 节点间心跳间隔（可按对方节点类型、服务 ID 覆盖心跳间隔和读超时）
+	ClusterMissedBeats  int               `json:"cluster_missed_beats"`   // 连续多少个心跳没有收到（响应）时视为连接失效（0 使用默认值 3）
+	ClusterSendQueue    int               `json:"cluster_send_queue"`     // 每个连接发送队列的容量（消息数，0 使用默认值 256）
+	ClusterSendOverflow string            `json:"cluster_send_overflow"`  // 发送队列满时的处理方式：block（等待，最多等待写超时）、reject（立即失败）、close（断开连接）
+	ClusterFlowWindow   int               `json:"cluster_flow_window"`    // 每个连接允许对方在途（未处理完）的请求数，对方达到时等待本节点处理（0 表示不限制）
+	ClusterWorkers      int               `json:"cluster_workers"`        // 同时处理业务请求的协程数，用尽时暂停读取连接（0 表示在各连接的接收协程中依次处理）
+	ClusterIPFilter     IPFilterConfig    `json:"cluster_ip_filter"`      // 本节点接受连接的来源 IP 规则（配置变更时重新加载）
+	ClusterConnLimit    ConnLimitConfig   `json:"cluster_conn_limit"`     // 本节点接受连接的限制（防止连接风暴）
+	ClusterProxyProto   ProxyProtoConfig  `json:"cluster_proxy_protocol"` // 位于负载均衡之后时解析 PROXY 协议头，取得客户端的真实地址
+	ClusterWebSocket    WebSocketConfig   `json:"cluster_websocket"`      // 同时接受 WebSocket 连接（浏览器客户端、只允许 HTTP(S) 出站的环境）
+	ClusterUnixSocket   string            `json:"cluster_unix_socket"`    // 同时监听的 Unix 域套接字路径（同一主机上的进程通过 unix:// 地址连接，空串表示不监听）
+	ClusterUDP          bool              `json:"cluster_udp"`            // 在 TCP 同端口接收 UDP 报文（不认证，只应在可信网络中启用；发送方按路由选择 UDP）
+	ClusterQUIC         QUICConfig        `json:"cluster_quic"`           // 在 TCP 同端口（UDP）接受 QUIC 连接，连接其他节点时优先使用 QUIC（需启用节点间 TLS，不能与 cluster_udp 同时启用）
+	ClusterRateLimit    float64           `json:"cluster_rate_limit"`     // 每个节点每秒最多发出的请求数（0 表示不限制）
+	ClusterMaxInflight  int               `json:"cluster_max_inflight"`   // 每个节点最多同时在途的请求数（0 表示不限制）
+	ClusterBandwidth    int64             `json:"cluster_bandwidth"`      // 每个节点连接每秒最多写入的字节数（0 表示不限制，控制消息和心跳不受限制）
+	ClusterBatch        BatchConfig       `json:"cluster_batch"`          // 集群节点发送合并
+	ClusterReconnect    ReconnectConfig   `json:"cluster_reconnect"`      // 集群节点重连退避策略
+	ClusterBreaker      BreakerConfig     `json:"cluster_breaker"`        // 集群节点熔断
+}
+
+// TLSConfig 节点间 TLS 配置（cert_file 为空时不启用）
+type TLSConfig struct {
+	CertFile   string `json:"cert_file"`   // 本节点证书（同时用作服务端和客户端证书）
+	KeyFile    string `json:"key_file"`    // 本节点私钥
+	CAFile     string `json:"ca_file"`     // 签发节点证书的 CA，用于校验对方证书（为空时使用系统根证书校验服务端证书）
+	ServerName string `json:"server_name"` // 校验服务端证书的名称（为空时只校验证书链，不校验主机名）
+	ClientAuth string `json:"client_auth"` // 客户端证书校验方式：require（默认，双向 TLS）、verify_if_given、none
+}
+
+// WebSocketConfig WebSocket 监听配置（addr 为空时不启用）
+// 启用节点间 TLS 时使用 wss，浏览器客户端通常没有客户端证书，需将 client_auth 设为 verify_if_given 或 none
+type WebSocketConfig struct {
+	Addr string `json:"addr"` // 监听地址（如 ":8443"）
+	Path string `json:"path"` // 升级为 WebSocket 的 HTTP 路径（默认 "/"）
+}
+
+// AuthConfig 节点间 token 认证配置（token 和 node_tokens 都为空时不认证）
+// 节点握手时出示 node_tokens 中自己服务 ID 对应的 token，未配置时出示共享的 token
+type AuthConfig struct {
+	Token       string            `json:"token"`        // 共享 token
+	NodeTokens  map[string]string `json:"node_tokens"`  // 按服务 ID 配置的 token（优先于共享 token）
+	MaxFailures int               `json:"max_failures"` // 同一 IP 连续认证失败达到该次数后锁定（默认 5）
+	Lockout     string            `json:"lockout"`      // 锁定时长（默认 "5m"）
+}
+
+// HeartbeatConfig 节点间心跳配置（读超时须大于心跳间隔，否则正常的连接会在两次心跳之间超时）
+type HeartbeatConfig struct {
+	Interval string                     `json:"interval"` // 心跳间隔（默认 "10s"，读超时为 cluster_read_timeout）
+	Types    map[string]HeartbeatTiming `json:"types"`    // 按对方节点类型覆盖
+	Services map[string]HeartbeatTiming `json:"services"` // 按对方服务 ID 覆盖（优先于节点类型）
+}
+
+// HeartbeatTiming 覆盖的心跳间隔和读超时（空串沿用上一级配置）
+type HeartbeatTiming struct {
+	Interval    string `json:"interval"`     // 心跳间隔（如 "5s"）
+	ReadTimeout string `json:"read_timeout"` // 等待下一条消息的超时（如 "15s"，"0s" 表示不超时）
+}
+
+// IPFilterConfig 接受连接的来源 IP 规则（CIDR 或单个 IP，都为空时不过滤）
+// 先匹配拒绝规则；允许规则不为空时只接受匹配的地址（需包含其他节点和 Consul 健康检查的来源地址）
+type IPFilterConfig struct {
+	Allow []string `json:"allow"` // 允许的地址（如 "10.0.0.0/8"）
+	Deny  []string `json:"deny"`  // 拒绝的地址（优先于允许规则）
+}
+
+// ConnLimitConfig 服务器连接限制配置（零值表示不限制）
+type ConnLimitConfig struct {
+	MaxConns    int     `json:"max_conns"`    // 最多同时保持的连接数
+	AcceptRate  float64 `json:"accept_rate"`  // 每秒最多接受的新连接数
+	AcceptBurst int     `json:"accept_burst"` // 接受新连接的突发容量（默认为每秒连接数向上取整）
+	Reject      string  `json:"reject"`       // 超过限制时的处理方式：close（默认，接受后立即关闭）、wait（暂停接受，新连接在监听队列中等待）
+}
+
+// ProxyProtoConfig PROXY 协议（HAProxy v1、v2）配置
+// 启用后来自受信任地址的连接按 PROXY 头中的地址过滤、认证和记录日志（只应信任负载均衡的地址，否则来源地址可被伪造）
+type ProxyProtoConfig struct {
+	Mode    string   `json:"mode"`    // off（默认）、optional（受信任地址的连接可以没有 PROXY 头）、required（受信任地址的连接必须有 PROXY 头）
+	Trusted []string `json:"trusted"` // 负载均衡的地址（CIDR 或单个 IP，mode 不为 off 时必须配置）
+}
+
+// QUICConfig QUIC 传输配置（enabled 为 false 时不启用）
+type QUICConfig struct {
+	Enabled bool `json:"enabled"`  // 监听 QUIC 连接，连接其他节点时先尝试 QUIC（失败时使用 TCP）
+	ZeroRTT bool `json:"zero_rtt"` // 接受 0-RTT 数据（重连时少一次往返，0-RTT 请求可能被重放，应同时启用请求去重）
+}
+
+// ReconnectConfig 集群节点重连退避配置（零值使用默认值）
+type ReconnectConfig struct {
+	InitialDelay string  `json:"initial_delay"` // 首次失败后的重连间隔（如 "1s"）
+	MaxDelay     string  `json:"max_delay"`     // 重连间隔上限（如 "30s"）
+	Multiplier   float64 `json:"multiplier"`    // 每次失败后间隔的倍数
+	Jitter       float64 `json:"jitter"`        // 随机抖动比例 0 ~ 1（小于 0 表示不抖动）
+	MaxAttempts  int     `json:"max_attempts"`  // 连续失败达到该次数后隔离节点
+}
+
+// BreakerConfig 集群节点熔断配置（零值使用默认值）
+type BreakerConfig struct {
+	Enabled     bool    `json:"enabled"`
+	Window      string  `json:"window"`       // 统计窗口（如 "10s"）
+	MinRequests int     `json:"min_requests"` // 窗口内最少请求数
+	ErrorRate   float64 `json:"error_rate"`   // 失败率阈值 0 ~ 1
+	SlowCall    string  `json:"slow_call"`    // 耗时超过该值视为慢请求（空串表示不按延迟熔断）
+	SlowRate    float64 `json:"slow_rate"`    // 慢请求比例阈值 0 ~ 1
+	OpenTimeout string  `json:"open_timeout"` // 熔断持续时间（如 "5s"）
+}
+
+// BatchConfig 集群节点发送合并配置（多条小消息拼接后一次写入）
+type BatchConfig struct {
+	Enabled       bool   `json:"enabled"`
+	MaxBytes      int    `json:"max_bytes"`      // 单次写入最多合并的字节数（默认 64KB）
+	FlushInterval string `json:"flush_interval"` // 队列为空时最多等待后续消息的时间（空串表示不等待）
 }
 
 // ConsulConfig Consul 配置
@@ -40,6 +171,7 @@ type AppConfig struct {
 	Id          uint16         `json:"id"`
 	Type        string         `json:"type"`
 	Environment string         `json:"environment"` // dev, test, prod
+	Version     string         `json:"version"`     // 应用版本（节点握手时告知对方）
 	Addr        Addr           `json:"addr"`
 	Data        map[string]any `json:"data"` // 自定义数据
 }
@@ -160,7 +292,11 @@ func setFieldValue(field reflect.Value, value interface{}) error {
 				field.Set(reflect.MakeMap(field.Type()))
 			}
 			for k, v := range mapValue {
-				field.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(v))
+				elem, err := convertValue(v, field.Type().Elem())
+				if err != nil {
+					return fmt.Errorf("%s: %w", k, err)
+				}
+				field.SetMapIndex(reflect.ValueOf(k).Convert(field.Type().Key()), elem)
 			}
 		}
 
@@ -169,7 +305,11 @@ func setFieldValue(field reflect.Value, value interface{}) error {
 		if sliceValue, ok := value.([]interface{}); ok {
 			newSlice := reflect.MakeSlice(field.Type(), len(sliceValue), len(sliceValue))
 			for i, item := range sliceValue {
-				newSlice.Index(i).Set(reflect.ValueOf(item))
+				elem, err := convertValue(item, field.Type().Elem())
+				if err != nil {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+				newSlice.Index(i).Set(elem)
 			}
 			field.Set(newSlice)
 		}
@@ -184,6 +324,26 @@ func setFieldValue(field reflect.Value, value interface{}) error {
 	return nil
 }
 
+// convertValue 将 JSON 解析出的值转换为类型 t（map、slice 的元素，如结构体、数字）
+// 可直接赋值时直接使用，否则重新编码为 JSON 后解析，类型不匹配时返回错误
+func convertValue(value interface{}, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+	if v := reflect.ValueOf(value); v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("无法转换为 %s: %w", t, err)
+	}
+	return ptr.Elem(), nil
+}
+
 // MergeFromJSON 从 JSON 字符串合并配置到全局配置
 // 只解析 JSON 中存在的字段并合并
 func MergeFromJSON(jsonStr string) error {
@@ -202,9 +362,32 @@ func MergeFromJSON(jsonStr string) error {
 		return fmt.Errorf("解析配置 JSON 失败: %w", err)
 	}
 
+	// 合并到副本（map 字段也复制），全部成功后才替换全局配置，避免出错时留下部分合并的配置
+	merged, err := cfg.clone()
+	if err != nil {
+		return err
+	}
+
 	// 使用反射合并 JSON 数据
-	configValue := reflect.ValueOf(cfg).Elem()
-	return mergeFromMap(configValue, jsonMap)
+	if err := mergeFromMap(reflect.ValueOf(merged).Elem(), jsonMap); err != nil {
+		return err
+	}
+	globalConfig = merged
+	return nil
+}
+
+// clone 深复制配置（经 JSON 编码，不序列化的字段单独复制）
+func (c *Config) clone() (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("复制配置失败: %w", err)
+	}
+	cloned := &Config{}
+	if err := json.Unmarshal(data, cloned); err != nil {
+		return nil, fmt.Errorf("复制配置失败: %w", err)
+	}
+	cloned.AppConfigKey = c.AppConfigKey
+	return cloned, nil
 }
 
 // ToJSON 将配置转换为 JSON 字符串
@@ -1,6 +1,8 @@
 package consumers
 
 import (
+	"context"
+
 	"github.com/charry/config"
 	"github.com/charry/constants/event_name"
 	"github.com/charry/constants/priority"
@@ -16,7 +18,7 @@ func (c *ClientCreatedConsumer) CaseEvent() []string {
 	return []string{event_name.ConsulClientCreated}
 }
 
-func (c *ClientCreatedConsumer) Triggered(evt *event.Event) error {
+func (c *ClientCreatedConsumer) Triggered(ctx context.Context, evt *event.Event) error {
 	logger.Info("Consul 客户端已创建，加载配置并注册监听...")
 
 	// 获取配置
@@ -67,7 +69,7 @@ func (c *KVChangedConsumer) CaseEvent() []string {
 	return []string{event_name.ConsulKVChanged}
 }
 
-func (c *KVChangedConsumer) Triggered(evt *event.Event) error {
+func (c *KVChangedConsumer) Triggered(ctx context.Context, evt *event.Event) error {
 	kvEvt, ok := evt.Data.(*consul.KVChangedEvent)
 	if !ok {
 		return nil
@@ -114,7 +116,7 @@ func (c *ShutdownConsumer) CaseEvent() []string {
 	return []string{event_name.AppShutdown}
 }
 
-func (c *ShutdownConsumer) Triggered(evt *event.Event) error {
+func (c *ShutdownConsumer) Triggered(ctx context.Context, evt *event.Event) error {
 	logger.Info("停止配置监听...")
 	consul.StopWatch()
 	return nil
@@ -6,6 +6,12 @@ const (
 	AppShutdown = "app.shutdown"
 )
 
+// 事件模块相关事件
+const (
+	// EventDeadLetter 死信事件（确认模式下超过最大投递次数）
+	EventDeadLetter = "event.dead_letter"
+)
+
 // Consul 相关事件
 const (
 	// ConsulClientCreated Consul 客户端创建完成事件
@@ -15,6 +21,69 @@ const (
 	ConsulKVChanged = "consul.kv.changed"
 )
 
+// 集群相关事件
+const (
+	// ClusterGossipStart 使用 Gossip 服务发现启动（替代 ConsulClientCreated 触发 TCP 服务器和集群初始化）
+	ClusterGossipStart = "cluster.gossip.start"
+
+	// ClusterNodeAdded 集群节点加入（数据为 cluster.NodeEventData）
+	ClusterNodeAdded = "cluster.node.added"
+
+	// ClusterNodeRemoved 集群节点移除（数据为 cluster.NodeEventData）
+	ClusterNodeRemoved = "cluster.node.removed"
+
+	// ClusterNodeUpdated 集群节点配置变化（数据为 cluster.NodeUpdatedEventData）
+	ClusterNodeUpdated = "cluster.node.updated"
+
+	// ClusterChanged 一次服务变化处理完成，集群成员或配置有变化（数据为 cluster.ClusterChangedEventData）
+	ClusterChanged = "cluster.changed"
+
+	// ClusterNodeQuarantined 集群节点因连续失败被隔离（数据为 cluster.QuarantineEventData）
+	ClusterNodeQuarantined = "cluster.node.quarantined"
+
+	// ClusterNodeReconnecting 集群节点重连失败，已按退避策略安排下次重连（数据为 cluster.ReconnectEventData）
+	ClusterNodeReconnecting = "cluster.node.reconnecting"
+
+	// ClusterNodeReconnected 集群节点重连成功（数据为 cluster.ReconnectEventData）
+	ClusterNodeReconnected = "cluster.node.reconnected"
+
+	// ClusterNodePaused 集群节点被暂停，不再被选中（数据为 cluster.NodeEventData）
+	ClusterNodePaused = "cluster.node.paused"
+
+	// ClusterNodeResumed 暂停的集群节点已恢复（数据为 cluster.NodeEventData）
+	ClusterNodeResumed = "cluster.node.resumed"
+
+	// ClusterStateProgress 从其他节点接收状态时每应用一块发布（数据为 cluster.StateTransferEventData）
+	ClusterStateProgress = "cluster.state.progress"
+
+	// ClusterStateTransferred 从其他节点接收状态完成（数据为 cluster.StateTransferEventData）
+	ClusterStateTransferred = "cluster.state.transferred"
+
+	// ClusterBreakerChanged 集群节点熔断器状态变化（数据为 cluster.BreakerEventData）
+	ClusterBreakerChanged = "cluster.breaker.changed"
+
+	// ClusterAuthFailed 其他节点握手认证失败（数据为 tcp.AuthFailedEventData）
+	ClusterAuthFailed = "cluster.auth.failed"
+
+	// ClusterOwnershipChanged 参与 key 归属计算的健康成员变化，本节点持有的 key 可能变化（数据为 cluster.OwnershipEventData）
+	ClusterOwnershipChanged = "cluster.ownership.changed"
+
+	// ClusterFailover 角色的活动节点切换到备用节点（数据为 cluster.FailoverEventData）
+	ClusterFailover = "cluster.failover"
+
+	// ClusterPartitionSuspected 疑似网络分区：已连接节点比例低于法定人数（数据为 cluster.PartitionEventData）
+	ClusterPartitionSuspected = "cluster.partition.suspected"
+
+	// ClusterPartitionResolved 网络分区已恢复（数据为 cluster.PartitionEventData）
+	ClusterPartitionResolved = "cluster.partition.resolved"
+
+	// ClusterShardAcquired 本节点获得分片（数据为 cluster.ShardEventData）
+	ClusterShardAcquired = "cluster.shard.acquired"
+
+	// ClusterShardReleased 本节点释放分片（数据为 cluster.ShardEventData）
+	ClusterShardReleased = "cluster.shard.released"
+)
+
 // 配置相关事件
 const (
 	// ConfigChanged 配置变更事件
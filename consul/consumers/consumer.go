@@ -1,6 +1,8 @@
 package consumers
 
 import (
+	"context"
+
 	"github.com/charry/constants/event_name"
 	"github.com/charry/constants/priority"
 	"github.com/charry/consul"
@@ -16,7 +18,7 @@ func (c *ServiceRegisterConsumer) CaseEvent() []string {
 	return []string{event_name.ConsulClientCreated}
 }
 
-func (c *ServiceRegisterConsumer) Triggered(evt *event.Event) error {
+func (c *ServiceRegisterConsumer) Triggered(ctx context.Context, evt *event.Event) error {
 	logger.Info("注册服务到 Consul...")
 
 	// 注册服务
@@ -43,7 +45,7 @@ func (c *ServiceDeregisterConsumer) CaseEvent() []string {
 	return []string{event_name.AppShutdown}
 }
 
-func (c *ServiceDeregisterConsumer) Triggered(evt *event.Event) error {
+func (c *ServiceDeregisterConsumer) Triggered(ctx context.Context, evt *event.Event) error {
 	logger.Info("关闭 Consul 模块...")
 	consul.Close()
 	return nil
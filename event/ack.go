@@ -0,0 +1,155 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/charry/constants/event_name"
+	"github.com/charry/logger"
+)
+
+// defaultMaxAttempts 默认最大投递次数
+const defaultMaxAttempts = 3
+
+// AckConsumer 确认模式消费者
+// 处理器通过 GetDelivery(ctx) 显式 Ack 或 Nack(delay)
+// 未显式确认时：返回 nil 视为 Ack，返回 error 视为 Nack(0)
+// Nack 的事件会重新投递，超过最大投递次数后发布到死信事件
+type AckConsumer interface {
+	Consumer
+
+	// MaxAttempts 最大投递次数（含首次投递），<= 0 时使用默认值 3
+	MaxAttempts() int
+}
+
+// Delivery 一次事件投递
+type Delivery struct {
+	// 第几次投递（从 1 开始）
+	Attempt int
+
+	mu      sync.Mutex
+	settled bool
+	nacked  bool
+	delay   time.Duration
+}
+
+// Ack 确认事件已处理
+func (d *Delivery) Ack() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.settled {
+		d.settled = true
+	}
+}
+
+// Nack 拒绝事件，delay 后重新投递
+func (d *Delivery) Nack(delay time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.settled {
+		d.settled = true
+		d.nacked = true
+		d.delay = delay
+	}
+}
+
+// isNacked 是否已被拒绝
+func (d *Delivery) isNacked() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.nacked
+}
+
+// DeadLetter 死信事件数据
+type DeadLetter struct {
+	Event    *Event // 原始事件
+	Consumer string // 消费者类型
+	Attempts int    // 已投递次数
+	Reason   string // 最后一次失败原因
+}
+
+// deliveryKey Delivery 在 context 中的键
+type deliveryKey struct{}
+
+// GetDelivery 获取当前投递信息
+// 非确认模式消费者返回 nil
+func GetDelivery(ctx context.Context) *Delivery {
+	delivery, _ := ctx.Value(deliveryKey{}).(*Delivery)
+	return delivery
+}
+
+// settle 根据确认结果决定是否重新投递，返回是否已安排重新投递
+// 重新投递时沿用 item 中发布方的上下文
+func (b *Bus) settle(item *asyncEvent, consumer AckConsumer, delivery *Delivery, err error) bool {
+	event := item.event
+
+	delivery.mu.Lock()
+	if !delivery.settled {
+		delivery.settled = true
+		delivery.nacked = err != nil
+	}
+	nacked, delay := delivery.nacked, delivery.delay
+	delivery.mu.Unlock()
+
+	if !nacked {
+		return false
+	}
+
+	maxAttempts := consumer.MaxAttempts()
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	if delivery.Attempt >= maxAttempts {
+		b.deadLetter(consumer, event, delivery.Attempt, err)
+		return false
+	}
+
+	next := &asyncEvent{
+		ctx:      item.ctx,
+		event:    event,
+		consumer: item.consumer,
+		attempt:  delivery.Attempt + 1,
+		tracker:  item.tracker,
+	}
+
+	logger.Warnf("事件将重新投递: %s, 第 %d 次, 延迟 %v", event.Name, next.attempt, delay)
+	afterFunc(delay, func() {
+		if b.ctx.Err() != nil {
+			next.tracker.done(next.consumer, delivery.Attempt, errBusStopped)
+			return // 事件总线已停止
+		}
+		b.enqueue(next)
+	})
+	return true
+}
+
+// deadLetter 发布死信事件
+func (b *Bus) deadLetter(consumer Consumer, event *Event, attempts int, err error) {
+	b.deadLetterCount.Add(1)
+
+	reason := "nack"
+	if err != nil {
+		reason = err.Error()
+	}
+	logger.Errorf("事件超过最大投递次数，进入死信: %s, 次数: %d, 原因: %s", event.Name, attempts, reason)
+
+	// 避免死信消费者自身失败时无限循环
+	if event.Name == event_name.EventDeadLetter {
+		return
+	}
+
+	b.Publish(NewEvent(event_name.EventDeadLetter, &DeadLetter{
+		Event:    event,
+		Consumer: fmt.Sprintf("%T", consumer),
+		Attempts: attempts,
+		Reason:   reason,
+	}))
+}
+
+// GetDeadLetterCount 获取进入死信的事件数
+func (b *Bus) GetDeadLetterCount() uint64 {
+	return b.deadLetterCount.Load()
+}
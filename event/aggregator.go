@@ -0,0 +1,179 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// AggregatorConfig 聚合器配置
+type AggregatorConfig struct {
+	// 需要聚合的事件名（全部到齐才算完成）
+	Events []string
+
+	// 从事件中提取关联键（例如订单号），返回 false 表示忽略该事件
+	Key func(event *Event) (string, bool)
+
+	// 等待窗口（从收到第一个事件开始计时）
+	Window time.Duration
+
+	// 全部到齐后发布的事件名
+	CompleteEvent string
+
+	// 超时后发布的事件名（为空则超时只丢弃）
+	TimeoutEvent string
+}
+
+// AggregateResult 聚合结果（CompleteEvent / TimeoutEvent 的事件数据）
+type AggregateResult struct {
+	Key     string            // 关联键
+	Events  map[string]*Event // 已收到的事件：eventName -> event
+	Missing []string          // 未收到的事件名（仅超时时非空）
+}
+
+// Aggregator 扇入聚合器
+// 作为消费者注册到事件总线，等待同一关联键的一组事件在窗口内全部到达
+type Aggregator struct {
+	bus    *Bus
+	config AggregatorConfig
+
+	// 聚合中的分组：key -> group
+	groups map[string]*aggregateGroup
+	mu     sync.Mutex
+}
+
+// aggregateGroup 同一关联键的聚合分组
+type aggregateGroup struct {
+	events map[string]*Event
+	timer  Timer
+}
+
+// NewAggregator 创建聚合器
+func NewAggregator(bus *Bus, config AggregatorConfig) (*Aggregator, error) {
+	if len(config.Events) == 0 {
+		return nil, fmt.Errorf("聚合事件列表不能为空")
+	}
+	if config.Key == nil {
+		return nil, fmt.Errorf("关联键提取函数不能为空")
+	}
+	if config.Window <= 0 {
+		return nil, fmt.Errorf("聚合窗口必须大于 0")
+	}
+	if config.CompleteEvent == "" {
+		return nil, fmt.Errorf("完成事件名不能为空")
+	}
+
+	return &Aggregator{
+		bus:    bus,
+		config: config,
+		groups: make(map[string]*aggregateGroup),
+	}, nil
+}
+
+// KeyFromData 按字段名从 map 类型的事件数据中提取关联键
+func KeyFromData(field string) func(event *Event) (string, bool) {
+	return func(event *Event) (string, bool) {
+		var value interface{}
+		switch data := event.Data.(type) {
+		case map[string]interface{}:
+			value = data[field]
+		case map[string]string:
+			value = data[field]
+		default:
+			return "", false
+		}
+
+		if value == nil || value == "" {
+			return "", false
+		}
+		return fmt.Sprintf("%v", value), true
+	}
+}
+
+func (a *Aggregator) CaseEvent() []string {
+	return a.config.Events
+}
+
+func (a *Aggregator) Triggered(ctx context.Context, evt *Event) error {
+	key, ok := a.config.Key(evt)
+	if !ok {
+		return nil
+	}
+
+	a.mu.Lock()
+	group, exists := a.groups[key]
+	if !exists {
+		group = &aggregateGroup{events: make(map[string]*Event)}
+		group.timer = afterFunc(a.config.Window, func() {
+			a.expire(key, group)
+		})
+		a.groups[key] = group
+	}
+	group.events[evt.Name] = evt
+
+	if len(group.events) < len(a.config.Events) {
+		a.mu.Unlock()
+		return nil
+	}
+
+	// 全部到齐
+	group.timer.Stop()
+	delete(a.groups, key)
+	a.mu.Unlock()
+
+	a.bus.Publish(NewEvent(a.config.CompleteEvent, &AggregateResult{
+		Key:    key,
+		Events: group.events,
+	}))
+	return nil
+}
+
+func (a *Aggregator) Async() bool {
+	return false // 同步执行，只做记录
+}
+
+func (a *Aggregator) Priority() uint32 {
+	return 0
+}
+
+// Stop 停止聚合器，丢弃所有未完成的分组
+func (a *Aggregator) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, group := range a.groups {
+		group.timer.Stop()
+	}
+	a.groups = make(map[string]*aggregateGroup)
+}
+
+// expire 聚合窗口超时
+func (a *Aggregator) expire(key string, group *aggregateGroup) {
+	a.mu.Lock()
+	if a.groups[key] != group {
+		a.mu.Unlock()
+		return // 已完成或已停止
+	}
+	delete(a.groups, key)
+	a.mu.Unlock()
+
+	var missing []string
+	for _, name := range a.config.Events {
+		if _, ok := group.events[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	logger.Warnf("事件聚合超时: key=%s, 缺少: %v", key, missing)
+
+	if a.config.TimeoutEvent != "" {
+		a.bus.Publish(NewEvent(a.config.TimeoutEvent, &AggregateResult{
+			Key:     key,
+			Events:  group.events,
+			Missing: missing,
+		}))
+	}
+}
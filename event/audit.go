@@ -0,0 +1,458 @@
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// AuditSyncPolicy 审计日志刷盘策略
+type AuditSyncPolicy string
+
+const (
+	AuditSyncAlways   AuditSyncPolicy = "always"   // 每条记录都 fsync
+	AuditSyncInterval AuditSyncPolicy = "interval" // 按间隔 fsync
+	AuditSyncNone     AuditSyncPolicy = "none"     // 交给操作系统
+)
+
+// 审计日志文件命名
+const (
+	auditFilePrefix = "audit-"
+	auditFileSuffix = ".jsonl"
+)
+
+// AuditConfig 审计日志配置
+type AuditConfig struct {
+	Dir          string            // 日志目录
+	Events       []string          // 只记录这些事件（为空记录全部）
+	Filter       func(*Event) bool // 自定义过滤（返回 true 才记录）
+	MaxSize      int64             // 单文件最大字节数，超过后滚动（默认 100MB）
+	MaxFiles     int               // 最多保留文件数（<= 0 不限制）
+	MaxAge       time.Duration     // 文件最长保留时间（<= 0 不限制）
+	SyncPolicy   AuditSyncPolicy   // 刷盘策略（默认 interval）
+	SyncInterval time.Duration     // interval 策略的刷盘间隔（默认 1 秒）
+}
+
+// AuditRecord 审计记录（一行 JSON）
+// 每条记录包含上一条的哈希，形成哈希链，篡改或删除中间记录可被检测
+type AuditRecord struct {
+	Seq           uint64          `json:"seq"`
+	Time          time.Time       `json:"time"`
+	Id            string          `json:"id"`
+	Name          string          `json:"name"`
+	CorrelationId string          `json:"correlation_id,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+	PrevHash      string          `json:"prev_hash"`
+	Hash          string          `json:"hash"`
+}
+
+// AuditLog 事件审计日志
+// 作为消费者注册到事件总线，将事件以 JSONL 追加写入滚动文件
+type AuditLog struct {
+	config AuditConfig
+
+	// 当前文件
+	file     *os.File
+	writer   *bufio.Writer
+	size     int64
+	seq      uint64
+	lastHash string
+	mu       sync.Mutex
+
+	// 写入队列
+	recordChan chan *Event
+	stopChan   chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewAuditLog 创建审计日志并打开文件
+func NewAuditLog(config AuditConfig) (*AuditLog, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("审计日志目录不能为空")
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = 100 << 20
+	}
+	if config.SyncPolicy == "" {
+		config.SyncPolicy = AuditSyncInterval
+	}
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = time.Second
+	}
+
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建审计日志目录失败: %w", err)
+	}
+
+	a := &AuditLog{
+		config:     config,
+		recordChan: make(chan *Event, 1000),
+		stopChan:   make(chan struct{}),
+	}
+
+	// 从最新的文件接续哈希链
+	if err := a.resume(); err != nil {
+		return nil, err
+	}
+	if err := a.rotate(); err != nil {
+		return nil, err
+	}
+
+	a.wg.Add(1)
+	go a.loop()
+
+	return a, nil
+}
+
+func (a *AuditLog) CaseEvent() []string {
+	if len(a.config.Events) == 0 {
+		return []string{AllEvents}
+	}
+	return a.config.Events
+}
+
+func (a *AuditLog) Triggered(ctx context.Context, event *Event) error {
+	if a.config.Filter != nil && !a.config.Filter(event) {
+		return nil
+	}
+
+	select {
+	case a.recordChan <- event:
+	case <-a.stopChan:
+	}
+	return nil
+}
+
+func (a *AuditLog) Async() bool {
+	return false // 同步执行，只入队，由写入协程落盘
+}
+
+func (a *AuditLog) Priority() uint32 {
+	return 0
+}
+
+// Close 写完队列中的记录并关闭文件
+func (a *AuditLog) Close() {
+	close(a.stopChan)
+	a.wg.Wait()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closeFile()
+}
+
+// loop 写入协程
+func (a *AuditLog) loop() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-a.recordChan:
+			a.write(event)
+		case <-ticker.C:
+			if a.config.SyncPolicy == AuditSyncInterval {
+				a.sync()
+			}
+		case <-a.stopChan:
+			// 写完剩余记录
+			for {
+				select {
+				case event := <-a.recordChan:
+					a.write(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// write 写入一条记录
+func (a *AuditLog) write(event *Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%v", event.Data))
+	}
+
+	a.seq++
+	record := &AuditRecord{
+		Seq:           a.seq,
+		Time:          time.Now(),
+		Id:            event.Id,
+		Name:          event.Name,
+		CorrelationId: event.CorrelationId,
+		Data:          data,
+		PrevHash:      a.lastHash,
+	}
+	record.Hash = hashAuditRecord(record)
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		logger.Errorf("序列化审计记录失败: %v, 事件: %s", err, event.Name)
+		return
+	}
+	line = append(line, '\n')
+
+	if a.size+int64(len(line)) > a.config.MaxSize {
+		if err := a.rotate(); err != nil {
+			logger.Errorf("滚动审计日志失败: %v", err)
+		}
+	}
+	if a.writer == nil {
+		return
+	}
+
+	n, err := a.writer.Write(line)
+	a.size += int64(n)
+	if err != nil {
+		logger.Errorf("写入审计日志失败: %v, 事件: %s", err, event.Name)
+		return
+	}
+	a.lastHash = record.Hash
+
+	if a.config.SyncPolicy == AuditSyncAlways {
+		a.flush(true)
+	}
+}
+
+// sync 按策略刷盘
+func (a *AuditLog) sync() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.flush(true)
+}
+
+// flush 刷新缓冲区，fsync 为 true 时同时落盘
+func (a *AuditLog) flush(fsync bool) {
+	if a.writer == nil {
+		return
+	}
+	if err := a.writer.Flush(); err != nil {
+		logger.Errorf("刷新审计日志失败: %v", err)
+		return
+	}
+	if fsync {
+		if err := a.file.Sync(); err != nil {
+			logger.Errorf("审计日志落盘失败: %v", err)
+		}
+	}
+}
+
+// rotate 滚动到新文件并清理过期文件
+func (a *AuditLog) rotate() error {
+	a.closeFile()
+
+	name := fmt.Sprintf("%s%s%s", auditFilePrefix, time.Now().Format("20060102T150405.000000000"), auditFileSuffix)
+	file, err := os.OpenFile(filepath.Join(a.config.Dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开审计日志文件失败: %w", err)
+	}
+
+	a.file = file
+	a.writer = bufio.NewWriter(file)
+	a.size = 0
+
+	a.cleanup()
+	return nil
+}
+
+// closeFile 关闭当前文件
+func (a *AuditLog) closeFile() {
+	if a.file == nil {
+		return
+	}
+	a.flush(a.config.SyncPolicy != AuditSyncNone)
+	a.file.Close()
+	a.file = nil
+	a.writer = nil
+}
+
+// cleanup 按保留策略删除旧文件
+func (a *AuditLog) cleanup() {
+	files, err := listAuditFiles(a.config.Dir)
+	if err != nil {
+		logger.Warnf("列出审计日志文件失败: %v", err)
+		return
+	}
+
+	for i, path := range files {
+		// 最新的文件总是保留（当前正在写入）
+		if i == len(files)-1 {
+			break
+		}
+
+		expired := a.config.MaxFiles > 0 && len(files)-i > a.config.MaxFiles
+		if !expired && a.config.MaxAge > 0 {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > a.config.MaxAge {
+				expired = true
+			}
+		}
+
+		if expired {
+			if err := os.Remove(path); err != nil {
+				logger.Warnf("删除审计日志文件失败: %s, %v", path, err)
+			}
+		}
+	}
+}
+
+// resume 读取最新文件的最后一条记录，接续序号和哈希链
+func (a *AuditLog) resume() error {
+	files, err := listAuditFiles(a.config.Dir)
+	if err != nil {
+		return fmt.Errorf("列出审计日志文件失败: %w", err)
+	}
+
+	for i := len(files) - 1; i >= 0; i-- {
+		record, err := readLastAuditRecord(files[i])
+		if err != nil {
+			return err
+		}
+		if record != nil {
+			a.seq = record.Seq
+			a.lastHash = record.Hash
+			return nil
+		}
+	}
+	return nil
+}
+
+// VerifyAuditDir 校验目录下所有审计日志的哈希链
+// 发现记录被篡改、删除或乱序时返回错误
+func VerifyAuditDir(dir string) error {
+	files, err := listAuditFiles(dir)
+	if err != nil {
+		return fmt.Errorf("列出审计日志文件失败: %w", err)
+	}
+
+	first := true
+	var prevHash string
+	var prevSeq uint64
+
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("打开审计日志文件失败: %w", err)
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 16<<20)
+		for line := 1; scanner.Scan(); line++ {
+			var record AuditRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				file.Close()
+				return fmt.Errorf("%s:%d 解析记录失败: %w", path, line, err)
+			}
+
+			// 保留策略会删除最早的文件，因此第一条记录不校验前驱
+			if !first && (record.PrevHash != prevHash || record.Seq != prevSeq+1) {
+				file.Close()
+				return fmt.Errorf("%s:%d 哈希链断裂: seq=%d", path, line, record.Seq)
+			}
+			if hashAuditRecord(&record) != record.Hash {
+				file.Close()
+				return fmt.Errorf("%s:%d 记录哈希不匹配: seq=%d", path, line, record.Seq)
+			}
+
+			first = false
+			prevHash = record.Hash
+			prevSeq = record.Seq
+		}
+		file.Close()
+
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("读取审计日志文件失败: %s, %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// hashAuditRecord 计算记录哈希（不含 Hash 字段本身）
+func hashAuditRecord(record *AuditRecord) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d|%s|%s|%s|%s|", record.Seq, record.Time.UTC().Format(time.RFC3339Nano),
+		record.Id, record.Name, record.CorrelationId)
+	h.Write(record.Data)
+	io.WriteString(h, "|"+record.PrevHash)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// listAuditFiles 列出目录下的审计日志文件（按时间正序）
+func listAuditFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, auditFilePrefix) && strings.HasSuffix(name, auditFileSuffix) {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readLastAuditRecord 读取文件最后一条记录，空文件返回 nil
+func readLastAuditRecord(path string) (*AuditRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开审计日志文件失败: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("读取审计日志文件信息失败: %w", err)
+	}
+
+	// 只读取末尾一段
+	const tailSize = 1 << 20
+	offset := info.Size() - tailSize
+	if offset < 0 {
+		offset = 0
+	}
+	buf := make([]byte, info.Size()-offset)
+	if _, err := file.ReadAt(buf, offset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("读取审计日志文件失败: %w", err)
+	}
+
+	buf = bytes.TrimRight(buf, "\n")
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	if idx := bytes.LastIndexByte(buf, '\n'); idx >= 0 {
+		buf = buf[idx+1:]
+	}
+
+	var record AuditRecord
+	if err := json.Unmarshal(buf, &record); err != nil {
+		return nil, fmt.Errorf("解析审计日志最后一条记录失败: %s, %w", path, err)
+	}
+	return &record, nil
+}
@@ -1,28 +1,83 @@
 package event
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/charry/logger"
 )
 
 // Bus 事件总线
 type Bus struct {
-	// 事件消费者映射: eventName -> []Consumer
-	consumers map[string][]Consumer
+	// 事件消费者注册表: eventName -> []Consumer（分片、写时复制，读取无锁）
+	consumers *consumerRegistry
+
+	// 事件转换器映射: eventName -> []transformer（按 order 排序）
+	transformers map[string][]transformer
+
+	// 事件升级函数: eventName -> fromVersion -> upcaster
+	upcasters map[string]map[int]UpcastFunc
+
+	// 事件当前版本: eventName -> version
+	versions map[string]int
 
 	// 事件队列（用于异步消费者）
-	eventChan chan *Event
+	queue eventQueue
 
 	// 停止通道
 	stopChan chan struct{}
 
+	// 总线上下文，Stop 时取消，用于通知消费者退出
+	ctx    context.Context
+	cancel context.CancelFunc
+
 	// 互斥锁
 	mu sync.RWMutex
 
+	// 动态订阅: subscriptionId -> subscription
+	subscriptions map[string]*subscription
+
+	// 消费者邮箱: consumer -> mailbox（MailboxConsumer 专用）
+	mailboxes map[Consumer]*mailbox
+	mailboxMu sync.RWMutex
+
+	// 消费者统计: statsKey -> stats
+	stats   map[string]*consumerStats
+	statsMu sync.RWMutex
+
+	// 已加载的插件（绝对路径）
+	plugins  map[string]bool
+	pluginMu sync.Mutex
+
+	// 等待响应的请求: eventId -> replyChan
+	pending   map[string]chan *Event
+	pendingMu sync.Mutex
+
 	// 工作协程数量
 	workerCount int
+
+	// 同步模式：异步消费者也在发布方协程中执行（用于测试）
+	synchronous bool
+
+	// 过期丢弃的事件数
+	expiredCount atomic.Uint64
+
+	// 进入死信的事件数
+	deadLetterCount atomic.Uint64
+
+	// 处理超时的次数
+	timeoutCount atomic.Uint64
+
+	// 消费者默认处理超时（<= 0 表示不限制）
+	handlerTimeout time.Duration
+
+	// 过期回调（可选）
+	expiredHandler func(event *Event)
 }
 
 // NewBus 创建新的事件总线
@@ -31,61 +86,151 @@ func NewBus(workerCount int) *Bus {
 		workerCount = 10 // 默认 10 个工作协程
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &Bus{
-		consumers:   make(map[string][]Consumer),
-		eventChan:   make(chan *Event, 1000), // 缓冲 1000 个事件
-		stopChan:    make(chan struct{}),
-		workerCount: workerCount,
+		consumers:     newConsumerRegistry(),
+		transformers:  make(map[string][]transformer),
+		upcasters:     make(map[string]map[int]UpcastFunc),
+		versions:      make(map[string]int),
+		subscriptions: make(map[string]*subscription),
+		plugins:       make(map[string]bool),
+		stats:         make(map[string]*consumerStats),
+		mailboxes:     make(map[Consumer]*mailbox),
+		queue:         newChanQueue(1000), // 缓冲 1000 个事件
+		stopChan:      make(chan struct{}),
+		pending:       make(map[string]chan *Event),
+		ctx:           ctx,
+		cancel:        cancel,
+		workerCount:   workerCount,
 	}
 }
 
+// asyncEvent 异步队列中的事件，携带发布时的上下文和目标消费者
+type asyncEvent struct {
+	ctx      context.Context
+	event    *Event
+	consumer Consumer
+	attempt  int              // 第几次投递（确认模式下用于重新投递）
+	tracker  *dispatchTracker // 分发结果跟踪（PublishWithCallback 时非空）
+}
+
 // Register 注册事件消费者
 func (b *Bus) Register(consumer Consumer) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
 	events := consumer.CaseEvent()
 	for _, eventName := range events {
-		b.consumers[eventName] = append(b.consumers[eventName], consumer)
+		b.consumers.add(eventName, consumer)
 		logger.Infof("注册消费者到事件: %s", eventName)
 	}
 }
 
 // Publish 发布事件
-// 注意：消费者只在启动时注册，运行时只读，因此不需要加锁
 // 按优先级顺序触发消费者（优先级数值越小越先执行）
 func (b *Bus) Publish(event *Event) {
-	consumers := b.consumers[event.Name]
+	b.PublishContext(context.Background(), event)
+}
 
-	if len(consumers) == 0 {
+// PublishContext 携带上下文发布事件
+// ctx 中的超时、取消信号和链路数据会传递给消费者
+func (b *Bus) PublishContext(ctx context.Context, event *Event) {
+	b.publish(ctx, event, nil)
+}
+
+// publish 分发事件，tracker 非空时跟踪每个消费者的处理结果
+func (b *Bus) publish(ctx context.Context, event *Event, tracker *dispatchTracker) {
+	// 过期事件直接丢弃
+	if b.dropIfExpired(event) {
+		tracker.fail(errEventExpired)
+		return
+	}
+
+	// 旧版本事件升级到当前版本
+	if err := b.upcast(event); err != nil {
+		logger.Errorf("事件升级失败，丢弃事件: %s, %v", event.Name, err)
+		tracker.fail(err)
+		return
+	}
+
+	// 执行转换器
+	if err := b.transform(ctx, event); err != nil {
+		logger.Errorf("事件转换失败，丢弃事件: %s, %v", event.Name, err)
+		tracker.fail(err)
+		return
+	}
+
+	// 响应事件先投递给等待中的请求方
+	b.deliverReply(event)
+
+	// 注册表读取无锁，运行时增删订阅不会阻塞分发
+	consumers := b.consumers.get(event.Name)
+	wildcards := b.consumers.get(AllEvents)
+
+	if len(consumers) == 0 && len(wildcards) == 0 {
 		// 没有消费者关注此事件
+		tracker.expect(0)
 		return
 	}
 
-	// 按优先级排序（正序）
-	sortedConsumers := make([]Consumer, len(consumers))
-	copy(sortedConsumers, consumers)
+	// 合并通配消费者，按优先级排序（正序）
+	sortedConsumers := make([]Consumer, 0, len(consumers)+len(wildcards))
+	sortedConsumers = append(sortedConsumers, consumers...)
+	sortedConsumers = append(sortedConsumers, wildcards...)
 	sort.Slice(sortedConsumers, func(i, j int) bool {
 		return sortedConsumers[i].Priority() < sortedConsumers[j].Priority()
 	})
 
+	// 先登记消费者数量，避免异步消费者在分发完成前就触发回调
+	tracker.expect(len(sortedConsumers))
+
 	// 按优先级顺序触发
 	for _, consumer := range sortedConsumers {
+		item := &asyncEvent{ctx: ctx, event: event, consumer: consumer, attempt: 1, tracker: tracker}
 		if consumer.Async() {
 			// 异步执行：放入队列
-			select {
-			case b.eventChan <- event:
-				// 成功放入队列
-			default:
-				logger.Warnf("事件队列已满，丢弃事件: %s", event.Name)
-			}
+			b.enqueue(item)
 		} else {
 			// 同步执行：由当前线程直接执行
-			b.handleEvent(consumer, event)
+			b.handleEvent(item)
 		}
 	}
 }
 
+// enqueue 放入异步队列，同步模式下直接执行
+func (b *Bus) enqueue(item *asyncEvent) {
+	if b.synchronous {
+		b.handleEvent(item)
+		return
+	}
+
+	// 独立邮箱消费者
+	if box := b.mailboxFor(item.consumer); box != nil {
+		if err := b.pushMailbox(box, item); err != nil {
+			logger.Warnf("%v，丢弃事件: %s, 消费者: %T", err, item.event.Name, item.consumer)
+			item.tracker.done(item.consumer, item.attempt, err)
+		}
+		return
+	}
+
+	if !b.queue.push(item) {
+		logger.Warnf("事件队列已满，丢弃事件: %s", item.event.Name)
+		item.tracker.done(item.consumer, item.attempt, errQueueFull)
+	}
+}
+
+// UseRingBuffer 使用预分配的无锁环形队列替代默认的通道队列（容量向上取整为 2 的幂）
+// 适用于高吞吐场景，工作协程批量取出事件处理
+// 需在 Start 之前设置
+func (b *Bus) UseRingBuffer(size int) {
+	b.queue = newRingQueue(size)
+}
+
+// SetSynchronous 设置同步模式
+// 同步模式下所有消费者都在发布方协程中按优先级执行，Publish 返回时处理已全部完成，便于测试
+// 需在 Start 之前设置
+func (b *Bus) SetSynchronous(synchronous bool) {
+	b.synchronous = synchronous
+}
+
 // Start 启动事件总线（启动工作协程处理异步事件）
 func (b *Bus) Start() {
 	logger.Infof("启动事件总线，工作协程数: %d", b.workerCount)
@@ -98,50 +243,132 @@ func (b *Bus) Start() {
 // Stop 停止事件总线
 func (b *Bus) Stop() {
 	logger.Info("停止事件总线...")
+	b.cancel() // 通知正在执行的消费者退出
 	close(b.stopChan)
-	close(b.eventChan)
 }
 
 // worker 工作协程，处理异步事件
 func (b *Bus) worker(id int) {
+	buf := make([]*asyncEvent, 0, workerBatchSize)
 	for {
-		select {
-		case <-b.stopChan:
+		batch := b.queue.popBatch(buf[:0], b.stopChan)
+		if batch == nil {
 			logger.Infof("事件总线工作协程 %d 已停止", id)
 			return
-		case event, ok := <-b.eventChan:
-			if !ok {
-				return
-			}
+		}
 
-			// 查找消费者并执行（运行时只读，不需要加锁）
-			consumers := b.consumers[event.Name]
+		for i, item := range batch {
+			batch[i] = nil // 释放引用
 
-			for _, consumer := range consumers {
-				if consumer.Async() {
-					b.handleEvent(consumer, event)
-				}
+			// 在队列中积压过久的事件直接丢弃
+			if b.dropIfExpired(item.event) {
+				item.tracker.done(item.consumer, item.attempt, errEventExpired)
+				continue
 			}
+
+			b.handleEvent(item)
 		}
 	}
 }
 
 // handleEvent 处理事件
-func (b *Bus) handleEvent(consumer Consumer, event *Event) {
+// 传给消费者的 ctx 同时受发布方 ctx、处理超时和总线生命周期控制
+func (b *Bus) handleEvent(item *asyncEvent) {
+	parent, consumer, event := item.ctx, item.consumer, item.event
+
+	// 不满足订阅过滤条件的事件直接跳过（不计入统计）
+	if f, ok := consumer.(interface{ accepts(*Event) bool }); ok && !f.accepts(event) {
+		item.tracker.done(consumer, item.attempt, nil)
+		return
+	}
+
+	ctx, cancel := b.handlerContext(parent, consumer)
+	defer cancel()
+	stop := context.AfterFunc(b.ctx, cancel)
+	defer stop()
+
+	// 确认模式：注入投递信息
+	ackConsumer, ackMode := consumer.(AckConsumer)
+	var delivery *Delivery
+	if ackMode {
+		delivery = &Delivery{Attempt: item.attempt}
+		ctx = context.WithValue(ctx, deliveryKey{}, delivery)
+	}
+
+	start := time.Now()
+	err := b.invoke(ctx, consumer, event)
+	b.recordStats(consumer, time.Since(start), err)
+	if err != nil {
+		// 超时由本次处理的超时设置触发（而不是发布方 ctx 到期）时单独计数
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+			b.timeoutCount.Add(1)
+			logger.Errorf("事件处理超时: %v, 事件: %s, 超时: %v", err, event.Name, b.handlerTimeoutFor(consumer))
+		} else {
+			logger.Errorf("事件处理失败: %v, 事件: %s", err, event.Name)
+		}
+	}
+
+	if ackMode {
+		if b.settle(item, ackConsumer, delivery, err) {
+			return // 等待重新投递，结果以最后一次投递为准
+		}
+		if err == nil && delivery.isNacked() {
+			err = errNacked // 显式 Nack 后进入死信
+		}
+	}
+
+	item.tracker.done(consumer, item.attempt, err)
+}
+
+// invoke 调用消费者（捕获 panic）
+func (b *Bus) invoke(ctx context.Context, consumer Consumer, event *Event) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			logger.Errorf("事件处理发生 panic: %v, 事件: %s", r, event.Name)
+			err = fmt.Errorf("panic: %v", r)
 		}
 	}()
 
-	if err := consumer.Triggered(event); err != nil {
-		logger.Errorf("事件处理失败: %v, 事件: %s", err, event.Name)
+	return consumer.Triggered(ctx, event)
+}
+
+// SetExpiredHandler 设置事件过期回调
+// 需在 Start 之前设置
+func (b *Bus) SetExpiredHandler(handler func(event *Event)) {
+	b.expiredHandler = handler
+}
+
+// GetExpiredCount 获取过期丢弃的事件数
+func (b *Bus) GetExpiredCount() uint64 {
+	return b.expiredCount.Load()
+}
+
+// dropIfExpired 丢弃过期事件，返回是否已丢弃
+// 事件分发给多个消费者时每个消费者都会丢弃，过期计数、日志和回调只在第一次丢弃时记录
+func (b *Bus) dropIfExpired(event *Event) bool {
+	if !event.IsExpired() {
+		return false
+	}
+	if event.expired.Swap(true) {
+		return true
+	}
+
+	b.expiredCount.Add(1)
+	logger.Warnf("事件已过期，丢弃: %s", event.Name)
+
+	if b.expiredHandler != nil {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Errorf("事件过期回调发生 panic: %v, 事件: %s", r, event.Name)
+				}
+			}()
+			b.expiredHandler(event)
+		}()
 	}
+	return true
 }
 
 // GetConsumerCount 获取指定事件的消费者数量
 func (b *Bus) GetConsumerCount(eventName string) int {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-	return len(b.consumers[eventName])
+	return len(b.consumers.get(eventName))
 }
@@ -0,0 +1,145 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/charry/logger"
+)
+
+// 分发失败原因
+var (
+	errEventExpired = errors.New("事件已过期")
+	errQueueFull    = errors.New("事件队列已满")
+	errBusStopped   = errors.New("事件总线已停止")
+	errNacked       = errors.New("事件被拒绝")
+)
+
+// HandlerResult 单个消费者的处理结果
+type HandlerResult struct {
+	Consumer string // 消费者类型
+	Attempts int    // 投递次数（确认模式下可能大于 1）
+	Err      error  // 处理失败原因，nil 表示成功
+}
+
+// DispatchResult 一次事件分发的结果
+type DispatchResult struct {
+	Event   *Event
+	Err     error           // 分发本身失败（过期、转换失败），此时 Results 为空
+	Results []HandlerResult // 各消费者的处理结果（按完成顺序）
+}
+
+// Success 是否分发成功且所有消费者都处理成功
+func (r DispatchResult) Success() bool {
+	if r.Err != nil {
+		return false
+	}
+	for _, result := range r.Results {
+		if result.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed 返回处理失败的消费者结果
+func (r DispatchResult) Failed() []HandlerResult {
+	var failed []HandlerResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// PublishWithCallback 发布事件，所有消费者（包括异步消费者）处理完成后调用 callback
+// callback 在最后一个完成的消费者所在协程中执行，只调用一次
+// 事件总线停止时，队列中尚未处理的事件不会触发回调
+func (b *Bus) PublishWithCallback(event *Event, callback func(result DispatchResult)) {
+	b.PublishContextWithCallback(context.Background(), event, callback)
+}
+
+// PublishContextWithCallback 携带上下文发布事件，所有消费者处理完成后调用 callback
+// callback 为 nil 时等同于 PublishContext（不跟踪处理结果）
+func (b *Bus) PublishContextWithCallback(ctx context.Context, event *Event, callback func(result DispatchResult)) {
+	if callback == nil {
+		b.PublishContext(ctx, event)
+		return
+	}
+	b.publish(ctx, event, &dispatchTracker{
+		result:   DispatchResult{Event: event},
+		callback: callback,
+	})
+}
+
+// dispatchTracker 跟踪一次分发中各消费者的处理结果
+// 所有方法允许在 nil 上调用（未使用回调时不跟踪）
+type dispatchTracker struct {
+	mu        sync.Mutex
+	result    DispatchResult
+	remaining int
+	callback  func(result DispatchResult)
+}
+
+// expect 登记需要等待的消费者数量，为 0 时立即回调
+func (t *dispatchTracker) expect(n int) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.remaining = n
+	t.result.Results = make([]HandlerResult, 0, n)
+	t.mu.Unlock()
+
+	if n == 0 {
+		t.finish()
+	}
+}
+
+// fail 分发失败，立即回调
+func (t *dispatchTracker) fail(err error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.result.Err = err
+	t.mu.Unlock()
+
+	t.finish()
+}
+
+// done 记录一个消费者的处理结果，全部完成时回调
+func (t *dispatchTracker) done(consumer Consumer, attempts int, err error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.result.Results = append(t.result.Results, HandlerResult{
+		Consumer: fmt.Sprintf("%T", consumer),
+		Attempts: attempts,
+		Err:      err,
+	})
+	t.remaining--
+	finished := t.remaining == 0
+	t.mu.Unlock()
+
+	if finished {
+		t.finish()
+	}
+}
+
+// finish 调用回调（捕获 panic）
+func (t *dispatchTracker) finish() {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("分发完成回调发生 panic: %v, 事件: %s", r, t.result.Event.Name)
+		}
+	}()
+	t.callback(t.result)
+}
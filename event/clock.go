@@ -0,0 +1,70 @@
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 时钟接口
+// 事件创建时间、过期判断、定时调度、重新投递和聚合窗口都通过它获取时间，便于测试替换
+type Clock interface {
+	// Now 当前时间
+	Now() time.Time
+
+	// AfterFunc d 之后在独立协程（或由时钟决定的协程）中执行 f
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer AfterFunc 返回的定时器
+type Timer interface {
+	// Stop 取消定时器，返回是否在触发前成功取消
+	Stop() bool
+}
+
+// realClock 系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+var (
+	// clock 当前使用的时钟（可替换，便于测试）
+	clock   Clock = realClock{}
+	clockMu sync.RWMutex
+)
+
+// SetClock 替换时钟，传入 nil 恢复系统时钟
+// 返回之前的时钟，便于测试结束后恢复
+func SetClock(c Clock) Clock {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+
+	prev := clock
+	if c == nil {
+		c = realClock{}
+	}
+	clock = c
+	return prev
+}
+
+// currentClock 获取当前时钟
+func currentClock() Clock {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return clock
+}
+
+// now 当前时间
+func now() time.Time {
+	return currentClock().Now()
+}
+
+// afterFunc d 之后执行 f
+func afterFunc(d time.Duration, f func()) Timer {
+	return currentClock().AfterFunc(d, f)
+}
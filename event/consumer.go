@@ -1,14 +1,20 @@
 package event
 
+import "context"
+
+// AllEvents 通配事件名，CaseEvent 返回它表示关注所有事件
+const AllEvents = "*"
+
 // Consumer 事件消费者接口
 type Consumer interface {
 	// CaseEvent 返回关注的事件名列表
-	// 允许关注多个事件
+	// 允许关注多个事件，返回 AllEvents 表示关注所有事件
 	CaseEvent() []string
 
 	// Triggered 事件触发时调用
-	// 传入事件数据
-	Triggered(event *Event) error
+	// ctx 携带取消信号、超时和链路数据，事件总线停止时会被取消
+	// 长耗时的消费者应监听 ctx.Done() 及时退出
+	Triggered(ctx context.Context, event *Event) error
 
 	// Async 是否异步执行
 	// 返回 true：异步执行（默认）
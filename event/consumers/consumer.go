@@ -0,0 +1,67 @@
+package consumers
+
+import (
+	"context"
+
+	"github.com/charry/config"
+	"github.com/charry/constants/event_name"
+	"github.com/charry/constants/priority"
+	"github.com/charry/event"
+)
+
+// ScheduleSyncConsumer 定时事件同步消费者
+// 配置加载或变更后，按最新配置同步定时任务（运行时添加的任务不受影响）
+type ScheduleSyncConsumer struct{}
+
+func (c *ScheduleSyncConsumer) CaseEvent() []string {
+	return []string{event_name.ConsulClientCreated, event_name.ConfigChanged}
+}
+
+func (c *ScheduleSyncConsumer) Triggered(ctx context.Context, evt *event.Event) error {
+	if event.GlobalScheduler == nil {
+		return nil
+	}
+
+	cfg := config.Get()
+	event.GlobalScheduler.SyncFromConfig(cfg.Server.EventSchedules)
+	return nil
+}
+
+func (c *ScheduleSyncConsumer) Async() bool {
+	return false // 同步执行
+}
+
+func (c *ScheduleSyncConsumer) Priority() uint32 {
+	return priority.ConsulConfigLoad + 1 // 在配置加载之后
+}
+
+// PluginSyncConsumer 事件插件同步消费者
+// 配置加载或变更后，加载新增的事件插件（插件无法卸载，移除配置不会生效）
+type PluginSyncConsumer struct{}
+
+func (c *PluginSyncConsumer) CaseEvent() []string {
+	return []string{event_name.ConsulClientCreated, event_name.ConfigChanged}
+}
+
+func (c *PluginSyncConsumer) Triggered(ctx context.Context, evt *event.Event) error {
+	if event.GlobalBus == nil {
+		return nil
+	}
+
+	cfg := config.Get()
+	return event.GlobalBus.LoadPlugins(cfg.Server.EventPlugins)
+}
+
+func (c *PluginSyncConsumer) Async() bool {
+	return false // 同步执行
+}
+
+func (c *PluginSyncConsumer) Priority() uint32 {
+	return priority.ConsulConfigLoad + 1 // 在配置加载之后
+}
+
+// init 自动注册事件模块相关的事件消费者
+func init() {
+	event.RegisterConsumer(&ScheduleSyncConsumer{})
+	event.RegisterConsumer(&PluginSyncConsumer{})
+}
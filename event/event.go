@@ -1,18 +1,65 @@
 package event
 
+import (
+	"sync/atomic"
+	"time"
+)
+
 // Event 事件类型
 type Event struct {
+	// 事件 ID（NewEvent 自动生成 UUIDv7）
+	Id string
+
 	// 事件名称
 	Name string
 
 	// 事件对象（任意类型）
 	Data interface{}
+
+	// 数据版本（0 表示新创建的事件，发布时标记为当前版本；旧版本事件发布时会被升级）
+	Version int
+
+	// 关联 ID（响应事件指向请求事件的 Id）
+	CorrelationId string
+
+	// 创建时间
+	CreatedAt time.Time
+
+	// 过期时间（零值表示永不过期）
+	ExpireAt time.Time
+
+	// 是否已记录过期（多个消费者丢弃同一事件时只计数、回调一次）
+	expired atomic.Bool
 }
 
 // NewEvent 创建新事件
 func NewEvent(name string, data interface{}) *Event {
 	return &Event{
-		Name: name,
-		Data: data,
+		Id:        NewID(),
+		Name:      name,
+		Data:      data,
+		CreatedAt: now(),
 	}
 }
+
+// WithTTL 设置事件存活时间
+// 超过存活时间仍未分发的事件会被丢弃
+func (e *Event) WithTTL(ttl time.Duration) *Event {
+	createdAt := e.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = now()
+	}
+	e.ExpireAt = createdAt.Add(ttl)
+	return e
+}
+
+// WithVersion 设置事件数据版本（用于重放存储的旧事件）
+func (e *Event) WithVersion(version int) *Event {
+	e.Version = version
+	return e
+}
+
+// IsExpired 判断事件是否已过期
+func (e *Event) IsExpired() bool {
+	return !e.ExpireAt.IsZero() && now().After(e.ExpireAt)
+}
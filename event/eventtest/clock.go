@@ -0,0 +1,117 @@
+package eventtest
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charry/event"
+)
+
+// FakeClock 手动推进的时钟
+// 定时回调只在 Advance / Set 时于调用方协程中按时间顺序执行，结果可预测
+type FakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+	seq    uint64
+	mu     sync.Mutex
+}
+
+// fakeTimer FakeClock 的定时器
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	seq   uint64 // 同一时间按创建顺序触发
+	fn    func()
+}
+
+// NewFakeClock 创建时钟
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// UseFakeClock 创建时钟并替换事件模块的时钟，测试结束时恢复
+func UseFakeClock(t testing.TB, start time.Time) *FakeClock {
+	t.Helper()
+
+	c := NewFakeClock(start)
+	prev := event.SetClock(c)
+	t.Cleanup(func() {
+		event.SetClock(prev)
+	})
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) event.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	t := &fakeTimer{clock: c, when: c.now.Add(d), seq: c.seq, fn: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance 推进时间，依次执行到期的定时回调（回调中新建的到期定时器也会执行）
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set 推进到指定时间（早于当前时间时不回退）
+func (c *FakeClock) Set(target time.Time) {
+	for {
+		c.mu.Lock()
+		sort.Slice(c.timers, func(i, j int) bool {
+			if c.timers[i].when.Equal(c.timers[j].when) {
+				return c.timers[i].seq < c.timers[j].seq
+			}
+			return c.timers[i].when.Before(c.timers[j].when)
+		})
+
+		if len(c.timers) == 0 || c.timers[0].when.After(target) {
+			if target.After(c.now) {
+				c.now = target
+			}
+			c.mu.Unlock()
+			return
+		}
+
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		if t.when.After(c.now) {
+			c.now = t.when
+		}
+		c.mu.Unlock()
+
+		// 不持锁执行，回调中可以再创建定时器
+		t.fn()
+	}
+}
+
+// Pending 未触发的定时器数量
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
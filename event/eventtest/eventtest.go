@@ -0,0 +1,38 @@
+// Package eventtest 事件模块测试工具
+// 提供同步执行的事件总线、事件记录器、断言和可手动推进的时钟，测试事件流时无需 sleep
+package eventtest
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/charry/event"
+)
+
+// NewBus 创建同步模式的事件总线并注册消费者
+// Publish 返回时所有消费者（包括异步消费者）都已执行完毕；测试结束时自动停止，无需手动 Stop
+func NewBus(t testing.TB, consumers ...event.Consumer) *event.Bus {
+	t.Helper()
+
+	bus := event.NewBus(1)
+	bus.SetSynchronous(true)
+	for _, consumer := range consumers {
+		bus.Register(consumer)
+	}
+	t.Cleanup(bus.Stop)
+	return bus
+}
+
+// UseSequentialIDs 使用可预测的事件 ID（prefix-1、prefix-2 ...），测试结束时恢复
+func UseSequentialIDs(t testing.TB, prefix string) {
+	t.Helper()
+
+	var seq atomic.Uint64
+	prev := event.SetIDGenerator(func() string {
+		return fmt.Sprintf("%s-%d", prefix, seq.Add(1))
+	})
+	t.Cleanup(func() {
+		event.SetIDGenerator(prev)
+	})
+}
@@ -0,0 +1,214 @@
+package eventtest_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charry/event"
+	"github.com/charry/event/eventtest"
+)
+
+// countingConsumer 异步消费者，记录处理次数
+type countingConsumer struct {
+	name  string
+	count atomic.Int32
+}
+
+func (c *countingConsumer) CaseEvent() []string {
+	return []string{c.name}
+}
+
+func (c *countingConsumer) Triggered(ctx context.Context, evt *event.Event) error {
+	c.count.Add(1)
+	return nil
+}
+
+func (c *countingConsumer) Async() bool {
+	return true
+}
+
+func (c *countingConsumer) Priority() uint32 {
+	return 10
+}
+
+func TestRecorderOnSynchronousBus(t *testing.T) {
+	eventtest.UseSequentialIDs(t, "order")
+	consumer := &countingConsumer{name: "order.created"}
+	bus := eventtest.NewBus(t, consumer)
+	rec := eventtest.NewRecorder(bus)
+
+	bus.Publish(event.NewEvent("order.created", map[string]interface{}{"id": "A1"}))
+
+	// 同步模式下 Publish 返回时异步消费者也已执行
+	if got := consumer.count.Load(); got != 1 {
+		t.Fatalf("异步消费者执行次数: 期望 1, 实际 %d", got)
+	}
+	evt := rec.AssertPublished(t, "order.created", eventtest.DataEquals("id", "A1"))
+	if evt.Id != "order-1" {
+		t.Fatalf("事件 ID: 期望 order-1, 实际 %s", evt.Id)
+	}
+	rec.AssertNotPublished(t, "order.created", eventtest.DataEquals("id", "B2"))
+	rec.AssertCount(t, "order.cancelled", 0)
+
+	rec.Reset()
+	rec.AssertCount(t, "order.created", 0)
+}
+
+func TestFakeClockDrivesScheduler(t *testing.T) {
+	clock := eventtest.UseFakeClock(t, time.Date(2026, 1, 1, 0, 0, 30, 0, time.UTC))
+	bus := eventtest.NewBus(t)
+	rec := eventtest.NewRecorder(bus, "tick")
+
+	scheduler := event.NewScheduler(bus)
+	t.Cleanup(scheduler.Stop)
+	if err := scheduler.AddJob("tick", "0 * * * * *", "tick", nil); err != nil {
+		t.Fatal(err)
+	}
+	if clock.Pending() != 1 {
+		t.Fatalf("未触发的定时器: 期望 1, 实际 %d", clock.Pending())
+	}
+
+	clock.Advance(29 * time.Second)
+	rec.AssertCount(t, "tick", 0)
+
+	clock.Advance(time.Second)
+	rec.AssertCount(t, "tick", 1)
+
+	clock.Advance(2 * time.Minute)
+	rec.AssertCount(t, "tick", 3)
+}
+
+func TestExpiredEventCountedOnce(t *testing.T) {
+	clock := eventtest.UseFakeClock(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	first := &countingConsumer{name: "order.timeout"}
+	second := &countingConsumer{name: "order.timeout"}
+	bus := event.NewBus(1)
+	bus.Register(first)
+	bus.Register(second)
+	var expired atomic.Int32
+	bus.SetExpiredHandler(func(evt *event.Event) { expired.Add(1) })
+
+	// 事件在队列中积压到过期后工作协程才启动，两个消费者都丢弃该事件
+	results := make(chan event.DispatchResult, 1)
+	bus.PublishWithCallback(event.NewEvent("order.timeout", nil).WithTTL(time.Second), func(result event.DispatchResult) {
+		results <- result
+	})
+	clock.Advance(2 * time.Second)
+	bus.Start()
+	t.Cleanup(bus.Stop)
+
+	var result event.DispatchResult
+	select {
+	case result = <-results:
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待分发完成回调超时")
+	}
+	if got := len(result.Failed()); got != 2 {
+		t.Fatalf("丢弃过期事件的消费者数: 期望 2, 实际 %d", got)
+	}
+	if got := bus.GetExpiredCount(); got != 1 {
+		t.Fatalf("过期事件数: 期望 1, 实际 %d", got)
+	}
+	if got := expired.Load(); got != 1 {
+		t.Fatalf("过期回调次数: 期望 1, 实际 %d", got)
+	}
+	if first.count.Load() != 0 || second.count.Load() != 0 {
+		t.Fatal("过期事件不应交给消费者处理")
+	}
+}
+
+// mailboxConsumer 独立邮箱消费者
+type mailboxConsumer struct {
+	countingConsumer
+}
+
+func (c *mailboxConsumer) MailboxSize() int {
+	return 7
+}
+
+func (c *mailboxConsumer) OverflowPolicy() event.OverflowPolicy {
+	return event.OverflowDropNewest
+}
+
+func TestSubscribedMailboxConsumer(t *testing.T) {
+	consumer := &mailboxConsumer{countingConsumer{name: "order.paid"}}
+	event.RegisterHandler("test.mailbox", func(options map[string]interface{}) (event.Consumer, error) {
+		return consumer, nil
+	})
+	bus := event.NewBus(1)
+	t.Cleanup(bus.Stop)
+
+	id, err := bus.Subscribe("test.mailbox", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 恢复已存在的订阅时跳过，不重复注册
+	if err := bus.Restore(bus.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if got := bus.GetConsumerCount("order.paid"); got != 1 {
+		t.Fatalf("消费者数: 期望 1, 实际 %d", got)
+	}
+
+	done := make(chan event.DispatchResult, 1)
+	bus.PublishWithCallback(event.NewEvent("order.paid", nil), func(result event.DispatchResult) {
+		done <- result
+	})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待分发完成回调超时")
+	}
+
+	// 动态订阅的包装保留 MailboxConsumer 接口，事件进入按订阅 ID 统计的独立邮箱
+	box, ok := bus.GetStats().Mailboxes[id]
+	if !ok {
+		t.Fatalf("订阅 %s 没有独立邮箱", id)
+	}
+	if box.Capacity != 7 {
+		t.Fatalf("邮箱容量: 期望 7, 实际 %d", box.Capacity)
+	}
+	if got := consumer.count.Load(); got != 1 {
+		t.Fatalf("消费者执行次数: 期望 1, 实际 %d", got)
+	}
+}
+
+func TestSubscriptionStatsFollowClockAndUnsubscribe(t *testing.T) {
+	start := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	eventtest.UseFakeClock(t, start)
+	event.RegisterHandler("test.stats", func(options map[string]interface{}) (event.Consumer, error) {
+		return &countingConsumer{name: "order.shipped"}, nil
+	})
+	bus := eventtest.NewBus(t)
+
+	id, err := bus.Subscribe("test.stats", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.Publish(event.NewEvent("order.shipped", nil))
+
+	stats, ok := bus.GetSubscriptionStats(id)
+	if !ok || stats.Processed != 1 {
+		t.Fatalf("订阅统计: %+v, %v", stats, ok)
+	}
+	if !stats.LastProcessed.Equal(start) {
+		t.Fatalf("最后处理时间: 期望 %v, 实际 %v", start, stats.LastProcessed)
+	}
+
+	bus.Unsubscribe(id)
+	if _, ok := bus.GetSubscriptionStats(id); ok {
+		t.Fatal("移除订阅后统计应被删除")
+	}
+}
+
+func TestPublishWithNilCallback(t *testing.T) {
+	consumer := &countingConsumer{name: "order.closed"}
+	bus := eventtest.NewBus(t, consumer)
+
+	bus.PublishWithCallback(event.NewEvent("order.closed", nil), nil)
+	if got := consumer.count.Load(); got != 1 {
+		t.Fatalf("消费者执行次数: 期望 1, 实际 %d", got)
+	}
+}
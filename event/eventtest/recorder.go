@@ -0,0 +1,133 @@
+package eventtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/charry/event"
+)
+
+// Matcher 事件匹配函数
+type Matcher func(evt *event.Event) bool
+
+// Any 匹配任意事件
+func Any(evt *event.Event) bool {
+	return true
+}
+
+// DataEquals 匹配 map 类型事件数据中指定字段的值
+func DataEquals(field string, value interface{}) Matcher {
+	return func(evt *event.Event) bool {
+		switch data := evt.Data.(type) {
+		case map[string]interface{}:
+			v, ok := data[field]
+			return ok && v == value
+		case map[string]string:
+			v, ok := data[field]
+			return ok && v == value
+		}
+		return false
+	}
+}
+
+// Recorder 记录收到的事件的消费者（同步执行）
+type Recorder struct {
+	names  []string
+	events []*event.Event
+	mu     sync.Mutex
+}
+
+// NewRecorder 创建事件记录器并注册到事件总线
+// 不指定事件名时记录所有事件
+func NewRecorder(bus *event.Bus, names ...string) *Recorder {
+	if len(names) == 0 {
+		names = []string{event.AllEvents}
+	}
+	r := &Recorder{names: names}
+	bus.Register(r)
+	return r
+}
+
+func (r *Recorder) CaseEvent() []string {
+	return r.names
+}
+
+func (r *Recorder) Triggered(ctx context.Context, evt *event.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, evt)
+	return nil
+}
+
+func (r *Recorder) Async() bool {
+	return false // 同步执行，发布返回时已记录
+}
+
+func (r *Recorder) Priority() uint32 {
+	return 0 // 最先执行，记录的是转换后、其他消费者处理前的事件
+}
+
+// Events 获取已记录的事件（按发布顺序）
+func (r *Recorder) Events() []*event.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*event.Event(nil), r.events...)
+}
+
+// Find 获取指定名称且满足条件的事件
+func (r *Recorder) Find(name string, matcher Matcher) []*event.Event {
+	if matcher == nil {
+		matcher = Any
+	}
+
+	var found []*event.Event
+	for _, evt := range r.Events() {
+		if evt.Name == name && matcher(evt) {
+			found = append(found, evt)
+		}
+	}
+	return found
+}
+
+// Count 获取指定名称的事件数量
+func (r *Recorder) Count(name string) int {
+	return len(r.Find(name, nil))
+}
+
+// Reset 清空已记录的事件
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = nil
+}
+
+// AssertPublished 断言发布过指定名称且满足条件的事件，返回第一个匹配的事件
+func (r *Recorder) AssertPublished(t testing.TB, name string, matcher Matcher) *event.Event {
+	t.Helper()
+
+	found := r.Find(name, matcher)
+	if len(found) == 0 {
+		t.Fatalf("未发布满足条件的事件: %s（共记录 %d 个事件）", name, len(r.Events()))
+		return nil
+	}
+	return found[0]
+}
+
+// AssertNotPublished 断言没有发布过指定名称且满足条件的事件
+func (r *Recorder) AssertNotPublished(t testing.TB, name string, matcher Matcher) {
+	t.Helper()
+
+	if found := r.Find(name, matcher); len(found) > 0 {
+		t.Fatalf("不应发布的事件已发布: %s（%d 次）", name, len(found))
+	}
+}
+
+// AssertCount 断言指定名称的事件发布次数
+func (r *Recorder) AssertCount(t testing.TB, name string, want int) {
+	t.Helper()
+
+	if got := r.Count(name); got != want {
+		t.Fatalf("事件 %s 发布次数不符: 期望 %d, 实际 %d", name, want, got)
+	}
+}
@@ -0,0 +1,510 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter 编译后的事件过滤表达式
+//
+// 语法示例：
+//
+//	name == "order.paid" && data.amount > 100
+//	data.severity in ["high", "critical"] || !(data.internal == true)
+//	data.tags contains "vip"
+//
+// 字段：id、name、version、correlation_id、data（data.a.b 访问嵌套字段，结构体按 JSON 字段名访问）
+// 运算符：== != > >= < <= in contains && || ! 以及括号
+// 字面量：字符串（双引号）、数字、true、false、null、列表 [..]
+type Filter struct {
+	expr string
+	eval filterNode
+}
+
+// filterNode 表达式节点
+type filterNode func(env *filterEnv) interface{}
+
+// filterEnv 求值环境（data 按需转换，同一事件只转换一次）
+type filterEnv struct {
+	event     *Event
+	data      interface{}
+	converted bool
+}
+
+// CompileFilter 编译过滤表达式
+func CompileFilter(expr string) (*Filter, error) {
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("过滤表达式错误: %s, %w", expr, err)
+	}
+
+	p := &filterParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("位置 %d 存在多余内容: %s", p.peek().pos, p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("过滤表达式错误: %s, %w", expr, err)
+	}
+
+	return &Filter{expr: expr, eval: node}, nil
+}
+
+// MustCompileFilter 编译过滤表达式，失败时 panic
+func MustCompileFilter(expr string) *Filter {
+	f, err := CompileFilter(expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// Match 判断事件是否满足过滤条件
+func (f *Filter) Match(event *Event) bool {
+	return truthy(f.eval(&filterEnv{event: event}))
+}
+
+// String 返回原始表达式
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// ---------- 词法分析 ----------
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+type filterToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lexFilter 将表达式拆分为词法单元
+func lexFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '(' || c == ')' || c == '[' || c == ']' || c == ',':
+			kinds := map[rune]tokenKind{'(': tokenLParen, ')': tokenRParen, '[': tokenLBracket, ']': tokenRBracket, ',': tokenComma}
+			tokens = append(tokens, filterToken{kind: kinds[c], text: string(c), pos: i})
+			i++
+
+		case c == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("位置 %d 的字符串未结束", start)
+			}
+			i++
+			tokens = append(tokens, filterToken{kind: tokenString, text: sb.String(), pos: start})
+
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			word := string(runes[start:i])
+			kind := tokenIdent
+			if word == "in" || word == "contains" {
+				kind = tokenOp
+			}
+			tokens = append(tokens, filterToken{kind: kind, text: word, pos: start})
+
+		default:
+			start := i
+			op := ""
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "==", "!=", ">=", "<=", "&&", "||":
+					op = two
+				}
+			}
+			if op == "" {
+				switch c {
+				case '>', '<', '!':
+					op = string(c)
+				default:
+					return nil, fmt.Errorf("位置 %d 存在非法字符: %q", i, c)
+				}
+			}
+			i += len(op)
+			tokens = append(tokens, filterToken{kind: tokenOp, text: op, pos: start})
+		}
+	}
+
+	return append(tokens, filterToken{kind: tokenEOF, text: "<结尾>", pos: len(runes)}), nil
+}
+
+// ---------- 语法分析 ----------
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == tokenOp && t.text == op
+}
+
+// parseOr or := and ('||' and)*
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(env *filterEnv) interface{} {
+			return truthy(l(env)) || truthy(right(env))
+		}
+	}
+	return left, nil
+}
+
+// parseAnd and := not ('&&' not)*
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(env *filterEnv) interface{} {
+			return truthy(l(env)) && truthy(right(env))
+		}
+	}
+	return left, nil
+}
+
+// parseNot not := '!' not | compare
+func (p *filterParser) parseNot() (filterNode, error) {
+	if p.isOp("!") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(env *filterEnv) interface{} {
+			return !truthy(operand(env))
+		}, nil
+	}
+	return p.parseCompare()
+}
+
+// parseCompare compare := primary (op primary)?
+func (p *filterParser) parseCompare() (filterNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind != tokenOp {
+		return left, nil
+	}
+
+	var cmp func(a, b interface{}) bool
+	switch t.text {
+	case "==":
+		cmp = filterEqual
+	case "!=":
+		cmp = func(a, b interface{}) bool { return !filterEqual(a, b) }
+	case ">", ">=", "<", "<=":
+		cmp = filterOrder(t.text)
+	case "in":
+		cmp = func(a, b interface{}) bool { return filterContains(b, a) }
+	case "contains":
+		cmp = filterContains
+	default:
+		return left, nil // && || 由上层处理
+	}
+	p.next()
+
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return func(env *filterEnv) interface{} {
+		return cmp(left(env), right(env))
+	}, nil
+}
+
+// parsePrimary primary := '(' or ')' | list | literal | field
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenRParen {
+			return nil, fmt.Errorf("位置 %d 缺少右括号", t.pos)
+		}
+		return node, nil
+
+	case tokenLBracket:
+		return p.parseList(t)
+
+	case tokenString:
+		value := t.text
+		return func(*filterEnv) interface{} { return value }, nil
+
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("位置 %d 数字格式错误: %s", t.pos, t.text)
+		}
+		return func(*filterEnv) interface{} { return value }, nil
+
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			value := t.text == "true"
+			return func(*filterEnv) interface{} { return value }, nil
+		case "null":
+			return func(*filterEnv) interface{} { return nil }, nil
+		}
+		return compileField(t)
+
+	default:
+		return nil, fmt.Errorf("位置 %d 缺少操作数，遇到: %s", t.pos, t.text)
+	}
+}
+
+// parseList list := '[' (primary (',' primary)*)? ']'
+func (p *filterParser) parseList(open filterToken) (filterNode, error) {
+	var items []filterNode
+	for p.peek().kind != tokenRBracket {
+		if len(items) > 0 {
+			if p.next().kind != tokenComma {
+				return nil, fmt.Errorf("位置 %d 的列表缺少逗号", open.pos)
+			}
+		}
+		item, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	p.next()
+
+	return func(env *filterEnv) interface{} {
+		values := make([]interface{}, len(items))
+		for i, item := range items {
+			values[i] = item(env)
+		}
+		return values
+	}, nil
+}
+
+// compileField 编译字段访问
+func compileField(t filterToken) (filterNode, error) {
+	parts := strings.Split(t.text, ".")
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("位置 %d 字段名格式错误: %s", t.pos, t.text)
+		}
+	}
+
+	switch parts[0] {
+	case "id", "name", "version", "correlation_id":
+		if len(parts) > 1 {
+			return nil, fmt.Errorf("位置 %d 字段 %s 没有子字段", t.pos, parts[0])
+		}
+	case "data":
+	default:
+		return nil, fmt.Errorf("位置 %d 未知字段: %s", t.pos, parts[0])
+	}
+
+	root, path := parts[0], parts[1:]
+	return func(env *filterEnv) interface{} {
+		switch root {
+		case "id":
+			return env.event.Id
+		case "name":
+			return env.event.Name
+		case "version":
+			return float64(env.event.Version)
+		case "correlation_id":
+			return env.event.CorrelationId
+		}
+
+		value := env.eventData()
+		for _, key := range path {
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			value = m[key]
+		}
+		return value
+	}, nil
+}
+
+// eventData 获取 JSON 形式的事件数据（map / 基础类型）
+func (env *filterEnv) eventData() interface{} {
+	if env.converted {
+		return env.data
+	}
+	env.converted = true
+
+	switch data := env.event.Data.(type) {
+	case nil, string, bool, float64:
+		env.data = data
+	default:
+		raw, err := json.Marshal(data)
+		if err == nil {
+			_ = json.Unmarshal(raw, &env.data)
+		}
+	}
+	return env.data
+}
+
+// ---------- 求值 ----------
+
+// truthy 判断值的真假（nil、false、0、空串、空列表为假）
+func truthy(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case float64:
+		return value != 0
+	case string:
+		return value != ""
+	case []interface{}:
+		return len(value) > 0
+	case map[string]interface{}:
+		return len(value) > 0
+	}
+	return true
+}
+
+// filterEqual 比较两个值是否相等
+func filterEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// filterOrder 数字或字符串大小比较，类型不一致时为 false
+func filterOrder(op string) func(a, b interface{}) bool {
+	return func(a, b interface{}) bool {
+		var c int
+		switch x := a.(type) {
+		case float64:
+			y, ok := b.(float64)
+			if !ok {
+				return false
+			}
+			switch {
+			case x < y:
+				c = -1
+			case x > y:
+				c = 1
+			}
+		case string:
+			y, ok := b.(string)
+			if !ok {
+				return false
+			}
+			c = strings.Compare(x, y)
+		default:
+			return false
+		}
+
+		switch op {
+		case ">":
+			return c > 0
+		case ">=":
+			return c >= 0
+		case "<":
+			return c < 0
+		default:
+			return c <= 0
+		}
+	}
+}
+
+// filterContains 列表包含元素，或字符串包含子串
+func filterContains(container, item interface{}) bool {
+	switch c := container.(type) {
+	case []interface{}:
+		for _, v := range c {
+			if filterEqual(v, item) {
+				return true
+			}
+		}
+	case string:
+		s, ok := item.(string)
+		return ok && strings.Contains(c, s)
+	case map[string]interface{}:
+		key, ok := item.(string)
+		if ok {
+			_, exists := c[key]
+			return exists
+		}
+	}
+	return false
+}
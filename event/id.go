@@ -0,0 +1,91 @@
+package event
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// IDGenerator ID 生成函数
+type IDGenerator func() string
+
+var (
+	// idGenerator 当前使用的 ID 生成函数（可替换，便于测试）
+	idGenerator IDGenerator = NewUUIDv7
+	idMu        sync.RWMutex
+
+	// UUIDv7 同一毫秒内的单调序列
+	uuidMu     sync.Mutex
+	uuidLastMs int64
+	uuidSeq    uint16
+)
+
+// NewID 生成事件 ID
+func NewID() string {
+	idMu.RLock()
+	gen := idGenerator
+	idMu.RUnlock()
+	return gen()
+}
+
+// SetIDGenerator 替换 ID 生成函数，传入 nil 恢复默认（UUIDv7）
+// 返回之前的生成函数，便于测试结束后恢复
+func SetIDGenerator(gen IDGenerator) IDGenerator {
+	idMu.Lock()
+	defer idMu.Unlock()
+
+	prev := idGenerator
+	if gen == nil {
+		gen = NewUUIDv7
+	}
+	idGenerator = gen
+	return prev
+}
+
+// NewUUIDv7 生成 UUIDv7（RFC 9562）
+// 48 位毫秒时间戳 + 12 位单调序列 + 62 位随机数，按时间有序
+func NewUUIDv7() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	ms := time.Now().UnixMilli()
+
+	uuidMu.Lock()
+	if ms <= uuidLastMs {
+		// 同一毫秒（或时钟回拨）：序列递增，溢出时借用下一毫秒
+		uuidSeq++
+		if uuidSeq > 0x0fff {
+			uuidSeq = 0
+			uuidLastMs++
+		}
+		ms = uuidLastMs
+	} else {
+		uuidLastMs = ms
+		uuidSeq = uint16(b[6]&0x07)<<8 | uint16(b[7]) // 随机起点，留出递增空间
+	}
+	seq := uuidSeq
+	uuidMu.Unlock()
+
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = 0x70 | byte(seq>>8)&0x0f // 版本 7
+	b[7] = byte(seq)
+	b[8] = b[8]&0x3f | 0x80 // 变体 10
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:36], b[10:16])
+	return string(s[:])
+}
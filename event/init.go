@@ -1,6 +1,10 @@
 package event
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/charry/config"
 	"github.com/charry/logger"
 )
@@ -9,6 +13,9 @@ var (
 	// GlobalBus 全局事件总线
 	GlobalBus *Bus
 
+	// GlobalScheduler 全局定时事件调度器
+	GlobalScheduler *Scheduler
+
 	// pendingConsumers 待注册的消费者列表
 	pendingConsumers []Consumer
 )
@@ -32,6 +39,18 @@ func Init() error {
 
 	// 创建事件总线
 	GlobalBus = NewBus(workerCount)
+	if cfg.Server.EventRingBufferSize > 0 {
+		GlobalBus.UseRingBuffer(cfg.Server.EventRingBufferSize)
+	}
+
+	// 消费者默认处理超时
+	if cfg.Server.EventHandlerTimeout != "" {
+		timeout, err := time.ParseDuration(cfg.Server.EventHandlerTimeout)
+		if err != nil {
+			return fmt.Errorf("解析事件处理超时失败: %w", err)
+		}
+		GlobalBus.SetHandlerTimeout(timeout)
+	}
 
 	// 启动事件总线
 	GlobalBus.Start()
@@ -43,6 +62,15 @@ func Init() error {
 	logger.Infof("✓ 已自动注册 %d 个事件消费者", len(pendingConsumers))
 	pendingConsumers = nil // 清空列表
 
+	// 加载配置中的事件插件（失败只记录日志，不影响启动）
+	if err := GlobalBus.LoadPlugins(cfg.Server.EventPlugins); err != nil {
+		logger.Warnf("部分事件插件加载失败: %v", err)
+	}
+
+	// 创建定时事件调度器并加载配置中的任务
+	GlobalScheduler = NewScheduler(GlobalBus)
+	GlobalScheduler.SyncFromConfig(cfg.Server.EventSchedules)
+
 	logger.Info("✓ 事件模块初始化完成")
 	return nil
 }
@@ -51,6 +79,9 @@ func Init() error {
 func Close() {
 	if GlobalBus != nil {
 		logger.Info("关闭事件模块...")
+		if GlobalScheduler != nil {
+			GlobalScheduler.Stop()
+		}
 		GlobalBus.Stop()
 		logger.Info("✓ 事件模块已关闭")
 	}
@@ -65,6 +96,40 @@ func Register(consumer Consumer) {
 	}
 }
 
+// RegisterUpcaster 注册事件升级函数到全局事件总线
+func RegisterUpcaster(eventName string, fromVersion int, fn UpcastFunc) {
+	if GlobalBus != nil {
+		GlobalBus.RegisterUpcaster(eventName, fromVersion, fn)
+	} else {
+		logger.Warn("事件总线未初始化，无法注册升级函数")
+	}
+}
+
+// Subscribe 按名称创建消费者并订阅到全局事件总线
+func Subscribe(handler string, events []string, options map[string]interface{}) (string, error) {
+	if GlobalBus == nil {
+		return "", fmt.Errorf("事件总线未初始化")
+	}
+	return GlobalBus.Subscribe(handler, events, options)
+}
+
+// Unsubscribe 从全局事件总线移除动态订阅
+func Unsubscribe(id string) bool {
+	if GlobalBus == nil {
+		return false
+	}
+	return GlobalBus.Unsubscribe(id)
+}
+
+// RegisterTransformer 注册事件转换器到全局事件总线
+func RegisterTransformer(eventName string, order uint32, fn TransformFunc) {
+	if GlobalBus != nil {
+		GlobalBus.RegisterTransformer(eventName, order, fn)
+	} else {
+		logger.Warn("事件总线未初始化，无法注册转换器")
+	}
+}
+
 // Publish 发布事件到全局事件总线
 func Publish(event *Event) {
 	if GlobalBus != nil {
@@ -74,7 +139,38 @@ func Publish(event *Event) {
 	}
 }
 
+// PublishContext 携带上下文发布事件到全局事件总线
+func PublishContext(ctx context.Context, event *Event) {
+	if GlobalBus != nil {
+		GlobalBus.PublishContext(ctx, event)
+	} else {
+		logger.Warn("事件总线未初始化，无法发布事件")
+	}
+}
+
+// PublishWithCallback 发布事件到全局事件总线，所有消费者处理完成后调用 callback
+func PublishWithCallback(event *Event, callback func(result DispatchResult)) {
+	if GlobalBus != nil {
+		GlobalBus.PublishWithCallback(event, callback)
+	} else {
+		logger.Warn("事件总线未初始化，无法发布事件")
+	}
+}
+
 // PublishEvent 便捷方法：创建并发布事件
 func PublishEvent(name string, data interface{}) {
 	Publish(NewEvent(name, data))
 }
+
+// Request 通过全局事件总线发布请求事件并等待响应
+func Request(ctx context.Context, event *Event, timeout time.Duration) (*Event, error) {
+	if GlobalBus == nil {
+		return nil, fmt.Errorf("事件总线未初始化")
+	}
+	return GlobalBus.Request(ctx, event, timeout)
+}
+
+// Reply 通过全局事件总线回复请求事件
+func Reply(original *Event, data interface{}) {
+	Publish(NewReply(original, data))
+}
@@ -0,0 +1,214 @@
+package event
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/charry/logger"
+)
+
+// OverflowPolicy 邮箱满时的处理策略
+type OverflowPolicy int
+
+const (
+	OverflowDropNewest OverflowPolicy = iota // 丢弃新事件（默认）
+	OverflowDropOldest                       // 丢弃最早的事件，放入新事件
+	OverflowBlock                            // 阻塞发布方直到有空位（总线停止时放弃）
+)
+
+// defaultMailboxSize 默认邮箱容量
+const defaultMailboxSize = 100
+
+var (
+	errMailboxFull   = errors.New("消费者邮箱已满")
+	errMailboxClosed = errors.New("消费者已移除")
+)
+
+// MailboxConsumer 独立邮箱消费者（actor 模式）
+// 异步事件不进入共享队列，而是放入消费者自己的有界邮箱，由专属协程按顺序处理；
+// 处理慢只会积压自己的邮箱，不会占用共享工作协程
+// 消费者必须是可比较的类型（通常是指针），否则退回共享队列
+type MailboxConsumer interface {
+	Consumer
+
+	// MailboxSize 邮箱容量，<= 0 时使用默认值 100
+	MailboxSize() int
+
+	// OverflowPolicy 邮箱满时的处理策略
+	OverflowPolicy() OverflowPolicy
+}
+
+// MailboxStats 邮箱统计
+type MailboxStats struct {
+	Depth    int    // 当前积压的事件数
+	Capacity int    // 邮箱容量
+	Dropped  uint64 // 因邮箱满被丢弃的事件数
+}
+
+// mailbox 消费者邮箱
+type mailbox struct {
+	items   chan *asyncEvent
+	policy  OverflowPolicy
+	dropped atomic.Uint64
+	closed  chan struct{} // 消费者移除时关闭，处理协程退出
+	pushMu  sync.RWMutex  // 放入时持有读锁，移除时持有写锁清空邮箱（保证之后不再有事件放入）
+}
+
+// mailboxFor 获取消费者的邮箱，首次使用时创建并启动处理协程
+// 非 MailboxConsumer 或不可比较的消费者返回 nil
+func (b *Bus) mailboxFor(consumer Consumer) *mailbox {
+	mc, ok := consumer.(MailboxConsumer)
+	if !ok {
+		return nil
+	}
+
+	// 先检查是否可比较，不可比较的消费者作为 map 的键会 panic
+	if !reflect.TypeOf(consumer).Comparable() {
+		logger.Warnf("邮箱消费者不可比较，退回共享队列: %T", consumer)
+		return nil
+	}
+
+	b.mailboxMu.RLock()
+	box, exists := b.mailboxes[consumer]
+	b.mailboxMu.RUnlock()
+	if exists {
+		return box
+	}
+
+	b.mailboxMu.Lock()
+	defer b.mailboxMu.Unlock()
+
+	if box, exists = b.mailboxes[consumer]; exists {
+		return box
+	}
+
+	size := mc.MailboxSize()
+	if size <= 0 {
+		size = defaultMailboxSize
+	}
+	box = &mailbox{
+		items:  make(chan *asyncEvent, size),
+		policy: mc.OverflowPolicy(),
+		closed: make(chan struct{}),
+	}
+	b.mailboxes[consumer] = box
+	go b.runMailbox(box)
+
+	return box
+}
+
+// removeMailbox 移除消费者的邮箱（消费者被移除时调用），处理协程退出，积压的事件以错误结束
+func (b *Bus) removeMailbox(consumer Consumer) {
+	if !reflect.TypeOf(consumer).Comparable() {
+		return
+	}
+
+	b.mailboxMu.Lock()
+	box, exists := b.mailboxes[consumer]
+	delete(b.mailboxes, consumer)
+	b.mailboxMu.Unlock()
+	if !exists {
+		return
+	}
+
+	// 先关闭使阻塞的放入返回，再等待正在放入的协程完成，之后清空的邮箱不会再有事件放入
+	close(box.closed)
+	box.pushMu.Lock()
+	defer box.pushMu.Unlock()
+	for {
+		select {
+		case item := <-box.items:
+			item.tracker.done(item.consumer, item.attempt, errMailboxClosed)
+		default:
+			return
+		}
+	}
+}
+
+// pushMailbox 按溢出策略放入邮箱，放不进时返回原因
+func (b *Bus) pushMailbox(box *mailbox, item *asyncEvent) error {
+	box.pushMu.RLock()
+	defer box.pushMu.RUnlock()
+
+	select {
+	case <-box.closed:
+		return errMailboxClosed
+	default:
+	}
+
+	switch box.policy {
+	case OverflowBlock:
+		select {
+		case box.items <- item:
+			return nil
+		case <-box.closed:
+			return errMailboxClosed
+		case <-b.stopChan:
+			return errMailboxFull
+		}
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case box.items <- item:
+				return nil
+			default:
+			}
+
+			select {
+			case old := <-box.items:
+				box.dropped.Add(1)
+				logger.Warnf("消费者邮箱已满，丢弃最早的事件: %s", old.event.Name)
+				old.tracker.done(old.consumer, old.attempt, errMailboxFull)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case box.items <- item:
+			return nil
+		default:
+			box.dropped.Add(1)
+			return errMailboxFull
+		}
+	}
+}
+
+// runMailbox 邮箱处理协程，按顺序处理事件
+func (b *Bus) runMailbox(box *mailbox) {
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		case <-box.closed:
+			return
+		case item := <-box.items:
+			// 在邮箱中积压过久的事件直接丢弃
+			if b.dropIfExpired(item.event) {
+				item.tracker.done(item.consumer, item.attempt, errEventExpired)
+				continue
+			}
+
+			b.handleEvent(item)
+		}
+	}
+}
+
+// mailboxStats 获取各邮箱统计
+func (b *Bus) mailboxStats() map[string]MailboxStats {
+	b.mailboxMu.RLock()
+	defer b.mailboxMu.RUnlock()
+
+	stats := make(map[string]MailboxStats, len(b.mailboxes))
+	for consumer, box := range b.mailboxes {
+		stats[statsKey(consumer)] = MailboxStats{
+			Depth:    len(box.items),
+			Capacity: cap(box.items),
+			Dropped:  box.dropped.Load(),
+		}
+	}
+	return stats
+}
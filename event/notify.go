@@ -0,0 +1,279 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// 通知消费者工厂名称（用于 Subscribe）
+const (
+	EmailHandlerName  = "email"
+	NotifyHandlerName = "notify"
+)
+
+// Notification 渲染后的通知
+type Notification struct {
+	To      []string
+	Subject string
+	Body    string
+	HTML    bool   // Body 是否为 HTML
+	Event   *Event // 触发通知的事件
+}
+
+// Notifier 通知发送接口（SMTP、短信、IM 等）
+type Notifier interface {
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+// NotifierFunc 函数形式的 Notifier
+type NotifierFunc func(ctx context.Context, notification *Notification) error
+
+func (f NotifierFunc) Notify(ctx context.Context, notification *Notification) error {
+	return f(ctx, notification)
+}
+
+var (
+	// notifiers 已注册的通知发送器：name -> notifier
+	notifiers  = make(map[string]Notifier)
+	notifierMu sync.RWMutex
+)
+
+// RegisterNotifier 按名称注册通知发送器，供 notify 消费者通过 "notifier" 参数引用
+func RegisterNotifier(name string, notifier Notifier) {
+	notifierMu.Lock()
+	defer notifierMu.Unlock()
+	notifiers[name] = notifier
+}
+
+// SMTPNotifier 通过 SMTP 发送邮件
+type SMTPNotifier struct {
+	Addr     string // 服务器地址（host:port）
+	Username string // 认证用户名（为空不认证）
+	Password string
+	From     string // 发件人
+}
+
+// Notify 发送邮件
+// net/smtp 不支持 ctx，发送过程不会因 ctx 取消而中断
+func (n *SMTPNotifier) Notify(ctx context.Context, notification *Notification) error {
+	if len(notification.To) == 0 {
+		return fmt.Errorf("收件人不能为空")
+	}
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		host, _, err := net.SplitHostPort(n.Addr)
+		if err != nil {
+			return fmt.Errorf("SMTP 地址格式错误: %s, %w", n.Addr, err)
+		}
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+
+	contentType := "text/plain"
+	if notification.HTML {
+		contentType = "text/html"
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(notification.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", notification.Subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s; charset=UTF-8\r\n\r\n", contentType)
+	msg.WriteString(notification.Body)
+
+	if err := smtp.SendMail(n.Addr, auth, n.From, notification.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
+
+// NotifyOptions 通知消费者配置
+// To、Subject、Body 均为 text/template 模板，模板数据为 {Event, Data}，例如 {{.Data.email}}
+type NotifyOptions struct {
+	Events   []string // 关注的事件（不能为空）
+	To       []string // 收件人模板（渲染为空的收件人会被忽略）
+	Subject  string   // 标题模板
+	Body     string   // 正文模板
+	HTML     bool     // 正文是否为 HTML
+	Sync     bool     // 是否同步执行（默认异步）
+	Priority uint32   // 优先级
+}
+
+// notifyTemplateData 模板数据
+type notifyTemplateData struct {
+	Event *Event
+	Data  interface{}
+}
+
+// NotifyHandler 按模板渲染事件并发送通知的消费者
+type NotifyHandler struct {
+	notifier Notifier
+	options  NotifyOptions
+	to       []*template.Template
+	subject  *template.Template
+	body     *template.Template
+}
+
+// NewNotifyHandler 创建通知消费者（创建时编译模板）
+func NewNotifyHandler(notifier Notifier, options NotifyOptions) (*NotifyHandler, error) {
+	if notifier == nil {
+		return nil, fmt.Errorf("通知发送器不能为空")
+	}
+	if len(options.Events) == 0 {
+		return nil, fmt.Errorf("通知事件列表不能为空")
+	}
+	if len(options.To) == 0 {
+		return nil, fmt.Errorf("收件人不能为空")
+	}
+
+	h := &NotifyHandler{notifier: notifier, options: options}
+
+	var err error
+	for i, to := range options.To {
+		t, err := parseNotifyTemplate(fmt.Sprintf("to[%d]", i), to)
+		if err != nil {
+			return nil, err
+		}
+		h.to = append(h.to, t)
+	}
+	if h.subject, err = parseNotifyTemplate("subject", options.Subject); err != nil {
+		return nil, err
+	}
+	if h.body, err = parseNotifyTemplate("body", options.Body); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// NewEmailHandler 创建通过 SMTP 发送邮件的通知消费者
+func NewEmailHandler(smtpNotifier *SMTPNotifier, options NotifyOptions) (*NotifyHandler, error) {
+	if smtpNotifier == nil || smtpNotifier.Addr == "" || smtpNotifier.From == "" {
+		return nil, fmt.Errorf("SMTP 地址和发件人不能为空")
+	}
+	return NewNotifyHandler(smtpNotifier, options)
+}
+
+func (h *NotifyHandler) CaseEvent() []string {
+	return h.options.Events
+}
+
+func (h *NotifyHandler) Triggered(ctx context.Context, event *Event) error {
+	data := &notifyTemplateData{Event: event, Data: event.Data}
+
+	notification := &Notification{HTML: h.options.HTML, Event: event}
+	for _, t := range h.to {
+		to, err := renderNotifyTemplate(t, data)
+		if err != nil {
+			return err
+		}
+		if to = strings.TrimSpace(to); to != "" {
+			notification.To = append(notification.To, to)
+		}
+	}
+	if len(notification.To) == 0 {
+		return fmt.Errorf("收件人为空，事件: %s", event.Name)
+	}
+
+	var err error
+	if notification.Subject, err = renderNotifyTemplate(h.subject, data); err != nil {
+		return err
+	}
+	if notification.Body, err = renderNotifyTemplate(h.body, data); err != nil {
+		return err
+	}
+
+	return h.notifier.Notify(ctx, notification)
+}
+
+func (h *NotifyHandler) Async() bool {
+	return !h.options.Sync
+}
+
+func (h *NotifyHandler) Priority() uint32 {
+	return h.options.Priority
+}
+
+// parseNotifyTemplate 编译模板（缺失字段渲染为空）
+func parseNotifyTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析通知模板 %s 失败: %w", name, err)
+	}
+	return t, nil
+}
+
+// renderNotifyTemplate 渲染模板
+func renderNotifyTemplate(t *template.Template, data *notifyTemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染通知模板 %s 失败: %w", t.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+// notifyOptionsFromMap 从订阅参数解析通知配置
+// 参数：to（字符串或列表）、subject、body、html、sync
+func notifyOptionsFromMap(options map[string]interface{}) NotifyOptions {
+	var opts NotifyOptions
+	opts.Subject, _ = options["subject"].(string)
+	opts.Body, _ = options["body"].(string)
+	opts.HTML, _ = options["html"].(bool)
+	opts.Sync, _ = options["sync"].(bool)
+
+	switch to := options["to"].(type) {
+	case string:
+		opts.To = []string{to}
+	case []interface{}:
+		for _, item := range to {
+			opts.To = append(opts.To, fmt.Sprintf("%v", item))
+		}
+	}
+
+	// 关注的事件由订阅的 Events 指定（必须指定，否则会关注所有事件）
+	opts.Events = []string{AllEvents}
+	return opts
+}
+
+func init() {
+	// email: 参数 smtp_addr、smtp_username、smtp_password、from 以及通知模板参数
+	RegisterHandler(EmailHandlerName, func(options map[string]interface{}) (Consumer, error) {
+		notifier := &SMTPNotifier{}
+		notifier.Addr, _ = options["smtp_addr"].(string)
+		notifier.Username, _ = options["smtp_username"].(string)
+		notifier.Password, _ = options["smtp_password"].(string)
+		notifier.From, _ = options["from"].(string)
+
+		handler, err := NewEmailHandler(notifier, notifyOptionsFromMap(options))
+		if err != nil {
+			return nil, err
+		}
+		return handler, nil
+	})
+
+	// notify: 参数 notifier（RegisterNotifier 注册的名称）以及通知模板参数
+	RegisterHandler(NotifyHandlerName, func(options map[string]interface{}) (Consumer, error) {
+		name, _ := options["notifier"].(string)
+
+		notifierMu.RLock()
+		notifier, exists := notifiers[name]
+		notifierMu.RUnlock()
+		if !exists {
+			return nil, fmt.Errorf("未注册的通知发送器: %s", name)
+		}
+
+		handler, err := NewNotifyHandler(notifier, notifyOptionsFromMap(options))
+		if err != nil {
+			return nil, err
+		}
+		return handler, nil
+	})
+}
@@ -0,0 +1,87 @@
+package event
+
+import (
+	"fmt"
+	"path/filepath"
+	"plugin"
+
+	"github.com/charry/logger"
+)
+
+// PluginRegisterSymbol 插件导出的注册函数名
+// 插件需导出 func Register(bus *event.Bus) error，在其中注册消费者、转换器或消费者工厂
+const PluginRegisterSymbol = "Register"
+
+// PluginRegisterFunc 插件注册函数
+type PluginRegisterFunc func(bus *Bus) error
+
+// LoadPlugin 加载 Go 插件（.so 文件）并调用其注册函数
+// 同一路径只加载一次；Go 插件无法卸载，已注册的消费者在进程生命周期内一直有效
+func (b *Bus) LoadPlugin(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("解析插件路径失败: %s, %w", path, err)
+	}
+
+	b.pluginMu.Lock()
+	defer b.pluginMu.Unlock()
+
+	if b.plugins[absPath] {
+		return nil
+	}
+
+	p, err := plugin.Open(absPath)
+	if err != nil {
+		return fmt.Errorf("打开插件失败: %s, %w", absPath, err)
+	}
+
+	symbol, err := p.Lookup(PluginRegisterSymbol)
+	if err != nil {
+		return fmt.Errorf("插件缺少注册函数 %s: %s, %w", PluginRegisterSymbol, absPath, err)
+	}
+
+	var register PluginRegisterFunc
+	switch fn := symbol.(type) {
+	case func(*Bus) error:
+		register = fn
+	case *PluginRegisterFunc:
+		register = *fn
+	default:
+		return fmt.Errorf("插件注册函数类型错误: %s, %T", absPath, symbol)
+	}
+
+	if err := b.runPluginRegister(register); err != nil {
+		return fmt.Errorf("插件注册失败: %s, %w", absPath, err)
+	}
+
+	b.plugins[absPath] = true
+	logger.Infof("已加载事件插件: %s", absPath)
+	return nil
+}
+
+// LoadPlugins 依次加载插件，已加载的插件会被跳过
+// 单个插件加载失败不影响其他插件，最后汇总返回错误
+func (b *Bus) LoadPlugins(paths []string) error {
+	var failed []string
+	for _, path := range paths {
+		if err := b.LoadPlugin(path); err != nil {
+			logger.Errorf("加载事件插件失败: %v", err)
+			failed = append(failed, path)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个插件加载失败: %v", len(failed), failed)
+	}
+	return nil
+}
+
+// runPluginRegister 执行插件注册函数（捕获 panic）
+func (b *Bus) runPluginRegister(register PluginRegisterFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return register(b)
+}
@@ -0,0 +1,193 @@
+package event
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// workerBatchSize 工作协程每次从环形队列取出的最大事件数
+const workerBatchSize = 64
+
+// eventQueue 异步事件队列
+type eventQueue interface {
+	// push 放入事件，队列已满返回 false
+	push(item *asyncEvent) bool
+
+	// popBatch 阻塞直到取出至少一个事件（最多 cap(buf) 个）或 stop 关闭（返回 nil）
+	popBatch(buf []*asyncEvent, stop <-chan struct{}) []*asyncEvent
+
+	// length 队列中的事件数
+	length() int
+}
+
+// chanQueue 基于带缓冲通道的队列（默认）
+type chanQueue chan *asyncEvent
+
+func newChanQueue(size int) chanQueue {
+	return make(chanQueue, size)
+}
+
+func (q chanQueue) push(item *asyncEvent) bool {
+	select {
+	case q <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q chanQueue) popBatch(buf []*asyncEvent, stop <-chan struct{}) []*asyncEvent {
+	select {
+	case <-stop:
+		return nil
+	case item := <-q:
+		return append(buf, item)
+	}
+}
+
+func (q chanQueue) length() int {
+	return len(q)
+}
+
+// ringQueue 预分配的无锁环形队列（多生产者多消费者）
+// 每个槽位带序号，生产者和消费者通过 CAS 争用位置，不需要加锁；
+// 工作协程批量取出事件，减少高吞吐下的调度开销
+type ringQueue struct {
+	cells []ringCell
+	mask  uint64
+
+	_       [56]byte // 避免 enqueue / dequeue 位置伪共享
+	enqueue atomic.Uint64
+	_       [56]byte
+	dequeue atomic.Uint64
+	_       [56]byte
+
+	// 空闲的工作协程在此等待，push 后唤醒
+	notify chan struct{}
+}
+
+// ringCell 环形队列槽位
+type ringCell struct {
+	seq  atomic.Uint64
+	item *asyncEvent
+}
+
+// newRingQueue 创建环形队列，容量向上取整为 2 的幂
+func newRingQueue(size int) *ringQueue {
+	capacity := 2
+	for capacity < size {
+		capacity <<= 1
+	}
+
+	q := &ringQueue{
+		cells:  make([]ringCell, capacity),
+		mask:   uint64(capacity - 1),
+		notify: make(chan struct{}, 1),
+	}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+func (q *ringQueue) push(item *asyncEvent) bool {
+	pos := q.enqueue.Load()
+	for {
+		cell := &q.cells[pos&q.mask]
+		seq := cell.seq.Load()
+
+		switch diff := int64(seq) - int64(pos); {
+		case diff == 0:
+			if q.enqueue.CompareAndSwap(pos, pos+1) {
+				cell.item = item
+				cell.seq.Store(pos + 1)
+				q.wake()
+				return true
+			}
+			pos = q.enqueue.Load()
+		case diff < 0:
+			return false // 队列已满
+		default:
+			pos = q.enqueue.Load()
+		}
+	}
+}
+
+// pop 取出一个事件，队列为空返回 nil
+func (q *ringQueue) pop() *asyncEvent {
+	pos := q.dequeue.Load()
+	for {
+		cell := &q.cells[pos&q.mask]
+		seq := cell.seq.Load()
+
+		switch diff := int64(seq) - int64(pos+1); {
+		case diff == 0:
+			if q.dequeue.CompareAndSwap(pos, pos+1) {
+				item := cell.item
+				cell.item = nil
+				cell.seq.Store(pos + q.mask + 1)
+				return item
+			}
+			pos = q.dequeue.Load()
+		case diff < 0:
+			return nil // 队列为空
+		default:
+			pos = q.dequeue.Load()
+		}
+	}
+}
+
+func (q *ringQueue) popBatch(buf []*asyncEvent, stop <-chan struct{}) []*asyncEvent {
+	for spins := 0; ; spins++ {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+
+		for len(buf) < cap(buf) {
+			item := q.pop()
+			if item == nil {
+				break
+			}
+			buf = append(buf, item)
+		}
+
+		if len(buf) > 0 {
+			// 还有剩余事件时唤醒其他空闲的工作协程
+			if q.length() > 0 {
+				q.wake()
+			}
+			return buf
+		}
+
+		// 短暂自旋后再休眠，降低高吞吐时的唤醒开销
+		if spins < 4 {
+			runtime.Gosched()
+			continue
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-q.notify:
+			spins = 0
+		}
+	}
+}
+
+func (q *ringQueue) length() int {
+	n := int64(q.enqueue.Load()) - int64(q.dequeue.Load())
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
+
+// wake 唤醒一个等待中的工作协程
+func (q *ringQueue) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,87 @@
+package event
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+// registryShardCount 消费者注册表分片数（2 的幂）
+const registryShardCount = 32
+
+// consumerRegistry 按事件名分片的消费者注册表
+// 读取完全无锁：每个分片保存不可变的 map，写入时在分片锁内复制后整体替换（写时复制）
+// 运行时增删订阅只会短暂阻塞同一分片的其他写入，不会阻塞事件分发
+type consumerRegistry struct {
+	shards [registryShardCount]registryShard
+}
+
+// registryShard 注册表分片
+type registryShard struct {
+	consumers atomic.Pointer[map[string][]Consumer]
+	mu        sync.Mutex // 只用于串行化写入
+}
+
+// newConsumerRegistry 创建消费者注册表
+func newConsumerRegistry() *consumerRegistry {
+	r := &consumerRegistry{}
+	for i := range r.shards {
+		empty := make(map[string][]Consumer)
+		r.shards[i].consumers.Store(&empty)
+	}
+	return r
+}
+
+// shard 获取事件名所在分片
+func (r *consumerRegistry) shard(eventName string) *registryShard {
+	h := fnv.New32a()
+	h.Write([]byte(eventName))
+	return &r.shards[h.Sum32()&(registryShardCount-1)]
+}
+
+// get 获取事件的消费者列表（只读，调用方不能修改）
+func (r *consumerRegistry) get(eventName string) []Consumer {
+	return (*r.shard(eventName).consumers.Load())[eventName]
+}
+
+// add 添加消费者
+func (r *consumerRegistry) add(eventName string, consumer Consumer) {
+	r.update(eventName, func(list []Consumer) []Consumer {
+		next := make([]Consumer, len(list), len(list)+1)
+		copy(next, list)
+		return append(next, consumer)
+	})
+}
+
+// remove 移除消费者（按实例比较）
+func (r *consumerRegistry) remove(eventName string, consumer Consumer) {
+	r.update(eventName, func(list []Consumer) []Consumer {
+		next := make([]Consumer, 0, len(list))
+		for _, c := range list {
+			if c != consumer {
+				next = append(next, c)
+			}
+		}
+		return next
+	})
+}
+
+// update 在分片锁内复制分片并替换指定事件的消费者列表
+func (r *consumerRegistry) update(eventName string, fn func(list []Consumer) []Consumer) {
+	s := r.shard(eventName)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := *s.consumers.Load()
+	next := make(map[string][]Consumer, len(old)+1)
+	for name, list := range old {
+		next[name] = list
+	}
+
+	if list := fn(old[eventName]); len(list) > 0 {
+		next[eventName] = list
+	} else {
+		delete(next, eventName)
+	}
+	s.consumers.Store(&next)
+}
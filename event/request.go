@@ -0,0 +1,87 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReplySuffix 响应事件名后缀（请求事件名 + 后缀）
+const ReplySuffix = ".reply"
+
+// Request 发布请求事件并阻塞等待响应
+// 消费者通过 Reply 回复，响应事件的 CorrelationId 与请求事件 Id 对应
+// timeout <= 0 时只受 ctx 控制；没有消费者（包括关注 AllEvents 的消费者）时直接返回错误
+func (b *Bus) Request(ctx context.Context, event *Event, timeout time.Duration) (*Event, error) {
+	if b.GetConsumerCount(event.Name)+b.GetConsumerCount(AllEvents) == 0 {
+		return nil, fmt.Errorf("没有消费者处理请求事件: %s", event.Name)
+	}
+
+	if event.Id == "" {
+		event.Id = NewID()
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// 先登记等待，避免同步消费者在 Publish 返回前就已回复
+	replyChan := make(chan *Event, 1)
+	b.pendingMu.Lock()
+	b.pending[event.Id] = replyChan
+	b.pendingMu.Unlock()
+
+	defer func() {
+		b.pendingMu.Lock()
+		delete(b.pending, event.Id)
+		b.pendingMu.Unlock()
+	}()
+
+	b.PublishContext(ctx, event)
+
+	select {
+	case reply := <-replyChan:
+		return reply, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("等待事件响应失败: %s, %w", event.Name, ctx.Err())
+	case <-b.ctx.Done():
+		return nil, fmt.Errorf("事件总线已停止: %s", event.Name)
+	}
+}
+
+// Reply 回复请求事件
+func (b *Bus) Reply(original *Event, data interface{}) {
+	b.Publish(NewReply(original, data))
+}
+
+// NewReply 创建请求事件的响应事件
+func NewReply(original *Event, data interface{}) *Event {
+	return &Event{
+		Id:            NewID(),
+		Name:          original.Name + ReplySuffix,
+		Data:          data,
+		CorrelationId: original.Id,
+		CreatedAt:     now(),
+	}
+}
+
+// deliverReply 将响应事件投递给等待中的请求方
+func (b *Bus) deliverReply(event *Event) {
+	if event.CorrelationId == "" {
+		return
+	}
+
+	b.pendingMu.Lock()
+	replyChan, exists := b.pending[event.CorrelationId]
+	b.pendingMu.Unlock()
+
+	if exists {
+		select {
+		case replyChan <- event:
+		default:
+			// 已有响应，忽略重复回复
+		}
+	}
+}
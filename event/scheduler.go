@@ -0,0 +1,202 @@
+package event
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/charry/logger"
+)
+
+// configJobPrefix 配置文件定义的任务 ID 前缀
+const configJobPrefix = "config:"
+
+// Scheduler 定时事件调度器
+// 按 cron 表达式定时发布事件，支持运行时增删任务
+type Scheduler struct {
+	bus *Bus
+
+	// 任务列表：jobId -> job
+	jobs map[string]*scheduleJob
+	mu   sync.Mutex
+
+	// 状态
+	stopped bool
+}
+
+// scheduleJob 定时任务
+type scheduleJob struct {
+	id        string
+	spec      string
+	eventName string
+	data      interface{}
+	schedule  *CronSchedule
+
+	// 下一次触发的定时器
+	timer   Timer
+	stopped bool
+	mu      sync.Mutex
+}
+
+// NewScheduler 创建定时事件调度器
+func NewScheduler(bus *Bus) *Scheduler {
+	return &Scheduler{
+		bus:  bus,
+		jobs: make(map[string]*scheduleJob),
+	}
+}
+
+// AddJob 添加定时任务，同 ID 任务已存在时替换
+func (s *Scheduler) AddJob(id, spec, eventName string, data interface{}) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return fmt.Errorf("调度器已停止")
+	}
+
+	if old, exists := s.jobs[id]; exists {
+		old.stop()
+	}
+
+	job := &scheduleJob{
+		id:        id,
+		spec:      spec,
+		eventName: eventName,
+		data:      data,
+		schedule:  schedule,
+	}
+	s.jobs[id] = job
+	s.scheduleNext(job)
+
+	logger.Infof("添加定时任务: %s [%s] -> %s", id, spec, eventName)
+	return nil
+}
+
+// RemoveJob 移除定时任务
+func (s *Scheduler) RemoveJob(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, exists := s.jobs[id]; exists {
+		job.stop()
+		delete(s.jobs, id)
+		logger.Infof("移除定时任务: %s", id)
+	}
+}
+
+// Jobs 获取所有任务 ID
+func (s *Scheduler) Jobs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.jobs))
+	for id := range s.jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SyncFromConfig 按配置同步任务（事件名 -> cron 表达式）
+// 只影响配置定义的任务，运行时添加的任务保持不变
+// cron 表达式为空表示禁用该任务
+func (s *Scheduler) SyncFromConfig(schedules map[string]string) {
+	s.mu.Lock()
+	var stale []string
+	for id, job := range s.jobs {
+		if !strings.HasPrefix(id, configJobPrefix) {
+			continue
+		}
+		if spec := schedules[job.eventName]; spec != job.spec {
+			stale = append(stale, id)
+		}
+	}
+	existing := make(map[string]bool, len(s.jobs))
+	for id := range s.jobs {
+		existing[id] = true
+	}
+	s.mu.Unlock()
+
+	for _, id := range stale {
+		s.RemoveJob(id)
+		delete(existing, id)
+	}
+
+	for eventName, spec := range schedules {
+		id := configJobPrefix + eventName
+		if spec == "" || existing[id] {
+			continue
+		}
+		if err := s.AddJob(id, spec, eventName, nil); err != nil {
+			logger.Errorf("添加定时任务失败: %s, %v", id, err)
+		}
+	}
+}
+
+// Stop 停止调度器及所有任务
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+
+	for _, job := range s.jobs {
+		job.stop()
+	}
+	s.jobs = make(map[string]*scheduleJob)
+}
+
+// scheduleNext 安排任务的下一次触发
+func (s *Scheduler) scheduleNext(job *scheduleJob) {
+	current := now()
+	next := job.schedule.Next(current)
+	if next.IsZero() {
+		logger.Warnf("定时任务不会再触发: %s [%s]", job.id, job.spec)
+		return
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	if job.stopped {
+		return
+	}
+	job.timer = afterFunc(next.Sub(current), func() {
+		s.fire(job)
+	})
+}
+
+// fire 触发任务：发布事件并安排下一次触发
+func (s *Scheduler) fire(job *scheduleJob) {
+	job.mu.Lock()
+	stopped := job.stopped
+	job.mu.Unlock()
+
+	if stopped {
+		return
+	}
+
+	s.bus.Publish(NewEvent(job.eventName, job.data))
+	s.scheduleNext(job)
+}
+
+// stop 停止任务
+func (j *scheduleJob) stop() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.stopped = true
+	if j.timer != nil {
+		j.timer.Stop()
+	}
+}
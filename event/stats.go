@@ -0,0 +1,134 @@
+package event
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ConsumerStats 消费者（订阅）处理统计
+type ConsumerStats struct {
+	Processed     uint64        // 处理次数（含失败）
+	Errors        uint64        // 失败次数
+	LastError     string        // 最后一次失败原因
+	AvgLatency    time.Duration // 平均处理耗时
+	LastProcessed time.Time     // 最后一次处理时间
+}
+
+// Stats 事件总线统计
+type Stats struct {
+	QueueLength   int                      // 异步队列中待处理的事件数
+	ExpiredCount  uint64                   // 过期丢弃的事件数
+	DeadLetters   uint64                   // 进入死信的事件数
+	TimeoutCount  uint64                   // 处理超时的次数
+	Subscriptions map[string]ConsumerStats // 各消费者统计：动态订阅按订阅 ID，其余按消费者类型
+	Mailboxes     map[string]MailboxStats  // 各独立邮箱统计（键同 Subscriptions）
+}
+
+// consumerStats 消费者统计（累计值）
+type consumerStats struct {
+	mu            sync.Mutex
+	processed     uint64
+	errors        uint64
+	lastError     string
+	totalLatency  time.Duration
+	lastProcessed time.Time
+}
+
+// snapshot 导出统计
+func (s *consumerStats) snapshot() ConsumerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := ConsumerStats{
+		Processed:     s.processed,
+		Errors:        s.errors,
+		LastError:     s.lastError,
+		LastProcessed: s.lastProcessed,
+	}
+	if s.processed > 0 {
+		stats.AvgLatency = s.totalLatency / time.Duration(s.processed)
+	}
+	return stats
+}
+
+// GetStats 获取事件总线统计
+func (b *Bus) GetStats() Stats {
+	b.statsMu.RLock()
+	subscriptions := make(map[string]ConsumerStats, len(b.stats))
+	for key, s := range b.stats {
+		subscriptions[key] = s.snapshot()
+	}
+	b.statsMu.RUnlock()
+
+	return Stats{
+		QueueLength:   b.queue.length(),
+		ExpiredCount:  b.GetExpiredCount(),
+		DeadLetters:   b.GetDeadLetterCount(),
+		TimeoutCount:  b.GetTimeoutCount(),
+		Subscriptions: subscriptions,
+		Mailboxes:     b.mailboxStats(),
+	}
+}
+
+// GetSubscriptionStats 获取单个消费者的统计
+// id 为动态订阅 ID，或静态注册消费者的类型名（如 "*consumers.ScheduleSyncConsumer"）
+func (b *Bus) GetSubscriptionStats(id string) (ConsumerStats, bool) {
+	b.statsMu.RLock()
+	s, exists := b.stats[id]
+	b.statsMu.RUnlock()
+
+	if !exists {
+		return ConsumerStats{}, false
+	}
+	return s.snapshot(), true
+}
+
+// recordStats 记录一次处理结果
+func (b *Bus) recordStats(consumer Consumer, latency time.Duration, err error) {
+	key := statsKey(consumer)
+
+	b.statsMu.RLock()
+	s, exists := b.stats[key]
+	b.statsMu.RUnlock()
+
+	if !exists {
+		b.statsMu.Lock()
+		// 已移除的订阅不再创建统计（移除前开始的处理在移除后完成）
+		if sc, ok := consumer.(interface{ unsubscribed() bool }); ok && sc.unsubscribed() {
+			b.statsMu.Unlock()
+			return
+		}
+		if s, exists = b.stats[key]; !exists {
+			s = &consumerStats{}
+			b.stats[key] = s
+		}
+		b.statsMu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.processed++
+	s.totalLatency += latency
+	s.lastProcessed = now()
+	if err != nil {
+		s.errors++
+		s.lastError = err.Error()
+	}
+}
+
+// removeStats 删除动态订阅的统计（移除订阅时调用）
+func (b *Bus) removeStats(consumer Consumer) {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+	delete(b.stats, statsKey(consumer))
+}
+
+// statsKey 消费者统计键：动态订阅使用订阅 ID，其余使用消费者类型名
+func statsKey(consumer Consumer) string {
+	if sc, ok := consumer.(interface{ subscriptionId() string }); ok {
+		return sc.subscriptionId()
+	}
+	return fmt.Sprintf("%T", consumer)
+}
@@ -0,0 +1,299 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// HandlerFactory 按名称创建消费者的工厂
+// options 为订阅时传入的参数（需可 JSON 序列化，快照恢复时原样传回）
+type HandlerFactory func(options map[string]interface{}) (Consumer, error)
+
+var (
+	// handlerFactories 已注册的消费者工厂：name -> factory
+	handlerFactories = make(map[string]HandlerFactory)
+	handlerMu        sync.RWMutex
+)
+
+// RegisterHandler 按名称注册消费者工厂
+// 在各 consumers 包的 init() 中调用，动态订阅和快照恢复都通过名称查找工厂
+func RegisterHandler(name string, factory HandlerFactory) {
+	handlerMu.Lock()
+	defer handlerMu.Unlock()
+	handlerFactories[name] = factory
+}
+
+// getHandlerFactory 查找消费者工厂
+func getHandlerFactory(name string) (HandlerFactory, bool) {
+	handlerMu.RLock()
+	defer handlerMu.RUnlock()
+	factory, ok := handlerFactories[name]
+	return factory, ok
+}
+
+// Subscription 动态订阅描述（可序列化）
+type Subscription struct {
+	Id      string                 `json:"id"`
+	Handler string                 `json:"handler"`           // 消费者工厂名称
+	Events  []string               `json:"events,omitempty"`  // 关注的事件（为空则使用消费者自身的 CaseEvent）
+	Filter  string                 `json:"filter,omitempty"`  // 过滤表达式（见 CompileFilter，为空不过滤）
+	Options map[string]interface{} `json:"options,omitempty"` // 工厂参数
+}
+
+// errSubscriptionExists 订阅 ID 已存在
+var errSubscriptionExists = errors.New("订阅已存在")
+
+// subscription 已生效的动态订阅
+type subscription struct {
+	spec     Subscription
+	consumer Consumer
+	wrapped  *subscribedConsumer // consumer 包装的订阅（consumer 可能再包装为保留可选接口的类型）
+}
+
+// subscribedConsumer 按订阅描述包装的消费者（覆盖关注的事件、过滤事件）
+type subscribedConsumer struct {
+	Consumer
+	id      string
+	events  []string
+	filter  *Filter
+	removed atomic.Bool // 订阅已移除
+}
+
+// subscriptionId 订阅 ID（用于统计）
+func (c *subscribedConsumer) subscriptionId() string {
+	return c.id
+}
+
+// unsubscribed 订阅是否已移除（移除后不再记录统计）
+func (c *subscribedConsumer) unsubscribed() bool {
+	return c.removed.Load()
+}
+
+func (c *subscribedConsumer) CaseEvent() []string {
+	if len(c.events) > 0 {
+		return c.events
+	}
+	return c.Consumer.CaseEvent()
+}
+
+// accepts 事件是否满足订阅的过滤条件
+func (c *subscribedConsumer) accepts(event *Event) bool {
+	return c.filter == nil || c.filter.Match(event)
+}
+
+// Timeout 保留被包装消费者的处理超时
+func (c *subscribedConsumer) Timeout() time.Duration {
+	if tc, ok := c.Consumer.(TimeoutConsumer); ok {
+		return tc.Timeout()
+	}
+	return 0
+}
+
+// ackSubscribedConsumer 包装确认模式消费者（保留 AckConsumer 接口）
+type ackSubscribedConsumer struct {
+	*subscribedConsumer
+}
+
+func (c *ackSubscribedConsumer) MaxAttempts() int {
+	return c.Consumer.(AckConsumer).MaxAttempts()
+}
+
+// mailboxSubscribedConsumer 包装独立邮箱消费者（保留 MailboxConsumer 接口）
+type mailboxSubscribedConsumer struct {
+	*subscribedConsumer
+}
+
+func (c *mailboxSubscribedConsumer) MailboxSize() int {
+	return c.Consumer.(MailboxConsumer).MailboxSize()
+}
+
+func (c *mailboxSubscribedConsumer) OverflowPolicy() OverflowPolicy {
+	return c.Consumer.(MailboxConsumer).OverflowPolicy()
+}
+
+// ackMailboxSubscribedConsumer 包装同时为确认模式和独立邮箱的消费者
+type ackMailboxSubscribedConsumer struct {
+	*mailboxSubscribedConsumer
+}
+
+func (c *ackMailboxSubscribedConsumer) MaxAttempts() int {
+	return c.Consumer.(AckConsumer).MaxAttempts()
+}
+
+// Subscribe 按名称创建消费者并订阅，返回订阅 ID
+// 与 Register 不同，动态订阅可以被 Unsubscribe 移除，并会记录到快照中
+func (b *Bus) Subscribe(handler string, events []string, options map[string]interface{}) (string, error) {
+	return b.SubscribeSpec(Subscription{
+		Handler: handler,
+		Events:  events,
+		Options: options,
+	})
+}
+
+// SubscribeSpec 按订阅描述订阅（可指定过滤表达式），返回订阅 ID
+// 过滤表达式在订阅时编译，语法错误直接返回；指定的 ID 已存在时返回错误
+func (b *Bus) SubscribeSpec(spec Subscription) (string, error) {
+	if spec.Id == "" {
+		spec.Id = NewID()
+	}
+	if err := b.subscribe(spec); err != nil {
+		return "", err
+	}
+	return spec.Id, nil
+}
+
+// Unsubscribe 移除动态订阅
+func (b *Bus) Unsubscribe(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub, exists := b.subscriptions[id]
+	if !exists {
+		return false
+	}
+	delete(b.subscriptions, id)
+
+	for _, eventName := range sub.consumer.CaseEvent() {
+		b.consumers.remove(eventName, sub.consumer)
+	}
+	b.removeMailbox(sub.consumer)
+	sub.wrapped.removed.Store(true)
+	b.removeStats(sub.consumer)
+
+	logger.Infof("移除动态订阅: %s (%s)", id, sub.spec.Handler)
+	return true
+}
+
+// Snapshot 导出所有动态订阅（按 ID 排序）
+func (b *Bus) Snapshot() []Subscription {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	specs := make([]Subscription, 0, len(b.subscriptions))
+	for _, sub := range b.subscriptions {
+		specs = append(specs, sub.spec)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Id < specs[j].Id
+	})
+	return specs
+}
+
+// Restore 按快照恢复动态订阅，保留原订阅 ID
+// 已存在的订阅会被跳过；单个订阅恢复失败不影响其他订阅，最后汇总返回错误
+func (b *Bus) Restore(specs []Subscription) error {
+	var failed []string
+	for _, spec := range specs {
+		if spec.Id == "" {
+			spec.Id = NewID()
+		}
+
+		err := b.subscribe(spec)
+		if errors.Is(err, errSubscriptionExists) {
+			continue
+		}
+		if err != nil {
+			logger.Errorf("恢复动态订阅失败: %s (%s), %v", spec.Id, spec.Handler, err)
+			failed = append(failed, spec.Id)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个订阅恢复失败: %v", len(failed), failed)
+	}
+	return nil
+}
+
+// SaveSnapshot 将动态订阅快照写入文件
+func (b *Bus) SaveSnapshot(path string) error {
+	data, err := json.MarshalIndent(b.Snapshot(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化订阅快照失败: %w", err)
+	}
+
+	// 先写临时文件再重命名，避免写到一半时进程退出导致快照损坏
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入订阅快照失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入订阅快照失败: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot 从文件恢复动态订阅，文件不存在时直接返回
+func (b *Bus) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取订阅快照失败: %w", err)
+	}
+
+	var specs []Subscription
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return fmt.Errorf("解析订阅快照失败: %w", err)
+	}
+	return b.Restore(specs)
+}
+
+// subscribe 创建消费者并注册订阅
+func (b *Bus) subscribe(spec Subscription) error {
+	factory, ok := getHandlerFactory(spec.Handler)
+	if !ok {
+		return fmt.Errorf("未注册的消费者: %s", spec.Handler)
+	}
+
+	var filter *Filter
+	if spec.Filter != "" {
+		var err error
+		if filter, err = CompileFilter(spec.Filter); err != nil {
+			return err
+		}
+	}
+
+	consumer, err := factory(spec.Options)
+	if err != nil {
+		return fmt.Errorf("创建消费者失败: %s, %w", spec.Handler, err)
+	}
+	wrapped := &subscribedConsumer{Consumer: consumer, id: spec.Id, events: spec.Events, filter: filter}
+	consumer = wrapSubscribed(consumer, wrapped)
+
+	// 检查 ID 和登记在同一次加锁中完成，避免并发订阅同一 ID 时重复注册
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, exists := b.subscriptions[spec.Id]; exists {
+		return fmt.Errorf("%w: %s", errSubscriptionExists, spec.Id)
+	}
+	b.subscriptions[spec.Id] = &subscription{spec: spec, consumer: consumer, wrapped: wrapped}
+	b.Register(consumer)
+
+	logger.Infof("添加动态订阅: %s (%s)", spec.Id, spec.Handler)
+	return nil
+}
+
+// wrapSubscribed 按被包装消费者实现的可选接口选择包装类型（AckConsumer、MailboxConsumer）
+func wrapSubscribed(consumer Consumer, wrapped *subscribedConsumer) Consumer {
+	_, ack := consumer.(AckConsumer)
+	_, mailbox := consumer.(MailboxConsumer)
+	switch {
+	case ack && mailbox:
+		return &ackMailboxSubscribedConsumer{&mailboxSubscribedConsumer{wrapped}}
+	case ack:
+		return &ackSubscribedConsumer{wrapped}
+	case mailbox:
+		return &mailboxSubscribedConsumer{wrapped}
+	default:
+		return wrapped
+	}
+}
@@ -0,0 +1,44 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// TimeoutConsumer 自定义处理超时的消费者
+// 超时后传给消费者的 ctx 会被取消，消费者需监听 ctx.Done() 才能及时退出
+type TimeoutConsumer interface {
+	Consumer
+
+	// Timeout 单次处理超时时间，<= 0 时使用事件总线的默认超时
+	Timeout() time.Duration
+}
+
+// SetHandlerTimeout 设置消费者默认处理超时（<= 0 表示不限制）
+// 需在 Start 之前设置
+func (b *Bus) SetHandlerTimeout(timeout time.Duration) {
+	b.handlerTimeout = timeout
+}
+
+// GetTimeoutCount 获取处理超时的次数
+func (b *Bus) GetTimeoutCount() uint64 {
+	return b.timeoutCount.Load()
+}
+
+// handlerTimeoutFor 获取消费者的处理超时
+func (b *Bus) handlerTimeoutFor(consumer Consumer) time.Duration {
+	if tc, ok := consumer.(TimeoutConsumer); ok {
+		if timeout := tc.Timeout(); timeout > 0 {
+			return timeout
+		}
+	}
+	return b.handlerTimeout
+}
+
+// handlerContext 创建消费者上下文，配置了超时时附加超时
+func (b *Bus) handlerContext(parent context.Context, consumer Consumer) (context.Context, context.CancelFunc) {
+	if timeout := b.handlerTimeoutFor(consumer); timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+	return context.WithCancel(parent)
+}
@@ -0,0 +1,59 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/charry/logger"
+)
+
+// TransformFunc 事件转换函数
+// 可直接修改 event（补充字段、规范化数据等）
+// 返回 error 时事件被丢弃，不再分发给消费者
+type TransformFunc func(ctx context.Context, event *Event) error
+
+// transformer 已注册的转换器
+type transformer struct {
+	order uint32
+	fn    TransformFunc
+}
+
+// RegisterTransformer 注册事件转换器
+// 同一事件的转换器按 order 正序执行（数值越小越先执行），在分发给消费者之前完成
+func (b *Bus) RegisterTransformer(eventName string, order uint32, fn TransformFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	list := append(b.transformers[eventName], transformer{order: order, fn: fn})
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].order < list[j].order
+	})
+	b.transformers[eventName] = list
+
+	logger.Infof("注册事件转换器: %s, order=%d", eventName, order)
+}
+
+// transform 依次执行事件转换器
+func (b *Bus) transform(ctx context.Context, event *Event) error {
+	b.mu.RLock()
+	list := b.transformers[event.Name]
+	b.mu.RUnlock()
+
+	for i, t := range list {
+		if err := b.runTransformer(ctx, t, event); err != nil {
+			return fmt.Errorf("转换器 %d (order=%d) 失败: %w", i, t.order, err)
+		}
+	}
+	return nil
+}
+
+// runTransformer 执行单个转换器（捕获 panic）
+func (b *Bus) runTransformer(ctx context.Context, t transformer, event *Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return t.fn(ctx, event)
+}
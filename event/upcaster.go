@@ -0,0 +1,90 @@
+package event
+
+import (
+	"fmt"
+
+	"github.com/charry/logger"
+)
+
+// UpcastFunc 事件数据升级函数，将旧版本的数据迁移为下一版本
+type UpcastFunc func(data interface{}) (interface{}, error)
+
+// RegisterUpcaster 注册事件升级函数，将 fromVersion 版本的数据升级到 fromVersion+1
+// 事件的当前版本为已注册升级函数的最高目标版本，未注册升级函数的事件版本始终为 1
+func (b *Bus) RegisterUpcaster(eventName string, fromVersion int, fn UpcastFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	chain, exists := b.upcasters[eventName]
+	if !exists {
+		chain = make(map[int]UpcastFunc)
+		b.upcasters[eventName] = chain
+	}
+	chain[fromVersion] = fn
+
+	if fromVersion+1 > b.versions[eventName] {
+		b.versions[eventName] = fromVersion + 1
+	}
+
+	logger.Infof("注册事件升级函数: %s v%d -> v%d", eventName, fromVersion, fromVersion+1)
+}
+
+// CurrentVersion 获取事件的当前版本
+func (b *Bus) CurrentVersion(eventName string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.currentVersion(eventName)
+}
+
+// currentVersion 获取事件的当前版本（需持有锁）
+func (b *Bus) currentVersion(eventName string) int {
+	if version, exists := b.versions[eventName]; exists {
+		return version
+	}
+	return 1
+}
+
+// upcast 将事件升级到当前版本
+// 版本为 0 的事件视为新创建的事件，直接标记为当前版本
+func (b *Bus) upcast(event *Event) error {
+	b.mu.RLock()
+	current := b.currentVersion(event.Name)
+	chain := b.upcasters[event.Name]
+	b.mu.RUnlock()
+
+	if event.Version == 0 {
+		event.Version = current
+		return nil
+	}
+	if event.Version > current {
+		return fmt.Errorf("事件版本 v%d 高于当前版本 v%d", event.Version, current)
+	}
+
+	for event.Version < current {
+		fn, exists := chain[event.Version]
+		if !exists {
+			return fmt.Errorf("缺少升级函数: v%d -> v%d", event.Version, event.Version+1)
+		}
+		if err := b.runUpcaster(fn, event); err != nil {
+			return fmt.Errorf("升级 v%d -> v%d 失败: %w", event.Version, event.Version+1, err)
+		}
+		event.Version++
+	}
+	return nil
+}
+
+// runUpcaster 执行单个升级函数（捕获 panic）
+func (b *Bus) runUpcaster(fn UpcastFunc, event *Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	data, err := fn(event.Data)
+	if err != nil {
+		return err
+	}
+	event.Data = data
+	return nil
+}
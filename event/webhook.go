@@ -0,0 +1,288 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// WebhookHandlerName webhook 消费者工厂名称（用于 Subscribe）
+const WebhookHandlerName = "webhook"
+
+// webhook 请求头
+const (
+	WebhookHeaderEvent     = "X-Charry-Event"
+	WebhookHeaderEventId   = "X-Charry-Event-Id"
+	WebhookHeaderTimestamp = "X-Charry-Timestamp"
+	WebhookHeaderSignature = "X-Charry-Signature"
+)
+
+// WebhookOptions webhook 消费者配置
+type WebhookOptions struct {
+	Events  []string          // 关注的事件（为空则关注所有事件）
+	Secret  string            // HMAC-SHA256 签名密钥（为空不签名）
+	Headers map[string]string // 附加请求头
+	Timeout time.Duration     // 单次请求超时（默认 10 秒）
+
+	MaxRetries int           // 失败后最大重试次数（默认 3，< 0 不重试）
+	Backoff    time.Duration // 首次重试间隔，之后翻倍（默认 500 毫秒）
+	MaxBackoff time.Duration // 最大重试间隔（默认 30 秒）
+
+	BreakerThreshold int           // 连续失败多少次后熔断（默认 5，< 0 不熔断）
+	BreakerCooldown  time.Duration // 熔断持续时间，之后放行一次试探请求（默认 30 秒）
+
+	Sync     bool         // 是否同步执行（默认异步）
+	Priority uint32       // 优先级
+	Client   *http.Client // 自定义 HTTP 客户端（为空时按 Timeout 创建）
+}
+
+// webhookPayload webhook 请求体
+type webhookPayload struct {
+	Id            string      `json:"id"`
+	Name          string      `json:"name"`
+	Version       int         `json:"version"`
+	CorrelationId string      `json:"correlation_id,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	Data          interface{} `json:"data,omitempty"`
+}
+
+// WebhookHandler 将事件以 JSON POST 到指定地址的消费者
+type WebhookHandler struct {
+	url     string
+	options WebhookOptions
+	client  *http.Client
+
+	// 熔断状态
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewWebhookHandler 创建 webhook 消费者
+func NewWebhookHandler(url string, options WebhookOptions) (*WebhookHandler, error) {
+	if url == "" {
+		return nil, fmt.Errorf("webhook 地址不能为空")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.MaxRetries == 0 {
+		options.MaxRetries = 3
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = 500 * time.Millisecond
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = 30 * time.Second
+	}
+	if options.BreakerThreshold == 0 {
+		options.BreakerThreshold = 5
+	}
+	if options.BreakerCooldown <= 0 {
+		options.BreakerCooldown = 30 * time.Second
+	}
+
+	client := options.Client
+	if client == nil {
+		client = &http.Client{Timeout: options.Timeout}
+	}
+
+	return &WebhookHandler{
+		url:     url,
+		options: options,
+		client:  client,
+	}, nil
+}
+
+func (h *WebhookHandler) CaseEvent() []string {
+	if len(h.options.Events) == 0 {
+		return []string{AllEvents}
+	}
+	return h.options.Events
+}
+
+func (h *WebhookHandler) Triggered(ctx context.Context, event *Event) error {
+	if err := h.allow(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(&webhookPayload{
+		Id:            event.Id,
+		Name:          event.Name,
+		Version:       event.Version,
+		CorrelationId: event.CorrelationId,
+		CreatedAt:     event.CreatedAt,
+		Data:          event.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化 webhook 请求失败: %w", err)
+	}
+
+	backoff := h.options.Backoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := h.post(ctx, event, body)
+		if err == nil {
+			h.report(true)
+			return nil
+		}
+
+		if !retryable || attempt >= h.options.MaxRetries || ctx.Err() != nil {
+			h.report(false)
+			return fmt.Errorf("webhook 投递失败: %s, %w", h.url, err)
+		}
+
+		logger.Warnf("webhook 投递失败，%v 后重试: %s, %v", backoff, h.url, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			h.report(false)
+			return fmt.Errorf("webhook 投递失败: %s, %w", h.url, ctx.Err())
+		}
+
+		backoff *= 2
+		if backoff > h.options.MaxBackoff {
+			backoff = h.options.MaxBackoff
+		}
+	}
+}
+
+func (h *WebhookHandler) Async() bool {
+	return !h.options.Sync
+}
+
+func (h *WebhookHandler) Priority() uint32 {
+	return h.options.Priority
+}
+
+// SignWebhook 计算请求体签名（sha256=<hex>），接收方用同一密钥校验
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post 发送一次请求，返回失败是否可重试
+func (h *WebhookHandler) post(ctx context.Context, event *Event, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range h.options.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set(WebhookHeaderEvent, event.Name)
+	req.Header.Set(WebhookHeaderEventId, event.Id)
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(time.Now().Unix(), 10))
+	if h.options.Secret != "" {
+		req.Header.Set(WebhookHeaderSignature, SignWebhook(h.options.Secret, body))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return true, err // 网络错误可重试
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	// 5xx 和 429 可重试，其余 4xx 视为请求本身有误
+	err = fmt.Errorf("响应状态码 %d", resp.StatusCode)
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
+
+// allow 熔断检查：熔断期间直接失败，冷却后只放行一个试探请求
+func (h *WebhookHandler) allow() error {
+	if h.options.BreakerThreshold < 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.failures < h.options.BreakerThreshold {
+		return nil
+	}
+	if time.Now().Before(h.openUntil) || h.probing {
+		return fmt.Errorf("webhook 已熔断: %s", h.url)
+	}
+	h.probing = true
+	return nil
+}
+
+// report 记录投递结果，更新熔断状态
+func (h *WebhookHandler) report(success bool) {
+	if h.options.BreakerThreshold < 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.probing = false
+	if success {
+		h.failures = 0
+		return
+	}
+
+	h.failures++
+	if h.failures >= h.options.BreakerThreshold {
+		h.openUntil = time.Now().Add(h.options.BreakerCooldown)
+		logger.Errorf("webhook 连续失败 %d 次，熔断 %v: %s", h.failures, h.options.BreakerCooldown, h.url)
+	}
+}
+
+// newWebhookFromOptions 从订阅参数创建 webhook 消费者
+// 参数：url、secret、headers（对象）、timeout / backoff（时长字符串）、max_retries、sync
+func newWebhookFromOptions(options map[string]interface{}) (Consumer, error) {
+	url, _ := options["url"].(string)
+
+	var opts WebhookOptions
+	opts.Secret, _ = options["secret"].(string)
+	opts.Sync, _ = options["sync"].(bool)
+
+	if headers, ok := options["headers"].(map[string]interface{}); ok {
+		opts.Headers = make(map[string]string, len(headers))
+		for key, value := range headers {
+			opts.Headers[key] = fmt.Sprintf("%v", value)
+		}
+	}
+	if retries, ok := options["max_retries"].(float64); ok {
+		opts.MaxRetries = int(retries)
+	}
+	for key, target := range map[string]*time.Duration{"timeout": &opts.Timeout, "backoff": &opts.Backoff} {
+		if value, ok := options[key].(string); ok {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("webhook 参数 %s 格式错误: %w", key, err)
+			}
+			*target = d
+		}
+	}
+
+	handler, err := NewWebhookHandler(url, opts)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+func init() {
+	RegisterHandler(WebhookHandlerName, newWebhookFromOptions)
+}
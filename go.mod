@@ -4,6 +4,7 @@ go 1.25.3
 
 require (
 	github.com/hashicorp/consul/api v1.33.0
+	github.com/quic-go/quic-go v0.58.0
 	go.uber.org/zap v1.27.1
 )
 
@@ -24,6 +25,7 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mitchellh/go-homedir v1.1.0 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/crypto v0.43.0 // indirect
 	golang.org/x/exp v0.0.0-20250808145144-a408d31f581a // indirect
 	golang.org/x/net v0.46.1-0.20251013234738-63d1a5100f82 // indirect
 	golang.org/x/sys v0.37.0 // indirect
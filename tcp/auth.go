@@ -0,0 +1,156 @@
+package tcp
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/charry/config"
+	"github.com/charry/constants/event_name"
+	"github.com/charry/event"
+	"github.com/charry/logger"
+)
+
+// HandshakeUnauthorizedCode 认证失败的响应码（Payload 为原因）
+const HandshakeUnauthorizedCode = CodeUnauthorized
+
+// 认证失败锁定默认参数
+const (
+	DefaultAuthMaxFailures = 5               // 同一 IP 连续认证失败达到该次数后锁定
+	DefaultAuthLockout     = 5 * time.Minute // 锁定时长
+)
+
+// AuthFailedEventData 节点认证失败事件数据
+type AuthFailedEventData struct {
+	RemoteAddr string // 对方地址
+	ServiceID  string // 对方声称的服务 ID
+	Reason     string
+	Failures   int           // 该 IP 连续失败次数
+	Locked     bool          // 该 IP 是否已被锁定
+	LockedFor  time.Duration // 锁定时长（未锁定时为 0）
+}
+
+// authFailure IP 认证失败记录
+type authFailure struct {
+	failures    int
+	lockedUntil time.Time
+}
+
+var (
+	// authFailures 认证失败记录：IP -> failure
+	authFailures   = make(map[string]*authFailure)
+	authFailuresMu sync.Mutex
+)
+
+// authEnabled 是否启用 token 认证
+func authEnabled(cfg *config.AuthConfig) bool {
+	return cfg.Token != "" || len(cfg.NodeTokens) > 0
+}
+
+// localToken 本节点握手时出示的 token（按服务 ID 配置的优先于共享密钥）
+func localToken(cfg *config.AuthConfig, serviceID string) string {
+	if token, ok := cfg.NodeTokens[serviceID]; ok {
+		return token
+	}
+	return cfg.Token
+}
+
+// authenticate 校验对方出示的 token
+func authenticate(cfg *config.AuthConfig, remote *Handshake) error {
+	if !authEnabled(cfg) {
+		return nil
+	}
+
+	expected := localToken(cfg, remote.ServiceID)
+	if expected == "" {
+		return fmt.Errorf("未配置该节点的 token: %s", remote.ServiceID)
+	}
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(remote.Token)) != 1 {
+		return fmt.Errorf("token 校验失败: %s", remote.ServiceID)
+	}
+	return nil
+}
+
+// authLockoutOf 锁定参数（未配置时使用默认值）
+func authLockoutOf(cfg *config.AuthConfig) (int, time.Duration) {
+	maxFailures := cfg.MaxFailures
+	if maxFailures <= 0 {
+		maxFailures = DefaultAuthMaxFailures
+	}
+	lockout := DefaultAuthLockout
+	if cfg.Lockout != "" {
+		if d, err := time.ParseDuration(cfg.Lockout); err == nil && d > 0 {
+			lockout = d
+		}
+	}
+	return maxFailures, lockout
+}
+
+// remoteIP 连接对方的 IP
+func remoteIP(conn net.Conn) string {
+	addr := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// authLocked 该 IP 是否处于锁定中
+func authLocked(ip string) bool {
+	authFailuresMu.Lock()
+	defer authFailuresMu.Unlock()
+
+	f, ok := authFailures[ip]
+	return ok && time.Now().Before(f.lockedUntil)
+}
+
+// authFailed 记录一次认证失败并发布 ClusterAuthFailed 事件，达到次数后锁定该 IP
+func authFailed(cfg *config.AuthConfig, conn net.Conn, remote *Handshake, reason error) {
+	maxFailures, lockout := authLockoutOf(cfg)
+	ip := remoteIP(conn)
+	now := time.Now()
+
+	authFailuresMu.Lock()
+	// 清理已过期的记录，避免扫描者的地址无限累积
+	if len(authFailures) > 1024 {
+		for key, f := range authFailures {
+			if now.After(f.lockedUntil) {
+				delete(authFailures, key)
+			}
+		}
+	}
+	f, ok := authFailures[ip]
+	if !ok || (!f.lockedUntil.IsZero() && now.After(f.lockedUntil)) {
+		f = &authFailure{}
+		authFailures[ip] = f
+	}
+	f.failures++
+	data := &AuthFailedEventData{
+		RemoteAddr: conn.RemoteAddr().String(),
+		ServiceID:  remote.ServiceID,
+		Reason:     reason.Error(),
+		Failures:   f.failures,
+	}
+	if f.failures >= maxFailures {
+		f.lockedUntil = now.Add(lockout)
+		data.Locked = true
+		data.LockedFor = lockout
+	}
+	authFailuresMu.Unlock()
+
+	if data.Locked {
+		logger.Warnf("节点认证连续失败 %d 次，锁定 %s %v: %v", data.Failures, ip, lockout, reason)
+	} else {
+		logger.Warnf("节点认证失败: %s, %v", data.RemoteAddr, reason)
+	}
+	event.PublishEvent(event_name.ClusterAuthFailed, data)
+}
+
+// authSucceeded 认证成功，清除该 IP 的失败记录
+func authSucceeded(conn net.Conn) {
+	authFailuresMu.Lock()
+	defer authFailuresMu.Unlock()
+	delete(authFailures, remoteIP(conn))
+}
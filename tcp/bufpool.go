@@ -0,0 +1,62 @@
+package tcp
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// 缓冲池按容量分级（2 的幂）复用字节缓冲区，减少高消息速率下编码、解码消息的内存分配
+const (
+	minBufferClass = 8  // 最小分级 256 字节
+	maxBufferClass = 22 // 最大分级 4MB，更大的缓冲区不复用
+)
+
+// bufferPools 各分级的缓冲池：分级 c 中的缓冲区容量不小于 1<<c
+var bufferPools [maxBufferClass + 1]sync.Pool
+
+// Buffer 缓冲池中的字节缓冲区
+// 用完后调用 Release 归还，归还后不能再使用 B 及其切片（包括引用它的消息 Payload）
+type Buffer struct {
+	B []byte
+}
+
+// AcquireBuffer 从缓冲池取得长度为 size 的缓冲区（内容未清零）
+func AcquireBuffer(size int) *Buffer {
+	class := bufferClass(size)
+	if class > maxBufferClass {
+		return &Buffer{B: make([]byte, size)}
+	}
+	if b, ok := bufferPools[class].Get().(*Buffer); ok {
+		b.B = b.B[:size]
+		return b
+	}
+	return &Buffer{B: make([]byte, size, 1<<class)}
+}
+
+// Release 归还缓冲区（b 为 nil 时不做任何事），每个缓冲区只能归还一次
+func (b *Buffer) Release() {
+	if b == nil || cap(b.B) < 1<<minBufferClass {
+		return
+	}
+	// 按容量向下取整归入分级（追加写入后容量可能不是 2 的幂）
+	class := bits.Len(uint(cap(b.B))) - 1
+	if class > maxBufferClass {
+		return
+	}
+	b.B = b.B[:0]
+	bufferPools[class].Put(b)
+}
+
+// Write 追加数据（实现 io.Writer）
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.B = append(b.B, p...)
+	return len(p), nil
+}
+
+// bufferClass 容纳 size 字节的最小分级
+func bufferClass(size int) int {
+	if size <= 1<<minBufferClass {
+		return minBufferClass
+	}
+	return bits.Len(uint(size - 1))
+}
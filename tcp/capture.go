@@ -0,0 +1,266 @@
+package tcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// 抓包（调试模式）：记录连接上收发的每条消息的消息头（可选消息体前若干字节的十六进制），
+// 保存在环形缓冲区中供运行时查看（管理接口），也可同时追加写入文件（JSON Lines）
+// 收到的消息在解码时记录，发出的消息在写入连接（发送队列、写入加锁的连接）后重新解码记录，只应在排查协议问题时短时间开启
+// 系统模块（HeartbeatModule）的消息体从不记录：握手携带认证 token，配置下发携带完整配置
+
+// 抓包默认参数
+const (
+	DefaultCaptureSize = 1024 // 环形缓冲区容量（条数）
+	capturePayloadMax  = 4096 // 记录的消息体字节数上限
+)
+
+// 抓包方向
+const (
+	CaptureIn  = "in"  // 收到的消息
+	CaptureOut = "out" // 发出的消息
+)
+
+// CaptureOptions 抓包选项
+type CaptureOptions struct {
+	Size         int      `json:"size"`          // 环形缓冲区容量（条数，默认 DefaultCaptureSize）
+	PayloadBytes int      `json:"payload_bytes"` // 记录消息体前多少字节的十六进制（0 不记录，最多 capturePayloadMax，系统模块的消息不记录）
+	Modules      []uint32 `json:"modules"`       // 只记录这些模块的消息（为空时记录所有模块）
+	Heartbeats   bool     `json:"heartbeats"`    // 是否记录心跳（默认不记录）
+	File         string   `json:"file"`          // 同时追加写入的文件（为空时只保存在内存，只应由本进程的代码设置，不接受来自管理接口的值）
+}
+
+// CapturedFrame 记录的一条消息
+type CapturedFrame struct {
+	Time      time.Time         `json:"time"`
+	Dir       string            `json:"dir"` // CaptureIn、CaptureOut
+	Local     string            `json:"local,omitempty"`
+	Remote    string            `json:"remote,omitempty"`
+	Type      string            `json:"type"` // req、resp、stream
+	Module    uint32            `json:"module"`
+	Cmd       uint32            `json:"cmd"`
+	SessionId string            `json:"session_id"`
+	Code      uint32            `json:"code,omitempty"`  // 响应码（响应消息）
+	Kind      byte              `json:"kind,omitempty"`  // 帧类型（分块消息）
+	Flags     byte              `json:"flags,omitempty"` // 消息类型字节中的标志（FlagChecksum、FlagCompressed 等）
+	Size      int               `json:"size"`            // 消息在连接上的字节数
+	Length    int               `json:"length"`          // 消息体字节数（解压后）
+	Trace     string            `json:"trace,omitempty"` // 追踪上下文（traceparent 格式）
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Payload   string            `json:"payload,omitempty"` // 消息体前 PayloadBytes 字节的十六进制
+}
+
+// CaptureStatus 抓包状态
+type CaptureStatus struct {
+	Enabled  bool           `json:"enabled"`
+	Options  CaptureOptions `json:"options"`
+	Started  time.Time      `json:"started"`
+	Captured uint64         `json:"captured"` // 累计记录的消息数（超过容量时较早的被覆盖）
+}
+
+// capture 一次抓包：环形缓冲区和输出文件
+type capture struct {
+	opts    CaptureOptions
+	started time.Time
+
+	mu     sync.Mutex
+	frames []CapturedFrame
+	next   int
+	count  uint64
+	file   *os.File
+}
+
+var (
+	// capturing 是否正在抓包（收发消息时只检查该标志）
+	capturing atomic.Bool
+
+	// currentCapture 正在进行或最近一次的抓包（停止后仍可查看记录）
+	currentCapture atomic.Pointer[capture]
+	captureMu      sync.Mutex
+)
+
+// StartCapture 开始抓包（正在抓包时按新选项重新开始，之前的记录被丢弃）
+func StartCapture(opts CaptureOptions) error {
+	if opts.Size <= 0 {
+		opts.Size = DefaultCaptureSize
+	}
+	opts.PayloadBytes = min(max(opts.PayloadBytes, 0), capturePayloadMax)
+
+	c := &capture{opts: opts, started: time.Now(), frames: make([]CapturedFrame, 0, opts.Size)}
+	if opts.File != "" {
+		file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("打开抓包文件失败: %w", err)
+		}
+		c.file = file
+	}
+
+	captureMu.Lock()
+	defer captureMu.Unlock()
+	if old := currentCapture.Swap(c); old != nil {
+		old.close()
+	}
+	capturing.Store(true)
+	logger.Warnf("开始抓包: 容量 %d, 消息体 %d 字节, 模块 %v, 文件 %q", opts.Size, opts.PayloadBytes, opts.Modules, opts.File)
+	return nil
+}
+
+// StopCapture 停止抓包（记录保留到下次开始抓包）
+func StopCapture() {
+	captureMu.Lock()
+	defer captureMu.Unlock()
+	if !capturing.Swap(false) {
+		return
+	}
+	if c := currentCapture.Load(); c != nil {
+		c.close()
+		logger.Infof("停止抓包: 累计 %d 条", c.status().Captured)
+	}
+}
+
+// CapturedFrames 最近记录的消息（按时间顺序）
+func CapturedFrames() []CapturedFrame {
+	c := currentCapture.Load()
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.frames) < c.opts.Size {
+		return slices.Clone(c.frames)
+	}
+	return append(slices.Clone(c.frames[c.next:]), c.frames[:c.next]...)
+}
+
+// GetCaptureStatus 抓包状态
+func GetCaptureStatus() CaptureStatus {
+	c := currentCapture.Load()
+	if c == nil {
+		return CaptureStatus{}
+	}
+	status := c.status()
+	status.Enabled = capturing.Load()
+	return status
+}
+
+func (c *capture) status() CaptureStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CaptureStatus{Options: c.opts, Started: c.started, Captured: c.count}
+}
+
+// close 关闭输出文件
+func (c *capture) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+	}
+}
+
+// add 记录一条消息（按选项过滤）
+func (c *capture) add(frame CapturedFrame) {
+	if !c.opts.Heartbeats && IsHeartbeatMsg(frame.Module, frame.Cmd) {
+		return
+	}
+	if len(c.opts.Modules) > 0 && !slices.Contains(c.opts.Modules, frame.Module) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.frames) < c.opts.Size {
+		c.frames = append(c.frames, frame)
+	} else {
+		c.frames[c.next] = frame
+		c.next = (c.next + 1) % c.opts.Size
+	}
+	c.count++
+	if c.file != nil {
+		if line, err := json.Marshal(frame); err == nil {
+			c.file.Write(append(line, '\n'))
+		}
+	}
+}
+
+// addrConn 可以取得本端、对方地址的连接
+type addrConn interface {
+	LocalAddr() net.Addr
+	RemoteAddr() net.Addr
+}
+
+// captureMsg 记录解码后的消息（size 为消息在连接上的字节数）
+func captureMsg(dir string, conn addrConn, msg interface{}, size int, typeByte byte) {
+	c := currentCapture.Load()
+	if c == nil {
+		return
+	}
+	frame := CapturedFrame{
+		Time:  time.Now(),
+		Dir:   dir,
+		Size:  size,
+		Flags: typeByte &^ (msgTypeMask | headerVersionMask),
+	}
+	if conn != nil {
+		if addr := conn.LocalAddr(); addr != nil {
+			frame.Local = addr.String()
+		}
+		if addr := conn.RemoteAddr(); addr != nil {
+			frame.Remote = addr.String()
+		}
+	}
+
+	var payload []byte
+	switch v := msg.(type) {
+	case *ClusterReqMsg:
+		frame.Type, frame.Module, frame.Cmd, frame.SessionId = "req", v.Module, v.Cmd, v.SessionId
+		frame.Metadata, payload = maps.Clone(v.Metadata), v.Payload
+		if v.Trace != nil {
+			frame.Trace = v.Trace.String()
+		}
+	case *ClusterRespMsg:
+		frame.Type, frame.Module, frame.Cmd, frame.SessionId = "resp", v.Module, v.Cmd, v.SessionId
+		frame.Code, frame.Metadata, payload = v.Code, maps.Clone(v.Metadata), v.Payload
+		if v.Trace != nil {
+			frame.Trace = v.Trace.String()
+		}
+	case *ClusterStreamMsg:
+		frame.Type, frame.Module, frame.Cmd, frame.SessionId = "stream", v.Module, v.Cmd, v.SessionId
+		frame.Kind, payload = v.Kind, v.Payload
+	default:
+		return
+	}
+	frame.Length = len(payload)
+	if n := c.opts.PayloadBytes; n > 0 && frame.Module != HeartbeatModule {
+		frame.Payload = hex.EncodeToString(payload[:min(n, len(payload))])
+	}
+	c.add(frame)
+}
+
+// captureWritten 记录写入连接的数据中的各条消息（数据可能包含多条完整的消息）
+func captureWritten(conn addrConn, b []byte) {
+	for len(b) >= HeaderLenSize+1 {
+		size := HeaderLenSize + int(binary.BigEndian.Uint32(b))
+		if size > len(b) {
+			return
+		}
+		if msg, err := decodeMsg(bytes.NewReader(b[:size]), makeFrame); err == nil {
+			captureMsg(CaptureOut, conn, msg, size, b[HeaderLenSize])
+		}
+		b = b[size:]
+	}
+}
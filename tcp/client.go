@@ -0,0 +1,230 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/charry/event"
+)
+
+// DefaultClientTimeout Client.Call 的默认超时（ctx 未设置截止时间时使用）
+const DefaultClientTimeout = 10 * time.Second
+
+// ErrClientClosed 客户端已关闭或连接已断开
+var ErrClientClosed = errors.New("客户端已关闭")
+
+// ClientOptions 客户端选项
+type ClientOptions struct {
+	Frame      FrameOptions // 编码请求的可选项（按握手协商结果确定，见 FrameOptionsOf）
+	FlowWindow int          // 对方在握手时告知的请求窗口（> 0 时按窗口流量控制，见 FeatureFlowControl）
+
+	// OnResponse 收到没有请求在等待的响应时在接收协程中调用（为空时作为孤立响应报告），不能在其中调用 Close
+	OnResponse func(resp *ClusterRespMsg)
+	// OnOrphan 见 PendingOptions.OnOrphan
+	OnOrphan func(resp *ClusterRespMsg, late bool)
+}
+
+// Client 单个连接上的请求客户端：请求按 SessionId 关联响应，同一连接上可同时有多个请求在途
+// 由接收协程读取响应并交给等待的请求，连接断开时在途的请求全部失败
+type Client struct {
+	conn    net.Conn // 底层连接（接收协程读取）
+	writer  net.Conn // 写入加锁（启用流量控制时为 FlowConn）的连接
+	opts    ClientOptions
+	pending *PendingRequests
+
+	done      chan struct{}
+	err       error // 接收协程退出的原因（done 关闭后可读）
+	closeOnce sync.Once
+}
+
+// DialClient 连接节点、完成握手并创建客户端
+func DialClient(ctx context.Context, addr string) (*Client, error) {
+	conn, err := Dial(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %s, %w", addr, err)
+	}
+	remote, err := SendHandshake(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	caps, err := LocalHandshake().Negotiate(remote)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	opts := ClientOptions{Frame: FrameOptionsOf(caps)}
+	if caps.Has(FeatureFlowControl) {
+		opts.FlowWindow = remote.FlowWindow
+	}
+	return NewClient(conn, opts), nil
+}
+
+// NewClient 在已完成握手的连接上创建客户端并启动接收协程
+func NewClient(conn net.Conn, opts ClientOptions) *Client {
+	var writer net.Conn = NewLockedConn(conn)
+	if opts.FlowWindow > 0 {
+		writer = NewFlowConn(writer, FlowOptions{Window: opts.FlowWindow})
+	}
+	c := &Client{
+		conn:    conn,
+		writer:  writer,
+		opts:    opts,
+		pending: NewPendingRequests(PendingOptions{OnOrphan: opts.OnOrphan}),
+		done:    make(chan struct{}),
+	}
+	go c.receiveLoop()
+	return c
+}
+
+// Call 发送请求并等待响应（SessionId 为空时自动生成，ctx 未设置截止时间时使用 DefaultClientTimeout）
+func (c *Client) Call(ctx context.Context, req *ClusterReqMsg) (*ClusterRespMsg, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultClientTimeout)
+		defer cancel()
+	}
+	if req.SessionId == "" {
+		req.SessionId = event.NewID()
+	}
+	if len(req.SessionId) > HeaderSessionIdSize {
+		return nil, fmt.Errorf("sessionId 超过 %d 字节: %s", HeaderSessionIdSize, req.SessionId)
+	}
+
+	// 先登记再发送，避免响应先于登记到达
+	deadline, _ := ctx.Deadline()
+	call, err := c.pending.Add(req.SessionId, deadline)
+	if err != nil {
+		return nil, err
+	}
+	defer c.pending.Remove(req.SessionId)
+	c.pending.Bind(call, c.conn)
+
+	// 接收协程已退出时登记的请求不会再被 Fail
+	select {
+	case <-c.done:
+		return nil, c.closedErr()
+	default:
+	}
+
+	InjectTrace(ctx, req)
+	InjectMetadata(ctx, req)
+	if err := c.write(ctx, EncodeClusterReqMsgWith(req, c.opts.Frame)); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-call.Resp():
+		return resp, nil
+	case err := <-call.Err():
+		return nil, err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("等待响应失败: module=%d, cmd=%d, sessionId=%s, %w",
+			req.Module, req.Cmd, req.SessionId, ctx.Err())
+	}
+}
+
+// Send 发送请求，不等待响应（对方的响应交给 OnResponse）
+func (c *Client) Send(ctx context.Context, req *ClusterReqMsg) error {
+	select {
+	case <-c.done:
+		return c.closedErr()
+	default:
+	}
+	InjectTrace(ctx, req)
+	InjectMetadata(ctx, req)
+	return c.write(ctx, EncodeClusterReqMsgWith(req, c.opts.Frame))
+}
+
+// write 写入一条消息（写入失败时关闭连接，可能只写出了部分消息）
+func (c *Client) write(ctx context.Context, b []byte) error {
+	if _, err := writeWithContext(ctx, c.writer, b); err != nil {
+		c.Close()
+		return fmt.Errorf("发送请求失败: %w", err)
+	}
+	return nil
+}
+
+// Pending 等待响应的请求表统计
+func (c *Client) Pending() PendingStats {
+	return c.pending.Stats()
+}
+
+// RemoteAddr 对方地址
+func (c *Client) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Done 接收协程退出（连接断开或客户端关闭）时关闭
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close 关闭连接，在途的请求返回 ErrClientClosed
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		err = c.writer.Close()
+	})
+	<-c.done
+	return err
+}
+
+// closedErr 接收协程退出后请求失败的原因
+func (c *Client) closedErr() error {
+	if c.err != nil {
+		return fmt.Errorf("%w: %v", ErrClientClosed, c.err)
+	}
+	return ErrClientClosed
+}
+
+// receiveLoop 读取响应并交给等待的请求，连接断开时使在途的请求失败
+// 客户端不发送心跳，对方的读超时短于请求间隔时会断开空闲连接
+func (c *Client) receiveLoop() {
+	defer close(c.done)
+
+	for {
+		msg, err := DecodeMsg(c.conn)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				c.err = err
+			}
+			c.pending.Fail(nil, c.closedErr())
+			c.closeOnce.Do(func() {
+				c.writer.Close()
+			})
+			return
+		}
+
+		resp, ok := msg.(*ClusterRespMsg)
+		if !ok {
+			// 服务端推送的请求（处理器可能再发起请求，不阻塞接收）
+			if req, ok := msg.(*ClusterReqMsg); ok {
+				go HandlePush(c.writer, req, c.opts.Frame)
+			}
+			continue
+		}
+		if resp.Module == HeartbeatModule {
+			if IsFlowCreditMsg(resp.Module, resp.Cmd) {
+				if flow, ok := c.writer.(*FlowConn); ok {
+					flow.Grant(FlowCredits(resp))
+				}
+			}
+			// 其余系统消息（GoAway 等）由调用方按连接断开处理
+			continue
+		}
+		if c.pending.Resolve(resp) {
+			continue
+		}
+		if c.opts.OnResponse != nil {
+			c.opts.OnResponse(resp)
+			continue
+		}
+		c.pending.ReportOrphan(resp)
+	}
+}
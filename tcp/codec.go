@@ -0,0 +1,162 @@
+package tcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// 内置编解码器名称
+const (
+	CodecJSON  = "json"  // JSON（所有节点都支持，协商失败时使用）
+	CodecProto = "proto" // 消息自带 Marshal/Unmarshal 方法的 PB
+)
+
+// Codec 消息体编解码器
+// 消息体本身是不透明的字节，编解码器决定其格式：按 (module, cmd) 声明，未声明时使用连接协商的编解码器
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// ProtoMessage 生成代码自带编解码方法的消息（gogoproto、vtprotobuf 等生成的 PB 消息）
+type ProtoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+// ProtoCodec 使用消息自带 Marshal/Unmarshal 方法的 PB 编解码器
+type ProtoCodec struct{}
+
+func (ProtoCodec) Name() string { return CodecProto }
+
+func (ProtoCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(ProtoMessage)
+	if !ok {
+		return nil, fmt.Errorf("消息未实现 ProtoMessage: %T", v)
+	}
+	return msg.Marshal()
+}
+
+func (ProtoCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(ProtoMessage)
+	if !ok {
+		return fmt.Errorf("消息未实现 ProtoMessage: %T", v)
+	}
+	return msg.Unmarshal(data)
+}
+
+// JSONCodec JSON 编解码器
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string { return CodecJSON }
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+var (
+	// codecs 已注册的编解码器：name -> codec
+	codecs = map[string]Codec{
+		CodecJSON:  JSONCodec{},
+		CodecProto: ProtoCodec{},
+	}
+
+	// preferredCodec 本节点作为客户端时希望使用的编解码器（握手时提出，对方支持时采用）
+	preferredCodec = CodecJSON
+
+	// routeCodecs 按 (module, cmd) 声明的编解码器：(module << 32 | cmd) -> name
+	routeCodecs = make(map[uint64]string)
+
+	codecsMu sync.RWMutex
+)
+
+// RegisterCodec 注册编解码器（如 msgpack），同名时覆盖
+// 需在建立连接之前注册：握手时告知对方本节点支持的编解码器
+func RegisterCodec(name string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[name] = codec
+}
+
+// GetCodec 获取已注册的编解码器
+func GetCodec(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[name]
+	return codec, ok
+}
+
+// Codecs 已注册的编解码器名称（排序）
+func Codecs() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetPreferredCodec 设置本节点连接其他节点时希望使用的编解码器（默认 json）
+// 握手时提出，对方也支持时该连接上未声明编解码器的消息使用它，否则使用 json
+func SetPreferredCodec(name string) error {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, ok := codecs[name]; !ok {
+		return fmt.Errorf("编解码器未注册: %s", name)
+	}
+	preferredCodec = name
+	return nil
+}
+
+// PreferredCodec 本节点希望使用的编解码器
+func PreferredCodec() string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	return preferredCodec
+}
+
+// SetRouteCodec 声明 (module, cmd) 的消息体使用的编解码器（优先于连接协商的编解码器，双方需声明一致）
+func SetRouteCodec(module, cmd uint32, name string) error {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, ok := codecs[name]; !ok {
+		return fmt.Errorf("编解码器未注册: %s", name)
+	}
+	routeCodecs[uint64(module)<<32|uint64(cmd)] = name
+	return nil
+}
+
+// CodecFor 获取 (module, cmd) 使用的编解码器：已声明时使用声明的，否则使用连接协商的 negotiated（为空或未注册时为 json）
+func CodecFor(module, cmd uint32, negotiated string) Codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	if name, ok := routeCodecs[uint64(module)<<32|uint64(cmd)]; ok {
+		return codecs[name]
+	}
+	if codec, ok := codecs[negotiated]; ok {
+		return codec
+	}
+	return JSONCodec{}
+}
+
+// negotiateCodec 服务端选择连接使用的编解码器：客户端提出的编解码器本节点也支持时采用，否则使用 json
+func negotiateCodec(proposed string) string {
+	if _, ok := GetCodec(proposed); ok {
+		return proposed
+	}
+	return CodecJSON
+}
+
+// Codec 请求消息体的编解码器（按 (module, cmd) 声明或请求所在连接协商的结果）
+func (r *ClusterReqMsg) Codec() Codec {
+	return CodecFor(r.Module, r.Cmd, r.codec)
+}
@@ -0,0 +1,95 @@
+package tcp
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultCompressThreshold 默认的压缩阈值（消息体达到该字节数才压缩，太小的消息压缩收益低于开销）
+const DefaultCompressThreshold = 1024
+
+// compressThreshold 压缩阈值
+var compressThreshold atomic.Int64
+
+// flateWriters 复用压缩器（创建压缩器的开销远大于压缩小消息本身）
+var flateWriters = sync.Pool{
+	New: func() any {
+		w, _ := flate.NewWriter(nil, flate.BestSpeed)
+		return w
+	},
+}
+
+// flateReaders 复用解压器（每个解压器带有数十 KB 的窗口）
+var flateReaders = sync.Pool{
+	New: func() any {
+		return flate.NewReader(nil)
+	},
+}
+
+func init() {
+	compressThreshold.Store(DefaultCompressThreshold)
+}
+
+// SetCompressThreshold 设置压缩阈值（<= 0 时使用默认值）
+// 只在对方协商了 FeatureCompression 的连接上压缩，接收端按消息的 FlagCompressed 标志解压
+func SetCompressThreshold(size int64) {
+	if size <= 0 {
+		size = DefaultCompressThreshold
+	}
+	compressThreshold.Store(size)
+}
+
+// CompressThreshold 压缩阈值
+func CompressThreshold() int64 {
+	return compressThreshold.Load()
+}
+
+// compressPayload 压缩消息体（DEFLATE），压缩结果在缓冲池的缓冲区中，复制到消息后由调用方归还
+// 未达到阈值或压缩后没有变小时返回原消息体和 nil，调用方按原样发送
+func compressPayload(payload []byte) ([]byte, *Buffer) {
+	if int64(len(payload)) < CompressThreshold() {
+		return payload, nil
+	}
+
+	buf := AcquireBuffer(len(payload) / 2)
+	buf.B = buf.B[:0]
+	w := flateWriters.Get().(*flate.Writer)
+	defer flateWriters.Put(w)
+	w.Reset(buf)
+	if _, err := w.Write(payload); err != nil {
+		buf.Release()
+		return payload, nil
+	}
+	if err := w.Close(); err != nil {
+		buf.Release()
+		return payload, nil
+	}
+	if len(buf.B) >= len(payload) {
+		buf.Release()
+		return payload, nil
+	}
+	return buf.B, buf
+}
+
+// decompressPayload 解压消息体；数据损坏或解压后超过 MaxFrameSize 时返回 ErrProtocol
+func decompressPayload(data []byte) ([]byte, error) {
+	r := flateReaders.Get().(io.ReadCloser)
+	defer flateReaders.Put(r)
+	if err := r.(flate.Resetter).Reset(bytes.NewReader(data), nil); err != nil {
+		return nil, fmt.Errorf("%w: 解压消息体失败: %v", ErrProtocol, err)
+	}
+
+	limit := MaxFrameSize()
+	payload, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: 解压消息体失败: %v", ErrProtocol, err)
+	}
+	if int64(len(payload)) > limit {
+		return nil, fmt.Errorf("%w: 解压后消息体超过上限 %d", ErrProtocol, limit)
+	}
+	return payload, nil
+}
@@ -0,0 +1,158 @@
+package tcp
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// I/O 超时默认值
+const (
+	DefaultWriteTimeout = 10 * time.Second // 写入一条消息的超时
+	DefaultReadTimeout  = 30 * time.Second // 等待下一条消息的超时（默认心跳间隔的 3 倍，给予足够余量）
+)
+
+var (
+	// writeTimeout、readTimeout 全局 I/O 超时（0 表示不超时）
+	writeTimeout atomic.Int64
+	readTimeout  atomic.Int64
+)
+
+func init() {
+	writeTimeout.Store(int64(DefaultWriteTimeout))
+	readTimeout.Store(int64(DefaultReadTimeout))
+}
+
+// SetIOTimeouts 设置全局 I/O 超时（0 表示不超时，需在建立连接之前设置）
+// 写超时作用于之后创建的 LockedConn 的每次写入，对方停止接收时写入不会永久阻塞；
+// 读超时作用于服务端和连接池连接的每条消息，超时未收到完整的消息时断开连接
+func SetIOTimeouts(read, write time.Duration) {
+	readTimeout.Store(int64(max(read, 0)))
+	writeTimeout.Store(int64(max(write, 0)))
+}
+
+// WriteTimeout 全局写超时
+func WriteTimeout() time.Duration {
+	return time.Duration(writeTimeout.Load())
+}
+
+// ReadTimeout 全局读超时
+func ReadTimeout() time.Duration {
+	return time.Duration(readTimeout.Load())
+}
+
+// ReadFrame 读取一条消息，timeout 大于 0 时先设置读截止时间（超时未收到完整的消息时返回超时错误）
+func ReadFrame(conn net.Conn, timeout time.Duration) (interface{}, error) {
+	if timeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+	}
+	return DecodeMsg(conn)
+}
+
+// ReadFramePooled 与 ReadFrame 相同，但消息数据使用缓冲池的缓冲区（见 DecodeMsgPooled）
+func ReadFramePooled(conn net.Conn, timeout time.Duration) (interface{}, *Buffer, error) {
+	if timeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+	}
+	return DecodeMsgPooled(conn)
+}
+
+// LockedConn 写入加锁的连接，多个协程可并发写入完整消息而不交错
+// 每次写入都有写超时（默认为全局写超时），超时时可能只写出了部分消息，调用方应关闭连接
+type LockedConn struct {
+	net.Conn
+	writeMu      sync.Mutex
+	writeTimeout time.Duration
+}
+
+// NewLockedConn 包装连接（写超时为全局写超时）
+func NewLockedConn(conn net.Conn) *LockedConn {
+	return &LockedConn{Conn: conn, writeTimeout: WriteTimeout()}
+}
+
+// SetWriteTimeout 设置每次写入的超时（0 表示不超时）
+func (c *LockedConn) SetWriteTimeout(timeout time.Duration) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.writeTimeout = max(timeout, 0)
+}
+
+// Write 加锁写入（每次调用应写入一条完整消息）
+func (c *LockedConn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.writeTimeout > 0 {
+		c.Conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+		defer c.Conn.SetWriteDeadline(time.Time{})
+	}
+	n, err := c.Conn.Write(b)
+	if err == nil && capturing.Load() {
+		captureWritten(c.Conn, b)
+	}
+	return n, err
+}
+
+// WriteContext 加锁写入，ctx 的截止时间和写超时中较早的作为写截止时间，ctx 取消时中断写入
+// 写入被中断时可能只写出了部分消息，调用方应关闭连接
+func (c *LockedConn) WriteContext(ctx context.Context, b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	n, err := writeContext(ctx, c.Conn, b, c.writeTimeout)
+	if err == nil && capturing.Load() {
+		captureWritten(c.Conn, b)
+	}
+	return n, err
+}
+
+// contextWriter 支持按 ctx 写入的连接（LockedConn、QueuedConn、FlowConn）
+type contextWriter interface {
+	WriteContext(ctx context.Context, b []byte) (int, error)
+}
+
+// writeWithContext 连接支持时按 ctx 写入，否则直接写入
+func writeWithContext(ctx context.Context, conn net.Conn, b []byte) (int, error) {
+	if w, ok := conn.(contextWriter); ok {
+		return w.WriteContext(ctx, b)
+	}
+	return conn.Write(b)
+}
+
+// writeContext 写入一条消息：ctx 的截止时间和写超时中较早的作为写截止时间，ctx 取消时中断写入（调用方需保证没有并发写入）
+func writeContext(ctx context.Context, conn net.Conn, b []byte, timeout time.Duration) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+	if timeout > 0 {
+		if d := time.Now().Add(timeout); !hasDeadline || d.Before(deadline) {
+			deadline, hasDeadline = d, true
+		}
+	}
+	if hasDeadline {
+		conn.SetWriteDeadline(deadline)
+	}
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetWriteDeadline(time.Now())
+		close(interrupted)
+	})
+
+	n, err := conn.Write(b)
+
+	// 等待中断回调结束后再恢复写超时，避免影响之后的写入
+	stopped := stop()
+	if !stopped {
+		<-interrupted
+	}
+	if !stopped || hasDeadline {
+		conn.SetWriteDeadline(time.Time{})
+	}
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return n, err
+}
@@ -0,0 +1,165 @@
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// RejectPolicy 连接超过限制时的处理方式
+type RejectPolicy int
+
+const (
+	RejectClose RejectPolicy = iota // 接受后立即关闭（对方按重连退避重试）
+	RejectWait                      // 暂停接受连接，直到有连接断开、有新的接受额度（新连接在监听队列中等待）
+)
+
+// ParseRejectPolicy 解析连接超过限制时的处理方式（close、wait，空串为 close）
+func ParseRejectPolicy(s string) (RejectPolicy, error) {
+	switch s {
+	case "", "close":
+		return RejectClose, nil
+	case "wait":
+		return RejectWait, nil
+	default:
+		return RejectClose, fmt.Errorf("未知的连接拒绝方式: %q", s)
+	}
+}
+
+// ConnLimits 服务器连接限制（零值表示不限制），防止连接风暴和配置错误的客户端耗尽资源
+type ConnLimits struct {
+	MaxConns    int          // 最多同时保持的连接数（<= 0 不限制）
+	AcceptRate  float64      // 每秒最多接受的新连接数（<= 0 不限制）
+	AcceptBurst int          // 接受新连接的突发容量（默认为每秒连接数向上取整）
+	Reject      RejectPolicy // 超过限制时的处理方式
+}
+
+// rejectLogEvery 每拒绝多少个连接记录一次日志（避免连接风暴时刷屏）
+const rejectLogEvery = 100
+
+// SetConnLimits 设置连接限制（需在 Start 之前调用）
+func (s *Server) SetConnLimits(limits ConnLimits) {
+	s.limits = limits
+	s.slots = nil
+	if limits.MaxConns > 0 {
+		s.slots = make(chan struct{}, limits.MaxConns)
+	}
+	s.acceptLimiter = newAcceptLimiter(limits.AcceptRate, limits.AcceptBurst)
+}
+
+// RejectedConns 因超过连接限制被拒绝的连接数
+func (s *Server) RejectedConns() uint64 {
+	return s.rejected.Load()
+}
+
+// waitAdmission 等待连接名额和接受额度（RejectWait，在 Accept 之前调用），服务器停止时返回 false
+func (s *Server) waitAdmission() bool {
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+		case <-s.ctx.Done():
+			return false
+		}
+	}
+	if err := s.acceptLimiter.wait(s.ctx); err != nil {
+		s.releaseSlot()
+		return false
+	}
+	return true
+}
+
+// admit 检查新连接是否超过限制（RejectClose，在 Accept 之后调用），超过时关闭连接并返回 false
+func (s *Server) admit(conn net.Conn) bool {
+	reason := ""
+	if !s.acceptLimiter.allow() {
+		reason = fmt.Sprintf("超过每秒 %v 个新连接", s.limits.AcceptRate)
+	} else if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			reason = fmt.Sprintf("连接数达到上限 %d", s.limits.MaxConns)
+		}
+	}
+	if reason == "" {
+		return true
+	}
+
+	conn.Close()
+	if n := s.rejected.Add(1); n == 1 || n%rejectLogEvery == 0 {
+		logger.Warnf("%s，拒绝连接: %s（累计拒绝 %d）", reason, conn.RemoteAddr(), n)
+	}
+	return false
+}
+
+// releaseSlot 连接结束时归还名额
+func (s *Server) releaseSlot() {
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// acceptLimiter 接受新连接的令牌桶（为 nil 时不限制）
+type acceptLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// newAcceptLimiter 创建令牌桶（rate <= 0 时返回 nil）
+func newAcceptLimiter(rate float64, burst int) *acceptLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	b := float64(burst)
+	if b <= 0 {
+		b = math.Ceil(rate)
+	}
+	return &acceptLimiter{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+// reserve 取一个令牌，令牌不足时返回需要等待的时间
+func (l *acceptLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// allow 是否有令牌（有则取走）
+func (l *acceptLimiter) allow() bool {
+	return l == nil || l.reserve() == 0
+}
+
+// wait 等待并取走一个令牌，ctx 结束时返回错误
+func (l *acceptLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		delay := l.reserve()
+		if delay == 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
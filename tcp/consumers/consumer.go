@@ -1,6 +1,8 @@
 package consumers
 
 import (
+	"context"
+
 	"github.com/charry/config"
 	"github.com/charry/constants/event_name"
 	"github.com/charry/constants/priority"
@@ -14,10 +16,10 @@ import (
 type TCPServerStartConsumer struct{}
 
 func (c *TCPServerStartConsumer) CaseEvent() []string {
-	return []string{event_name.ConsulClientCreated}
+	return []string{event_name.ConsulClientCreated, event_name.ClusterGossipStart}
 }
 
-func (c *TCPServerStartConsumer) Triggered(evt *event.Event) error {
+func (c *TCPServerStartConsumer) Triggered(ctx context.Context, evt *event.Event) error {
 	logger.Info("初始化 TCP 服务器...")
 
 	// 获取最新配置
@@ -47,7 +49,7 @@ func (c *TCPServerStopConsumer) CaseEvent() []string {
 	return []string{event_name.AppShutdown}
 }
 
-func (c *TCPServerStopConsumer) Triggered(evt *event.Event) error {
+func (c *TCPServerStopConsumer) Triggered(ctx context.Context, evt *event.Event) error {
 	logger.Info("关闭 TCP 模块...")
 	tcp.Close()
 	return nil
@@ -61,8 +63,38 @@ func (c *TCPServerStopConsumer) Priority() uint32 {
 	return priority.RPCServerStop
 }
 
+// IPFilterSyncConsumer IP 过滤规则同步消费者
+// 配置变更后（如 Consul KV 更新）按最新配置重新加载来源 IP 规则，配置无效时保留原有规则
+type IPFilterSyncConsumer struct{}
+
+func (c *IPFilterSyncConsumer) CaseEvent() []string {
+	return []string{event_name.ConfigChanged}
+}
+
+func (c *IPFilterSyncConsumer) Triggered(ctx context.Context, evt *event.Event) error {
+	if tcp.GlobalServer == nil {
+		return nil
+	}
+
+	cfg := config.Get()
+	if err := tcp.SetupIPFilter(cfg.Server.ClusterIPFilter); err != nil {
+		logger.Errorf("重新加载 IP 过滤规则失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (c *IPFilterSyncConsumer) Async() bool {
+	return false // 同步执行
+}
+
+func (c *IPFilterSyncConsumer) Priority() uint32 {
+	return priority.ConsulConfigLoad + 1 // 在配置加载之后
+}
+
 // init 自动注册 TCP 相关的事件消费者
 func init() {
 	event.RegisterConsumer(&TCPServerStartConsumer{})
 	event.RegisterConsumer(&TCPServerStopConsumer{})
+	event.RegisterConsumer(&IPFilterSyncConsumer{})
 }
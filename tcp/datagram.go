@@ -0,0 +1,185 @@
+package tcp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/charry/logger"
+)
+
+// UDP 报文：与 TCP 使用相同的消息编码，每个报文为一条完整的请求消息，不回复、不重传
+// 用于允许丢失的消息（指标上报、在线状态等），避免占用 TCP 连接的发送队列
+// 报文不经过握手和 token 认证，只按 IP 过滤规则检查来源地址，只应在可信网络中启用
+const (
+	FeatureDatagram = "datagram" // 本节点在 TCP 同端口接收 UDP 报文
+
+	// MaxDatagramSize 单个报文的最大字节数（避免 IP 分片，超过时改用 TCP 发送）
+	MaxDatagramSize = 1200
+
+	// datagramReadSize 接收缓冲区大小（容纳任意 UDP 报文）
+	datagramReadSize = 64 * 1024
+)
+
+// ErrDatagramTooLarge 编码后的消息超过 MaxDatagramSize
+var ErrDatagramTooLarge = errors.New("消息超过 UDP 报文上限")
+
+var (
+	// datagramRoutes 以 UDP 报文发送的路由：(module << 32 | cmd)
+	datagramRoutes   = make(map[uint64]struct{})
+	datagramRoutesMu sync.RWMutex
+)
+
+// RouteDatagram 之后发往该路由的不等待响应的请求（SendReq）改用 UDP 报文发送
+// 对方未启用 UDP、报文过大或发送失败时仍使用 TCP
+func RouteDatagram(module, cmd uint32) {
+	datagramRoutesMu.Lock()
+	defer datagramRoutesMu.Unlock()
+	datagramRoutes[uint64(module)<<32|uint64(cmd)] = struct{}{}
+}
+
+// UnrouteDatagram 该路由恢复使用 TCP 发送
+func UnrouteDatagram(module, cmd uint32) {
+	datagramRoutesMu.Lock()
+	defer datagramRoutesMu.Unlock()
+	delete(datagramRoutes, uint64(module)<<32|uint64(cmd))
+}
+
+// IsDatagramRoute 该路由是否以 UDP 报文发送
+func IsDatagramRoute(module, cmd uint32) bool {
+	datagramRoutesMu.RLock()
+	defer datagramRoutesMu.RUnlock()
+	_, exists := datagramRoutes[uint64(module)<<32|uint64(cmd)]
+	return exists
+}
+
+// DatagramRoutes 以 UDP 报文发送的路由（按 module、cmd 排序）
+func DatagramRoutes() []Route {
+	datagramRoutesMu.RLock()
+	defer datagramRoutesMu.RUnlock()
+	return routesOf(datagramRoutes)
+}
+
+// EncodeDatagram 将请求编码为 UDP 报文（超过 MaxDatagramSize 时返回 ErrDatagramTooLarge）
+// 报文在缓冲池的缓冲区中，发送后调用 Release 归还
+func EncodeDatagram(req *ClusterReqMsg, opts FrameOptions) (*Buffer, error) {
+	frame := EncodeClusterReqMsgPooled(req, opts)
+	if size := len(frame.B); size > MaxDatagramSize {
+		frame.Release()
+		return nil, fmt.Errorf("%w: %d 字节", ErrDatagramTooLarge, size)
+	}
+	return frame, nil
+}
+
+// DatagramStats 接收 UDP 报文的统计
+type DatagramStats struct {
+	Received uint64 `json:"received"` // 处理的报文数
+	Dropped  uint64 `json:"dropped"`  // 丢弃的报文数（来源地址被拒绝、不符合协议、没有处理器）
+}
+
+// datagramServer 在 TCP 同端口接收 UDP 报文，按请求处理器、服务器路由处理（响应被丢弃）
+type datagramServer struct {
+	conn     *net.UDPConn
+	received atomic.Uint64
+	dropped  atomic.Uint64
+	wg       sync.WaitGroup
+}
+
+// ListenDatagram 在服务器的 TCP 端口上同时接收 UDP 报文（需在 Start 之前调用）
+func (s *Server) ListenDatagram() error {
+	addr, ok := s.listener.Addr().(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("监听地址不是 TCP 地址: %s", s.listener.Addr())
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: addr.IP, Port: addr.Port, Zone: addr.Zone})
+	if err != nil {
+		return fmt.Errorf("创建 UDP 监听失败: %w", err)
+	}
+
+	d := &datagramServer{conn: conn}
+	s.datagram = d
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.serve(s)
+	}()
+	logger.Infof("UDP 报文监听: %s", conn.LocalAddr())
+	return nil
+}
+
+// DatagramStats 接收 UDP 报文的统计（未启用时为零值）
+func (s *Server) DatagramStats() DatagramStats {
+	if s.datagram == nil {
+		return DatagramStats{}
+	}
+	return DatagramStats{Received: s.datagram.received.Load(), Dropped: s.datagram.dropped.Load()}
+}
+
+// serve 接收并依次处理报文，连接关闭时返回
+func (d *datagramServer) serve(s *Server) {
+	buf := make([]byte, datagramReadSize)
+	for {
+		n, addr, err := d.conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			logger.Warnf("接收 UDP 报文失败: %v", err)
+			continue
+		}
+		if !GetIPFilter().Allowed(addr.Addr()) {
+			d.drop("来源地址不在允许范围内: %s", addr)
+			continue
+		}
+
+		msg, err := DecodeMsg(bytes.NewReader(buf[:n]))
+		if err != nil {
+			d.drop("UDP 报文不符合协议: %s, %v", addr, err)
+			continue
+		}
+		req, ok := msg.(*ClusterReqMsg)
+		if !ok || IsHandshakeMsg(req.Module, req.Cmd) {
+			d.drop("UDP 报文只能是业务请求: %s", addr)
+			continue
+		}
+
+		done := s.beginRequest()
+		if d.handle(s, req, net.UDPAddrFromAddrPort(addr)) {
+			d.received.Add(1)
+		} else {
+			d.drop("UDP 报文没有处理器: module=%d, cmd=%d, %s", req.Module, req.Cmd, addr)
+		}
+		done()
+	}
+}
+
+// handle 交给请求处理器或服务器路由处理（不经过去重，对方不知道身份），没有处理器时返回 false
+func (d *datagramServer) handle(s *Server, req *ClusterReqMsg, addr net.Addr) bool {
+	if handler, exists := getReqHandler(req.Module, req.Cmd); exists {
+		handler(req)
+		return true
+	}
+	if fn, exists := s.router.lookup(req.Module, req.Cmd); exists {
+		reqCtx, cancelReq := requestContext(s.ctx, req)
+		defer cancelReq()
+		serve(fn, &RequestContext{Context: reqCtx, Req: req, RemoteAddr: addr})
+		return true
+	}
+	return false
+}
+
+// drop 丢弃报文（每 rejectLogEvery 个记录一次日志）
+func (d *datagramServer) drop(format string, args ...any) {
+	if n := d.dropped.Add(1); n == 1 || n%rejectLogEvery == 0 {
+		logger.Warnf(format+"（累计丢弃 %d）", append(args, n)...)
+	}
+}
+
+// close 停止接收报文
+func (d *datagramServer) close() {
+	d.conn.Close()
+	d.wg.Wait()
+}
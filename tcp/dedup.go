@@ -0,0 +1,124 @@
+package tcp
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dedupMaxEntries 去重窗口内最多记录的请求数（超出时提前淘汰最早的记录）
+const dedupMaxEntries = 100000
+
+// dedupEntry 已收到的请求
+type dedupEntry struct {
+	key  string
+	at   time.Time
+	done bool            // 是否已处理完成
+	resp *ClusterRespMsg // 处理结果（处理器不响应时为 nil）
+}
+
+// dedupCache 接收端请求去重：窗口内按 (对方服务 ID, SessionId) 识别重试、切换节点重发的请求
+type dedupCache struct {
+	window  time.Duration
+	entries map[string]*dedupEntry
+	order   []*dedupEntry // 按收到时间排序，用于过期淘汰
+	mu      sync.Mutex
+}
+
+var (
+	// dedup 请求去重（为 nil 时不去重）
+	dedup atomic.Pointer[dedupCache]
+
+	// dedupHits 被识别为重复的请求数
+	dedupHits atomic.Uint64
+)
+
+// SetDedupWindow 设置请求去重窗口（<= 0 关闭去重）
+// 窗口内来自同一节点、SessionId 相同的请求只执行一次：处理中的重复请求被丢弃，已处理的重复请求直接返回缓存的响应
+// 只对 RegisterReqHandler 注册的请求生效，SessionId 为空的请求不去重
+func SetDedupWindow(window time.Duration) {
+	if window <= 0 {
+		dedup.Store(nil)
+		return
+	}
+	dedup.Store(&dedupCache{
+		window:  window,
+		entries: make(map[string]*dedupEntry),
+	})
+}
+
+// DedupHits 被识别为重复的请求数
+func DedupHits() uint64 {
+	return dedupHits.Load()
+}
+
+// begin 登记请求，返回是否为新请求；重复请求返回已登记的记录
+func (c *dedupCache) begin(key string) (*dedupEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expire(time.Now())
+	if entry, exists := c.entries[key]; exists {
+		dedupHits.Add(1)
+		return entry, false
+	}
+
+	entry := &dedupEntry{key: key, at: time.Now()}
+	c.entries[key] = entry
+	c.order = append(c.order, entry)
+	return entry, true
+}
+
+// finish 记录请求的处理结果
+func (c *dedupCache) finish(entry *dedupEntry, resp *ClusterRespMsg) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.done = true
+	entry.resp = resp
+}
+
+// result 获取已处理请求的结果（处理中返回 false）
+func (c *dedupCache) result(entry *dedupEntry) (*ClusterRespMsg, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return entry.resp, entry.done
+}
+
+// expire 淘汰过期或超出数量的记录（调用方持有锁）
+func (c *dedupCache) expire(now time.Time) {
+	n := 0
+	for n < len(c.order) && (now.Sub(c.order[n].at) > c.window || len(c.order)-n > dedupMaxEntries) {
+		delete(c.entries, c.order[n].key)
+		n++
+	}
+	if n > 0 {
+		c.order = append(c.order[:0:0], c.order[n:]...)
+	}
+}
+
+// handleDedup 按去重窗口处理请求：新请求调用 handler 并缓存结果，重复请求返回缓存的结果
+// 返回 nil 表示不响应
+func handleDedup(peer string, req *ClusterReqMsg, handler ReqHandler) *ClusterRespMsg {
+	c := dedup.Load()
+	if c == nil || req.SessionId == "" {
+		return handler(req)
+	}
+
+	entry, isNew := c.begin(peer + "/" + req.SessionId)
+	if !isNew {
+		// 处理中的请求由原请求响应（响应按 SessionId 关联，对方可在任一连接上收到）
+		resp, done := c.result(entry)
+		if !done || resp == nil {
+			return nil
+		}
+		cached := *resp
+		return &cached
+	}
+
+	resp := handler(req)
+	if resp != nil {
+		resp.SessionId = req.SessionId
+	}
+	c.finish(entry, resp)
+	return resp
+}
@@ -0,0 +1,179 @@
+package tcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// 响应码：0 表示成功，非 0 时 Payload 为标准错误消息体（CodeError 的 JSON：code、message、details）
+// 响应码按模块分段：模块 m 使用 [m*ModuleCodeSpan, (m+1)*ModuleCodeSpan)，
+// 系统模块（HeartbeatModule = 0）的 1-999 为通用响应码，含义与 HTTP 状态码一致
+const ModuleCodeSpan uint32 = 1000
+
+// 通用响应码
+const (
+	CodeOK           uint32 = 0
+	CodeBadRequest   uint32 = 400 // 请求格式错误
+	CodeUnauthorized uint32 = 401 // 未认证
+	CodeForbidden    uint32 = 403 // 拒绝
+	CodeNotFound     uint32 = 404 // 没有对应的处理器或资源
+	CodeTimeout      uint32 = 408 // 处理超时
+	CodeConflict     uint32 = 409 // 状态冲突
+	CodeTooMany      uint32 = 429 // 超过限制
+	CodeInternal     uint32 = 500 // 处理失败
+	CodeUnavailable  uint32 = 503 // 暂时不可用（关闭中、过载）
+
+	// HandlerErrorCode 处理函数返回普通错误时的响应码
+	HandlerErrorCode = CodeInternal
+)
+
+var (
+	// errorCodes 已登记的响应码名称：code -> name
+	errorCodes = map[uint32]string{
+		CodeBadRequest:   "bad_request",
+		CodeUnauthorized: "unauthorized",
+		CodeForbidden:    "forbidden",
+		CodeNotFound:     "not_found",
+		CodeTimeout:      "timeout",
+		CodeConflict:     "conflict",
+		CodeTooMany:      "too_many",
+		CodeInternal:     "internal",
+		CodeUnavailable:  "unavailable",
+	}
+	errorCodesMu sync.RWMutex
+)
+
+// ModuleCode 模块的第 n 个响应码（n 为 1 到 ModuleCodeSpan-1）
+func ModuleCode(module, n uint32) uint32 {
+	return module*ModuleCodeSpan + n
+}
+
+// ModuleOfCode 响应码所属的模块
+func ModuleOfCode(code uint32) uint32 {
+	return code / ModuleCodeSpan
+}
+
+// RegisterErrorCode 登记模块的响应码及其名称，返回完整的响应码
+// n 超出模块的范围或响应码已登记时返回错误
+func RegisterErrorCode(module, n uint32, name string) (uint32, error) {
+	if n == 0 || n >= ModuleCodeSpan {
+		return 0, fmt.Errorf("响应码超出模块范围: module=%d, n=%d（1-%d）", module, n, ModuleCodeSpan-1)
+	}
+	code := ModuleCode(module, n)
+
+	errorCodesMu.Lock()
+	defer errorCodesMu.Unlock()
+	if existing, exists := errorCodes[code]; exists {
+		return 0, fmt.Errorf("响应码已登记: %d (%s)", code, existing)
+	}
+	errorCodes[code] = name
+	return code, nil
+}
+
+// MustRegisterErrorCode 登记模块的响应码，失败时 panic（用于包级变量初始化）
+func MustRegisterErrorCode(module, n uint32, name string) uint32 {
+	code, err := RegisterErrorCode(module, n, name)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+// ErrorCodeName 响应码的名称（未登记时为空串）
+func ErrorCodeName(code uint32) string {
+	errorCodesMu.RLock()
+	defer errorCodesMu.RUnlock()
+	return errorCodes[code]
+}
+
+// ErrorCodeInfo 已登记的响应码
+type ErrorCodeInfo struct {
+	Code   uint32 `json:"code"`
+	Module uint32 `json:"module"`
+	Name   string `json:"name"`
+}
+
+// ErrorCodes 已登记的响应码（按响应码排序）
+func ErrorCodes() []ErrorCodeInfo {
+	errorCodesMu.RLock()
+	defer errorCodesMu.RUnlock()
+
+	codes := make([]ErrorCodeInfo, 0, len(errorCodes))
+	for code, name := range errorCodes {
+		codes = append(codes, ErrorCodeInfo{Code: code, Module: ModuleOfCode(code), Name: name})
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// CodeError 携带响应码的错误，也是错误响应的标准消息体
+// 处理函数返回时以 Code 响应；调用方通过 RespError 取得
+type CodeError struct {
+	Code    uint32            `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"` // 附加信息（字段名、资源 ID 等）
+}
+
+func (e *CodeError) Error() string {
+	if name := ErrorCodeName(e.Code); name != "" {
+		return fmt.Sprintf("code=%d (%s), %s", e.Code, name, e.Message)
+	}
+	return fmt.Sprintf("code=%d, %s", e.Code, e.Message)
+}
+
+// WithDetail 添加附加信息
+func (e *CodeError) WithDetail(key, value string) *CodeError {
+	if e.Details == nil {
+		e.Details = make(map[string]string)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// NewCodeError 创建携带响应码的错误
+func NewCodeError(code uint32, format string, args ...any) error {
+	return &CodeError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewErrorResp 以标准错误消息体响应请求
+func NewErrorResp(req *ClusterReqMsg, code uint32, msg string) *ClusterRespMsg {
+	return errorResp(req, &CodeError{Code: code, Message: msg})
+}
+
+// ErrorResp 以错误响应请求：*CodeError 使用其响应码和附加信息，其他错误使用 HandlerErrorCode
+func ErrorResp(req *ClusterReqMsg, err error) *ClusterRespMsg {
+	var codeErr *CodeError
+	if !errors.As(err, &codeErr) {
+		codeErr = &CodeError{Code: HandlerErrorCode, Message: err.Error()}
+	}
+	return errorResp(req, codeErr)
+}
+
+// errorResp 编码标准错误消息体
+func errorResp(req *ClusterReqMsg, e *CodeError) *ClusterRespMsg {
+	payload, _ := json.Marshal(e)
+	return &ClusterRespMsg{
+		Module:    req.Module,
+		Cmd:       req.Cmd,
+		SessionId: req.SessionId,
+		Code:      e.Code,
+		Trace:     req.Trace,
+		Payload:   payload,
+	}
+}
+
+// RespError 响应中的错误（Code 为 0 时为 nil）
+// 消息体不是标准错误消息体时（旧版本节点、自定义错误）以消息体为错误信息
+func RespError(resp *ClusterRespMsg) *CodeError {
+	if resp.Code == CodeOK {
+		return nil
+	}
+	e := &CodeError{}
+	if err := json.Unmarshal(resp.Payload, e); err == nil && e.Code == resp.Code {
+		return e
+	}
+	return &CodeError{Code: resp.Code, Message: string(resp.Payload)}
+}
@@ -0,0 +1,147 @@
+package tcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/charry/logger"
+)
+
+// 消息头扩展字段（设置 FlagExtended 时位于固定消息头之后、消息体之前，双方协商了 FeatureExtensions 时才会发送）
+// 布局：Count(1) + Count 个 [Kind(1) + Len(2) + Data(Len)]，接收端跳过不认识的 Kind
+const (
+	ExtTrace    byte = 1 // 追踪上下文（TraceContext，25 字节）
+	ExtMetadata byte = 2 // 元数据：Count(2) + Count 个 [KeyLen(1) + Key + ValueLen(2) + Value]
+
+	extHeaderSize = 3 // Kind(1) + Len(2)
+)
+
+// encodeExtensions 编码消息头扩展字段（对方不支持或没有扩展字段时返回 nil）
+func encodeExtensions(trace *TraceContext, metadata map[string]string, opts FrameOptions) []byte {
+	if !opts.Extensions || (trace == nil && len(metadata) == 0) {
+		return nil
+	}
+
+	buf := []byte{0} // Count
+	if trace != nil {
+		data := make([]byte, 0, traceDataSize)
+		data = append(data, trace.TraceID[:]...)
+		data = append(data, trace.SpanID[:]...)
+		data = append(data, trace.Flags)
+		buf = appendExtension(buf, ExtTrace, data)
+	}
+	if len(metadata) > 0 {
+		buf = appendExtension(buf, ExtMetadata, encodeMetadata(metadata))
+	}
+	return buf
+}
+
+// appendExtension 追加一个扩展字段并增加计数
+func appendExtension(buf []byte, kind byte, data []byte) []byte {
+	buf[0]++
+	buf = append(buf, kind)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(data)))
+	return append(buf, data...)
+}
+
+// encodeMetadata 按键排序编码元数据
+// 键为空或超过 255 字节的项、放不下的项（扩展字段最多 65535 字节）被丢弃并记录日志
+func encodeMetadata(metadata map[string]string) []byte {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	buf := []byte{0, 0} // Count
+	count := 0
+	for _, key := range keys {
+		value := metadata[key]
+		size := 1 + len(key) + 2 + len(value)
+		if key == "" || len(key) > math.MaxUint8 || len(buf)+size > math.MaxUint16 {
+			logger.Warnf("元数据过长，丢弃: key=%q, 长度 %d", key, size)
+			continue
+		}
+		buf = append(buf, byte(len(key)))
+		buf = append(buf, key...)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(value)))
+		buf = append(buf, value...)
+		count++
+	}
+	binary.BigEndian.PutUint16(buf[0:2], uint16(count))
+	return buf
+}
+
+// frameExtensions 解码得到的消息头扩展字段
+type frameExtensions struct {
+	trace    *TraceContext
+	metadata map[string]string
+}
+
+// decodeExtensions 解码消息头扩展字段，返回扩展字段和其后的数据
+// 长度越界时返回 ErrProtocol
+func decodeExtensions(data []byte) (frameExtensions, []byte, error) {
+	var ext frameExtensions
+	if len(data) < 1 {
+		return ext, nil, fmt.Errorf("%w: 扩展字段不完整", ErrProtocol)
+	}
+	count := int(data[0])
+	data = data[1:]
+	for range count {
+		if len(data) < extHeaderSize {
+			return ext, nil, fmt.Errorf("%w: 扩展字段不完整", ErrProtocol)
+		}
+		kind := data[0]
+		size := int(binary.BigEndian.Uint16(data[1:3]))
+		if len(data) < extHeaderSize+size {
+			return ext, nil, fmt.Errorf("%w: 扩展字段长度 %d 越界", ErrProtocol, size)
+		}
+		value := data[extHeaderSize : extHeaderSize+size]
+		data = data[extHeaderSize+size:]
+
+		switch kind {
+		case ExtTrace:
+			if size != traceDataSize {
+				return ext, nil, fmt.Errorf("%w: 追踪上下文长度 %d", ErrProtocol, size)
+			}
+			tc := &TraceContext{Flags: value[24]}
+			copy(tc.TraceID[:], value[0:16])
+			copy(tc.SpanID[:], value[16:24])
+			ext.trace = tc
+		case ExtMetadata:
+			metadata, err := decodeMetadata(value)
+			if err != nil {
+				return ext, nil, err
+			}
+			ext.metadata = metadata
+		}
+	}
+	return ext, data, nil
+}
+
+// decodeMetadata 解码元数据
+func decodeMetadata(data []byte) (map[string]string, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("%w: 元数据不完整", ErrProtocol)
+	}
+	count := int(binary.BigEndian.Uint16(data[0:2]))
+	data = data[2:]
+	metadata := make(map[string]string, count)
+	for range count {
+		if len(data) < 1 || len(data) < 1+int(data[0])+2 {
+			return nil, fmt.Errorf("%w: 元数据不完整", ErrProtocol)
+		}
+		keyLen := int(data[0])
+		key := string(data[1 : 1+keyLen])
+		data = data[1+keyLen:]
+		valueLen := int(binary.BigEndian.Uint16(data[0:2]))
+		if len(data) < 2+valueLen {
+			return nil, fmt.Errorf("%w: 元数据长度 %d 越界", ErrProtocol, valueLen)
+		}
+		metadata[key] = string(data[2 : 2+valueLen])
+		data = data[2+valueLen:]
+	}
+	return metadata, nil
+}
@@ -0,0 +1,246 @@
+package tcp
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 流量控制：接收方在握手时告知每个连接的请求窗口（FlowWindow），发送方在途（对方尚未处理完）的业务请求达到窗口时等待，
+// 接收方每处理完一部分请求就发放相应的额度（FlowCreditCmd），快速的发送方不会压垮处理较慢的接收方
+// 只计算业务请求（系统模块 HeartbeatModule 的消息不占用窗口），双方协商了 FeatureFlowControl 时生效
+const (
+	FeatureFlowControl = "flowcontrol" // 按连接的请求窗口流量控制
+
+	FlowCreditCmd uint32 = 8 // 额度更新命令号（接收方发出的响应消息，Payload 为 4 字节的额度数）
+)
+
+// flowWindow 本节点作为接收方在每个连接上允许的在途请求数（0 表示不限制）
+var flowWindow atomic.Int64
+
+// SetFlowWindow 设置本节点在每个连接上允许对方在途的请求数（<= 0 表示不限制），之后建立的连接握手时告知对方
+func SetFlowWindow(window int) {
+	flowWindow.Store(int64(max(window, 0)))
+}
+
+// FlowWindow 本节点在每个连接上允许对方在途的请求数（0 表示不限制）
+func FlowWindow() int {
+	return int(flowWindow.Load())
+}
+
+// IsFlowCreditMsg 判断是否为额度更新消息
+func IsFlowCreditMsg(module, cmd uint32) bool {
+	return module == HeartbeatModule && cmd == FlowCreditCmd
+}
+
+// FlowCredits 额度更新消息中的额度数（格式错误时为 0）
+func FlowCredits(resp *ClusterRespMsg) int {
+	if len(resp.Payload) < 4 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(resp.Payload))
+}
+
+// flowCreditMsg 额度更新消息
+func flowCreditMsg(credits int) []byte {
+	payload := binary.BigEndian.AppendUint32(nil, uint32(credits))
+	return EncodeClusterRespMsg(&ClusterRespMsg{
+		Module:    HeartbeatModule,
+		Cmd:       FlowCreditCmd,
+		SessionId: "flow",
+		Payload:   payload,
+	})
+}
+
+// isFlowControlled 该请求是否占用流量控制窗口（业务请求）
+func isFlowControlled(module uint32) bool {
+	return module != HeartbeatModule
+}
+
+// flowReceiver 接收方的额度发放：每处理完窗口的四分之一（至少 1 个）请求发放一次额度
+// 启用处理协程时请求在多个协程中完成
+type flowReceiver struct {
+	batch     int
+	mu        sync.Mutex
+	completed int
+}
+
+// newFlowReceiver 创建额度发放（window <= 0 时返回 nil，不发放）
+func newFlowReceiver(window int) *flowReceiver {
+	if window <= 0 {
+		return nil
+	}
+	return &flowReceiver{batch: max(window/4, 1)}
+}
+
+// done 一个请求处理完成，累计到一批时向对方发放额度
+func (r *flowReceiver) done(conn net.Conn) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.completed++
+	credits := 0
+	if r.completed >= r.batch {
+		credits, r.completed = r.completed, 0
+	}
+	r.mu.Unlock()
+
+	if credits > 0 {
+		conn.Write(flowCreditMsg(credits))
+	}
+}
+
+// FlowOptions 发送方流量控制选项
+type FlowOptions struct {
+	Window int    // 对方在握手时告知的请求窗口
+	OnWait func() // 窗口用尽、写入需要等待时调用（用于统计）
+}
+
+// FlowStats 发送方流量控制统计
+type FlowStats struct {
+	Window   int           // 请求窗口
+	InFlight int           // 在途（对方尚未发放额度）的请求数
+	Waits    uint64        // 因窗口用尽等待的次数
+	WaitTime time.Duration // 累计等待时间
+}
+
+// FlowConn 发送方的流量控制连接：写入的消息中包含业务请求时，先取得相应的额度（窗口用尽时等待对方发放）
+// 一次写入多条消息（发送合并）时按其中的请求数取得额度；对方的额度更新由接收协程调用 Grant
+type FlowConn struct {
+	net.Conn
+	opts FlowOptions
+
+	mu      sync.Mutex
+	credits int
+	granted chan struct{} // 发放额度时关闭并替换，唤醒等待的写入
+
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	waits    atomic.Uint64
+	waitTime atomic.Int64
+}
+
+// NewFlowConn 创建流量控制连接（初始额度为整个窗口）
+func NewFlowConn(conn net.Conn, opts FlowOptions) *FlowConn {
+	opts.Window = max(opts.Window, 1)
+	return &FlowConn{
+		Conn:    conn,
+		opts:    opts,
+		credits: opts.Window,
+		granted: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (c *FlowConn) Write(b []byte) (int, error) {
+	return c.WriteContext(context.Background(), b)
+}
+
+// WriteContext 取得额度后写入（等待额度期间 ctx 结束或连接关闭时返回错误），写入失败时退回额度
+func (c *FlowConn) WriteContext(ctx context.Context, b []byte) (int, error) {
+	n := countFlowRequests(b)
+	if n > 0 {
+		if err := c.acquire(ctx, n); err != nil {
+			return 0, err
+		}
+	}
+
+	written, err := writeWithContext(ctx, c.Conn, b)
+	if err != nil && n > 0 {
+		c.Grant(n)
+	}
+	return written, err
+}
+
+// acquire 取得 n 个额度（超过窗口的批量写入在额度全部空闲时写入）
+func (c *FlowConn) acquire(ctx context.Context, n int) error {
+	need := min(n, c.opts.Window)
+	var start time.Time
+	for {
+		c.mu.Lock()
+		if c.credits >= need {
+			c.credits -= n
+			c.mu.Unlock()
+			if !start.IsZero() {
+				c.waitTime.Add(int64(time.Since(start)))
+			}
+			return nil
+		}
+		granted := c.granted
+		c.mu.Unlock()
+
+		if start.IsZero() {
+			start = time.Now()
+			c.waits.Add(1)
+			if c.opts.OnWait != nil {
+				c.opts.OnWait()
+			}
+		}
+		select {
+		case <-granted:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.closed:
+			return net.ErrClosed
+		}
+	}
+}
+
+// Grant 对方发放额度（额度最多恢复到整个窗口）
+func (c *FlowConn) Grant(n int) {
+	if n <= 0 {
+		return
+	}
+	c.mu.Lock()
+	c.credits = min(c.credits+n, c.opts.Window)
+	close(c.granted)
+	c.granted = make(chan struct{})
+	c.mu.Unlock()
+}
+
+// Stats 流量控制统计
+func (c *FlowConn) Stats() FlowStats {
+	c.mu.Lock()
+	inFlight := c.opts.Window - c.credits
+	c.mu.Unlock()
+	return FlowStats{
+		Window:   c.opts.Window,
+		InFlight: max(inFlight, 0),
+		Waits:    c.waits.Load(),
+		WaitTime: time.Duration(c.waitTime.Load()),
+	}
+}
+
+// Unwrap 被包装的连接
+func (c *FlowConn) Unwrap() net.Conn {
+	return c.Conn
+}
+
+// Close 关闭连接，等待额度的写入返回 net.ErrClosed
+func (c *FlowConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return c.Conn.Close()
+}
+
+// countFlowRequests 统计已编码数据中占用窗口的请求数（数据可能包含多条消息）
+func countFlowRequests(b []byte) int {
+	count := 0
+	for len(b) >= HeaderLenSize+1+4 {
+		if b[HeaderLenSize]&msgTypeMask == MsgTypeRequest && isFlowControlled(binary.BigEndian.Uint32(b[HeaderLenSize+1:])) {
+			count++
+		}
+		size := HeaderLenSize + int(binary.BigEndian.Uint32(b))
+		if size > len(b) {
+			break
+		}
+		b = b[size:]
+	}
+	return count
+}
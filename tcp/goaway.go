@@ -0,0 +1,93 @@
+package tcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// GoAwayCmd 关闭通知命令号（系统模块，与心跳共用模块号）
+// 服务端优雅关闭时向每个连接发送一条该命令的响应消息，对方收到后不再向本节点发送新请求
+const GoAwayCmd uint32 = 7
+
+// 优雅关闭参数
+const (
+	DefaultShutdownTimeout = 10 * time.Second       // tcp.Close 等待进行中请求的最长时间
+	shutdownQuietPeriod    = 200 * time.Millisecond // 没有进行中的请求且持续该时间没有收到请求时视为排空完成
+	shutdownPollInterval   = 50 * time.Millisecond
+)
+
+// IsGoAwayMsg 判断是否为关闭通知
+func IsGoAwayMsg(module, cmd uint32) bool {
+	return module == HeartbeatModule && cmd == GoAwayCmd
+}
+
+// goAwayMsg 关闭通知（不对应任何请求）
+func goAwayMsg() []byte {
+	return EncodeClusterRespMsg(&ClusterRespMsg{
+		Module:    HeartbeatModule,
+		Cmd:       GoAwayCmd,
+		SessionId: "goaway",
+	})
+}
+
+// Shutdown 优雅关闭服务器：停止接受新连接，向已握手的连接发送关闭通知，
+// 等待进行中的请求处理完成（对方发出关闭通知之前的请求可能仍在路上，需持续一小段时间没有新请求），
+// 然后关闭所有连接；ctx 结束时不再等待，立即关闭并返回 ctx 的错误
+func (s *Server) Shutdown(ctx context.Context) error {
+	if !s.shuttingDown.CompareAndSwap(false, true) {
+		return nil
+	}
+	logger.Info("优雅关闭 TCP 服务器...")
+
+	// 停止接受新连接
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	s.closeExtraListeners()
+
+	// 通知对方（由各连接的处理协程发送，避免与响应交错）
+	close(s.goAway)
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+
+	var err error
+	for !s.drained() {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			logger.Warnf("等待进行中的请求超时，强制关闭: 进行中 %d, %v", s.active.Load(), err)
+		case <-ticker.C:
+			continue
+		}
+		break
+	}
+
+	s.Stop()
+	return err
+}
+
+// drained 是否已排空：没有进行中的请求，且持续 shutdownQuietPeriod 没有收到请求
+func (s *Server) drained() bool {
+	last := time.Unix(0, s.lastRequest.Load())
+	return s.active.Load() == 0 && time.Since(last) >= shutdownQuietPeriod
+}
+
+// beginRequest 登记进行中的请求，返回结束登记的函数（s 为 nil 时不登记）
+func (s *Server) beginRequest() func() {
+	if s == nil {
+		return func() {}
+	}
+	s.active.Add(1)
+	s.lastRequest.Store(time.Now().UnixNano())
+	return func() {
+		s.active.Add(-1)
+	}
+}
+
+// ActiveRequests 进行中的请求数
+func (s *Server) ActiveRequests() int64 {
+	return s.active.Load()
+}
@@ -0,0 +1,89 @@
+package tcp
+
+import (
+	"sort"
+	"sync"
+)
+
+// ReqHandler 请求处理函数，返回 nil 表示不响应
+type ReqHandler func(req *ClusterReqMsg) *ClusterRespMsg
+
+var (
+	// reqHandlers 请求处理器：(module << 32 | cmd) -> handler
+	reqHandlers   = make(map[uint64]ReqHandler)
+	reqHandlersMu sync.RWMutex
+)
+
+// RegisterReqHandler 注册请求处理器（DefaultHandler 收到对应请求时调用，未注册的请求回显）
+func RegisterReqHandler(module, cmd uint32, handler ReqHandler) {
+	reqHandlersMu.Lock()
+	defer reqHandlersMu.Unlock()
+	reqHandlers[uint64(module)<<32|uint64(cmd)] = handler
+}
+
+// getReqHandler 获取请求处理器
+func getReqHandler(module, cmd uint32) (ReqHandler, bool) {
+	reqHandlersMu.RLock()
+	defer reqHandlersMu.RUnlock()
+	handler, exists := reqHandlers[uint64(module)<<32|uint64(cmd)]
+	return handler, exists
+}
+
+// StreamHandler 流消息处理函数，可通过 reply 多次回复（reply 可在其他协程中调用）
+type StreamHandler func(req *ClusterReqMsg, reply func(resp *ClusterRespMsg) error)
+
+var (
+	// streamHandlers 流消息处理器：(module << 32 | cmd) -> handler
+	streamHandlers   = make(map[uint64]StreamHandler)
+	streamHandlersMu sync.RWMutex
+)
+
+// RegisterStreamHandler 注册流消息处理器（同一连接上的消息按到达顺序调用）
+func RegisterStreamHandler(module, cmd uint32, handler StreamHandler) {
+	streamHandlersMu.Lock()
+	defer streamHandlersMu.Unlock()
+	streamHandlers[uint64(module)<<32|uint64(cmd)] = handler
+}
+
+// getStreamHandler 获取流消息处理器
+func getStreamHandler(module, cmd uint32) (StreamHandler, bool) {
+	streamHandlersMu.RLock()
+	defer streamHandlersMu.RUnlock()
+	handler, exists := streamHandlers[uint64(module)<<32|uint64(cmd)]
+	return handler, exists
+}
+
+// Route 已注册的消息路由
+type Route struct {
+	Module uint32 `json:"module"`
+	Cmd    uint32 `json:"cmd"`
+}
+
+// ReqRoutes 已注册请求处理器的路由（按 module、cmd 排序）
+func ReqRoutes() []Route {
+	reqHandlersMu.RLock()
+	defer reqHandlersMu.RUnlock()
+	return routesOf(reqHandlers)
+}
+
+// StreamRoutes 已注册流消息处理器的路由（按 module、cmd 排序）
+func StreamRoutes() []Route {
+	streamHandlersMu.RLock()
+	defer streamHandlersMu.RUnlock()
+	return routesOf(streamHandlers)
+}
+
+// routesOf 将路由键转换为排序后的路由
+func routesOf[H any](handlers map[uint64]H) []Route {
+	keys := make([]uint64, 0, len(handlers))
+	for key := range handlers {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	routes := make([]Route, len(keys))
+	for i, key := range keys {
+		routes[i] = Route{Module: uint32(key >> 32), Cmd: uint32(key)}
+	}
+	return routes
+}
@@ -0,0 +1,279 @@
+package tcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/charry/config"
+)
+
+// 握手相关常量（系统模块，与心跳共用模块号）
+// 连接建立后客户端发送的第一条消息必须是握手请求，服务端校验通过后以本节点的握手信息响应
+const (
+	HandshakeCmd          uint32 = 5             // 握手命令号
+	HandshakeRejectedCode        = CodeForbidden // 握手被拒绝的响应码（Payload 为原因，与旧版本兼容不使用标准错误消息体）
+)
+
+// 协议版本（双方按较低的版本通信）
+const (
+	ProtocolVersion    = 3 // 本节点的协议版本（2：消息类型字节支持标志位，如校验和、压缩；3：消息头版本 HeaderV2）
+	MinProtocolVersion = 1 // 兼容的最低协议版本，协商结果低于双方任一方的最低版本时拒绝连接
+)
+
+// 节点间可选功能（双方都支持时才能使用）
+const (
+	FeatureStreaming   = "streaming"   // 消息流（Stream）
+	FeatureCompression = "compression" // 消息压缩（需协议版本 2）
+	FeatureChecksum    = "checksum"    // 消息校验和（需协议版本 2）
+	FeatureChunked     = "chunked"     // 分块消息（SendStream）
+	FeatureExtensions  = "extensions"  // 消息头扩展字段（追踪上下文、元数据）
+)
+
+// featureMinVersions 功能要求的最低协议版本（协商的版本较低时不使用该功能）
+var featureMinVersions = map[string]int{
+	FeatureChecksum:    2,
+	FeatureCompression: 2,
+}
+
+// HandshakeTimeout 握手超时
+var HandshakeTimeout = 5 * time.Second
+
+var (
+	// localFeatures 本节点支持的功能
+	localFeatures   = []string{FeatureChunked, FeatureExtensions, FeaturePush, FeatureStreaming}
+	localFeaturesMu sync.RWMutex
+)
+
+// EnableFeature 声明本节点支持的功能（之后建立的连接握手时告知对方）
+func EnableFeature(feature string) {
+	localFeaturesMu.Lock()
+	defer localFeaturesMu.Unlock()
+	if !slices.Contains(localFeatures, feature) {
+		localFeatures = append(localFeatures, feature)
+		slices.Sort(localFeatures)
+	}
+}
+
+// LocalFeatures 本节点支持的功能
+func LocalFeatures() []string {
+	localFeaturesMu.RLock()
+	defer localFeaturesMu.RUnlock()
+	return slices.Clone(localFeatures)
+}
+
+// Handshake 握手信息
+type Handshake struct {
+	ServiceID          string   `json:"service_id"`
+	Type               string   `json:"type"`
+	Environment        string   `json:"environment"`
+	Cluster            string   `json:"cluster"`                        // 集群名称（cluster_name）
+	ProtocolVersion    int      `json:"protocol_version,omitempty"`     // 协议版本（旧版本节点未携带，按 1 处理）
+	MinProtocolVersion int      `json:"min_protocol_version,omitempty"` // 兼容的最低协议版本
+	Features           []string `json:"features,omitempty"`             // 支持的功能
+	AppVersion         string   `json:"app_version,omitempty"`          // 应用版本（app.version）
+	Codecs             []string `json:"codecs,omitempty"`               // 支持的编解码器
+	Codec              string   `json:"codec,omitempty"`                // 客户端提出的编解码器；服务端响应中为协商结果
+	Token              string   `json:"token,omitempty"`                // 认证 token（只由客户端出示，服务端响应中不携带）
+	FlowWindow         int      `json:"flow_window,omitempty"`          // 每个连接允许对方在途的请求数（0 表示不限制，见 FeatureFlowControl）
+}
+
+// Capabilities 握手协商结果：双方按此版本和功能通信
+type Capabilities struct {
+	ProtocolVersion int      `json:"protocol_version"` // 协商的协议版本（双方较低的版本）
+	Features        []string `json:"features"`         // 双方都支持的功能
+	AppVersion      string   `json:"app_version"`      // 对方应用版本
+	Codec           string   `json:"codec"`            // 连接协商的编解码器（未声明编解码器的消息使用）
+}
+
+// Has 是否可以使用指定功能（c 为 nil 时返回 false）
+func (c *Capabilities) Has(feature string) bool {
+	return c != nil && slices.Contains(c.Features, feature)
+}
+
+// LocalHandshake 本节点的握手信息
+func LocalHandshake() *Handshake {
+	cfg := config.Get()
+	serviceID := fmt.Sprintf("%s-%s-%d", cfg.App.Type, cfg.App.Environment, cfg.App.Id)
+	return &Handshake{
+		ServiceID:          serviceID,
+		Type:               cfg.App.Type,
+		Environment:        cfg.App.Environment,
+		Cluster:            cfg.Server.ClusterName,
+		ProtocolVersion:    ProtocolVersion,
+		MinProtocolVersion: MinProtocolVersion,
+		Features:           LocalFeatures(),
+		AppVersion:         cfg.App.Version,
+		Codecs:             Codecs(),
+		Codec:              PreferredCodec(),
+		Token:              localToken(&cfg.Server.ClusterAuth, serviceID),
+		FlowWindow:         FlowWindow(),
+	}
+}
+
+// Verify 校验对方是否属于同一环境和集群（不同环境、集群的节点不允许互连），且协议版本兼容
+func (h *Handshake) Verify(remote *Handshake) error {
+	if remote.Environment != h.Environment {
+		return fmt.Errorf("环境不一致: 本节点 %q, 对方 %q (%s)", h.Environment, remote.Environment, remote.ServiceID)
+	}
+	if remote.Cluster != h.Cluster {
+		return fmt.Errorf("集群不一致: 本节点 %q, 对方 %q (%s)", h.Cluster, remote.Cluster, remote.ServiceID)
+	}
+	_, err := h.Negotiate(remote)
+	return err
+}
+
+// Negotiate 协商协议版本和功能：取双方较低的协议版本和共同支持的功能（不包括该版本不支持的功能）
+// 编解码器取 remote 中的协商结果（客户端以服务端的握手响应调用）
+// 协商的版本低于任一方的最低兼容版本时返回错误
+func (h *Handshake) Negotiate(remote *Handshake) (*Capabilities, error) {
+	localVersion, localMin := versionsOf(h)
+	remoteVersion, remoteMin := versionsOf(remote)
+
+	version := min(localVersion, remoteVersion)
+	if version < max(localMin, remoteMin) {
+		return nil, fmt.Errorf("协议版本不兼容: 本节点 %d (最低 %d), 对方 %d (最低 %d) (%s)",
+			localVersion, localMin, remoteVersion, remoteMin, remote.ServiceID)
+	}
+
+	// 旧版本节点未携带功能列表，按版本 1 的基础功能处理
+	remoteFeatures := remote.Features
+	if remote.ProtocolVersion == 0 {
+		remoteFeatures = []string{FeatureStreaming}
+	}
+
+	caps := &Capabilities{
+		ProtocolVersion: version,
+		AppVersion:      remote.AppVersion,
+		Features:        []string{},
+		Codec:           CodecJSON,
+	}
+	if _, ok := GetCodec(remote.Codec); ok {
+		caps.Codec = remote.Codec
+	}
+	for _, feature := range h.Features {
+		if slices.Contains(remoteFeatures, feature) && version >= featureMinVersions[feature] {
+			caps.Features = append(caps.Features, feature)
+		}
+	}
+	slices.Sort(caps.Features)
+	return caps, nil
+}
+
+// versionsOf 握手信息中的协议版本和最低兼容版本（未携带时按 1 处理）
+func versionsOf(h *Handshake) (version, minVersion int) {
+	version = max(h.ProtocolVersion, 1)
+	minVersion = h.MinProtocolVersion
+	if minVersion <= 0 {
+		minVersion = 1
+	}
+	return version, min(minVersion, version)
+}
+
+// IsHandshakeMsg 判断是否为握手消息
+func IsHandshakeMsg(module, cmd uint32) bool {
+	return module == HeartbeatModule && cmd == HandshakeCmd
+}
+
+// SendHandshake 发送握手请求并等待响应（需在连接上的接收协程启动之前调用）
+// 返回对方的握手信息；对方拒绝或与本节点不属于同一环境、集群时返回错误
+func SendHandshake(conn net.Conn) (*Handshake, error) {
+	local := LocalHandshake()
+	payload, err := json.Marshal(local)
+	if err != nil {
+		return nil, fmt.Errorf("序列化握手信息失败: %w", err)
+	}
+
+	conn.SetDeadline(time.Now().Add(HandshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	req := &ClusterReqMsg{
+		Module:    HeartbeatModule,
+		Cmd:       HandshakeCmd,
+		SessionId: "handshake",
+		Payload:   payload,
+	}
+	if _, err := conn.Write(EncodeClusterReqMsg(req)); err != nil {
+		return nil, fmt.Errorf("发送握手请求失败: %w", err)
+	}
+
+	msg, err := DecodeMsg(conn)
+	if err != nil {
+		return nil, fmt.Errorf("读取握手响应失败: %w", err)
+	}
+	resp, ok := msg.(*ClusterRespMsg)
+	if !ok || !IsHandshakeMsg(resp.Module, resp.Cmd) {
+		return nil, fmt.Errorf("握手响应格式错误")
+	}
+	if resp.Code != 0 {
+		return nil, fmt.Errorf("对方拒绝握手: %s", resp.Payload)
+	}
+
+	remote := &Handshake{}
+	if err := json.Unmarshal(resp.Payload, remote); err != nil {
+		return nil, fmt.Errorf("解析握手响应失败: %w", err)
+	}
+	remote.Token = ""
+	if remote.Codec == "" {
+		// 旧版本节点未协商编解码器
+		remote.Codec = CodecJSON
+	}
+	if err := local.Verify(remote); err != nil {
+		return nil, err
+	}
+	return remote, nil
+}
+
+// HandleHandshakeReq 处理握手请求：校验、认证通过时以本节点握手信息响应，否则响应拒绝原因并返回错误
+// 返回对方的握手信息，其中 Codec 为协商的编解码器
+// 启用 token 认证时，同一 IP 连续认证失败过多会被暂时锁定
+func HandleHandshakeReq(conn net.Conn, req *ClusterReqMsg) (*Handshake, error) {
+	cfg := config.Get()
+	local := LocalHandshake()
+	local.Token = ""
+	resp := &ClusterRespMsg{
+		Module:    req.Module,
+		Cmd:       req.Cmd,
+		SessionId: req.SessionId,
+	}
+	reject := func(code uint32, err error) (*Handshake, error) {
+		resp.Code = code
+		resp.Payload = []byte(err.Error())
+		conn.Write(EncodeClusterRespMsg(resp))
+		return nil, err
+	}
+
+	auth := &cfg.Server.ClusterAuth
+	if authEnabled(auth) && authLocked(remoteIP(conn)) {
+		return reject(HandshakeUnauthorizedCode, fmt.Errorf("认证失败次数过多，已锁定: %s", remoteIP(conn)))
+	}
+
+	remote := &Handshake{}
+	if err := json.Unmarshal(req.Payload, remote); err != nil {
+		return reject(HandshakeRejectedCode, fmt.Errorf("解析握手请求失败: %w", err))
+	}
+	if err := local.Verify(remote); err != nil {
+		return reject(HandshakeRejectedCode, err)
+	}
+	if err := authenticate(auth, remote); err != nil {
+		authFailed(auth, conn, remote, err)
+		return reject(HandshakeUnauthorizedCode, err)
+	}
+	if authEnabled(auth) {
+		authSucceeded(conn)
+	}
+	remote.Token = ""
+
+	// 选择连接使用的编解码器，双方都以协商结果为准
+	local.Codec = negotiateCodec(remote.Codec)
+	remote.Codec = local.Codec
+
+	resp.Payload, _ = json.Marshal(local)
+	if _, err := conn.Write(EncodeClusterRespMsg(resp)); err != nil {
+		return nil, err
+	}
+	return remote, nil
+}
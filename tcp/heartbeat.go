@@ -1,8 +1,15 @@
 package tcp
 
 import (
+	"encoding/binary"
+	"fmt"
 	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/charry/config"
 )
 
 // 心跳相关常量
@@ -12,8 +19,192 @@ const (
 	HeartbeatCode   uint32 = 0 // 心跳响应码
 )
 
-// HeartbeatInterval 心跳发送间隔（10秒）
-var HeartbeatInterval = 10 * time.Second
+// DefaultHeartbeatInterval 默认心跳发送间隔
+const DefaultHeartbeatInterval = 10 * time.Second
+
+// HeartbeatInterval 心跳发送间隔（cluster_heartbeat.interval，可按对方节点覆盖，见 HeartbeatTimingFor）
+var HeartbeatInterval = DefaultHeartbeatInterval
+
+// HeartbeatTiming 心跳间隔和读超时
+type HeartbeatTiming struct {
+	Interval    time.Duration // 心跳间隔
+	ReadTimeout time.Duration // 等待下一条消息的超时（0 表示不超时）
+}
+
+// Validate 检查读超时大于心跳间隔（否则正常的连接会在两次心跳之间超时）
+func (t HeartbeatTiming) Validate() error {
+	if t.Interval <= 0 {
+		return fmt.Errorf("心跳间隔须大于 0: %v", t.Interval)
+	}
+	if t.ReadTimeout > 0 && t.ReadTimeout <= t.Interval {
+		return fmt.Errorf("读超时 %v 须大于心跳间隔 %v", t.ReadTimeout, t.Interval)
+	}
+	return nil
+}
+
+// heartbeatOverride 按节点类型或服务 ID 覆盖的心跳参数（为 nil 的字段沿用上一级）
+type heartbeatOverride struct {
+	interval    *time.Duration
+	readTimeout *time.Duration
+}
+
+// apply 覆盖心跳参数
+func (o heartbeatOverride) apply(t HeartbeatTiming) HeartbeatTiming {
+	if o.interval != nil {
+		t.Interval = *o.interval
+	}
+	if o.readTimeout != nil {
+		t.ReadTimeout = *o.readTimeout
+	}
+	return t
+}
+
+// heartbeatOverrides 心跳参数覆盖
+type heartbeatOverrides struct {
+	types    map[string]heartbeatOverride
+	services map[string]heartbeatOverride
+}
+
+// heartbeatTimings 心跳参数覆盖（未配置时为 nil）
+var heartbeatTimings atomic.Pointer[heartbeatOverrides]
+
+// HeartbeatTimingFor 与对方节点之间的心跳间隔和读超时：全局配置依次按对方节点类型、服务 ID 覆盖
+func HeartbeatTimingFor(serviceID, nodeType string) HeartbeatTiming {
+	timing := HeartbeatTiming{Interval: HeartbeatInterval, ReadTimeout: ReadTimeout()}
+	if overrides := heartbeatTimings.Load(); overrides != nil {
+		timing = overrides.types[nodeType].apply(timing)
+		timing = overrides.services[serviceID].apply(timing)
+	}
+	return timing
+}
+
+// SetupHeartbeat 按配置设置心跳间隔和按节点类型、服务 ID 的覆盖（需在 SetIOTimeouts 之后、建立连接之前调用）
+// 全局、各节点类型、各服务 ID 以及服务 ID 与任一节点类型组合后的读超时都须大于心跳间隔
+func SetupHeartbeat(cfg config.HeartbeatConfig) error {
+	interval, err := durationOr(cfg.Interval, DefaultHeartbeatInterval)
+	if err != nil {
+		return fmt.Errorf("解析心跳间隔失败: %w", err)
+	}
+	global := HeartbeatTiming{Interval: interval, ReadTimeout: ReadTimeout()}
+	if err := global.Validate(); err != nil {
+		return fmt.Errorf("心跳配置无效: %w", err)
+	}
+
+	overrides := &heartbeatOverrides{
+		types:    make(map[string]heartbeatOverride, len(cfg.Types)),
+		services: make(map[string]heartbeatOverride, len(cfg.Services)),
+	}
+	for name, timing := range cfg.Types {
+		o, err := parseHeartbeatOverride(timing)
+		if err == nil {
+			err = o.apply(global).Validate()
+		}
+		if err != nil {
+			return fmt.Errorf("节点类型 %s 的心跳配置无效: %w", name, err)
+		}
+		overrides.types[name] = o
+	}
+	for name, timing := range cfg.Services {
+		o, err := parseHeartbeatOverride(timing)
+		if err == nil {
+			err = o.apply(global).Validate()
+		}
+		for nodeType, t := range overrides.types {
+			if err != nil {
+				break
+			}
+			if err = o.apply(t.apply(global)).Validate(); err != nil {
+				err = fmt.Errorf("与节点类型 %s 组合: %w", nodeType, err)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("服务 %s 的心跳配置无效: %w", name, err)
+		}
+		overrides.services[name] = o
+	}
+
+	HeartbeatInterval = interval
+	heartbeatTimings.Store(overrides)
+	return nil
+}
+
+// parseHeartbeatOverride 解析心跳参数覆盖（空串沿用上一级）
+func parseHeartbeatOverride(timing config.HeartbeatTiming) (heartbeatOverride, error) {
+	var o heartbeatOverride
+	if timing.Interval != "" {
+		interval, err := time.ParseDuration(timing.Interval)
+		if err != nil {
+			return o, fmt.Errorf("解析心跳间隔失败: %w", err)
+		}
+		o.interval = &interval
+	}
+	if timing.ReadTimeout != "" {
+		timeout, err := time.ParseDuration(timing.ReadTimeout)
+		if err != nil {
+			return o, fmt.Errorf("解析读超时失败: %w", err)
+		}
+		timeout = max(timeout, 0)
+		o.readTimeout = &timeout
+	}
+	return o, nil
+}
+
+// DefaultMaxMissedHeartbeats 默认连续多少个心跳没有收到（响应）时视为连接失效
+const DefaultMaxMissedHeartbeats = 3
+
+// maxMissedHeartbeats 连续多少个心跳没有收到（响应）时视为连接失效
+var maxMissedHeartbeats atomic.Int64
+
+func init() {
+	maxMissedHeartbeats.Store(DefaultMaxMissedHeartbeats)
+}
+
+// SetMaxMissedHeartbeats 设置连续多少个心跳没有收到（响应）时视为连接失效（<= 0 时使用默认值）
+func SetMaxMissedHeartbeats(n int) {
+	if n <= 0 {
+		n = DefaultMaxMissedHeartbeats
+	}
+	maxMissedHeartbeats.Store(int64(n))
+}
+
+// MaxMissedHeartbeats 连续多少个心跳没有收到（响应）时视为连接失效
+func MaxMissedHeartbeats() int {
+	return int(maxMissedHeartbeats.Load())
+}
+
+// 心跳消息体：序号、发送时间（UnixNano）、发送方最近测得的往返时间、发送间隔（纳秒），各 8 字节
+// 响应原样带回消息体，发送方据此计算往返时间；旧版本节点发送空消息体，只统计收到的次数
+const heartbeatPayloadSize = 32
+
+// heartbeatPayload 心跳消息体
+type heartbeatPayload struct {
+	Seq      uint64
+	SentAt   int64
+	RTT      time.Duration
+	Interval time.Duration
+}
+
+func (p *heartbeatPayload) encode() []byte {
+	buf := make([]byte, heartbeatPayloadSize)
+	binary.BigEndian.PutUint64(buf[0:], p.Seq)
+	binary.BigEndian.PutUint64(buf[8:], uint64(p.SentAt))
+	binary.BigEndian.PutUint64(buf[16:], uint64(p.RTT))
+	binary.BigEndian.PutUint64(buf[24:], uint64(p.Interval))
+	return buf
+}
+
+// decodeHeartbeatPayload 解析心跳消息体（长度不足时返回 false）
+func decodeHeartbeatPayload(data []byte) (heartbeatPayload, bool) {
+	if len(data) < heartbeatPayloadSize {
+		return heartbeatPayload{}, false
+	}
+	return heartbeatPayload{
+		Seq:      binary.BigEndian.Uint64(data[0:]),
+		SentAt:   int64(binary.BigEndian.Uint64(data[8:])),
+		RTT:      time.Duration(binary.BigEndian.Uint64(data[16:])),
+		Interval: time.Duration(binary.BigEndian.Uint64(data[24:])),
+	}, true
+}
 
 // SendHeartbeat 发送心跳消息
 func SendHeartbeat(conn net.Conn) error {
@@ -38,7 +229,7 @@ func IsHeartbeatMsg(module, cmd uint32) bool {
 	return module == HeartbeatModule && cmd == HeartbeatCmd
 }
 
-// HandleHeartbeatReq 处理心跳请求
+// HandleHeartbeatReq 处理心跳请求（原样带回消息体）
 func HandleHeartbeatReq(conn net.Conn, req *ClusterReqMsg) error {
 	// 响应心跳
 	resp := &ClusterRespMsg{
@@ -46,10 +237,184 @@ func HandleHeartbeatReq(conn net.Conn, req *ClusterReqMsg) error {
 		Cmd:       req.Cmd,
 		SessionId: req.SessionId,
 		Code:      HeartbeatCode,
-		Payload:   []byte{},
+		Payload:   req.Payload,
 	}
 
 	data := EncodeClusterRespMsg(resp)
 	_, err := conn.Write(data)
 	return err
 }
+
+// HeartbeatStats 连接的心跳统计
+// 发送方统计发出的心跳和收到的响应；接收方统计收到的心跳，往返时间为对方在心跳中告知的值
+type HeartbeatStats struct {
+	Sent        uint64        // 发出的心跳数（接收方为 0）
+	Received    uint64        // 收到的心跳响应数（接收方为收到的心跳数）
+	Lost        uint64        // 丢失的心跳数（发送方为下一次心跳前没有收到响应的次数，接收方为序号的缺口）
+	Missed      int           // 连续没有收到响应的心跳数（发送方）
+	RTT         time.Duration // 最近一次往返时间
+	SmoothedRTT time.Duration // 往返时间（平滑值）
+	LastSeen    time.Time     // 最近一次收到心跳（响应）的时间
+}
+
+// LossRate 心跳丢失率（0 ~ 1）
+func (s HeartbeatStats) LossRate() float64 {
+	total := s.Received + s.Lost
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Lost) / float64(total)
+}
+
+// heartbeatRTTAlpha 往返时间平滑系数
+const heartbeatRTTAlpha = 0.3
+
+// HeartbeatTracker 单个连接的心跳跟踪
+// 发送方用 Send、Ack 发送心跳和处理响应，连续 MaxMissedHeartbeats 个心跳没有响应时 Dead 返回 true；
+// 接收方用 Observe 处理收到的心跳，Timeout 按对方的心跳间隔给出等待下一个心跳的最长时间
+type HeartbeatTracker struct {
+	seq      uint64        // 最近一次发出（接收方为收到）的序号
+	acked    uint64        // 最近一次收到响应的序号
+	sentAt   time.Time     // 最近一次发出心跳的时间
+	interval time.Duration // 对方的心跳间隔（接收方）
+	stats    HeartbeatStats
+	mu       sync.Mutex
+}
+
+// NewHeartbeatTracker 创建心跳跟踪
+func NewHeartbeatTracker() *HeartbeatTracker {
+	return &HeartbeatTracker{}
+}
+
+// Send 发出一次心跳（interval 为发送间隔，告知对方用于判断连接失效）
+// 上一次心跳没有收到响应时计为丢失，返回 missed 为 true
+func (t *HeartbeatTracker) Send(conn net.Conn, interval time.Duration) (missed bool, err error) {
+	t.mu.Lock()
+	if t.seq > t.acked {
+		missed = true
+		t.stats.Lost++
+		t.stats.Missed++
+	}
+	t.seq++
+	t.sentAt = time.Now()
+	t.stats.Sent++
+	payload := heartbeatPayload{Seq: t.seq, SentAt: t.sentAt.UnixNano(), RTT: t.stats.RTT, Interval: interval}
+	t.mu.Unlock()
+
+	req := &ClusterReqMsg{
+		Module:    HeartbeatModule,
+		Cmd:       HeartbeatCmd,
+		SessionId: "heartbeat",
+		Payload:   payload.encode(),
+	}
+	_, err = conn.Write(EncodeClusterReqMsg(req))
+	return missed, err
+}
+
+// Ack 收到心跳响应，返回本次往返时间
+// 对方为旧版本（响应没有带回消息体）时视为最近一次心跳的响应
+func (t *HeartbeatTracker) Ack(resp *ClusterRespMsg) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	seq, sentAt := t.seq, t.sentAt
+	if payload, ok := decodeHeartbeatPayload(resp.Payload); ok {
+		seq, sentAt = payload.Seq, time.Unix(0, payload.SentAt)
+	}
+	if seq > t.seq || sentAt.IsZero() {
+		return 0 // 不是本连接发出的心跳
+	}
+
+	// 迟到的响应（已计为丢失）同样说明连接仍然可用
+	t.acked = max(t.acked, seq)
+	t.stats.Missed = 0
+	t.stats.Received++
+	t.stats.LastSeen = now
+	rtt := now.Sub(sentAt)
+	t.recordRTT(rtt)
+	return rtt
+}
+
+// Observe 收到对方的心跳（接收方），按序号统计丢失
+func (t *HeartbeatTracker) Observe(req *ClusterReqMsg) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stats.Received++
+	t.stats.LastSeen = time.Now()
+	payload, ok := decodeHeartbeatPayload(req.Payload)
+	if !ok {
+		return
+	}
+	if t.seq > 0 && payload.Seq > t.seq+1 {
+		t.stats.Lost += payload.Seq - t.seq - 1
+	}
+	t.seq = max(t.seq, payload.Seq)
+	t.interval = payload.Interval
+	if payload.RTT > 0 {
+		t.recordRTT(payload.RTT)
+	}
+}
+
+// recordRTT 记录往返时间（需持有锁）
+func (t *HeartbeatTracker) recordRTT(rtt time.Duration) {
+	t.stats.RTT = rtt
+	if t.stats.SmoothedRTT == 0 {
+		t.stats.SmoothedRTT = rtt
+	} else {
+		t.stats.SmoothedRTT = time.Duration(heartbeatRTTAlpha*float64(rtt) + (1-heartbeatRTTAlpha)*float64(t.stats.SmoothedRTT))
+	}
+}
+
+// Dead 连续 MaxMissedHeartbeats 个心跳没有收到响应（发送方）
+func (t *HeartbeatTracker) Dead() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats.Missed >= MaxMissedHeartbeats()
+}
+
+// Timeout 接收方等待下一个心跳的最长时间：对方的心跳间隔乘以 MaxMissedHeartbeats，多等半个间隔容忍抖动
+// 还没有收到带间隔的心跳（对方为旧版本）时返回 0
+func (t *HeartbeatTracker) Timeout() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.interval <= 0 {
+		return 0
+	}
+	return t.interval*time.Duration(MaxMissedHeartbeats()) + t.interval/2
+}
+
+// Stats 心跳统计
+func (t *HeartbeatTracker) Stats() HeartbeatStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// ConnHeartbeat 服务器上一个已握手连接的心跳统计
+type ConnHeartbeat struct {
+	Peer       string // 对方服务 ID
+	RemoteAddr string // 对方地址
+	HeartbeatStats
+
+	tracker *HeartbeatTracker
+}
+
+// Heartbeats 各已握手连接收到的心跳统计（按对方服务 ID、地址排序）
+func (s *Server) Heartbeats() []ConnHeartbeat {
+	var beats []ConnHeartbeat
+	s.beats.Range(func(_, value any) bool {
+		beat := *value.(*ConnHeartbeat)
+		beat.HeartbeatStats = beat.tracker.Stats()
+		beats = append(beats, beat)
+		return true
+	})
+	sort.Slice(beats, func(i, j int) bool {
+		if beats[i].Peer != beats[j].Peer {
+			return beats[i].Peer < beats[j].Peer
+		}
+		return beats[i].RemoteAddr < beats[j].RemoteAddr
+	})
+	return beats
+}
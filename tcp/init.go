@@ -1,6 +1,10 @@
 package tcp
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/charry/config"
 	"github.com/charry/logger"
 )
@@ -15,12 +19,154 @@ var (
 func Init(cfg config.Config) error {
 	logger.Info("初始化 TCP 模块...")
 
+	// 节点间 TLS（需在创建服务器之前启用）
+	if err := SetupTLS(cfg.Server.ClusterTLS); err != nil {
+		return err
+	}
+
+	// 单条消息最大长度
+	SetMaxFrameSize(cfg.Server.ClusterMaxFrameSize)
+
+	// 读写超时（需在建立连接之前设置）
+	read, err := durationOr(cfg.Server.ClusterReadTimeout, DefaultReadTimeout)
+	if err != nil {
+		return fmt.Errorf("解析读超时失败: %w", err)
+	}
+	write, err := durationOr(cfg.Server.ClusterWriteTimeout, DefaultWriteTimeout)
+	if err != nil {
+		return fmt.Errorf("解析写超时失败: %w", err)
+	}
+	SetIOTimeouts(read, write)
+
+	// 心跳间隔（读超时须大于心跳间隔）和失效判定
+	if err := SetupHeartbeat(cfg.Server.ClusterHeartbeat); err != nil {
+		return err
+	}
+	SetMaxMissedHeartbeats(cfg.Server.ClusterMissedBeats)
+
+	// 连接发送队列（需在建立连接之前设置）
+	overflow, err := ParseOverflowPolicy(cfg.Server.ClusterSendOverflow)
+	if err != nil {
+		return err
+	}
+	SetSendQueueOptions(SendQueueOptions{Size: cfg.Server.ClusterSendQueue, Overflow: overflow})
+
+	// 流量控制窗口（握手时告知对方，对方在途的请求达到窗口时等待本节点处理）
+	if cfg.Server.ClusterFlowWindow > 0 {
+		SetFlowWindow(cfg.Server.ClusterFlowWindow)
+		EnableFeature(FeatureFlowControl)
+	}
+
+	// 消息校验和（握手时告知对方，双方都启用时生效）
+	if cfg.Server.ClusterChecksum {
+		EnableFeature(FeatureChecksum)
+	}
+
+	// 消息压缩（握手时告知对方，双方都启用时超过阈值的消息体压缩后发送）
+	if cfg.Server.ClusterCompression > 0 {
+		SetCompressThreshold(cfg.Server.ClusterCompression)
+		EnableFeature(FeatureCompression)
+	}
+
+	// 接收端请求去重
+	if cfg.Server.ClusterDedupWindow != "" {
+		window, err := time.ParseDuration(cfg.Server.ClusterDedupWindow)
+		if err != nil {
+			return fmt.Errorf("解析请求去重窗口失败: %w", err)
+		}
+		SetDedupWindow(window)
+	}
+
+	// 连接其他节点时希望使用的编解码器（自定义编解码器需在此之前通过 RegisterCodec 注册）
+	if cfg.Server.ClusterCodec != "" {
+		if err := SetPreferredCodec(cfg.Server.ClusterCodec); err != nil {
+			return err
+		}
+	}
+
+	// 接受连接的来源 IP 规则（配置变更时由消费者重新加载）
+	if err := SetupIPFilter(cfg.Server.ClusterIPFilter); err != nil {
+		return err
+	}
+
+	// 连接超过限制时的处理方式
+	reject, err := ParseRejectPolicy(cfg.Server.ClusterConnLimit.Reject)
+	if err != nil {
+		return err
+	}
+
 	// 创建 TCP 服务器
 	server, err := NewServer(&cfg.App)
 	if err != nil {
 		return err
 	}
 
+	// 单个连接处理 panic 时只断开该连接
+	server.Use(RecoverConnections())
+
+	// 连接限制
+	limit := cfg.Server.ClusterConnLimit
+	server.SetConnLimits(ConnLimits{
+		MaxConns:    limit.MaxConns,
+		AcceptRate:  limit.AcceptRate,
+		AcceptBurst: limit.AcceptBurst,
+		Reject:      reject,
+	})
+
+	// PROXY 协议（位于负载均衡之后时取得客户端的真实地址）
+	if err := server.SetProxyProtocol(cfg.Server.ClusterProxyProto); err != nil {
+		server.listener.Close()
+		return err
+	}
+
+	// 处理业务请求的协程（用尽时暂停读取，由 TCP 流量控制使对方变慢）
+	server.SetHandlerWorkers(cfg.Server.ClusterWorkers)
+
+	// WebSocket 连接（与 TCP 连接共用处理器和连接限制）
+	if ws := cfg.Server.ClusterWebSocket; ws.Addr != "" {
+		path := ws.Path
+		if path == "" {
+			path = "/"
+		}
+		listener, err := ListenWebSocket(ws.Addr, path, serverTLS.Load())
+		if err != nil {
+			server.listener.Close()
+			return err
+		}
+		server.AddListener(listener)
+	}
+
+	// Unix 域套接字（同一主机上的进程不经过 TCP 协议栈连接，与 TCP 连接共用处理器和连接限制）
+	if path := cfg.Server.ClusterUnixSocket; path != "" {
+		listener, err := ListenUnix(path, serverTLS.Load())
+		if err != nil {
+			server.listener.Close()
+			server.closeExtraListeners()
+			return err
+		}
+		server.AddListener(listener)
+	}
+
+	// UDP 报文（握手时告知对方，对方发往 UDP 路由的请求改用报文发送）
+	if cfg.Server.ClusterUDP {
+		if err := server.ListenDatagram(); err != nil {
+			server.listener.Close()
+			server.closeExtraListeners()
+			return err
+		}
+		EnableFeature(FeatureDatagram)
+	}
+
+	// QUIC（同端口 UDP，每个请求使用独立的流；连接其他节点时先尝试 QUIC）
+	if quicCfg := cfg.Server.ClusterQUIC; quicCfg.Enabled {
+		if err := server.ListenQUIC(quicCfg.ZeroRTT); err != nil {
+			server.listener.Close()
+			server.closeExtraListeners()
+			return err
+		}
+		SetPreferQUIC(true)
+	}
+
 	// 保存全局服务器
 	GlobalServer = server
 
@@ -32,11 +178,21 @@ func Init(cfg config.Config) error {
 	return nil
 }
 
-// Close 关闭 TCP 模块
+// durationOr 解析时长配置，空串时返回默认值
+func durationOr(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// Close 关闭 TCP 模块（优雅关闭，最多等待 DefaultShutdownTimeout）
 func Close() {
 	if GlobalServer != nil {
 		logger.Info("关闭 TCP 模块...")
-		GlobalServer.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+		defer cancel()
+		GlobalServer.Shutdown(ctx)
 		logger.Info("✓ TCP 模块已关闭")
 	}
 }
@@ -0,0 +1,137 @@
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+
+	"github.com/charry/config"
+	"github.com/charry/logger"
+)
+
+// IPFilter 接受连接时按来源 IP 过滤：先匹配拒绝规则，允许规则不为空时只接受匹配的地址
+type IPFilter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// NewIPFilter 创建 IP 过滤规则（CIDR 如 "10.0.0.0/8"，或单个 IP）
+func NewIPFilter(allow, deny []string) (*IPFilter, error) {
+	f := &IPFilter{}
+	var err error
+	if f.allow, err = parsePrefixes(allow); err != nil {
+		return nil, fmt.Errorf("解析允许规则失败: %w", err)
+	}
+	if f.deny, err = parsePrefixes(deny); err != nil {
+		return nil, fmt.Errorf("解析拒绝规则失败: %w", err)
+	}
+	return f, nil
+}
+
+// parsePrefixes 解析 CIDR 或单个 IP
+func parsePrefixes(rules []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(rules))
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if strings.Contains(rule, "/") {
+			prefix, err := netip.ParsePrefix(rule)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(rule)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// Allowed 是否接受来自该地址的连接（f 为 nil 时全部接受）
+func (f *IPFilter) Allowed(addr netip.Addr) bool {
+	if f == nil {
+		return true
+	}
+	addr = addr.Unmap()
+	for _, prefix := range f.deny {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, prefix := range f.allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedConn 是否接受该连接（无法解析来源地址时拒绝，Unix 域套接字连接来自本机，总是接受）
+func (f *IPFilter) AllowedConn(conn net.Conn) bool {
+	if f == nil || isUnixConn(conn) {
+		return true
+	}
+	addrPort, err := netip.ParseAddrPort(conn.RemoteAddr().String())
+	if err != nil {
+		return false
+	}
+	return f.Allowed(addrPort.Addr())
+}
+
+// ipFilter 全局 IP 过滤规则（为 nil 时不过滤）
+var ipFilter atomic.Pointer[IPFilter]
+
+// SetIPFilter 设置 IP 过滤规则（f 为 nil 时不过滤），对之后接受的连接生效
+func SetIPFilter(f *IPFilter) {
+	ipFilter.Store(f)
+}
+
+// GetIPFilter 当前的 IP 过滤规则
+func GetIPFilter() *IPFilter {
+	return ipFilter.Load()
+}
+
+// SetupIPFilter 按配置设置 IP 过滤规则（规则都为空时不过滤，配置无效时保留原有规则并返回错误）
+// 配置变更（如 Consul KV 更新）时重新调用
+func SetupIPFilter(cfg config.IPFilterConfig) error {
+	if len(cfg.Allow) == 0 && len(cfg.Deny) == 0 {
+		SetIPFilter(nil)
+		return nil
+	}
+	f, err := NewIPFilter(cfg.Allow, cfg.Deny)
+	if err != nil {
+		return fmt.Errorf("IP 过滤配置无效: %w", err)
+	}
+	SetIPFilter(f)
+	logger.Infof("IP 过滤规则已更新: 允许 %v, 拒绝 %v", cfg.Allow, cfg.Deny)
+	return nil
+}
+
+// DeniedConns 因 IP 过滤被拒绝的连接数
+func (s *Server) DeniedConns() uint64 {
+	return s.denied.Load()
+}
+
+// filterConn 按 IP 过滤规则检查新连接，不接受时关闭连接并返回 false
+func (s *Server) filterConn(conn net.Conn) bool {
+	if GetIPFilter().AllowedConn(conn) {
+		return true
+	}
+	conn.Close()
+	if n := s.denied.Add(1); n == 1 || n%rejectLogEvery == 0 {
+		logger.Warnf("来源地址不在允许范围内，拒绝连接: %s（累计拒绝 %d）", conn.RemoteAddr(), n)
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package tcp
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"time"
+)
+
+// 常用的元数据键（元数据随请求、响应的扩展字段传递，不影响消息体格式）
+const (
+	MetaTenant        = "tenant"        // 租户 ID
+	MetaAuthorization = "authorization" // 调用方凭证（如终端用户的 token）
+	MetaDeadline      = "deadline"      // 请求截止时间（Unix 毫秒），服务端据此设置 RequestContext 的截止时间
+)
+
+// metadataKey 元数据在 context 中的键
+type metadataKey struct{}
+
+// ContextWithMetadata 在 ctx 中附带元数据（与 ctx 中已有的元数据合并，同名键覆盖），
+// 之后经该 ctx 发出的请求携带这些元数据
+func ContextWithMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	merged := maps.Clone(MetadataFromContext(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(metadata))
+	}
+	maps.Copy(merged, metadata)
+	return context.WithValue(ctx, metadataKey{}, merged)
+}
+
+// MetadataFromContext 获取 ctx 中的元数据（服务端处理函数的 RequestContext 中为收到的请求的元数据），不应修改
+func MetadataFromContext(ctx context.Context) map[string]string {
+	metadata, _ := ctx.Value(metadataKey{}).(map[string]string)
+	return metadata
+}
+
+// InjectMetadata 将 ctx 中的元数据合并到 req.Metadata（req 中已有的键优先）
+func InjectMetadata(ctx context.Context, req *ClusterReqMsg) {
+	metadata := MetadataFromContext(ctx)
+	if len(metadata) == 0 {
+		return
+	}
+	if req.Metadata == nil {
+		req.Metadata = make(map[string]string, len(metadata))
+	}
+	for key, value := range metadata {
+		if _, exists := req.Metadata[key]; !exists {
+			req.Metadata[key] = value
+		}
+	}
+}
+
+// SetDeadline 在元数据中设置请求截止时间（MetaDeadline）
+func SetDeadline(metadata map[string]string, deadline time.Time) {
+	metadata[MetaDeadline] = strconv.FormatInt(deadline.UnixMilli(), 10)
+}
+
+// DeadlineOf 元数据中的请求截止时间（没有或格式错误时返回 false）
+func DeadlineOf(metadata map[string]string) (time.Time, bool) {
+	value, ok := metadata[MetaDeadline]
+	if !ok {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+// requestContext 服务端请求的 ctx：附带请求的追踪上下文和元数据，元数据中有截止时间时设置截止时间
+func requestContext(parent context.Context, req *ClusterReqMsg) (context.Context, context.CancelFunc) {
+	ctx := parent
+	if req.Trace != nil {
+		ctx = ContextWithTrace(ctx, *req.Trace)
+	}
+	if len(req.Metadata) > 0 {
+		ctx = context.WithValue(ctx, metadataKey{}, req.Metadata)
+		if deadline, ok := DeadlineOf(req.Metadata); ok {
+			return context.WithDeadline(ctx, deadline)
+		}
+	}
+	return ctx, func() {}
+}
@@ -0,0 +1,81 @@
+package tcp
+
+import (
+	"net"
+	"runtime/debug"
+	"sync"
+
+	"github.com/charry/logger"
+)
+
+// ConnMiddleware 连接中间件：包装连接处理器，在处理连接前后执行通用逻辑（认证、统计、日志、限流等）
+// 不调用 next 即拒绝连接（连接由服务器在处理结束后关闭）
+type ConnMiddleware func(next ConnectionHandler) ConnectionHandler
+
+// ConnectionHandlerFunc 函数形式的连接处理器
+type ConnectionHandlerFunc func(conn net.Conn)
+
+// HandleConnection 处理新连接
+func (f ConnectionHandlerFunc) HandleConnection(conn net.Conn) {
+	f(conn)
+}
+
+// Use 添加连接中间件（需在 Start 之前调用），先添加的在外层，同样作用于 SetHandler 设置的处理器
+func (s *Server) Use(mw ...ConnMiddleware) {
+	s.middlewares = append(s.middlewares, mw...)
+}
+
+// chain 按中间件包装连接处理器
+func (s *Server) chain() ConnectionHandler {
+	handler := s.handler
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+	return handler
+}
+
+// RecoverConnections 连接处理 panic 时记录日志并断开该连接，不影响其他连接
+func RecoverConnections() ConnMiddleware {
+	return func(next ConnectionHandler) ConnectionHandler {
+		return ConnectionHandlerFunc(func(conn net.Conn) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Errorf("连接处理 panic，断开: %s, %v\n%s", conn.RemoteAddr(), r, debug.Stack())
+				}
+			}()
+			next.HandleConnection(conn)
+		})
+	}
+}
+
+// LimitConnsPerIP 限制同一 IP 的并发连接数，超过时直接断开新连接（limit <= 0 时不限制）
+func LimitConnsPerIP(limit int) ConnMiddleware {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+
+	return func(next ConnectionHandler) ConnectionHandler {
+		if limit <= 0 {
+			return next
+		}
+		return ConnectionHandlerFunc(func(conn net.Conn) {
+			ip := remoteIP(conn)
+			mu.Lock()
+			if counts[ip] >= limit {
+				mu.Unlock()
+				logger.Warnf("IP 连接数超过上限 %d，断开: %s", limit, conn.RemoteAddr())
+				return
+			}
+			counts[ip]++
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				if counts[ip]--; counts[ip] <= 0 {
+					delete(counts, ip)
+				}
+				mu.Unlock()
+			}()
+			next.HandleConnection(conn)
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package tcp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// ErrRequestTimeout 请求超过截止时间仍未收到响应
+var ErrRequestTimeout = errors.New("等待响应超时")
+
+// 等待响应的请求表默认参数
+const (
+	DefaultPendingSweep = time.Second // 清理超时请求的周期
+	DefaultLateWindow   = time.Minute // 超时、放弃的请求在该时间内收到的响应计为迟到的响应
+)
+
+// PendingOptions 等待响应的请求表选项
+type PendingOptions struct {
+	SweepInterval time.Duration // 清理超时请求的周期（默认 DefaultPendingSweep）
+	LateWindow    time.Duration // 超时、放弃的请求在该时间内收到的响应计为迟到（默认 DefaultLateWindow）
+
+	// OnOrphan 收到孤立响应（没有请求在等待）时调用，late 表示对应的请求已超时或被放弃（为空时记录日志）
+	OnOrphan func(resp *ClusterRespMsg, late bool)
+}
+
+// PendingStats 等待响应的请求表统计
+type PendingStats struct {
+	Pending  int    // 等待响应的请求数
+	Timeouts uint64 // 超过截止时间仍未收到响应的请求数
+	Orphans  uint64 // 孤立响应数（包括迟到的响应）
+	Late     uint64 // 迟到的响应数（对应的请求已超时或被放弃）
+}
+
+// PendingRequest 等待响应的请求
+type PendingRequest struct {
+	SessionId string
+	Deadline  time.Time // 截止时间（零值表示不超时）
+
+	conn   net.Conn             // 发送请求的连接（连接断开时用于失败对应请求）
+	respCh chan *ClusterRespMsg // 响应（缓冲 1）
+	errCh  chan error           // 失败原因（缓冲 1）
+}
+
+// Resp 收到的响应
+func (r *PendingRequest) Resp() <-chan *ClusterRespMsg {
+	return r.respCh
+}
+
+// Err 请求失败（超时、连接断开）的原因
+func (r *PendingRequest) Err() <-chan error {
+	return r.errCh
+}
+
+// PendingRequests 等待响应的请求表：按 SessionId 登记请求和截止时间，将收到的响应交给等待方
+// 超过截止时间仍在表中的请求被清理（等待方收到 ErrRequestTimeout），没有请求在等待的响应作为孤立响应报告
+type PendingRequests struct {
+	opts PendingOptions
+
+	mu       sync.Mutex
+	entries  map[string]*PendingRequest
+	recent   map[string]time.Time // 已超时、放弃的请求：sessionId -> 迟到窗口结束时间
+	sweeping bool                 // 清理协程是否在运行（表为空时退出）
+
+	timeouts atomic.Uint64
+	orphans  atomic.Uint64
+	late     atomic.Uint64
+}
+
+// NewPendingRequests 创建等待响应的请求表
+func NewPendingRequests(opts PendingOptions) *PendingRequests {
+	if opts.SweepInterval <= 0 {
+		opts.SweepInterval = DefaultPendingSweep
+	}
+	if opts.LateWindow <= 0 {
+		opts.LateWindow = DefaultLateWindow
+	}
+	return &PendingRequests{
+		opts:    opts,
+		entries: make(map[string]*PendingRequest),
+		recent:  make(map[string]time.Time),
+	}
+}
+
+// Add 登记等待响应的请求（须在发送之前登记，避免响应先于登记到达），SessionId 已有请求在途时返回错误
+func (p *PendingRequests) Add(sessionId string, deadline time.Time) (*PendingRequest, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.entries[sessionId]; exists {
+		return nil, fmt.Errorf("sessionId 已有请求在途: %s", sessionId)
+	}
+	r := &PendingRequest{
+		SessionId: sessionId,
+		Deadline:  deadline,
+		respCh:    make(chan *ClusterRespMsg, 1),
+		errCh:     make(chan error, 1),
+	}
+	p.entries[sessionId] = r
+	delete(p.recent, sessionId)
+
+	if !p.sweeping {
+		p.sweeping = true
+		go p.sweepLoop()
+	}
+	return r, nil
+}
+
+// Bind 登记请求所在的连接（写入前调用，连接断开时 Fail 使其失败）
+func (p *PendingRequests) Bind(r *PendingRequest, conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r.conn = conn
+}
+
+// Remove 移除请求（等待方结束等待时调用），仍未收到响应的请求之后收到的响应计为迟到
+// 已过截止时间仍未收到响应的请求计为超时（等待方通常先于清理协程发现超时）
+func (p *PendingRequests) Remove(sessionId string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, exists := p.entries[sessionId]
+	if !exists {
+		return
+	}
+	now := time.Now()
+	delete(p.entries, sessionId)
+	p.recent[sessionId] = now.Add(p.opts.LateWindow)
+	if !r.Deadline.IsZero() && !now.Before(r.Deadline) {
+		p.timeouts.Add(1)
+	}
+}
+
+// Resolve 将响应交给等待中的请求，返回响应是否已被处理
+// 对应的请求已超时或被放弃时作为迟到的孤立响应报告并返回 true；没有对应的请求时返回 false（由调用方继续分发）
+func (p *PendingRequests) Resolve(resp *ClusterRespMsg) bool {
+	p.mu.Lock()
+	r, exists := p.entries[resp.SessionId]
+	if exists {
+		delete(p.entries, resp.SessionId)
+	}
+	_, late := p.recent[resp.SessionId]
+	if late {
+		delete(p.recent, resp.SessionId)
+	}
+	p.mu.Unlock()
+
+	if exists {
+		r.respCh <- resp
+		return true
+	}
+	if late {
+		p.orphan(resp, true)
+		return true
+	}
+	return false
+}
+
+// ReportOrphan 报告没有请求在等待、也无人处理的响应
+func (p *PendingRequests) ReportOrphan(resp *ClusterRespMsg) {
+	p.orphan(resp, false)
+}
+
+// orphan 统计并报告孤立响应（未设置 OnOrphan 时每 rejectLogEvery 个记录一次日志）
+func (p *PendingRequests) orphan(resp *ClusterRespMsg, late bool) {
+	n := p.orphans.Add(1)
+	if late {
+		p.late.Add(1)
+	}
+	if p.opts.OnOrphan != nil {
+		p.opts.OnOrphan(resp, late)
+		return
+	}
+	if n == 1 || n%rejectLogEvery == 0 {
+		logger.Warnf("收到孤立响应: module=%d, cmd=%d, sessionId=%s, 迟到 %v（累计 %d）",
+			resp.Module, resp.Cmd, resp.SessionId, late, n)
+	}
+}
+
+// Fail 使指定连接上（conn 为 nil 时为所有连接）等待的请求失败
+func (p *PendingRequests) Fail(conn net.Conn, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for sessionId, r := range p.entries {
+		if conn != nil && r.conn != conn {
+			continue
+		}
+		delete(p.entries, sessionId)
+		r.errCh <- err
+	}
+}
+
+// Len 等待响应的请求数
+func (p *PendingRequests) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// Stats 请求表统计
+func (p *PendingRequests) Stats() PendingStats {
+	return PendingStats{
+		Pending:  p.Len(),
+		Timeouts: p.timeouts.Load(),
+		Orphans:  p.orphans.Load(),
+		Late:     p.late.Load(),
+	}
+}
+
+// sweepLoop 定期清理超时的请求和过期的迟到记录，表为空时退出（下次登记时重新启动）
+func (p *PendingRequests) sweepLoop() {
+	ticker := time.NewTicker(p.opts.SweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !p.sweep(time.Now()) {
+			return
+		}
+	}
+}
+
+// sweep 清理一次，返回是否还需要继续清理
+func (p *PendingRequests) sweep(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for sessionId, r := range p.entries {
+		if r.Deadline.IsZero() || now.Before(r.Deadline) {
+			continue
+		}
+		delete(p.entries, sessionId)
+		p.recent[sessionId] = now.Add(p.opts.LateWindow)
+		p.timeouts.Add(1)
+		r.errCh <- fmt.Errorf("%w: sessionId=%s", ErrRequestTimeout, sessionId)
+	}
+	for sessionId, until := range p.recent {
+		if now.After(until) {
+			delete(p.recent, sessionId)
+		}
+	}
+
+	if len(p.entries) == 0 && len(p.recent) == 0 {
+		p.sweeping = false
+		return false
+	}
+	return true
+}
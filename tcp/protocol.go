@@ -2,14 +2,39 @@ package tcp
 
 import (
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"sync/atomic"
 )
 
 // 消息类型
 const (
 	MsgTypeRequest  byte = 0 // 请求消息
 	MsgTypeResponse byte = 1 // 响应消息
+	MsgTypeStream   byte = 2 // 分块消息帧（见 SendStream，对方协商了 FeatureChunked 时才能发送）
+)
+
+// 消息类型字节中的标志位（协议版本 2 起，对方协商了对应功能时才会设置）
+const (
+	FlagChecksum   byte = 0x80 // 消息末尾附带 CRC32-C 校验和
+	FlagCompressed byte = 0x40 // 消息体经过 DEFLATE 压缩
+	FlagExtended   byte = 0x08 // 消息头之后附带扩展字段（追踪上下文、元数据，见 ExtTrace）
+
+	msgTypeMask byte = 0x07 // 消息类型占低 3 位
+	knownFlags  byte = FlagChecksum | FlagCompressed | FlagExtended
+)
+
+// 消息头版本（消息类型字节的第 4、5 位），决定 Len 之后各字段的布局
+// 新的布局只在对方协商的协议版本支持时使用，新旧节点滚动升级期间可以互通
+const (
+	HeaderV1 byte = 0 // SessionId 为 36 字节文本（所有协议版本都支持）
+	HeaderV2 byte = 1 // SessionId 为 16 字节二进制 UUID（需协议版本 3）
+
+	headerVersionMask  byte = 0x30
+	headerVersionShift      = 4
 )
 
 // 消息头长度
@@ -19,44 +44,154 @@ const (
 	HeaderModuleSize    = 4  // Module 字段长度
 	HeaderCmdSize       = 4  // Cmd 字段长度
 	HeaderSessionIdSize = 36 // SessionId 字段长度（UUID）
+	HeaderSessionIdV2   = 16 // SessionId 字段长度（HeaderV2，二进制 UUID）
 	HeaderCodeSize      = 4  // Code 字段长度（仅响应消息）
+	HeaderKindSize      = 1  // Kind 字段长度（仅分块消息帧）
+	ChecksumSize        = 4  // 校验和长度（仅设置 FlagChecksum 时，位于消息末尾）
 
 	// 请求消息头长度：4 + 1 + 4 + 4 + 36 = 49
 	ClusterReqHeaderSize = HeaderLenSize + HeaderIsRespSize + HeaderModuleSize + HeaderCmdSize + HeaderSessionIdSize
 
 	// 响应消息头长度：4 + 1 + 4 + 4 + 36 + 4 = 53
 	ClusterRespHeaderSize = HeaderLenSize + HeaderIsRespSize + HeaderModuleSize + HeaderCmdSize + HeaderSessionIdSize + HeaderCodeSize
+
+	// 分块消息帧头长度：4 + 1 + 4 + 4 + 36 + 1 = 50
+	ClusterStreamHeaderSize = ClusterReqHeaderSize + HeaderKindSize
 )
 
+// DefaultMaxFrameSize 默认的单条消息最大长度（16MB）
+const DefaultMaxFrameSize = 16 << 20
+
+// ErrProtocol 对方发送的数据不符合协议（长度超限、消息头不完整等），连接已无法继续解析，需要关闭
+var ErrProtocol = errors.New("协议错误")
+
+// ErrChecksum 消息校验和不一致（数据在传输中损坏），同时满足 errors.Is(err, ErrProtocol)
+var ErrChecksum = fmt.Errorf("%w: 校验和不一致", ErrProtocol)
+
+// checksumTable CRC32-C 表（多数 CPU 有硬件指令）
+var checksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// maxFrameSize 单条消息最大长度（Len 字段的值）
+var maxFrameSize atomic.Int64
+
+func init() {
+	maxFrameSize.Store(DefaultMaxFrameSize)
+}
+
+// SetMaxFrameSize 设置接收的单条消息最大长度（<= 0 时使用默认值）
+// 对方声明的长度超过该值时不分配缓冲区，DecodeMsg 返回 ErrProtocol
+func SetMaxFrameSize(size int64) {
+	if size <= 0 {
+		size = DefaultMaxFrameSize
+	}
+	maxFrameSize.Store(size)
+}
+
+// MaxFrameSize 接收的单条消息最大长度
+func MaxFrameSize() int64 {
+	return maxFrameSize.Load()
+}
+
 // ClusterReqMsg 集群请求消息
 type ClusterReqMsg struct {
-	Module    uint32 // 模块号
-	Cmd       uint32 // 命令号
-	SessionId string // 会话ID（UUID，36字节）
-	Payload   []byte // 消息体（PB 序列化）
+	Module    uint32            // 模块号
+	Cmd       uint32            // 命令号
+	SessionId string            // 会话ID（UUID，36字节）
+	Trace     *TraceContext     // 追踪上下文（可为空，对方不支持扩展字段时不发送）
+	Metadata  map[string]string // 元数据（租户、凭证、截止时间等，可为空，对方不支持扩展字段时不发送）
+	Payload   []byte            // 消息体（格式由编解码器决定，见 Codec）
+
+	codec string // 所在连接协商的编解码器（服务端收到时设置）
 }
 
 // ClusterRespMsg 集群响应消息
 type ClusterRespMsg struct {
+	Module    uint32            // 模块号
+	Cmd       uint32            // 命令号
+	SessionId string            // 会话ID（UUID，36字节）
+	Code      uint32            // 错误码（0 为正常）
+	Trace     *TraceContext     // 追踪上下文（服务端带回请求的追踪上下文）
+	Metadata  map[string]string // 元数据（可为空，对方不支持扩展字段时不发送）
+	Payload   []byte            // 消息体（PB 序列化）
+}
+
+// ClusterStreamMsg 分块消息帧（同一条分块消息的帧共享 SessionId）
+type ClusterStreamMsg struct {
 	Module    uint32 // 模块号
 	Cmd       uint32 // 命令号
 	SessionId string // 会话ID（UUID，36字节）
-	Code      uint32 // 错误码（0 为正常）
-	Payload   []byte // 消息体（PB 序列化）
+	Kind      byte   // 帧类型（StreamOpen、StreamChunk、StreamClose、StreamAbort）
+	Payload   []byte // 打开帧为附带的消息体，数据帧为数据块，中止帧为原因
+}
+
+// FrameOptions 编码消息时的可选项（只能使用对方协商过的功能）
+type FrameOptions struct {
+	Checksum   bool // 附带校验和，接收端校验不一致时断开连接
+	Compress   bool // 消息体达到 CompressThreshold 时压缩
+	Extensions bool // 发送消息头扩展字段（追踪上下文、元数据），否则丢弃
+
+	// HeaderVersion 消息头版本（HeaderV1、HeaderV2），SessionId 不是 UUID 的消息（如心跳）总是使用 HeaderV1
+	HeaderVersion byte
+}
+
+// FrameOptionsOf 按握手协商结果确定连接上编码消息的可选项（caps 为 nil 时不启用任何选项）
+func FrameOptionsOf(caps *Capabilities) FrameOptions {
+	opts := FrameOptions{
+		Checksum:   caps.Has(FeatureChecksum),
+		Compress:   caps.Has(FeatureCompression),
+		Extensions: caps.Has(FeatureExtensions),
+	}
+	if caps != nil && caps.ProtocolVersion >= 3 {
+		opts.HeaderVersion = HeaderV2
+	}
+	return opts
 }
 
 // EncodeClusterReqMsg 编码请求消息
 func EncodeClusterReqMsg(msg *ClusterReqMsg) []byte {
-	payloadLen := len(msg.Payload)
-	totalLen := ClusterReqHeaderSize + payloadLen
+	return EncodeClusterReqMsgWith(msg, FrameOptions{})
+}
 
-	buf := make([]byte, totalLen)
+// EncodeClusterReqMsgWith 按可选项编码请求消息
+func EncodeClusterReqMsgWith(msg *ClusterReqMsg, opts FrameOptions) []byte {
+	return encodeClusterReqMsg(msg, opts, makeFrame)
+}
+
+// EncodeClusterReqMsgPooled 按可选项将请求消息编码到缓冲池的缓冲区
+// 适合同步写入的场景（写入返回后调用 Release），写入发送队列、合并发送等会保留数据的场景使用 EncodeClusterReqMsgWith
+func EncodeClusterReqMsgPooled(msg *ClusterReqMsg, opts FrameOptions) *Buffer {
+	var frame *Buffer
+	encodeClusterReqMsg(msg, opts, func(size int) []byte {
+		frame = AcquireBuffer(size)
+		return frame.B
+	})
+	return frame
+}
+
+// encodeClusterReqMsg 编码请求消息，编码结果的缓冲区由 alloc 分配
+func encodeClusterReqMsg(msg *ClusterReqMsg, opts FrameOptions, alloc func(size int) []byte) []byte {
+	payload, compressed := msg.Payload, false
+	if opts.Compress {
+		var pooled *Buffer
+		payload, pooled = compressPayload(msg.Payload)
+		defer pooled.Release()
+		compressed = pooled != nil
+	}
+	sessionId, headerVersion := encodeSessionId(msg.SessionId, opts.HeaderVersion)
+	ext := encodeExtensions(msg.Trace, msg.Metadata, opts)
+	payloadLen := len(payload)
+	totalLen := ClusterReqHeaderSize - HeaderSessionIdSize + len(sessionId) + len(ext) + payloadLen
+	if opts.Checksum {
+		totalLen += ChecksumSize
+	}
+
+	buf := alloc(totalLen)
 
 	// Len (4字节) - 消息体长度（不包含 Len 字段本身）
 	binary.BigEndian.PutUint32(buf[0:4], uint32(totalLen-4))
 
-	// IsResp (1字节) - 0 表示请求
-	buf[4] = MsgTypeRequest
+	// IsResp (1字节) - 0 表示请求，第 4、5 位为消息头版本
+	buf[4] = MsgTypeRequest | headerVersion<<headerVersionShift
 
 	// Module (4字节)
 	binary.BigEndian.PutUint32(buf[5:9], msg.Module)
@@ -64,27 +199,71 @@ func EncodeClusterReqMsg(msg *ClusterReqMsg) []byte {
 	// Cmd (4字节)
 	binary.BigEndian.PutUint32(buf[9:13], msg.Cmd)
 
-	// SessionId (36字节) - UUID
-	copy(buf[13:49], []byte(padSessionId(msg.SessionId)))
+	// SessionId (36字节文本，HeaderV2 为 16字节二进制) - UUID
+	n := 13 + copy(buf[13:], sessionId)
+
+	// 扩展字段（可选）
+	n += copy(buf[n:], ext)
 
 	// Payload (N字节)
-	copy(buf[49:], msg.Payload)
+	copy(buf[n:], payload)
 
+	if compressed {
+		buf[4] |= FlagCompressed
+	}
+	if ext != nil {
+		buf[4] |= FlagExtended
+	}
+	if opts.Checksum {
+		appendChecksum(buf)
+	}
 	return buf
 }
 
 // EncodeClusterRespMsg 编码响应消息
 func EncodeClusterRespMsg(msg *ClusterRespMsg) []byte {
-	payloadLen := len(msg.Payload)
-	totalLen := ClusterRespHeaderSize + payloadLen
+	return EncodeClusterRespMsgWith(msg, FrameOptions{})
+}
 
-	buf := make([]byte, totalLen)
+// EncodeClusterRespMsgWith 按可选项编码响应消息
+func EncodeClusterRespMsgWith(msg *ClusterRespMsg, opts FrameOptions) []byte {
+	return encodeClusterRespMsg(msg, opts, makeFrame)
+}
+
+// EncodeClusterRespMsgPooled 按可选项将响应消息编码到缓冲池的缓冲区（写入返回后调用 Release，见 EncodeClusterReqMsgPooled）
+func EncodeClusterRespMsgPooled(msg *ClusterRespMsg, opts FrameOptions) *Buffer {
+	var frame *Buffer
+	encodeClusterRespMsg(msg, opts, func(size int) []byte {
+		frame = AcquireBuffer(size)
+		return frame.B
+	})
+	return frame
+}
+
+// encodeClusterRespMsg 编码响应消息，编码结果的缓冲区由 alloc 分配
+func encodeClusterRespMsg(msg *ClusterRespMsg, opts FrameOptions, alloc func(size int) []byte) []byte {
+	payload, compressed := msg.Payload, false
+	if opts.Compress {
+		var pooled *Buffer
+		payload, pooled = compressPayload(msg.Payload)
+		defer pooled.Release()
+		compressed = pooled != nil
+	}
+	sessionId, headerVersion := encodeSessionId(msg.SessionId, opts.HeaderVersion)
+	ext := encodeExtensions(msg.Trace, msg.Metadata, opts)
+	payloadLen := len(payload)
+	totalLen := ClusterRespHeaderSize - HeaderSessionIdSize + len(sessionId) + len(ext) + payloadLen
+	if opts.Checksum {
+		totalLen += ChecksumSize
+	}
+
+	buf := alloc(totalLen)
 
 	// Len (4字节) - 消息体长度（不包含 Len 字段本身）
 	binary.BigEndian.PutUint32(buf[0:4], uint32(totalLen-4))
 
-	// IsResp (1字节) - 1 表示响应
-	buf[4] = MsgTypeResponse
+	// IsResp (1字节) - 1 表示响应，第 4、5 位为消息头版本
+	buf[4] = MsgTypeResponse | headerVersion<<headerVersionShift
 
 	// Module (4字节)
 	binary.BigEndian.PutUint32(buf[5:9], msg.Module)
@@ -92,43 +271,192 @@ func EncodeClusterRespMsg(msg *ClusterRespMsg) []byte {
 	// Cmd (4字节)
 	binary.BigEndian.PutUint32(buf[9:13], msg.Cmd)
 
-	// SessionId (36字节) - UUID
-	copy(buf[13:49], []byte(padSessionId(msg.SessionId)))
+	// SessionId (36字节文本，HeaderV2 为 16字节二进制) - UUID
+	n := 13 + copy(buf[13:], sessionId)
 
 	// Code (4字节) - 错误码
-	binary.BigEndian.PutUint32(buf[49:53], msg.Code)
+	binary.BigEndian.PutUint32(buf[n:n+4], msg.Code)
+	n += 4
+
+	// 扩展字段（可选）
+	n += copy(buf[n:], ext)
+
+	// Payload (N字节)
+	copy(buf[n:], payload)
+
+	if compressed {
+		buf[4] |= FlagCompressed
+	}
+	if ext != nil {
+		buf[4] |= FlagExtended
+	}
+	if opts.Checksum {
+		appendChecksum(buf)
+	}
+	return buf
+}
+
+// EncodeClusterStreamMsgWith 按可选项编码分块消息帧
+func EncodeClusterStreamMsgWith(msg *ClusterStreamMsg, opts FrameOptions) []byte {
+	payload, compressed := msg.Payload, false
+	if opts.Compress {
+		var pooled *Buffer
+		payload, pooled = compressPayload(msg.Payload)
+		defer pooled.Release()
+		compressed = pooled != nil
+	}
+	sessionId, headerVersion := encodeSessionId(msg.SessionId, opts.HeaderVersion)
+	payloadLen := len(payload)
+	totalLen := ClusterStreamHeaderSize - HeaderSessionIdSize + len(sessionId) + payloadLen
+	if opts.Checksum {
+		totalLen += ChecksumSize
+	}
+
+	buf := make([]byte, totalLen)
+
+	// Len (4字节) - 消息体长度（不包含 Len 字段本身）
+	binary.BigEndian.PutUint32(buf[0:4], uint32(totalLen-4))
+
+	// IsResp (1字节) - 2 表示分块消息帧，第 4、5 位为消息头版本
+	buf[4] = MsgTypeStream | headerVersion<<headerVersionShift
+
+	// Module (4字节)
+	binary.BigEndian.PutUint32(buf[5:9], msg.Module)
+
+	// Cmd (4字节)
+	binary.BigEndian.PutUint32(buf[9:13], msg.Cmd)
+
+	// SessionId (36字节文本，HeaderV2 为 16字节二进制) - UUID
+	n := 13 + copy(buf[13:], sessionId)
+
+	// Kind (1字节) - 帧类型
+	buf[n] = msg.Kind
 
 	// Payload (N字节)
-	copy(buf[53:], msg.Payload)
+	copy(buf[n+1:], payload)
 
+	if compressed {
+		buf[4] |= FlagCompressed
+	}
+	if opts.Checksum {
+		appendChecksum(buf)
+	}
 	return buf
 }
 
+// makeFrame 分配编码结果的缓冲区（调用方持有）
+func makeFrame(size int) []byte {
+	return make([]byte, size)
+}
+
+// appendChecksum 设置校验和标志，并在消息末尾预留的位置写入校验和
+// 校验和覆盖 Len 之后、校验和之前的全部字节（包括消息类型字节）
+func appendChecksum(buf []byte) {
+	buf[4] |= FlagChecksum
+	end := len(buf) - ChecksumSize
+	binary.BigEndian.PutUint32(buf[end:], crc32.Checksum(buf[4:end], checksumTable))
+}
+
 // DecodeMsg 解码消息（自动判断请求或响应）
+// 消息长度超过 MaxFrameSize、小于消息头长度或校验和不一致时返回 ErrProtocol，调用方应关闭连接
 func DecodeMsg(reader io.Reader) (interface{}, error) {
-	// 1. 读取 Len (4字节)
-	lenBuf := make([]byte, 4)
-	if _, err := io.ReadFull(reader, lenBuf); err != nil {
+	return decodeMsg(reader, makeFrame)
+}
+
+// DecodeMsgPooled 解码消息，消息数据读入缓冲池的缓冲区，Payload 直接引用该缓冲区（不复制）
+// 处理完成后调用返回的 Buffer 的 Release 归还（之后不能再使用消息的 Payload），消息需要保留时不归还即可（由 GC 回收）
+func DecodeMsgPooled(reader io.Reader) (interface{}, *Buffer, error) {
+	var frame *Buffer
+	msg, err := decodeMsg(reader, func(size int) []byte {
+		frame = AcquireBuffer(size)
+		return frame.B
+	})
+	if err != nil {
+		frame.Release()
+		return nil, nil, err
+	}
+	return msg, frame, nil
+}
+
+// decodeMsg 解码消息，消息数据的缓冲区由 alloc 分配
+func decodeMsg(reader io.Reader, alloc func(size int) []byte) (interface{}, error) {
+	// 1. 读取 Len (4字节) 和 IsResp (1字节)
+	var head [5]byte
+	if _, err := io.ReadFull(reader, head[:4]); err != nil {
 		return nil, fmt.Errorf("读取长度失败: %w", err)
 	}
-	msgLen := binary.BigEndian.Uint32(lenBuf)
+	msgLen := binary.BigEndian.Uint32(head[0:4])
+	if limit := MaxFrameSize(); int64(msgLen) > limit {
+		return nil, fmt.Errorf("%w: 消息长度 %d 超过上限 %d", ErrProtocol, msgLen, limit)
+	}
 
-	// 2. 读取 IsResp (1字节)
-	isRespBuf := make([]byte, 1)
-	if _, err := io.ReadFull(reader, isRespBuf); err != nil {
+	// 2. IsResp：低 3 位为消息类型，第 3 位为 FlagExtended，第 4、5 位为消息头版本，高位为标志
+	if _, err := io.ReadFull(reader, head[4:5]); err != nil {
 		return nil, fmt.Errorf("读取消息类型失败: %w", err)
 	}
-	isResp := isRespBuf[0]
+	typeByte := head[4]
+	if headerVersion := headerVersionOf(typeByte); headerVersion > HeaderV2 {
+		return nil, fmt.Errorf("%w: 未知消息头版本 %d", ErrProtocol, headerVersion)
+	}
+	flags := typeByte &^ (msgTypeMask | headerVersionMask)
+	if flags&^knownFlags != 0 {
+		return nil, fmt.Errorf("%w: 未知标志 0x%02x", ErrProtocol, flags)
+	}
+	// 消息头中 SessionId 之外的部分和校验和的长度按消息头版本、标志调整
+	minLen := int64(sessionIdSize(headerVersionOf(typeByte))) - HeaderSessionIdSize - HeaderLenSize
+	if flags&FlagChecksum != 0 {
+		minLen += ChecksumSize
+	}
 
 	// 3. 根据类型解码
-	switch isResp {
+	var msg interface{}
+	var err error
+	switch isResp := typeByte & msgTypeMask; isResp {
 	case MsgTypeRequest:
-		return decodeClusterReqMsg(reader, msgLen)
+		if int64(msgLen) < ClusterReqHeaderSize+minLen {
+			return nil, fmt.Errorf("%w: 请求消息长度 %d 小于消息头", ErrProtocol, msgLen)
+		}
+		msg, err = decodeClusterReqMsg(reader, msgLen, typeByte, alloc)
 	case MsgTypeResponse:
-		return decodeClusterRespMsg(reader, msgLen)
+		if int64(msgLen) < ClusterRespHeaderSize+minLen {
+			return nil, fmt.Errorf("%w: 响应消息长度 %d 小于消息头", ErrProtocol, msgLen)
+		}
+		msg, err = decodeClusterRespMsg(reader, msgLen, typeByte, alloc)
+	case MsgTypeStream:
+		if int64(msgLen) < ClusterStreamHeaderSize+minLen {
+			return nil, fmt.Errorf("%w: 分块消息帧长度 %d 小于消息头", ErrProtocol, msgLen)
+		}
+		msg, err = decodeClusterStreamMsg(reader, msgLen, typeByte, alloc)
 	default:
-		return nil, fmt.Errorf("未知消息类型: %d", isResp)
+		return nil, fmt.Errorf("%w: 未知消息类型 %d", ErrProtocol, isResp)
 	}
+
+	// 4. 抓包时记录从连接收到的消息
+	if err == nil && capturing.Load() {
+		if conn, ok := reader.(addrConn); ok {
+			captureMsg(CaptureIn, conn, msg, HeaderLenSize+int(msgLen), typeByte)
+		}
+	}
+	return msg, err
+}
+
+// readFrameBody 读取消息类型字节之后的部分（长度 msgLen - 1，缓冲区由 alloc 分配）
+// 设置了 FlagChecksum 时校验并去除末尾的校验和，不一致时返回 ErrChecksum
+func readFrameBody(reader io.Reader, msgLen uint32, typeByte byte, alloc func(size int) []byte) ([]byte, error) {
+	buf := alloc(int(msgLen - 1))
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return nil, err
+	}
+	if typeByte&FlagChecksum == 0 {
+		return buf, nil
+	}
+
+	end := len(buf) - ChecksumSize
+	digest := crc32.Update(crc32.Checksum([]byte{typeByte}, checksumTable), checksumTable, buf[:end])
+	if digest != binary.BigEndian.Uint32(buf[end:]) {
+		return nil, ErrChecksum
+	}
+	return buf[:end], nil
 }
 
 // padSessionId 填充 SessionId 到 36 字节
@@ -140,6 +468,67 @@ func padSessionId(sessionId string) string {
 	return sessionId + string(make([]byte, 36-len(sessionId)))
 }
 
+// headerVersionOf 消息类型字节中的消息头版本
+func headerVersionOf(typeByte byte) byte {
+	return typeByte & headerVersionMask >> headerVersionShift
+}
+
+// sessionIdSize 消息头版本对应的 SessionId 字段长度
+func sessionIdSize(headerVersion byte) int {
+	if headerVersion == HeaderV2 {
+		return HeaderSessionIdV2
+	}
+	return HeaderSessionIdSize
+}
+
+// encodeSessionId 按消息头版本编码 SessionId，返回编码结果和实际使用的消息头版本
+// HeaderV2 要求 SessionId 为小写规范格式的 UUID（与 event.NewID 一致），否则退回 HeaderV1
+func encodeSessionId(sessionId string, headerVersion byte) ([]byte, byte) {
+	if headerVersion >= HeaderV2 {
+		if id, ok := parseUUID(sessionId); ok {
+			return id[:], HeaderV2
+		}
+	}
+	return []byte(padSessionId(sessionId)), HeaderV1
+}
+
+// decodeSessionId 按消息头版本解码 SessionId
+func decodeSessionId(data []byte, headerVersion byte) string {
+	if headerVersion == HeaderV2 {
+		return formatUUID(data)
+	}
+	return trimSessionId(string(data))
+}
+
+// parseUUID 解析小写规范格式的 UUID（xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx），
+// 只接受能按原样还原的字符串，保证接收端解码得到相同的 SessionId
+func parseUUID(s string) ([16]byte, bool) {
+	var id [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return id, false
+	}
+	hexStr := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36]
+	if _, err := hex.Decode(id[:], []byte(hexStr)); err != nil {
+		return id, false
+	}
+	return id, formatUUID(id[:]) == s
+}
+
+// formatUUID 将 16 字节 UUID 格式化为小写规范格式
+func formatUUID(id []byte) string {
+	var s [36]byte
+	hex.Encode(s[0:8], id[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], id[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], id[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], id[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:36], id[10:16])
+	return string(s[:])
+}
+
 // trimSessionId 去除 SessionId 的填充
 func trimSessionId(sessionId string) string {
 	// 去除右侧空格和 null 字符
@@ -152,39 +541,97 @@ func trimSessionId(sessionId string) string {
 }
 
 // decodeClusterReqMsg 解码请求消息
-func decodeClusterReqMsg(reader io.Reader, msgLen uint32) (*ClusterReqMsg, error) {
-	// 读取剩余部分：Module(4) + Cmd(4) + SessionId(36) + Payload(N)
-	remainLen := msgLen - 1 // 减去已读的 IsResp
-	buf := make([]byte, remainLen)
-	if _, err := io.ReadFull(reader, buf); err != nil {
+func decodeClusterReqMsg(reader io.Reader, msgLen uint32, typeByte byte, alloc func(size int) []byte) (*ClusterReqMsg, error) {
+	// 读取剩余部分：Module(4) + Cmd(4) + SessionId(36，HeaderV2 为 16) + 扩展字段（可选） + Payload(N)
+	buf, err := readFrameBody(reader, msgLen, typeByte, alloc)
+	if err != nil {
 		return nil, fmt.Errorf("读取请求消息失败: %w", err)
 	}
 
+	headerVersion := headerVersionOf(typeByte)
+	n := 8 + sessionIdSize(headerVersion)
 	msg := &ClusterReqMsg{
 		Module:    binary.BigEndian.Uint32(buf[0:4]),
 		Cmd:       binary.BigEndian.Uint32(buf[4:8]),
-		SessionId: trimSessionId(string(buf[8:44])),
-		Payload:   buf[44:],
+		SessionId: decodeSessionId(buf[8:n], headerVersion),
+		Payload:   buf[n:],
+	}
+	if typeByte&FlagExtended != 0 {
+		var ext frameExtensions
+		if ext, msg.Payload, err = decodeExtensions(msg.Payload); err != nil {
+			return nil, err
+		}
+		msg.Trace, msg.Metadata = ext.trace, ext.metadata
+	}
+	if typeByte&FlagCompressed != 0 {
+		if msg.Payload, err = decompressPayload(msg.Payload); err != nil {
+			return nil, err
+		}
 	}
 
 	return msg, nil
 }
 
 // decodeClusterRespMsg 解码响应消息
-func decodeClusterRespMsg(reader io.Reader, msgLen uint32) (*ClusterRespMsg, error) {
-	// 读取剩余部分：Module(4) + Cmd(4) + SessionId(36) + Code(4) + Payload(N)
-	remainLen := msgLen - 1 // 减去已读的 IsResp
-	buf := make([]byte, remainLen)
-	if _, err := io.ReadFull(reader, buf); err != nil {
+func decodeClusterRespMsg(reader io.Reader, msgLen uint32, typeByte byte, alloc func(size int) []byte) (*ClusterRespMsg, error) {
+	// 读取剩余部分：Module(4) + Cmd(4) + SessionId(36，HeaderV2 为 16) + Code(4) + 扩展字段（可选） + Payload(N)
+	buf, err := readFrameBody(reader, msgLen, typeByte, alloc)
+	if err != nil {
 		return nil, fmt.Errorf("读取响应消息失败: %w", err)
 	}
 
+	headerVersion := headerVersionOf(typeByte)
+	n := 8 + sessionIdSize(headerVersion)
 	msg := &ClusterRespMsg{
 		Module:    binary.BigEndian.Uint32(buf[0:4]),
 		Cmd:       binary.BigEndian.Uint32(buf[4:8]),
-		SessionId: trimSessionId(string(buf[8:44])),
-		Code:      binary.BigEndian.Uint32(buf[44:48]),
-		Payload:   buf[48:],
+		SessionId: decodeSessionId(buf[8:n], headerVersion),
+		Code:      binary.BigEndian.Uint32(buf[n : n+4]),
+		Payload:   buf[n+4:],
+	}
+	if typeByte&FlagExtended != 0 {
+		var ext frameExtensions
+		if ext, msg.Payload, err = decodeExtensions(msg.Payload); err != nil {
+			return nil, err
+		}
+		msg.Trace, msg.Metadata = ext.trace, ext.metadata
+	}
+	if typeByte&FlagCompressed != 0 {
+		if msg.Payload, err = decompressPayload(msg.Payload); err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
+
+// decodeClusterStreamMsg 解码分块消息帧
+func decodeClusterStreamMsg(reader io.Reader, msgLen uint32, typeByte byte, alloc func(size int) []byte) (*ClusterStreamMsg, error) {
+	// 读取剩余部分：Module(4) + Cmd(4) + SessionId(36，HeaderV2 为 16) + Kind(1) + Payload(N)
+	buf, err := readFrameBody(reader, msgLen, typeByte, alloc)
+	if err != nil {
+		return nil, fmt.Errorf("读取分块消息帧失败: %w", err)
+	}
+
+	headerVersion := headerVersionOf(typeByte)
+	n := 8 + sessionIdSize(headerVersion)
+	msg := &ClusterStreamMsg{
+		Module:    binary.BigEndian.Uint32(buf[0:4]),
+		Cmd:       binary.BigEndian.Uint32(buf[4:8]),
+		SessionId: decodeSessionId(buf[8:n], headerVersion),
+		Kind:      buf[n],
+		Payload:   buf[n+1:],
+	}
+	if typeByte&FlagExtended != 0 {
+		// 分块消息帧目前不携带扩展字段，跳过
+		if _, msg.Payload, err = decodeExtensions(msg.Payload); err != nil {
+			return nil, err
+		}
+	}
+	if typeByte&FlagCompressed != 0 {
+		if msg.Payload, err = decompressPayload(msg.Payload); err != nil {
+			return nil, err
+		}
 	}
 
 	return msg, nil
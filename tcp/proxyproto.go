@@ -0,0 +1,304 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charry/config"
+	"github.com/charry/logger"
+)
+
+// PROXY 协议（HAProxy v1、v2）：服务器位于负载均衡之后时，负载均衡在连接开头发送客户端的真实地址
+// 解析后连接的 RemoteAddr 为真实地址，日志、IP 过滤、token 认证锁定和健康检查识别都按真实地址处理
+// 只解析来自受信任地址（负载均衡）的连接，其他连接按直连处理，避免伪造来源地址
+
+// ProxyMode PROXY 协议的处理方式
+type ProxyMode int
+
+const (
+	ProxyOff      ProxyMode = iota // 不解析
+	ProxyOptional                  // 受信任地址的连接可以有 PROXY 头（没有时按直连处理）
+	ProxyRequired                  // 受信任地址的连接必须有 PROXY 头，否则断开
+)
+
+// ParseProxyMode 解析 PROXY 协议的处理方式（off、optional、required，空串为 off）
+func ParseProxyMode(s string) (ProxyMode, error) {
+	switch s {
+	case "", "off":
+		return ProxyOff, nil
+	case "optional":
+		return ProxyOptional, nil
+	case "required":
+		return ProxyRequired, nil
+	default:
+		return ProxyOff, fmt.Errorf("未知的 PROXY 协议处理方式: %q", s)
+	}
+}
+
+// ErrProxyHeader PROXY 头格式错误或缺失
+var ErrProxyHeader = errors.New("PROXY 头无效")
+
+// PROXY 协议常量
+const (
+	proxyV1Prefix = "PROXY "
+	proxyV1MaxLen = 107 // v1 头的最大长度（含 \r\n）
+
+	proxyV2HeaderLen = 16 // v2 固定部分：签名 12 字节、版本和命令、地址族、地址长度
+)
+
+// proxyV2Signature v2 头的签名
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// proxyListener 解析受信任地址连接的 PROXY 头（在 TLS 之下，PROXY 头先于 TLS 握手）
+type proxyListener struct {
+	net.Listener
+	mode    ProxyMode
+	trusted []netip.Prefix // 负载均衡的地址（不为空）
+}
+
+// SetProxyProtocol 按配置启用 PROXY 协议（需在 Start 之前调用，只作用于 TCP 监听）
+func (s *Server) SetProxyProtocol(cfg config.ProxyProtoConfig) error {
+	mode, err := ParseProxyMode(cfg.Mode)
+	if err != nil {
+		return err
+	}
+	if mode == ProxyOff {
+		return nil
+	}
+	trusted, err := parsePrefixes(cfg.Trusted)
+	if err != nil {
+		return fmt.Errorf("解析 PROXY 协议受信任地址失败: %w", err)
+	}
+	// 信任所有地址时任何客户端都可以发送 PROXY 头伪造来源地址（绕过 IP 过滤、认证锁定）
+	if len(trusted) == 0 {
+		return fmt.Errorf("启用 PROXY 协议（%s）时必须配置受信任地址", cfg.Mode)
+	}
+
+	var listener net.Listener = &proxyListener{Listener: s.tcpListener, mode: mode, trusted: trusted}
+	if tlsConfig := serverTLS.Load(); tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	s.listener = listener
+	logger.Infof("启用 PROXY 协议: %s, 受信任地址 %v", cfg.Mode, cfg.Trusted)
+	return nil
+}
+
+// Accept 接受连接，来自受信任地址的连接在第一次读取时解析 PROXY 头
+func (l *proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.isTrusted(conn) {
+		return conn, nil
+	}
+	return &proxyConn{Conn: conn, mode: l.mode, reader: bufio.NewReader(conn)}, nil
+}
+
+// isTrusted 连接是否来自受信任地址
+func (l *proxyListener) isTrusted(conn net.Conn) bool {
+	addrPort, err := netip.ParseAddrPort(conn.RemoteAddr().String())
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range l.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyConn 可能带有 PROXY 头的连接：读取数据前先解析 PROXY 头，之后 RemoteAddr、LocalAddr 为头中的地址
+type proxyConn struct {
+	net.Conn
+	mode   ProxyMode
+	reader *bufio.Reader
+
+	once   sync.Once
+	err    error
+	remote atomic.Pointer[net.TCPAddr]
+	local  atomic.Pointer[net.TCPAddr]
+}
+
+// Read 解析 PROXY 头后读取之后的数据
+func (c *proxyConn) Read(b []byte) (int, error) {
+	if err := c.readHeader(); err != nil {
+		return 0, err
+	}
+	return c.reader.Read(b)
+}
+
+// RemoteAddr 客户端的真实地址（PROXY 头解析前、没有 PROXY 头或为 LOCAL 命令时为连接的对方地址）
+func (c *proxyConn) RemoteAddr() net.Addr {
+	if addr := c.remote.Load(); addr != nil {
+		return addr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// LocalAddr 客户端连接的目标地址（负载均衡上的地址）
+func (c *proxyConn) LocalAddr() net.Addr {
+	if addr := c.local.Load(); addr != nil {
+		return addr
+	}
+	return c.Conn.LocalAddr()
+}
+
+// readHeader 解析 PROXY 头（只解析一次，须在握手超时内收到）
+func (c *proxyConn) readHeader() error {
+	c.once.Do(func() {
+		c.Conn.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+		defer c.Conn.SetReadDeadline(time.Time{})
+
+		src, dst, err := readProxyHeader(c.reader, c.mode == ProxyRequired)
+		if err != nil {
+			c.err = fmt.Errorf("%w: %s, %w", ErrProxyHeader, c.Conn.RemoteAddr(), err)
+			return
+		}
+		if src != nil {
+			c.remote.Store(src)
+			c.local.Store(dst)
+		}
+	})
+	return c.err
+}
+
+// readProxyHeader 读取 PROXY 头，返回源地址和目标地址（LOCAL 命令、UNKNOWN 地址族或没有 PROXY 头时为 nil）
+// required 为 false 时没有 PROXY 头不是错误，数据留在 reader 中
+func readProxyHeader(reader *bufio.Reader, required bool) (src, dst *net.TCPAddr, err error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case first[0] == 'P':
+		if prefix, err := reader.Peek(len(proxyV1Prefix)); err == nil && string(prefix) == proxyV1Prefix {
+			return readProxyV1(reader)
+		}
+	case first[0] == proxyV2Signature[0]:
+		if sig, err := reader.Peek(len(proxyV2Signature)); err == nil && bytes.Equal(sig, proxyV2Signature) {
+			return readProxyV2(reader)
+		}
+	}
+	if required {
+		return nil, nil, errors.New("缺少 PROXY 头")
+	}
+	return nil, nil, nil
+}
+
+// readProxyV1 解析 v1 头：PROXY TCP4|TCP6|UNKNOWN 源地址 目标地址 源端口 目标端口\r\n
+func readProxyV1(reader *bufio.Reader) (src, dst *net.TCPAddr, err error) {
+	var line []byte
+	for len(line) < proxyV1MaxLen {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, nil, fmt.Errorf("v1 头超过 %d 字节或未以 \\r\\n 结束", proxyV1MaxLen)
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, nil, fmt.Errorf("v1 头格式错误: %q", line)
+	}
+	if src, err = parseProxyV1Addr(fields[2], fields[4]); err != nil {
+		return nil, nil, err
+	}
+	if dst, err = parseProxyV1Addr(fields[3], fields[5]); err != nil {
+		return nil, nil, err
+	}
+	return src, dst, nil
+}
+
+// parseProxyV1Addr 解析 v1 头中的地址和端口
+func parseProxyV1Addr(ip, port string) (*net.TCPAddr, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("v1 头地址错误: %q", ip)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("v1 头端口错误: %q", port)
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(addr, uint16(p))), nil
+}
+
+// readProxyV2 解析 v2 头：签名、版本和命令、地址族和传输协议、地址长度、地址（和 TLV，忽略）
+func readProxyV2(reader *bufio.Reader) (src, dst *net.TCPAddr, err error) {
+	var head [proxyV2HeaderLen]byte
+	if _, err := io.ReadFull(reader, head[:]); err != nil {
+		return nil, nil, err
+	}
+	verCmd, family := head[12], head[13]
+	if verCmd>>4 != 2 {
+		return nil, nil, fmt.Errorf("v2 头版本错误: 0x%02x", verCmd)
+	}
+	body := make([]byte, binary.BigEndian.Uint16(head[14:16]))
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, nil, err
+	}
+
+	switch verCmd & 0x0f {
+	case 0x0: // LOCAL：负载均衡自身的连接（如健康检查），使用连接地址
+		return nil, nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, nil, fmt.Errorf("v2 头命令错误: 0x%02x", verCmd)
+	}
+
+	switch family {
+	case 0x11: // TCP over IPv4
+		if len(body) < 12 {
+			return nil, nil, errors.New("v2 头 IPv4 地址长度不足")
+		}
+		src = v2Addr(body[0:4], body[8:10])
+		dst = v2Addr(body[4:8], body[10:12])
+	case 0x21: // TCP over IPv6
+		if len(body) < 36 {
+			return nil, nil, errors.New("v2 头 IPv6 地址长度不足")
+		}
+		src = v2Addr(body[0:16], body[32:34])
+		dst = v2Addr(body[16:32], body[34:36])
+	default: // UNSPEC、UDP、Unix 地址：使用连接地址
+		return nil, nil, nil
+	}
+	return src, dst, nil
+}
+
+// v2Addr v2 头中的地址和端口
+func v2Addr(ip, port []byte) *net.TCPAddr {
+	addr, _ := netip.AddrFromSlice(ip)
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(addr, binary.BigEndian.Uint16(port)))
+}
+
+// proxyConnOf 连接中的 proxyConn（TLS 连接取其底层连接），不是时返回 nil
+func proxyConnOf(conn net.Conn) *proxyConn {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	pc, _ := conn.(*proxyConn)
+	return pc
+}
@@ -0,0 +1,174 @@
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+
+	"github.com/charry/event"
+	"github.com/charry/logger"
+)
+
+// 推送：服务端在对方建立的连接上向对方发送请求（推送通知、反向命令），不需要另建连接
+// 推送请求与对方发来的请求方向相反，各自按 SessionId 关联响应，互不冲突
+// 双方协商了 FeaturePush 时才推送，对方按 RegisterPushHandler 注册的处理器处理
+const (
+	FeaturePush = "push" // 接收并响应服务端推送的请求
+
+	PushUnhandledCode = CodeNotFound // 对方没有注册该推送的处理器时的响应码
+)
+
+var (
+	// pushHandlers 推送处理器：(module << 32 | cmd) -> handler
+	pushHandlers   = make(map[uint64]ReqHandler)
+	pushHandlersMu sync.RWMutex
+)
+
+// RegisterPushHandler 注册推送处理器（作为客户端收到服务端推送的请求时调用，返回 nil 表示不响应）
+func RegisterPushHandler(module, cmd uint32, handler ReqHandler) {
+	pushHandlersMu.Lock()
+	defer pushHandlersMu.Unlock()
+	pushHandlers[uint64(module)<<32|uint64(cmd)] = handler
+}
+
+// UnregisterPushHandler 注销推送处理器
+func UnregisterPushHandler(module, cmd uint32) {
+	pushHandlersMu.Lock()
+	defer pushHandlersMu.Unlock()
+	delete(pushHandlers, uint64(module)<<32|uint64(cmd))
+}
+
+// PushRoutes 已注册推送处理器的路由（按 module、cmd 排序）
+func PushRoutes() []Route {
+	pushHandlersMu.RLock()
+	defer pushHandlersMu.RUnlock()
+	return routesOf(pushHandlers)
+}
+
+// getPushHandler 获取推送处理器
+func getPushHandler(module, cmd uint32) (ReqHandler, bool) {
+	pushHandlersMu.RLock()
+	defer pushHandlersMu.RUnlock()
+	handler, exists := pushHandlers[uint64(module)<<32|uint64(cmd)]
+	return handler, exists
+}
+
+// HandlePush 处理服务端推送的请求并在 conn 上响应（客户端的接收协程收到请求消息时调用）
+// 没有注册处理器时以 PushUnhandledCode 响应
+func HandlePush(conn net.Conn, req *ClusterReqMsg, opts FrameOptions) {
+	var resp *ClusterRespMsg
+	if handler, exists := getPushHandler(req.Module, req.Cmd); exists {
+		if resp = handler(req); resp == nil {
+			return
+		}
+	} else {
+		logger.Warnf("未注册的推送: module=%d, cmd=%d, sessionId=%s", req.Module, req.Cmd, req.SessionId)
+		resp = NewErrorResp(req, PushUnhandledCode, "未注册的推送")
+	}
+	resp.SessionId = req.SessionId
+	if resp.Trace == nil {
+		resp.Trace = req.Trace
+	}
+	conn.Write(EncodeClusterRespMsgWith(resp, opts))
+}
+
+// pushConn 会话上的推送请求（协商了 FeaturePush 的连接）
+type pushConn struct {
+	frame   FrameOptions
+	pending *PendingRequests // 推送请求的响应
+}
+
+// pushSessionOf 选择对方的一个可推送会话（多个连接时轮流使用）
+func (s *Server) pushSessionOf(peer string) (*Session, error) {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	var sessions []*Session
+	for _, session := range s.sessions[peer] {
+		if session.push != nil {
+			sessions = append(sessions, session)
+		}
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("对方没有可推送的连接: %s", peer)
+	}
+	return sessions[s.pushNext.Add(1)%uint64(len(sessions))], nil
+}
+
+// PushPeers 可推送的对方节点服务 ID（已握手且协商了 FeaturePush，按服务 ID 排序）
+func (s *Server) PushPeers() []string {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	var peers []string
+	for peer, sessions := range s.sessions {
+		if slices.ContainsFunc(sessions, func(session *Session) bool { return session.push != nil }) {
+			peers = append(peers, peer)
+		}
+	}
+	slices.Sort(peers)
+	return peers
+}
+
+// Push 向对方节点推送请求，不等待响应（对方的响应作为孤立响应报告）
+func (s *Server) Push(ctx context.Context, peer string, req *ClusterReqMsg) error {
+	session, err := s.pushSessionOf(peer)
+	if err != nil {
+		return err
+	}
+	if req.SessionId == "" {
+		req.SessionId = event.NewID()
+	}
+	InjectTrace(ctx, req)
+	InjectMetadata(ctx, req)
+	if _, err := writeWithContext(ctx, session.conn, EncodeClusterReqMsgWith(req, session.push.frame)); err != nil {
+		return fmt.Errorf("推送失败: %s, %w", peer, err)
+	}
+	return nil
+}
+
+// PushCall 向对方节点推送请求并等待响应（SessionId 为空时自动生成，ctx 未设置截止时间时使用 DefaultClientTimeout）
+func (s *Server) PushCall(ctx context.Context, peer string, req *ClusterReqMsg) (*ClusterRespMsg, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultClientTimeout)
+		defer cancel()
+	}
+	session, err := s.pushSessionOf(peer)
+	if err != nil {
+		return nil, err
+	}
+	pc := session.push
+	if req.SessionId == "" {
+		req.SessionId = event.NewID()
+	}
+	if len(req.SessionId) > HeaderSessionIdSize {
+		return nil, fmt.Errorf("sessionId 超过 %d 字节: %s", HeaderSessionIdSize, req.SessionId)
+	}
+
+	// 先登记再发送，避免响应先于登记到达
+	deadline, _ := ctx.Deadline()
+	call, err := pc.pending.Add(req.SessionId, deadline)
+	if err != nil {
+		return nil, err
+	}
+	defer pc.pending.Remove(req.SessionId)
+
+	InjectTrace(ctx, req)
+	InjectMetadata(ctx, req)
+	if _, err := writeWithContext(ctx, session.conn, EncodeClusterReqMsgWith(req, pc.frame)); err != nil {
+		return nil, fmt.Errorf("推送失败: %s, %w", peer, err)
+	}
+
+	select {
+	case resp := <-call.Resp():
+		return resp, nil
+	case err := <-call.Err():
+		return nil, err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("等待推送响应失败: module=%d, cmd=%d, sessionId=%s, %w",
+			req.Module, req.Cmd, req.SessionId, ctx.Err())
+	}
+}
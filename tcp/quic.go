@@ -0,0 +1,496 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charry/logger"
+	"github.com/quic-go/quic-go"
+)
+
+// QUIC 传输：在 TCP 同端口（UDP）上接受 QUIC 连接，跨数据中心的有损链路上替代 TCP 连接
+// 同一 SessionId 的消息（请求与其响应、分块消息帧）使用同一个 QUIC 流，不同请求互不阻塞（丢包只影响所在的流）
+// 连接对上层仍是 net.Conn：Write 按 SessionId 把每条消息写入对应的流，Read 按到达顺序返回各流上的完整消息
+// QUIC 必须使用 TLS（节点间 TLS 的配置），可选 0-RTT 重连（0-RTT 数据可能被重放，应同时启用请求去重）
+const (
+	QUICScheme = "quic"
+
+	quicALPN           = "charry"         // TLS ALPN 协议名
+	quicStreamIdle     = 30 * time.Second // 流空闲多久后关闭写方向（请求处理更久时响应改用新流发送）
+	quicMaxStreams     = 1000             // 对方最多同时打开的流数（达到时 Write 等待）
+	quicDialTimeout    = 3 * time.Second  // 优先使用 QUIC 时握手的超时，超时后退回 TCP
+	quicFallbackPeriod = time.Minute      // QUIC 连接失败后多久内直接使用 TCP
+	quicReadQueue      = 64               // 已收到未读取的消息数（达到时暂停读取各流）
+)
+
+var (
+	// preferQUIC 连接不带 scheme 的地址时是否先尝试 QUIC（SetPreferQUIC）
+	preferQUIC atomic.Bool
+
+	// quicFailed QUIC 连接失败的地址：addr -> 失败时间（quicFallbackPeriod 内直接使用 TCP）
+	quicFailed sync.Map
+
+	// quicSessions 0-RTT 重连使用的 TLS 会话缓存
+	quicSessions = tls.NewLRUClientSessionCache(256)
+)
+
+// SetPreferQUIC 设置连接其他节点（不带 scheme 的 host:port 地址）时是否先尝试 QUIC，失败时退回 TCP
+func SetPreferQUIC(prefer bool) {
+	preferQUIC.Store(prefer)
+}
+
+// quicConfig QUIC 连接参数
+func quicConfig(zeroRTT bool) *quic.Config {
+	return &quic.Config{
+		MaxIncomingStreams: quicMaxStreams,
+		KeepAlivePeriod:    DefaultHeartbeatInterval,
+		Allow0RTT:          zeroRTT,
+	}
+}
+
+// ListenQUIC 在 TCP 监听的同一端口（UDP）接受 QUIC 连接（需启用节点间 TLS，需在 Start 之前调用）
+// 不能与 ListenDatagram 同时使用；zeroRTT 为 true 时接受 0-RTT 数据
+func (s *Server) ListenQUIC(zeroRTT bool) error {
+	if s.datagram != nil {
+		return errors.New("QUIC 与 UDP 报文使用同一端口，不能同时启用")
+	}
+	addr, ok := s.tcpListener.Addr().(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("监听地址不是 TCP 地址: %s", s.tcpListener.Addr())
+	}
+	listener, err := ListenQUIC(addr.String(), serverTLS.Load(), zeroRTT)
+	if err != nil {
+		return err
+	}
+	s.AddListener(listener)
+	return nil
+}
+
+// ListenQUIC 监听 QUIC 连接（tlsConfig 不能为 nil），返回的监听器可交给 Server.AddListener
+func ListenQUIC(addr string, tlsConfig *tls.Config, zeroRTT bool) (net.Listener, error) {
+	if tlsConfig == nil {
+		return nil, errors.New("QUIC 需要启用节点间 TLS")
+	}
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.NextProtos = []string{quicALPN}
+
+	listener, err := quic.ListenAddrEarly(addr, tlsConfig, quicConfig(zeroRTT))
+	if err != nil {
+		return nil, fmt.Errorf("创建 QUIC 监听失败: %w", err)
+	}
+	logger.Infof("QUIC 监听: %s（0-RTT: %v）", listener.Addr(), zeroRTT)
+	return &quicListener{listener: listener}, nil
+}
+
+// quicListener 将 QUIC 连接作为 net.Conn 接受
+type quicListener struct {
+	listener *quic.EarlyListener
+}
+
+func (l *quicListener) Accept() (net.Conn, error) {
+	conn, err := l.listener.Accept(context.Background())
+	if err != nil {
+		if errors.Is(err, quic.ErrServerClosed) {
+			return nil, net.ErrClosed
+		}
+		return nil, err
+	}
+	return newQUICConn(conn), nil
+}
+
+func (l *quicListener) Close() error {
+	return l.listener.Close()
+}
+
+func (l *quicListener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// DialQUIC 以 QUIC 连接 quic://host:port 地址（tlsConfig 不能为 nil，有缓存的会话时使用 0-RTT）
+func DialQUIC(ctx context.Context, addr string, tlsConfig *tls.Config) (net.Conn, error) {
+	if tlsConfig == nil {
+		return nil, errors.New("QUIC 需要启用节点间 TLS")
+	}
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.NextProtos = []string{quicALPN}
+	tlsConfig.ClientSessionCache = quicSessions
+
+	conn, err := quic.DialAddrEarly(ctx, strings.TrimPrefix(addr, QUICScheme+"://"), tlsConfig, quicConfig(false))
+	if err != nil {
+		return nil, err
+	}
+	return newQUICConn(conn), nil
+}
+
+// dialPreferQUIC 优先以 QUIC 连接 host:port 地址，失败时返回 false（由调用方使用 TCP）
+// 失败的地址在 quicFallbackPeriod 内不再尝试，避免每次连接都等待超时
+func dialPreferQUIC(ctx context.Context, addr string) (net.Conn, bool) {
+	if failedAt, ok := quicFailed.Load(addr); ok && time.Since(failedAt.(time.Time)) < quicFallbackPeriod {
+		return nil, false
+	}
+	tlsConfig := clientTLS.Load()
+	if tlsConfig == nil {
+		return nil, false
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, quicDialTimeout)
+	defer cancel()
+	conn, err := DialQUIC(dialCtx, addr, tlsConfig)
+	if err != nil {
+		quicFailed.Store(addr, time.Now())
+		logger.Warnf("QUIC 连接失败，改用 TCP: %s, %v", addr, err)
+		return nil, false
+	}
+	quicFailed.Delete(addr)
+	return conn, true
+}
+
+// quicStream 连接上某个 SessionId 的流
+type quicStream struct {
+	stream *quic.Stream
+	last   atomic.Int64 // 最近写入的时间（UnixNano）
+}
+
+// quicConn QUIC 连接：同一 SessionId 的消息使用同一个流，Read 合并各流收到的完整消息
+type quicConn struct {
+	conn *quic.Conn
+
+	// 发往对方的流：SessionId -> 流（对方打开的流上收到请求后，响应写回该流）
+	streams   map[string]*quicStream
+	streamsMu sync.Mutex
+
+	incoming chan []byte // 各流上收到的完整消息
+	pending  []byte      // 当前消息未读取的部分
+
+	readDeadline  atomic.Int64 // UnixNano，0 表示不超时
+	writeDeadline atomic.Int64
+
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  atomic.Pointer[error]
+}
+
+func newQUICConn(conn *quic.Conn) *quicConn {
+	c := &quicConn{
+		conn:     conn,
+		streams:  make(map[string]*quicStream),
+		incoming: make(chan []byte, quicReadQueue),
+		done:     make(chan struct{}),
+	}
+	go c.acceptLoop()
+	go c.sweepLoop()
+	return c
+}
+
+// Read 读取收到的消息（每次最多返回一条消息的数据）
+func (c *quicConn) Read(b []byte) (int, error) {
+	if len(c.pending) == 0 {
+		frame, err := c.nextFrame()
+		if err != nil {
+			return 0, err
+		}
+		c.pending = frame
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// nextFrame 等待下一条收到的消息
+func (c *quicConn) nextFrame() ([]byte, error) {
+	select {
+	case frame := <-c.incoming:
+		return frame, nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if deadline := c.readDeadline.Load(); deadline != 0 {
+		d := time.Until(time.Unix(0, deadline))
+		if d <= 0 {
+			return nil, os.ErrDeadlineExceeded
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case frame := <-c.incoming:
+		return frame, nil
+	case <-c.done:
+		return nil, c.err()
+	case <-timeout:
+		return nil, os.ErrDeadlineExceeded
+	}
+}
+
+// Write 写入一条或多条完整消息：每条消息写入其 SessionId 对应的流（没有时打开新流）
+// 响应写入后关闭该流的写方向
+func (c *quicConn) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		if len(b) < HeaderLenSize {
+			return written, fmt.Errorf("%w: 写入的数据不是完整的消息", ErrProtocol)
+		}
+		size := HeaderLenSize + int(binary.BigEndian.Uint32(b))
+		if size > len(b) {
+			return written, fmt.Errorf("%w: 写入的数据不是完整的消息", ErrProtocol)
+		}
+		if err := c.writeFrame(b[:size]); err != nil {
+			return written, err
+		}
+		written += size
+		b = b[size:]
+	}
+	return written, nil
+}
+
+// writeFrame 将一条消息写入对应的流
+func (c *quicConn) writeFrame(frame []byte) error {
+	msgType, sessionId, ok := frameSession(frame)
+	if !ok {
+		return fmt.Errorf("%w: 消息头不完整", ErrProtocol)
+	}
+
+	s, err := c.streamFor(sessionId)
+	if err != nil {
+		return err
+	}
+	if deadline := c.writeDeadline.Load(); deadline != 0 {
+		s.stream.SetWriteDeadline(time.Unix(0, deadline))
+	} else {
+		s.stream.SetWriteDeadline(time.Time{})
+	}
+	s.last.Store(time.Now().UnixNano())
+	if _, err := s.stream.Write(frame); err != nil {
+		c.closeStream(sessionId, s)
+		return err
+	}
+
+	// 响应是该 SessionId 的最后一条消息
+	if msgType == MsgTypeResponse {
+		c.closeStream(sessionId, s)
+	}
+	return nil
+}
+
+// streamFor SessionId 对应的流，没有时打开新流并开始读取
+func (c *quicConn) streamFor(sessionId string) (*quicStream, error) {
+	c.streamsMu.Lock()
+	defer c.streamsMu.Unlock()
+	if s, ok := c.streams[sessionId]; ok {
+		return s, nil
+	}
+
+	ctx := context.Background()
+	if deadline := c.writeDeadline.Load(); deadline != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, time.Unix(0, deadline))
+		defer cancel()
+	}
+	stream, err := c.conn.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s := &quicStream{stream: stream}
+	c.streams[sessionId] = s
+	go c.readStream(stream, false)
+	return s, nil
+}
+
+// closeStream 关闭流的写方向并移除（s 已被替换时只关闭 s）
+func (c *quicConn) closeStream(sessionId string, s *quicStream) {
+	c.streamsMu.Lock()
+	if c.streams[sessionId] == s {
+		delete(c.streams, sessionId)
+	}
+	c.streamsMu.Unlock()
+	s.stream.Close()
+}
+
+// acceptLoop 接受对方打开的流
+func (c *quicConn) acceptLoop() {
+	for {
+		stream, err := c.conn.AcceptStream(context.Background())
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		go c.readStream(stream, true)
+	}
+}
+
+// readStream 读取流上的完整消息放入 incoming
+// 对方打开的流上收到请求时，登记该 SessionId，之后的响应写回该流；本方打开的流上收到响应时释放该流
+func (c *quicConn) readStream(stream *quic.Stream, remote bool) {
+	defer stream.CancelRead(0)
+	for {
+		frame, err := readQUICFrame(stream)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				var streamErr *quic.StreamError
+				if !errors.As(err, &streamErr) {
+					c.fail(err)
+				}
+			}
+			return
+		}
+
+		if msgType, sessionId, ok := frameSession(frame); ok {
+			switch {
+			case remote && msgType == MsgTypeRequest:
+				c.streamsMu.Lock()
+				if _, exists := c.streams[sessionId]; !exists {
+					s := &quicStream{stream: stream}
+					s.last.Store(time.Now().UnixNano())
+					c.streams[sessionId] = s
+				}
+				c.streamsMu.Unlock()
+			case !remote && msgType == MsgTypeResponse:
+				// 收到响应后该请求结束，释放本方打开的流
+				c.streamsMu.Lock()
+				if s, exists := c.streams[sessionId]; exists && s.stream == stream {
+					delete(c.streams, sessionId)
+					stream.Close()
+				}
+				c.streamsMu.Unlock()
+			}
+		}
+
+		select {
+		case c.incoming <- frame:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// readQUICFrame 读取一条完整消息（含 Len 字段）
+func readQUICFrame(r io.Reader) ([]byte, error) {
+	var head [HeaderLenSize]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, err
+	}
+	msgLen := binary.BigEndian.Uint32(head[:])
+	if limit := MaxFrameSize(); int64(msgLen) > limit {
+		return nil, fmt.Errorf("%w: 消息长度 %d 超过上限 %d", ErrProtocol, msgLen, limit)
+	}
+	frame := make([]byte, HeaderLenSize+int(msgLen))
+	copy(frame, head[:])
+	if _, err := io.ReadFull(r, frame[HeaderLenSize:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+// frameSession 消息的类型和 SessionId（只解析消息头）
+func frameSession(frame []byte) (msgType byte, sessionId string, ok bool) {
+	const offset = HeaderLenSize + HeaderIsRespSize + HeaderModuleSize + HeaderCmdSize
+	if len(frame) <= HeaderLenSize {
+		return 0, "", false
+	}
+	typeByte := frame[HeaderLenSize]
+	headerVersion := headerVersionOf(typeByte)
+	end := offset + sessionIdSize(headerVersion)
+	if len(frame) < end {
+		return 0, "", false
+	}
+	return typeByte & msgTypeMask, decodeSessionId(frame[offset:end], headerVersion), true
+}
+
+// sweepLoop 关闭空闲的流（对方没有响应的请求、已结束的分块消息），避免流一直占用
+func (c *quicConn) sweepLoop() {
+	ticker := time.NewTicker(quicStreamIdle / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		idle := time.Now().Add(-quicStreamIdle).UnixNano()
+		c.streamsMu.Lock()
+		for sessionId, s := range c.streams {
+			if s.last.Load() < idle {
+				delete(c.streams, sessionId)
+				s.stream.Close()
+			}
+		}
+		c.streamsMu.Unlock()
+	}
+}
+
+// fail 连接出错（对方关闭、超时），之后 Read 返回错误
+func (c *quicConn) fail(err error) {
+	var appErr *quic.ApplicationError
+	if errors.As(err, &appErr) && appErr.ErrorCode == 0 {
+		err = io.EOF // 对方正常关闭
+	}
+	c.closeErr.CompareAndSwap(nil, &err)
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+// err 连接关闭的原因
+func (c *quicConn) err() error {
+	if err := c.closeErr.Load(); err != nil {
+		return *err
+	}
+	return net.ErrClosed
+}
+
+func (c *quicConn) Close() error {
+	c.fail(net.ErrClosed)
+	return c.conn.CloseWithError(0, "")
+}
+
+func (c *quicConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *quicConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *quicConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *quicConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.Store(deadlineNano(t))
+	return nil
+}
+
+// SetWriteDeadline 设置之后写入的截止时间，同时作用于正在写入的流（用于中断写入）
+func (c *quicConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.Store(deadlineNano(t))
+	c.streamsMu.Lock()
+	for _, s := range c.streams {
+		s.stream.SetWriteDeadline(t)
+	}
+	c.streamsMu.Unlock()
+	return nil
+}
+
+// deadlineNano 截止时间的 UnixNano（零值为 0，表示不超时）
+func deadlineNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,92 @@
+package tcp
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/charry/logger"
+)
+
+// RequestContext 服务端请求上下文
+type RequestContext struct {
+	context.Context // 连接断开、服务器停止或到达请求元数据中的截止时间时取消，附带请求的追踪上下文和元数据
+
+	Req        *ClusterReqMsg
+	Peer       string   // 对方服务 ID（握手时出示，UDP 报文为空）
+	Session    *Session // 所在连接的会话（UDP 报文为 nil）
+	RemoteAddr net.Addr // 对方地址
+}
+
+// HandlerFunc 服务端请求处理函数
+// 返回的响应消息自动填充 Module、Cmd（为 0 时）、SessionId 和 Trace（为空时），返回 nil 时不响应；
+// 返回错误时以错误响应（见 ErrorResp）：*CodeError 使用其响应码，其他错误使用 HandlerErrorCode，消息体为标准错误消息体
+type HandlerFunc func(ctx *RequestContext) (*ClusterRespMsg, error)
+
+// Router 服务端消息路由器（module/cmd -> 处理函数），与 cluster.Router 对应，用于接受连接的一方
+type Router struct {
+	// 路由表：(module << 32 | cmd) -> handler
+	handlers map[uint64]HandlerFunc
+	mu       sync.RWMutex
+}
+
+// NewRouter 创建路由器
+func NewRouter() *Router {
+	return &Router{
+		handlers: make(map[uint64]HandlerFunc),
+	}
+}
+
+// Handle 注册请求处理函数（同一路由重复注册时覆盖）
+func (r *Router) Handle(module, cmd uint32, fn HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[uint64(module)<<32|uint64(cmd)] = fn
+	logger.Infof("注册服务端处理函数: module=%d, cmd=%d", module, cmd)
+}
+
+// Unregister 注销请求处理函数
+func (r *Router) Unregister(module, cmd uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.handlers, uint64(module)<<32|uint64(cmd))
+}
+
+// Routes 已注册的路由（按 module、cmd 排序）
+func (r *Router) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return routesOf(r.handlers)
+}
+
+// lookup 获取请求处理函数（r 为 nil 时返回 false）
+func (r *Router) lookup(module, cmd uint32) (HandlerFunc, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, exists := r.handlers[uint64(module)<<32|uint64(cmd)]
+	return fn, exists
+}
+
+// serve 调用处理函数并生成响应（返回 nil 表示不响应）
+func serve(fn HandlerFunc, ctx *RequestContext) *ClusterRespMsg {
+	req := ctx.Req
+	resp, err := fn(ctx)
+	if err != nil {
+		return ErrorResp(req, err)
+	}
+	if resp == nil {
+		return nil
+	}
+
+	if resp.Module == 0 && resp.Cmd == 0 {
+		resp.Module, resp.Cmd = req.Module, req.Cmd
+	}
+	resp.SessionId = req.SessionId
+	if resp.Trace == nil {
+		resp.Trace = req.Trace
+	}
+	return resp
+}
@@ -0,0 +1,257 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy 发送队列满时的处理方式
+type OverflowPolicy int
+
+const (
+	OverflowBlock  OverflowPolicy = iota // 等待队列空出位置（最多等待写超时，ctx 结束时放弃）
+	OverflowReject                       // 立即返回 ErrSendQueueFull
+	OverflowClose                        // 断开连接（对方接收过慢，由重连恢复）
+)
+
+// DefaultSendQueueSize 默认的连接发送队列容量（消息数）
+const DefaultSendQueueSize = 256
+
+// ErrSendQueueFull 发送队列已满
+var ErrSendQueueFull = errors.New("发送队列已满")
+
+// SendQueueOptions 连接发送队列配置
+type SendQueueOptions struct {
+	Size     int            // 队列容量
+	Overflow OverflowPolicy // 队列满时的处理方式
+	OnFull   func()         // 队列满（被拒绝、断开或等待超时）时调用，用于统计（可为空）
+}
+
+// ParseOverflowPolicy 解析队列满时的处理方式（block、reject、close，空串为 block）
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch s {
+	case "", "block":
+		return OverflowBlock, nil
+	case "reject":
+		return OverflowReject, nil
+	case "close":
+		return OverflowClose, nil
+	default:
+		return OverflowBlock, fmt.Errorf("未知的发送队列溢出策略: %q", s)
+	}
+}
+
+// sendQueueOptions 全局发送队列配置
+var sendQueueOptions atomic.Pointer[SendQueueOptions]
+
+func init() {
+	sendQueueOptions.Store(&SendQueueOptions{Size: DefaultSendQueueSize})
+}
+
+// SetSendQueueOptions 设置全局发送队列配置（作用于之后建立的连接）
+func SetSendQueueOptions(opts SendQueueOptions) {
+	if opts.Size <= 0 {
+		opts.Size = DefaultSendQueueSize
+	}
+	sendQueueOptions.Store(&opts)
+}
+
+// GetSendQueueOptions 全局发送队列配置
+func GetSendQueueOptions() SendQueueOptions {
+	return *sendQueueOptions.Load()
+}
+
+// SendQueueStats 发送队列统计
+type SendQueueStats struct {
+	Depth     int    // 当前排队的消息数
+	MaxDepth  int    // 排队消息数峰值
+	Capacity  int    // 队列容量
+	Writes    uint64 // 写入的消息数
+	Overflows uint64 // 队列满（被拒绝或断开）的次数
+}
+
+// queuedWrite 排队等待写入的消息
+type queuedWrite struct {
+	ctx  context.Context
+	data []byte
+	done chan writeResult // 写入结果（缓冲 1）
+}
+
+// writeResult 写入结果
+type writeResult struct {
+	n   int
+	err error
+}
+
+// QueuedConn 由单个写协程按顺序写入的连接
+// 写入方把完整的消息放入发送队列并等待写入结果，心跳、请求和回复不会交错，队列长度可观测，
+// 队列满时按溢出策略等待、拒绝或断开；写协程在 Close 时退出，排队中的消息返回 net.ErrClosed
+type QueuedConn struct {
+	net.Conn
+
+	queue        chan *queuedWrite
+	overflow     OverflowPolicy
+	onFull       func()
+	writeTimeout atomic.Int64
+
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	// 统计
+	writes    atomic.Uint64
+	overflows atomic.Uint64
+	maxDepth  atomic.Int64
+}
+
+// NewQueuedConn 包装连接并启动写协程（opts.Size <= 0 时容量和溢出策略使用全局配置，写超时为全局写超时）
+func NewQueuedConn(conn net.Conn, opts SendQueueOptions) *QueuedConn {
+	if opts.Size <= 0 {
+		global := GetSendQueueOptions()
+		opts.Size, opts.Overflow = global.Size, global.Overflow
+	}
+	c := &QueuedConn{
+		Conn:     conn,
+		queue:    make(chan *queuedWrite, opts.Size),
+		overflow: opts.Overflow,
+		onFull:   opts.OnFull,
+		closed:   make(chan struct{}),
+	}
+	c.writeTimeout.Store(int64(WriteTimeout()))
+	go c.writeLoop()
+	return c
+}
+
+// SetWriteTimeout 设置每次写入的超时（0 表示不超时）
+func (c *QueuedConn) SetWriteTimeout(timeout time.Duration) {
+	c.writeTimeout.Store(int64(max(timeout, 0)))
+}
+
+// Write 排队写入一条完整消息并等待写入结果
+func (c *QueuedConn) Write(b []byte) (int, error) {
+	return c.WriteContext(context.Background(), b)
+}
+
+// WriteContext 排队写入一条完整消息并等待写入结果
+// 排队期间 ctx 结束时消息被跳过，写入期间 ctx 结束时中断写入（可能只写出了部分消息，调用方应关闭连接）
+func (c *QueuedConn) WriteContext(ctx context.Context, b []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	w := &queuedWrite{ctx: ctx, data: b, done: make(chan writeResult, 1)}
+	if err := c.push(ctx, w); err != nil {
+		return 0, err
+	}
+
+	select {
+	case r := <-w.done:
+		return r.n, r.err
+	case <-c.closed:
+		return 0, net.ErrClosed
+	}
+}
+
+// push 放入发送队列，队列满时按溢出策略处理
+func (c *QueuedConn) push(ctx context.Context, w *queuedWrite) error {
+	select {
+	case c.queue <- w:
+		c.recordDepth()
+		return nil
+	case <-c.closed:
+		return net.ErrClosed
+	default:
+	}
+
+	switch c.overflow {
+	case OverflowReject:
+		c.full()
+		return ErrSendQueueFull
+	case OverflowClose:
+		c.full()
+		c.Close()
+		return fmt.Errorf("%w，已断开连接", ErrSendQueueFull)
+	}
+
+	var timeout <-chan time.Time
+	if d := time.Duration(c.writeTimeout.Load()); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case c.queue <- w:
+		c.recordDepth()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.closed:
+		return net.ErrClosed
+	case <-timeout:
+		c.full()
+		return ErrSendQueueFull
+	}
+}
+
+// full 记录一次队列满
+func (c *QueuedConn) full() {
+	c.overflows.Add(1)
+	if c.onFull != nil {
+		c.onFull()
+	}
+}
+
+// recordDepth 记录排队消息数峰值
+func (c *QueuedConn) recordDepth() {
+	depth := int64(len(c.queue))
+	for {
+		old := c.maxDepth.Load()
+		if depth <= old || c.maxDepth.CompareAndSwap(old, depth) {
+			return
+		}
+	}
+}
+
+// writeLoop 写协程：按顺序写入排队的消息
+func (c *QueuedConn) writeLoop() {
+	for {
+		select {
+		case w := <-c.queue:
+			n, err := writeContext(w.ctx, c.Conn, w.data, time.Duration(c.writeTimeout.Load()))
+			if err == nil {
+				c.writes.Add(1)
+				if capturing.Load() {
+					captureWritten(c.Conn, w.data)
+				}
+			}
+			w.done <- writeResult{n: n, err: err}
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// Close 关闭连接并停止写协程
+func (c *QueuedConn) Close() error {
+	err := net.ErrClosed
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		err = c.Conn.Close()
+	})
+	return err
+}
+
+// Stats 发送队列统计
+func (c *QueuedConn) Stats() SendQueueStats {
+	return SendQueueStats{
+		Depth:     len(c.queue),
+		MaxDepth:  int(c.maxDepth.Load()),
+		Capacity:  cap(c.queue),
+		Writes:    c.writes.Load(),
+		Overflows: c.overflows.Load(),
+	}
+}
@@ -2,8 +2,12 @@ package tcp
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/netip"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,15 +18,46 @@ import (
 
 // Server TCP 服务器
 type Server struct {
-	addr     string
-	listener net.Listener
+	addr        string
+	listener    net.Listener
+	tcpListener net.Listener // TCP（或 Unix 域套接字）监听（listener 为其上的 TLS、PROXY 协议包装）
 
 	// 连接管理
 	conns   map[net.Conn]struct{}
 	connsMu sync.RWMutex
 
+	// 已握手连接的心跳跟踪：conn -> *ConnHeartbeat
+	beats sync.Map
+
+	// 连接限制（SetConnLimits）：连接名额（为 nil 时不限制）、接受新连接的令牌桶和被拒绝的连接数
+	limits        ConnLimits
+	slots         chan struct{}
+	acceptLimiter *acceptLimiter
+	rejected      atomic.Uint64
+	denied        atomic.Uint64 // 因 IP 过滤被拒绝的连接数
+
+	// 额外的监听器（AddListener，如 WebSocket）
+	extraListeners []net.Listener
+
+	// 已握手连接的会话：对方服务 ID -> 会话（同一节点可能有多个连接）
+	sessions   map[string][]*Session
+	sessionsMu sync.RWMutex
+	pushNext   atomic.Uint64 // 推送时轮流选择连接
+
+	// 处理业务请求的协程（SetHandlerWorkers，为 nil 时在接收协程中处理）
+	workers *handlerPool
+
+	// UDP 报文接收（ListenDatagram，为 nil 时未启用）
+	datagram *datagramServer
+
 	// 状态
-	running atomic.Bool
+	running      atomic.Bool
+	shuttingDown atomic.Bool   // 优雅关闭中（不再接受连接）
+	goAway       chan struct{} // 优雅关闭时关闭，各连接向对方发送关闭通知
+
+	// 进行中的请求数和最近收到请求的时间（UnixNano），优雅关闭时据此判断是否排空
+	active      atomic.Int64
+	lastRequest atomic.Int64
 
 	// 停止信号
 	ctx    context.Context
@@ -30,7 +65,9 @@ type Server struct {
 	wg     sync.WaitGroup
 
 	// 处理器
-	handler ConnectionHandler
+	handler     ConnectionHandler
+	router      *Router          // 服务端路由（DefaultHandler 使用）
+	middlewares []ConnMiddleware // 连接中间件（Use 添加）
 }
 
 // ConnectionHandler 连接处理器接口
@@ -40,47 +77,228 @@ type ConnectionHandler interface {
 }
 
 // DefaultHandler 默认处理器（支持协议解析和心跳）
-type DefaultHandler struct{}
+// 业务请求依次交给 RegisterReqHandler 注册的处理器、服务器路由（Server.Handle），都未注册时回显
+type DefaultHandler struct {
+	server *Server // 所属服务器（路由、优雅关闭），为空时只使用全局注册的处理器
+}
+
+func (h *DefaultHandler) HandleConnection(rawConn net.Conn) {
+	defer rawConn.Close()
 
-func (h *DefaultHandler) HandleConnection(conn net.Conn) {
+	// 请求上下文：连接断开或服务器停止时取消
+	base := context.Background()
+	var router *Router
+	var goAway chan struct{}
+	if h.server != nil {
+		base, router, goAway = h.server.ctx, h.server.router, h.server.goAway
+	}
+	connCtx, cancel := context.WithCancel(base)
+	defer cancel()
+
+	// 启用 TLS 时先完成 TLS 握手（校验对方的客户端证书）
+	if tlsConn, ok := rawConn.(*tls.Conn); ok {
+		tlsConn.SetDeadline(time.Now().Add(HandshakeTimeout))
+		if err := tlsConn.Handshake(); err != nil {
+			// 健康检查只建立 TCP 连接，不做 TLS 握手
+			if !isHealthCheckConn(rawConn) && !errors.Is(err, io.EOF) {
+				logger.Warnf("TLS 握手失败，断开: %s, %v", rawConn.RemoteAddr(), err)
+			}
+			return
+		}
+		tlsConn.SetDeadline(time.Time{})
+	}
+
+	// 流处理器可能在其他协程中回复，写入经发送队列由单个写协程完成
+	conn := NewQueuedConn(rawConn, SendQueueOptions{})
 	defer conn.Close()
 
-	// 设置初始读超时（心跳3秒一次，给予足够余量）
-	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	// 对方须在握手超时内发来握手请求
+	conn.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+
+	// 第一条消息必须是握手请求（拒绝其他环境、集群的节点）
+	handshaken := false
+	var peer string        // 对方服务 ID（用于请求去重）
+	var codec string       // 协商的编解码器
+	var frame FrameOptions // 编码响应的可选项（按协商的功能）
+	var flow *flowReceiver // 流量控制的额度发放（协商了 FeatureFlowControl 时）
+	var session *Session   // 握手后建立的会话（对方身份）
+	beats := NewHeartbeatTracker()
+	readTimeout := ReadTimeout() // 读超时（握手后按对方的节点类型、服务 ID 确定）
+
+	// 分块消息：连接断开时未收完的数据以 io.ErrUnexpectedEOF 结束
+	streams := newStreamReceiver(func(resp *ClusterRespMsg) error {
+		_, err := conn.Write(EncodeClusterRespMsgWith(resp, frame))
+		return err
+	})
+	defer streams.closeAll(io.ErrUnexpectedEOF)
 
 	for {
-		// 解码消息
-		msg, err := DecodeMsg(conn)
+		// 解码消息（消息数据在缓冲池的缓冲区中，只有确定不再引用的消息才归还，其余由 GC 回收）
+		msg, frameBuf, err := DecodeMsgPooled(conn)
 		if err != nil {
-			// 读取失败，结束连接
+			// 读取失败，结束连接（数据不符合协议时无法再定位下一条消息）
+			if errors.Is(err, ErrProtocol) {
+				logger.Warnf("消息不符合协议，断开: %s, %v", rawConn.RemoteAddr(), err)
+			}
 			return
 		}
 
-		// 收到消息后，重置超时（30秒，心跳10秒一次足够）
-		conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+		// 收到消息后，按读超时重置截止时间（读超时为 0 时不再超时）
+		// 对方在心跳中告知了心跳间隔时，连续 MaxMissedHeartbeats 个心跳没有收到即视为连接失效
+		timeout := readTimeout
+		if beat := beats.Timeout(); beat > 0 && (timeout <= 0 || beat < timeout) {
+			timeout = beat
+		}
+		if timeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(timeout))
+		} else {
+			conn.SetReadDeadline(time.Time{})
+		}
 
 		// 处理消息
 		switch v := msg.(type) {
 		case *ClusterReqMsg:
-			// 处理请求消息
+			if !handshaken {
+				if !IsHandshakeMsg(v.Module, v.Cmd) {
+					logger.Warnf("连接未握手，断开: %s", rawConn.RemoteAddr())
+					return
+				}
+				remote, err := HandleHandshakeReq(conn, v)
+				frameBuf.Release()
+				if err != nil {
+					logger.Warnf("拒绝连接: %s, %v", rawConn.RemoteAddr(), err)
+					return
+				}
+				logger.Debugf("握手成功: %s (%s)", remote.ServiceID, rawConn.RemoteAddr())
+				handshaken = true
+				peer = remote.ServiceID
+				codec = remote.Codec
+				readTimeout = HeartbeatTimingFor(remote.ServiceID, remote.Type).ReadTimeout
+				caps, err := LocalHandshake().Negotiate(remote)
+				if err == nil {
+					frame = FrameOptionsOf(caps)
+					if caps.Has(FeatureFlowControl) {
+						flow = newFlowReceiver(FlowWindow())
+					}
+				}
+				if h.server != nil {
+					h.server.beats.Store(rawConn, &ConnHeartbeat{Peer: peer, RemoteAddr: rawConn.RemoteAddr().String(), tracker: beats})
+					defer h.server.beats.Delete(rawConn)
+				}
+
+				// 建立会话，服务器按对方服务 ID 索引（推送、查询连接的节点）
+				session = newSession(conn, remote, caps)
+				if caps.Has(FeaturePush) {
+					session.push = &pushConn{frame: frame, pending: NewPendingRequests(PendingOptions{})}
+					defer session.push.pending.Fail(nil, fmt.Errorf("连接已断开: %s", peer))
+				}
+				if h.server != nil {
+					h.server.addSession(session)
+					defer h.server.removeSession(session)
+				}
+
+				// 优雅关闭时通知对方不再发送新请求
+				if goAway != nil {
+					go func() {
+						select {
+						case <-goAway:
+							conn.Write(goAwayMsg())
+						case <-connCtx.Done():
+						}
+					}()
+				}
+				continue
+			}
+			v.codec = codec
+
+			// 处理心跳请求
 			if IsHeartbeatMsg(v.Module, v.Cmd) {
-				// 处理心跳请求
+				beats.Observe(v)
 				HandleHeartbeatReq(conn, v)
-			} else {
-				// 处理业务请求（回显）
-				resp := &ClusterRespMsg{
-					Module:    v.Module,
-					Cmd:       v.Cmd,
-					SessionId: v.SessionId,
-					Code:      0,
-					Payload:   v.Payload,
+				frameBuf.Release()
+				continue
+			}
+
+			// 处理业务请求（优雅关闭时等待进行中的请求），处理完成后按批向对方发放额度
+			done := h.server.beginRequest()
+			finish := func() {
+				done()
+				if isFlowControlled(v.Module) {
+					flow.done(conn)
+				}
+			}
+			if handler, exists := getStreamHandler(v.Module, v.Cmd); exists {
+				// 处理流消息（可多次回复，同一连接上按到达顺序调用）
+				sessionId, trace := v.SessionId, v.Trace
+				handler(v, func(resp *ClusterRespMsg) error {
+					resp.SessionId = sessionId
+					if resp.Trace == nil {
+						resp.Trace = trace
+					}
+					_, err := conn.Write(EncodeClusterRespMsgWith(resp, frame))
+					return err
+				})
+				finish()
+				continue
+			}
+
+			// 其余请求交给处理协程（启用时，处理协程用尽时暂停读取该连接）或在接收协程中处理
+			serveReq := func() {
+				defer finish()
+				if handler, exists := getReqHandler(v.Module, v.Cmd); exists {
+					// 处理已注册的请求（启用去重时重复的请求不再执行）
+					if resp := handleDedup(peer, v, handler); resp != nil {
+						resp.SessionId = v.SessionId
+						if resp.Trace == nil {
+							resp.Trace = v.Trace
+						}
+						conn.Write(EncodeClusterRespMsgWith(resp, frame))
+					}
+				} else if fn, exists := router.lookup(v.Module, v.Cmd); exists {
+					// 处理服务器路由的请求（同样按去重窗口去重），请求的追踪上下文、元数据和截止时间放入 ctx
+					reqCtx, cancelReq := requestContext(contextWithSession(connCtx, session), v)
+					rc := &RequestContext{Context: reqCtx, Req: v, Peer: peer, Session: session, RemoteAddr: rawConn.RemoteAddr()}
+					resp := handleDedup(peer, v, func(req *ClusterReqMsg) *ClusterRespMsg {
+						return serve(fn, rc)
+					})
+					cancelReq()
+					if resp != nil {
+						conn.Write(EncodeClusterRespMsgWith(resp, frame))
+					}
+				} else {
+					// 处理业务请求（回显）
+					resp := &ClusterRespMsg{
+						Module:    v.Module,
+						Cmd:       v.Cmd,
+						SessionId: v.SessionId,
+						Code:      0,
+						Trace:     v.Trace,
+						Payload:   v.Payload,
+					}
+					data := EncodeClusterRespMsgWith(resp, frame)
+					conn.Write(data)
 				}
-				data := EncodeClusterRespMsg(resp)
-				conn.Write(data)
+			}
+			if !h.server.dispatch(connCtx, conn, serveReq) {
+				done()
+				return
 			}
 
+		case *ClusterStreamMsg:
+			if !handshaken {
+				logger.Warnf("连接未握手，断开: %s", rawConn.RemoteAddr())
+				return
+			}
+			streams.handle(v)
+
 		case *ClusterRespMsg:
-			// 收到响应消息（客户端模式）
+			// 推送请求的响应
+			if session != nil && session.push != nil {
+				if !session.push.pending.Resolve(v) {
+					session.push.pending.ReportOrphan(v)
+				}
+				continue
+			}
 			logger.Infof("收到响应: module=%d, cmd=%d, sessionId=%s, code=%d",
 				v.Module, v.Cmd, v.SessionId, v.Code)
 		}
@@ -89,33 +307,58 @@ func (h *DefaultHandler) HandleConnection(conn net.Conn) {
 
 // NewServer 创建 TCP 服务器
 func NewServer(appConfig *config.AppConfig) (*Server, error) {
-	addr := fmt.Sprintf("%s:%d", appConfig.Addr.Host, appConfig.Addr.Port)
-
-	listener, err := net.Listen("tcp", addr)
+	addr := JoinAddr(appConfig.Addr.Host, appConfig.Addr.Port)
+
+	// host 为 unix:// 地址时监听 Unix 域套接字（同一主机上的进程通信）
+	var tcpListener net.Listener
+	var err error
+	if IsUnixAddr(addr) {
+		tcpListener, err = ListenUnix(UnixPath(addr), nil)
+	} else {
+		tcpListener, err = net.Listen("tcp", addr)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("创建 TCP 监听失败: %w", err)
 	}
+	listener := tcpListener
+	if tlsConfig := serverTLS.Load(); tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	server := &Server{
-		addr:     addr,
-		listener: listener,
-		conns:    make(map[net.Conn]struct{}),
-		ctx:      ctx,
-		cancel:   cancel,
-		handler:  &DefaultHandler{}, // 默认处理器
+		addr:        addr,
+		listener:    listener,
+		tcpListener: tcpListener,
+		conns:       make(map[net.Conn]struct{}),
+		sessions:    make(map[string][]*Session),
+		goAway:      make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+		router:      NewRouter(),
 	}
+	server.handler = &DefaultHandler{server: server} // 默认处理器
 
 	logger.Infof("TCP 服务器创建成功: %s", addr)
 	return server, nil
 }
 
-// SetHandler 设置连接处理器
+// SetHandler 设置连接处理器（替换 DefaultHandler 后服务器路由不再生效）
 func (s *Server) SetHandler(handler ConnectionHandler) {
 	s.handler = handler
 }
 
+// Handle 在服务器路由上注册请求处理函数（对方发来 module、cmd 的请求时调用）
+func (s *Server) Handle(module, cmd uint32, fn HandlerFunc) {
+	s.router.Handle(module, cmd, fn)
+}
+
+// Router 服务器路由
+func (s *Server) Router() *Router {
+	return s.router
+}
+
 // Start 启动服务器
 func (s *Server) Start() error {
 	if !s.running.CompareAndSwap(false, true) {
@@ -124,32 +367,114 @@ func (s *Server) Start() error {
 
 	logger.Infof("TCP 服务器启动: %s", s.addr)
 
+	// 按中间件包装连接处理器
+	handler := s.chain()
+
+	// 额外的监听器（如 WebSocket）在各自的协程中接受连接
+	for _, listener := range s.extraListeners {
+		go s.acceptLoop(listener, handler)
+	}
+	return s.acceptLoop(s.listener, handler)
+}
+
+// AddListener 添加额外的监听器（如 WebSocketListener，需在 Start 之前调用）
+// 接受的连接与 TCP 连接共用处理器、中间件、IP 过滤和连接限制，服务器停止时关闭
+func (s *Server) AddListener(listener net.Listener) {
+	s.extraListeners = append(s.extraListeners, listener)
+}
+
+// acceptLoop 从监听器接受连接并处理，监听器关闭时返回
+func (s *Server) acceptLoop(listener net.Listener, handler ConnectionHandler) error {
+	wait := s.limits.Reject == RejectWait
 	for {
-		conn, err := s.listener.Accept()
+		// 超过连接限制时暂停接受（RejectWait）
+		if wait && !s.waitAdmission() {
+			return nil // 正常关闭
+		}
+
+		conn, err := listener.Accept()
 		if err != nil {
+			if wait {
+				s.releaseSlot()
+			}
+			if s.shuttingDown.Load() {
+				return nil // 优雅关闭，已停止接受连接
+			}
 			select {
 			case <-s.ctx.Done():
 				return nil // 正常关闭
 			default:
+				if errors.Is(err, net.ErrClosed) {
+					return nil
+				}
 				logger.Errorf("接受连接失败: %v", err)
 				continue
 			}
 		}
 
-		// 记录连接
-		s.addConn(conn)
+		// 来自负载均衡的连接先在各自的协程中解析 PROXY 头，之后按真实地址过滤、记录
+		if pc := proxyConnOf(conn); pc != nil {
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				if err := pc.readHeader(); err != nil {
+					if !errors.Is(err, io.EOF) && !isHealthCheckConn(conn) {
+						logger.Warnf("解析 PROXY 头失败，断开: %v", err)
+					}
+					conn.Close()
+					if wait {
+						s.releaseSlot()
+					}
+					return
+				}
+				if s.acceptConn(conn, wait) {
+					s.serveConn(conn, handler)
+				}
+			}()
+			continue
+		}
 
-		// 处理连接
+		if !s.acceptConn(conn, wait) {
+			continue
+		}
 		s.wg.Add(1)
 		go func() {
 			defer s.wg.Done()
-			defer s.removeConn(conn)
-
-			s.handler.HandleConnection(conn)
+			s.serveConn(conn, handler)
 		}()
 	}
 }
 
+// acceptConn 按 IP 过滤规则和连接限制检查新连接，接受时记录连接并返回 true，否则关闭连接
+func (s *Server) acceptConn(conn net.Conn, wait bool) bool {
+	// 按来源 IP 过滤（先于连接限制，被拒绝的连接不占用额度）
+	if !s.filterConn(conn) {
+		if wait {
+			s.releaseSlot()
+		}
+		return false
+	}
+
+	// 超过连接限制时关闭新连接（RejectClose）
+	if !wait && !s.admit(conn) {
+		return false
+	}
+
+	// 记录连接
+	s.addConn(conn)
+	return true
+}
+
+// serveConn 处理已接受的连接，处理结束后关闭连接并归还名额
+func (s *Server) serveConn(conn net.Conn, handler ConnectionHandler) {
+	defer s.releaseSlot()
+	defer s.removeConn(conn)
+
+	// 中间件拒绝连接时不会调用处理器，处理结束后统一关闭
+	defer conn.Close()
+	handler.HandleConnection(conn)
+}
+
 // StartAsync 异步启动服务器
 func (s *Server) StartAsync() {
 	go func() {
@@ -174,6 +499,12 @@ func (s *Server) Stop() {
 	if s.listener != nil {
 		s.listener.Close()
 	}
+	s.closeExtraListeners()
+
+	// 停止接收 UDP 报文
+	if s.datagram != nil {
+		s.datagram.close()
+	}
 
 	// 关闭所有连接
 	s.closeAllConns()
@@ -184,6 +515,13 @@ func (s *Server) Stop() {
 	logger.Info("✓ TCP 服务器已停止")
 }
 
+// closeExtraListeners 关闭额外的监听器
+func (s *Server) closeExtraListeners() {
+	for _, listener := range s.extraListeners {
+		listener.Close()
+	}
+}
+
 // addConn 添加连接
 func (s *Server) addConn(conn net.Conn) {
 	s.connsMu.Lock()
@@ -209,24 +547,29 @@ func (s *Server) removeConn(conn net.Conn) {
 }
 
 // isHealthCheckConn 判断是否为健康检查连接
-// 通过来源 IP 判断（Consul 地址）
+// 通过来源 IP 判断（Consul 地址，经负载均衡时为 PROXY 头中的真实地址）
 func isHealthCheckConn(conn net.Conn) bool {
-	remoteAddr := conn.RemoteAddr().String()
-
 	// 获取配置中的 Consul 地址
-	cfg := config.Get()
-	consulAddr := cfg.Consul.Address
-
-	// 如果配置了 Consul 地址，检查是否来自该地址
-	if consulAddr != "" {
-		// 提取 IP（去除端口）
-		host, _, _ := net.SplitHostPort(consulAddr)
-		if host != "" && len(remoteAddr) > len(host) {
-			return remoteAddr[:len(host)] == host
-		}
+	consulAddr := config.Get().Consul.Address
+	if consulAddr == "" {
+		return false
 	}
 
-	return false
+	// 提取 IP（去除端口），按地址比较（IPv4 映射的 IPv6 地址与 IPv4 地址相同）
+	host, _, err := net.SplitHostPort(consulAddr)
+	if err != nil {
+		host = consulAddr
+	}
+	ip := remoteIP(conn)
+	if host == ip {
+		return true
+	}
+	consulIP, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	connIP, err := netip.ParseAddr(ip)
+	return err == nil && consulIP.Unmap() == connIP.Unmap()
 }
 
 // closeAllConns 关闭所有连接
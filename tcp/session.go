@@ -0,0 +1,132 @@
+package tcp
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session 服务器上已握手连接的会话：对方在握手时出示的身份和协商结果
+// 连接的第一条消息必须是握手请求（携带服务 ID、节点类型、环境），握手通过后建立会话，连接断开时移除
+// 处理器通过 RequestContext.Session 或 SessionFromContext 取得，可在会话上保存连接级的状态（Set、Get）
+type Session struct {
+	ServiceID   string        `json:"service_id"`
+	Type        string        `json:"type"`
+	Environment string        `json:"environment"`
+	Cluster     string        `json:"cluster"`
+	AppVersion  string        `json:"app_version,omitempty"`
+	RemoteAddr  string        `json:"remote_addr"`
+	Caps        *Capabilities `json:"capabilities"`
+	ConnectedAt time.Time     `json:"connected_at"`
+
+	conn   net.Conn  // 发送队列连接
+	push   *pushConn // 推送请求（协商了 FeaturePush 时）
+	values sync.Map
+}
+
+// newSession 按对方的握手信息创建会话
+func newSession(conn net.Conn, remote *Handshake, caps *Capabilities) *Session {
+	return &Session{
+		ServiceID:   remote.ServiceID,
+		Type:        remote.Type,
+		Environment: remote.Environment,
+		Cluster:     remote.Cluster,
+		AppVersion:  remote.AppVersion,
+		RemoteAddr:  conn.RemoteAddr().String(),
+		Caps:        caps,
+		ConnectedAt: time.Now(),
+		conn:        conn,
+	}
+}
+
+// Set 在会话上保存值（连接断开后随会话丢弃）
+func (s *Session) Set(key string, value any) {
+	s.values.Store(key, value)
+}
+
+// Get 会话上保存的值
+func (s *Session) Get(key string) (any, bool) {
+	return s.values.Load(key)
+}
+
+// Delete 删除会话上保存的值
+func (s *Session) Delete(key string) {
+	s.values.Delete(key)
+}
+
+// Close 断开会话的连接
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
+
+// sessionKey 请求 ctx 中会话的键
+type sessionKey struct{}
+
+// contextWithSession 将会话放入请求 ctx
+func contextWithSession(ctx context.Context, session *Session) context.Context {
+	if session == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext 请求 ctx 所在连接的会话（UDP 报文等没有会话时为 nil）
+func SessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionKey{}).(*Session)
+	return session
+}
+
+// addSession 登记会话（同一对方可能有多个连接，如连接池）
+func (s *Server) addSession(session *Session) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	s.sessions[session.ServiceID] = append(s.sessions[session.ServiceID], session)
+}
+
+// removeSession 移除会话
+func (s *Server) removeSession(session *Session) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	sessions := slices.DeleteFunc(s.sessions[session.ServiceID], func(c *Session) bool { return c == session })
+	if len(sessions) == 0 {
+		delete(s.sessions, session.ServiceID)
+	} else {
+		s.sessions[session.ServiceID] = sessions
+	}
+}
+
+// Sessions 所有已握手连接的会话（按服务 ID、地址排序）
+func (s *Server) Sessions() []*Session {
+	s.sessionsMu.RLock()
+	var sessions []*Session
+	for _, list := range s.sessions {
+		sessions = append(sessions, list...)
+	}
+	s.sessionsMu.RUnlock()
+
+	slices.SortFunc(sessions, func(a, b *Session) int {
+		if c := strings.Compare(a.ServiceID, b.ServiceID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.RemoteAddr, b.RemoteAddr)
+	})
+	return sessions
+}
+
+// SessionsOf 指定服务 ID 的节点的会话（未连接时为空）
+func (s *Server) SessionsOf(serviceID string) []*Session {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+	return slices.Clone(s.sessions[serviceID])
+}
+
+// Connected 指定服务 ID 的节点是否有已握手的连接
+func (s *Server) Connected(serviceID string) bool {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+	return len(s.sessions[serviceID]) > 0
+}
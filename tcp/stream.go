@@ -0,0 +1,199 @@
+package tcp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/charry/logger"
+)
+
+// 分块消息帧类型
+// 一条分块消息由一个打开帧、若干数据帧和一个关闭（或中止）帧组成，帧之间可以穿插同一连接上的其他消息
+const (
+	StreamOpen  byte = 0 // 打开：Payload 为随分块消息附带的消息体（如描述数据的元信息）
+	StreamChunk byte = 1 // 数据：Payload 为一个数据块
+	StreamClose byte = 2 // 关闭：数据发送完毕
+	StreamAbort byte = 3 // 中止：发送方放弃发送，Payload 为原因
+)
+
+// StreamUnhandledCode 分块消息没有注册处理器时的响应码
+const StreamUnhandledCode = CodeNotFound
+
+// StreamChunkSize 分块消息每个数据帧的最大字节数
+var StreamChunkSize = 64 << 10
+
+// ReaderHandler 分块消息处理函数，在独立的协程中调用，返回 nil 表示不响应
+// req 为打开帧（Payload 为附带的消息体），body 按到达顺序读出数据块，读到 io.EOF 表示数据完整，
+// 发送方中止或连接断开时返回错误；处理函数读取的速度决定连接的接收速度，返回时未读完的数据被丢弃
+type ReaderHandler func(req *ClusterReqMsg, body io.Reader) *ClusterRespMsg
+
+var (
+	// readerHandlers 分块消息处理器：(module << 32 | cmd) -> handler
+	readerHandlers   = make(map[uint64]ReaderHandler)
+	readerHandlersMu sync.RWMutex
+)
+
+// RegisterReaderHandler 注册分块消息处理器（DefaultHandler 收到对应的分块消息时调用）
+func RegisterReaderHandler(module, cmd uint32, handler ReaderHandler) {
+	readerHandlersMu.Lock()
+	defer readerHandlersMu.Unlock()
+	readerHandlers[uint64(module)<<32|uint64(cmd)] = handler
+}
+
+// getReaderHandler 获取分块消息处理器
+func getReaderHandler(module, cmd uint32) (ReaderHandler, bool) {
+	readerHandlersMu.RLock()
+	defer readerHandlersMu.RUnlock()
+	handler, exists := readerHandlers[uint64(module)<<32|uint64(cmd)]
+	return handler, exists
+}
+
+// ReaderRoutes 已注册分块消息处理器的路由（按 module、cmd 排序）
+func ReaderRoutes() []Route {
+	readerHandlersMu.RLock()
+	defer readerHandlersMu.RUnlock()
+	return routesOf(readerHandlers)
+}
+
+// SendStream 以分块消息发送 body，不必将整个数据放入一条消息（对方通过 RegisterReaderHandler 处理）
+// 返回分块消息的 SessionId，对方处理完成后以该 SessionId 响应
+func SendStream(conn net.Conn, module, cmd uint32, body io.Reader) (string, error) {
+	req := &ClusterReqMsg{Module: module, Cmd: cmd, SessionId: newSessionId()}
+	return req.SessionId, SendStreamWith(context.Background(), conn, req, body, FrameOptions{})
+}
+
+// SendStreamWith 按可选项发送分块消息：req.Payload 随打开帧发送，body 按 StreamChunkSize 分块发送
+// ctx 结束或读取 body 失败时发送中止帧并返回错误，对方的处理函数读取数据时得到错误
+func SendStreamWith(ctx context.Context, conn net.Conn, req *ClusterReqMsg, body io.Reader, opts FrameOptions) error {
+	frame := &ClusterStreamMsg{Module: req.Module, Cmd: req.Cmd, SessionId: req.SessionId, Kind: StreamOpen, Payload: req.Payload}
+	write := func() error {
+		if _, err := conn.Write(EncodeClusterStreamMsgWith(frame, opts)); err != nil {
+			return fmt.Errorf("发送分块消息失败: %w", err)
+		}
+		return nil
+	}
+	abort := func(cause error) error {
+		frame.Kind, frame.Payload = StreamAbort, []byte(cause.Error())
+		if err := write(); err != nil {
+			return err
+		}
+		return cause
+	}
+
+	if err := write(); err != nil {
+		return err
+	}
+
+	buf := make([]byte, StreamChunkSize)
+	for {
+		n, err := io.ReadFull(body, buf)
+		// 读取数据可能阻塞较久，读取后再检查 ctx
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return abort(ctxErr)
+		}
+		if n > 0 {
+			frame.Kind, frame.Payload = StreamChunk, buf[:n]
+			if err := write(); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return abort(fmt.Errorf("读取数据失败: %w", err))
+		}
+	}
+
+	frame.Kind, frame.Payload = StreamClose, nil
+	return write()
+}
+
+// newSessionId 生成随机的 SessionId（UUIDv4）
+func newSessionId() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40 // 版本 4
+	id[8] = id[8]&0x3f | 0x80 // 变体 10
+	return formatUUID(id[:])
+}
+
+// streamReceiver 连接上正在接收的分块消息（只在连接的接收协程中使用）
+type streamReceiver struct {
+	writers map[string]*io.PipeWriter // SessionId -> 处理函数读取端对应的写入端
+	reply   func(resp *ClusterRespMsg) error
+}
+
+// newStreamReceiver 创建分块消息接收器，reply 用于发送处理函数的响应
+func newStreamReceiver(reply func(resp *ClusterRespMsg) error) *streamReceiver {
+	return &streamReceiver{
+		writers: make(map[string]*io.PipeWriter),
+		reply:   reply,
+	}
+}
+
+// handle 处理一个分块消息帧
+func (r *streamReceiver) handle(msg *ClusterStreamMsg) {
+	switch msg.Kind {
+	case StreamOpen:
+		r.open(msg)
+	case StreamChunk:
+		// 处理函数已返回（读取端已关闭）或未注册处理器时丢弃
+		if w, ok := r.writers[msg.SessionId]; ok {
+			if _, err := w.Write(msg.Payload); err != nil {
+				delete(r.writers, msg.SessionId)
+			}
+		}
+	case StreamClose:
+		if w, ok := r.writers[msg.SessionId]; ok {
+			w.Close()
+			delete(r.writers, msg.SessionId)
+		}
+	case StreamAbort:
+		if w, ok := r.writers[msg.SessionId]; ok {
+			w.CloseWithError(fmt.Errorf("对方中止发送: %s", msg.Payload))
+			delete(r.writers, msg.SessionId)
+		}
+	default:
+		logger.Warnf("未知分块消息帧类型: %d, sessionId=%s", msg.Kind, msg.SessionId)
+	}
+}
+
+// open 打开分块消息：在独立的协程中调用处理函数，数据帧通过管道交给处理函数
+func (r *streamReceiver) open(msg *ClusterStreamMsg) {
+	req := &ClusterReqMsg{Module: msg.Module, Cmd: msg.Cmd, SessionId: msg.SessionId, Payload: msg.Payload}
+	handler, exists := getReaderHandler(msg.Module, msg.Cmd)
+	if !exists {
+		logger.Warnf("分块消息未注册处理器: module=%d, cmd=%d", msg.Module, msg.Cmd)
+		r.reply(NewErrorResp(req, StreamUnhandledCode, "未注册分块消息处理器"))
+		return
+	}
+	if old, ok := r.writers[msg.SessionId]; ok {
+		old.CloseWithError(fmt.Errorf("sessionId 被重新打开: %s", msg.SessionId))
+	}
+
+	pr, pw := io.Pipe()
+	r.writers[msg.SessionId] = pw
+	go func() {
+		resp := handler(req, pr)
+		// 处理函数返回后不再接收数据，之后的数据帧被丢弃
+		pr.Close()
+		if resp != nil {
+			resp.SessionId = req.SessionId
+			r.reply(resp)
+		}
+	}()
+}
+
+// closeAll 连接断开时结束所有未完成的分块消息
+func (r *streamReceiver) closeAll(err error) {
+	for sessionId, w := range r.writers {
+		w.CloseWithError(err)
+		delete(r.writers, sessionId)
+	}
+}
@@ -0,0 +1,161 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+
+	"github.com/charry/config"
+	"github.com/charry/logger"
+)
+
+var (
+	// 节点间双向 TLS 配置（为 nil 时不启用）
+	serverTLS atomic.Pointer[tls.Config]
+	clientTLS atomic.Pointer[tls.Config]
+)
+
+// 服务端校验客户端证书的方式（tls 配置 client_auth）
+const (
+	TLSClientAuthRequire       = "require"         // 要求并校验客户端证书（双向 TLS，默认）
+	TLSClientAuthVerifyIfGiven = "verify_if_given" // 客户端提供证书时校验
+	TLSClientAuthNone          = "none"            // 不要求客户端证书（单向 TLS）
+)
+
+// SetupTLS 按配置启用节点间 TLS（cert_file 为空时不启用）
+// 默认双向 TLS：服务端要求并校验对方的客户端证书，客户端校验服务端证书，双方证书都须由 ca_file 中的 CA 签发
+// client_auth 为 verify_if_given / none 时客户端证书可选；ca_file 为空时使用系统根证书校验服务端证书（此时不能要求客户端证书）
+// 需在创建服务器和连接其他节点之前调用
+func SetupTLS(cfg config.TLSConfig) error {
+	if cfg.CertFile == "" {
+		SetTLSConfig(nil, nil)
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("加载节点证书失败: %w", err)
+	}
+
+	var roots *x509.CertPool
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return fmt.Errorf("读取 CA 证书失败: %w", err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("CA 证书格式错误: %s", cfg.CAFile)
+		}
+	}
+
+	server := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    roots,
+		MinVersion:   tls.VersionTLS12,
+	}
+	switch cfg.ClientAuth {
+	case TLSClientAuthRequire, "":
+		server.ClientAuth = tls.RequireAndVerifyClientCert
+	case TLSClientAuthVerifyIfGiven:
+		server.ClientAuth = tls.VerifyClientCertIfGiven
+	case TLSClientAuthNone:
+		server.ClientAuth = tls.NoClientCert
+	default:
+		return fmt.Errorf("未知的客户端证书校验方式: %s", cfg.ClientAuth)
+	}
+	if roots == nil && server.ClientAuth != tls.NoClientCert {
+		return fmt.Errorf("校验客户端证书需要配置 ca_file")
+	}
+
+	client := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      roots,
+		ServerName:   cfg.ServerName,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.ServerName == "" {
+		// 节点按服务发现得到的地址互连，未配置名称时只校验证书链，不校验主机名
+		client.InsecureSkipVerify = true
+		client.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyChain(state, roots)
+		}
+	}
+	SetTLSConfig(server, client)
+
+	logger.Infof("✓ 已启用节点间 TLS: %s, 客户端证书 %s", cfg.CertFile, server.ClientAuth)
+	return nil
+}
+
+// SetTLSConfig 直接指定节点间 TLS 配置（server 为 nil 时服务端不启用 TLS，client 为 nil 时连接其他节点不使用 TLS）
+// 用于证书来自密钥管理服务等无法通过文件配置的场景，需在创建服务器和连接其他节点之前调用
+func SetTLSConfig(server, client *tls.Config) {
+	serverTLS.Store(server)
+	clientTLS.Store(client)
+}
+
+// TLSEnabled 是否启用了节点间 TLS（服务端）
+func TLSEnabled() bool {
+	return serverTLS.Load() != nil
+}
+
+// verifyChain 校验服务端证书链（不校验主机名，roots 为 nil 时使用系统根证书）
+func verifyChain(state tls.ConnectionState, roots *x509.CertPool) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("对方未提供证书")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := state.PeerCertificates[0].Verify(opts); err != nil {
+		return fmt.Errorf("校验对方证书失败: %w", err)
+	}
+	return nil
+}
+
+// Dial 连接其他节点（启用 TLS 时完成 TLS 握手后返回）
+// addr 带 scheme 时使用注册的传输（如 ws://、wss:// 建立 WebSocket 连接，unix:// 连接 Unix 域套接字，quic:// 建立 QUIC 连接，见 RegisterTransport），传入节点间 TLS 的客户端配置
+// 不带 scheme 时使用 TCP（SetPreferQUIC 启用时先尝试 QUIC）
+func Dial(ctx context.Context, addr string) (net.Conn, error) {
+	if dial, ok := transportOf(addr); ok {
+		return dial(ctx, addr, clientTLS.Load())
+	}
+	if preferQUIC.Load() {
+		if conn, ok := dialPreferQUIC(ctx, addr); ok {
+			return conn, nil
+		}
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := clientTLS.Load()
+	if cfg == nil {
+		return conn, nil
+	}
+	return clientHandshake(ctx, conn, cfg)
+}
+
+// clientHandshake 在连接上完成客户端 TLS 握手，失败时关闭连接
+func clientHandshake(ctx context.Context, conn net.Conn, cfg *tls.Config) (net.Conn, error) {
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("TLS 握手失败: %w", err)
+	}
+	return tlsConn, nil
+}
@@ -0,0 +1,93 @@
+package tcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// traceDataSize 追踪上下文扩展字段长度：TraceID(16) + SpanID(8) + Flags(1)
+const traceDataSize = 25
+
+// TraceContext 追踪上下文（与 W3C traceparent 对应），随请求跨节点传递，用于串联分布式追踪
+type TraceContext struct {
+	TraceID [16]byte // 整条调用链共享
+	SpanID  [8]byte  // 发出该消息的调用
+	Flags   byte     // 追踪标志（0x01 表示采样）
+}
+
+// TraceSampled 追踪标志：采样
+const TraceSampled byte = 0x01
+
+// NewTrace 开始一条新的调用链（随机的 TraceID 和 SpanID，标记为采样）
+func NewTrace() TraceContext {
+	var tc TraceContext
+	_, _ = rand.Read(tc.TraceID[:])
+	_, _ = rand.Read(tc.SpanID[:])
+	tc.Flags = TraceSampled
+	return tc
+}
+
+// Child 同一调用链中的下一跳（TraceID 和标志不变，新的 SpanID）
+func (tc TraceContext) Child() TraceContext {
+	_, _ = rand.Read(tc.SpanID[:])
+	return tc
+}
+
+// TraceIDString 十六进制的 TraceID（用于日志）
+func (tc TraceContext) TraceIDString() string {
+	return hex.EncodeToString(tc.TraceID[:])
+}
+
+// String W3C traceparent 格式（00-traceid-spanid-flags）
+func (tc TraceContext) String() string {
+	return fmt.Sprintf("00-%x-%x-%02x", tc.TraceID, tc.SpanID, tc.Flags)
+}
+
+// ParseTraceParent 解析 W3C traceparent 格式的追踪上下文（如 HTTP 网关收到的请求头）
+func ParseTraceParent(s string) (TraceContext, error) {
+	var tc TraceContext
+	parts := strings.Split(s, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return tc, fmt.Errorf("traceparent 格式错误: %q", s)
+	}
+	if _, err := hex.Decode(tc.TraceID[:], []byte(parts[1])); err != nil {
+		return tc, fmt.Errorf("traceparent 格式错误: %w", err)
+	}
+	if _, err := hex.Decode(tc.SpanID[:], []byte(parts[2])); err != nil {
+		return tc, fmt.Errorf("traceparent 格式错误: %w", err)
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return tc, fmt.Errorf("traceparent 格式错误: %w", err)
+	}
+	tc.Flags = flags[0]
+	return tc, nil
+}
+
+// traceKey 追踪上下文在 context 中的键
+type traceKey struct{}
+
+// ContextWithTrace 在 ctx 中附带追踪上下文（之后经该 ctx 发出的请求携带其下一跳）
+func ContextWithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, tc)
+}
+
+// TraceFromContext 获取 ctx 中的追踪上下文（服务端处理函数的 RequestContext 中为收到的请求的追踪上下文）
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey{}).(TraceContext)
+	return tc, ok
+}
+
+// InjectTrace req 未携带追踪上下文且 ctx 中有追踪上下文时，以其下一跳填充 req.Trace
+func InjectTrace(ctx context.Context, req *ClusterReqMsg) {
+	if req.Trace != nil {
+		return
+	}
+	if tc, ok := TraceFromContext(ctx); ok {
+		child := tc.Child()
+		req.Trace = &child
+	}
+}
@@ -0,0 +1,57 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"sync"
+)
+
+// DialFunc 建立到其他节点的连接（tlsConfig 为节点间 TLS 的客户端配置，未启用时为 nil）
+// 返回的连接上每次 Write 为一条或多条完整消息，Read 返回完整消息组成的字节流
+// 传输可以只提供单个有序字节流（请求按 SessionId 复用），也可以按 SessionId 使用独立的流（QUIC）
+type DialFunc func(ctx context.Context, addr string, tlsConfig *tls.Config) (net.Conn, error)
+
+var (
+	// transports 按地址 scheme 选择的传输：scheme -> dial
+	transports = map[string]DialFunc{
+		"ws":  DialWebSocket,
+		"wss": DialWebSocket,
+
+		UnixScheme: DialUnix,
+		QUICScheme: DialQUIC,
+	}
+	transportsMu sync.RWMutex
+)
+
+// RegisterTransport 注册地址为 scheme:// 时使用的传输（内置 ws、wss、unix 和 quic），Dial 按地址的 scheme 选择
+// 服务端将该传输的监听器（实现 net.Listener）通过 Server.AddListener 交给服务器，与 TCP 连接共用处理器和连接限制
+func RegisterTransport(scheme string, dial DialFunc) {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	transports[scheme] = dial
+}
+
+// Transports 已注册的传输 scheme
+func Transports() []string {
+	transportsMu.RLock()
+	defer transportsMu.RUnlock()
+	schemes := make([]string, 0, len(transports))
+	for scheme := range transports {
+		schemes = append(schemes, scheme)
+	}
+	return schemes
+}
+
+// transportOf 地址的 scheme 对应的传输（不带 scheme 的 host:port 地址使用 TCP，返回 false）
+func transportOf(addr string) (DialFunc, bool) {
+	scheme, _, ok := strings.Cut(addr, "://")
+	if !ok {
+		return nil, false
+	}
+	transportsMu.RLock()
+	defer transportsMu.RUnlock()
+	dial, exists := transports[scheme]
+	return dial, exists
+}
@@ -0,0 +1,89 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Unix 域套接字：同一主机上的进程（如 sidecar）通过 unix:///path/to.sock 地址通信，不经过 TCP 协议栈、不占用端口
+// 服务器地址的 host 为 unix:// 地址时监听该套接字（忽略端口），也可通过 cluster_unix_socket 在 TCP 之外同时监听
+// 连接池等通过 Dial 连接 unix:// 地址（注册为传输 UnixScheme），启用节点间 TLS 时同样完成 TLS 握手
+const UnixScheme = "unix"
+
+// IsUnixAddr 是否为 unix:// 地址
+func IsUnixAddr(addr string) bool {
+	return strings.HasPrefix(addr, UnixScheme+"://")
+}
+
+// UnixPath unix:// 地址中的套接字路径
+func UnixPath(addr string) string {
+	return strings.TrimPrefix(addr, UnixScheme+"://")
+}
+
+// JoinAddr 连接地址：host 为 unix:// 地址时原样返回，否则为 host:port
+func JoinAddr(host string, port int) string {
+	if IsUnixAddr(host) {
+		return host
+	}
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+// DialUnix 连接 unix:// 地址（tlsConfig 不为 nil 时完成 TLS 握手后返回）
+func DialUnix(ctx context.Context, addr string, tlsConfig *tls.Config) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", UnixPath(addr))
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		return conn, nil
+	}
+	return clientHandshake(ctx, conn, tlsConfig)
+}
+
+// ListenUnix 监听 Unix 域套接字（tlsConfig 不为 nil 时接受 TLS 连接）
+// 套接字文件已存在但没有进程监听时（上次异常退出遗留）先删除，监听器关闭时删除套接字文件
+func ListenUnix(path string, tlsConfig *tls.Config) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("创建 Unix 域套接字监听失败: %w", err)
+	}
+	if tlsConfig != nil {
+		return tls.NewListener(listener, tlsConfig), nil
+	}
+	return listener, nil
+}
+
+// removeStaleSocket 删除没有进程监听的套接字文件（文件不存在时忽略，不是套接字或仍在监听时返回错误）
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("Unix 域套接字路径已存在且不是套接字: %s", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("Unix 域套接字已被其他进程监听: %s", path)
+	}
+	return os.Remove(path)
+}
+
+// isUnixConn 是否为 Unix 域套接字连接（来源为本机进程，IP 过滤不适用）
+func isUnixConn(conn net.Conn) bool {
+	addr := conn.LocalAddr()
+	return addr != nil && addr.Network() == "unix"
+}
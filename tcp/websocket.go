@@ -0,0 +1,444 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// WebSocket 传输：每条节点消息作为一个（或多个分片的）二进制 WebSocket 消息发送，消息编码与 TCP 相同
+// 用于浏览器客户端、只允许 HTTP(S) 出站的环境；连接建立后同样须先握手（认证、集群校验）
+const (
+	// WebSocketSubprotocol WebSocket 子协议名称（客户端请求时服务端回应）
+	WebSocketSubprotocol = "charry"
+
+	// wsAcceptGUID 计算 Sec-WebSocket-Accept 的固定 GUID（RFC 6455）
+	wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	// wsCloseTimeout 关闭连接时发送关闭帧的超时
+	wsCloseTimeout = time.Second
+)
+
+// WebSocket 帧类型
+const (
+	wsOpContinuation byte = 0x0
+	wsOpText         byte = 0x1
+	wsOpBinary       byte = 0x2
+	wsOpClose        byte = 0x8
+	wsOpPing         byte = 0x9
+	wsOpPong         byte = 0xA
+)
+
+// wsConn 基于 WebSocket 的连接：Read 返回各二进制消息拼接而成的字节流，每次 Write 发送一条二进制消息
+type wsConn struct {
+	net.Conn
+	br     *bufio.Reader
+	client bool // 客户端发送的帧须掩码，服务端发送的帧不掩码
+
+	// 当前数据帧未读的字节数和掩码
+	remaining int64
+	masked    bool
+	mask      [4]byte
+	maskPos   int
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+func newWSConn(conn net.Conn, br *bufio.Reader, client bool) *wsConn {
+	return &wsConn{Conn: conn, br: br, client: client}
+}
+
+func (c *wsConn) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if err := c.nextFrame(); err != nil {
+			return 0, err
+		}
+	}
+
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.br.Read(p)
+	if c.masked {
+		for i := range n {
+			p[i] ^= c.mask[c.maskPos&3]
+			c.maskPos++
+		}
+	}
+	c.remaining -= int64(n)
+	return n, err
+}
+
+// nextFrame 读取下一个帧头：数据帧设置 remaining，控制帧就地处理（回复 ping、收到关闭帧时返回 io.EOF）
+func (c *wsConn) nextFrame() error {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return err
+	}
+	opcode := head[0] & 0x0f
+	masked := head[1]&0x80 != 0
+	if masked == c.client {
+		return fmt.Errorf("%w: WebSocket 帧掩码错误", ErrProtocol)
+	}
+
+	size := int64(head[1] & 0x7f)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return err
+		}
+		size = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return err
+		}
+		size = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if size < 0 || size > MaxFrameSize() {
+		return fmt.Errorf("%w: WebSocket 帧长度 %d 超过上限", ErrProtocol, size)
+	}
+
+	c.masked, c.maskPos = masked, 0
+	if masked {
+		if _, err := io.ReadFull(c.br, c.mask[:]); err != nil {
+			return err
+		}
+	}
+
+	switch opcode {
+	case wsOpBinary, wsOpContinuation:
+		c.remaining = size
+		return nil
+	case wsOpClose, wsOpPing, wsOpPong:
+		if size > 125 {
+			return fmt.Errorf("%w: WebSocket 控制帧长度 %d", ErrProtocol, size)
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(c.br, payload); err != nil {
+			return err
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= c.mask[i&3]
+			}
+		}
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return err
+			}
+		case wsOpClose:
+			c.closeOnce.Do(func() {
+				c.writeFrame(wsOpClose, nil)
+			})
+			return io.EOF
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: 不支持的 WebSocket 帧类型 %d（只接受二进制消息）", ErrProtocol, opcode)
+	}
+}
+
+func (c *wsConn) Write(b []byte) (int, error) {
+	if err := c.writeFrame(wsOpBinary, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// writeFrame 发送一个完整的帧（客户端按协议掩码）
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	head := make([]byte, 0, 14)
+	head = append(head, 0x80|opcode)
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch size := len(payload); {
+	case size <= 125:
+		head = append(head, maskBit|byte(size))
+	case size <= 0xffff:
+		head = append(head, maskBit|126)
+		head = binary.BigEndian.AppendUint16(head, uint16(size))
+	default:
+		head = append(head, maskBit|127)
+		head = binary.BigEndian.AppendUint64(head, uint64(size))
+	}
+
+	frame := payload
+	if c.client {
+		var mask [4]byte
+		_, _ = rand.Read(mask[:])
+		head = append(head, mask[:]...)
+		frame = make([]byte, len(payload))
+		for i := range payload {
+			frame[i] = payload[i] ^ mask[i&3]
+		}
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := (&net.Buffers{head, frame}).WriteTo(c.Conn)
+	return err
+}
+
+// Close 发送关闭帧后关闭底层连接
+func (c *wsConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.Conn.SetWriteDeadline(time.Now().Add(wsCloseTimeout))
+		c.writeFrame(wsOpClose, nil)
+	})
+	return c.Conn.Close()
+}
+
+// wsAcceptKey 按客户端的 Sec-WebSocket-Key 计算 Sec-WebSocket-Accept
+func wsAcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains 逗号分隔的请求头中是否包含 token（不区分大小写）
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for part := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WebSocketListener 接受 WebSocket 连接的监听器：作为 http.Handler 升级请求，升级后的连接由 Accept 返回
+// 可通过 Server.AddListener 交给服务器，与 TCP 连接共用处理器、IP 过滤和连接限制
+type WebSocketListener struct {
+	addr   net.Addr
+	conns  chan net.Conn
+	done   chan struct{}
+	once   sync.Once
+	server *http.Server // ListenWebSocket 创建的 HTTP 服务器（随监听器关闭）
+}
+
+// NewWebSocketListener 创建 WebSocket 监听器（挂到已有的 HTTP 服务上，addr 为该服务的监听地址）
+func NewWebSocketListener(addr net.Addr) *WebSocketListener {
+	return &WebSocketListener{
+		addr:  addr,
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+// ListenWebSocket 在 addr 上启动 HTTP 服务，接受 path 上的 WebSocket 连接（tlsConfig 不为 nil 时使用 wss）
+func ListenWebSocket(addr, path string, tlsConfig *tls.Config) (*WebSocketListener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("创建 WebSocket 监听失败: %w", err)
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	l := NewWebSocketListener(ln.Addr())
+	mux := http.NewServeMux()
+	mux.Handle(path, l)
+	l.server = &http.Server{Handler: mux, ReadHeaderTimeout: HandshakeTimeout}
+	go func() {
+		if err := l.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("WebSocket 服务运行错误: %v", err)
+		}
+	}()
+
+	logger.Infof("WebSocket 监听: %s%s", ln.Addr(), path)
+	return l, nil
+}
+
+// ServeHTTP 将请求升级为 WebSocket 连接，等待 Accept 取走
+func (l *WebSocketListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "需要 WebSocket 连接", http.StatusBadRequest)
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "不支持的 WebSocket 版本", http.StatusUpgradeRequired)
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "缺少 Sec-WebSocket-Key", http.StatusBadRequest)
+		return
+	}
+	select {
+	case <-l.done:
+		http.Error(w, "服务器已停止", http.StatusServiceUnavailable)
+		return
+	default:
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "不支持升级连接", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		logger.Warnf("升级 WebSocket 连接失败: %s, %v", r.RemoteAddr, err)
+		return
+	}
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAcceptKey(key) + "\r\n"
+	if headerContains(r.Header, "Sec-WebSocket-Protocol", WebSocketSubprotocol) {
+		resp += "Sec-WebSocket-Protocol: " + WebSocketSubprotocol + "\r\n"
+	}
+	conn.SetWriteDeadline(time.Now().Add(HandshakeTimeout))
+	if _, err := io.WriteString(conn, resp+"\r\n"); err != nil {
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	ws := newWSConn(conn, rw.Reader, false)
+	select {
+	case l.conns <- ws:
+	case <-l.done:
+		ws.Close()
+	}
+}
+
+// Accept 等待下一个 WebSocket 连接
+func (l *WebSocketListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close 停止接受连接（ListenWebSocket 创建的 HTTP 服务同时关闭，已升级的连接不受影响）
+func (l *WebSocketListener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+		if l.server != nil {
+			l.server.Close()
+		}
+	})
+	return nil
+}
+
+// Addr 监听地址
+func (l *WebSocketListener) Addr() net.Addr {
+	return l.addr
+}
+
+// DialWebSocket 建立 WebSocket 连接（rawURL 为 ws:// 或 wss://，wss 使用 tlsConfig，为 nil 时按系统根证书校验）
+func DialWebSocket(ctx context.Context, rawURL string, tlsConfig *tls.Config) (net.Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("WebSocket 地址格式错误: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "wss" {
+		cfg := tlsConfig.Clone()
+		if cfg == nil {
+			cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if cfg.ServerName == "" && !cfg.InsecureSkipVerify {
+			cfg.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS 握手失败: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	ws, err := wsHandshake(ctx, conn, u)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+// wsHandshake 发送升级请求并校验响应
+func wsHandshake(ctx context.Context, conn net.Conn, u *url.URL) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	var nonce [16]byte
+	_, _ = rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Path: u.Path, RawQuery: u.RawQuery},
+		Host:   u.Host,
+		Header: http.Header{
+			"Upgrade":                {"websocket"},
+			"Connection":             {"Upgrade"},
+			"Sec-WebSocket-Key":      {key},
+			"Sec-WebSocket-Version":  {"13"},
+			"Sec-WebSocket-Protocol": {WebSocketSubprotocol},
+		},
+	}
+	if req.URL.Path == "" {
+		req.URL.Path = "/"
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("发送 WebSocket 升级请求失败: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, fmt.Errorf("读取 WebSocket 升级响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body.Close()
+		return nil, fmt.Errorf("WebSocket 升级被拒绝: %s", resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(key) {
+		return nil, fmt.Errorf("%w: Sec-WebSocket-Accept 不匹配", ErrProtocol)
+	}
+	return newWSConn(conn, br, true), nil
+}
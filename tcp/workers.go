@@ -0,0 +1,94 @@
+package tcp
+
+import (
+	"context"
+	"net"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/charry/logger"
+)
+
+// 处理协程：启用后业务请求（流消息除外）交给服务器范围内数量有限的处理协程并发处理
+// 处理协程用尽时接收协程暂停读取该连接，不缓存、不丢弃请求，由 TCP 流量控制使对方的写入变慢
+// 未启用时请求在各连接的接收协程中依次处理（同样在处理期间不读取）
+
+// HandlerStats 处理协程统计
+type HandlerStats struct {
+	Workers       int           `json:"workers"`        // 处理协程上限（0 表示在接收协程中处理）
+	Busy          int           `json:"busy"`           // 正在处理请求的协程数
+	Saturated     uint64        `json:"saturated"`      // 处理协程用尽、接收协程暂停读取的次数
+	SaturatedTime time.Duration `json:"saturated_time"` // 累计暂停读取的时间
+}
+
+// handlerPool 服务器的处理协程名额
+type handlerPool struct {
+	slots         chan struct{}
+	saturated     atomic.Uint64
+	saturatedTime atomic.Int64
+}
+
+// SetHandlerWorkers 设置同时处理业务请求的协程上限（<= 0 时在接收协程中处理，需在 Start 之前调用）
+// 同一连接上的请求可能并发处理、乱序响应（按 SessionId 关联，不影响 Call）
+func (s *Server) SetHandlerWorkers(workers int) {
+	if workers <= 0 {
+		s.workers = nil
+		return
+	}
+	s.workers = &handlerPool{slots: make(chan struct{}, workers)}
+}
+
+// HandlerStats 处理协程统计
+func (s *Server) HandlerStats() HandlerStats {
+	p := s.workers
+	if p == nil {
+		return HandlerStats{}
+	}
+	return HandlerStats{
+		Workers:       cap(p.slots),
+		Busy:          len(p.slots),
+		Saturated:     p.saturated.Load(),
+		SaturatedTime: time.Duration(p.saturatedTime.Load()),
+	}
+}
+
+// dispatch 处理请求：启用处理协程时等待空闲名额后交给处理协程（等待期间不读取该连接），否则直接处理
+// 等待期间连接断开或服务器停止时返回 false
+func (s *Server) dispatch(ctx context.Context, conn net.Conn, fn func()) bool {
+	if s == nil || s.workers == nil {
+		fn()
+		return true
+	}
+
+	p := s.workers
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		start := time.Now()
+		if n := p.saturated.Add(1); n == 1 || n%rejectLogEvery == 0 {
+			logger.Warnf("处理协程已用尽（%d），暂停读取: %s（累计 %d 次）", cap(p.slots), conn.RemoteAddr(), n)
+		}
+		select {
+		case p.slots <- struct{}{}:
+			p.saturatedTime.Add(int64(time.Since(start)))
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-p.slots }()
+		defer func() {
+			// 与在接收协程中处理时一致：处理 panic 时断开该连接
+			if r := recover(); r != nil {
+				logger.Errorf("请求处理 panic，断开: %s, %v\n%s", conn.RemoteAddr(), r, debug.Stack())
+				conn.Close()
+			}
+		}()
+		fn()
+	}()
+	return true
+}